@@ -6,7 +6,9 @@ package middleware
 import (
 	"bytes"
 	"io"
+	"math/rand/v2"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +19,12 @@ import (
 
 const maxErrorOnlyCapturedRequestBodyBytes int64 = 1 << 20 // 1 MiB
 
+// forceLogHeader lets clients request full logging for an individual request.
+const forceLogHeader = "X-Force-Log"
+
+// sampleRandFloat returns the random value compared against the sample rate.
+var sampleRandFloat = rand.Float64
+
 // RequestLoggingMiddleware creates a Gin middleware that logs HTTP requests and responses.
 // It captures detailed information about the request and response, including headers and body,
 // and uses the provided RequestLogger to record this data. When full request logging is disabled,
@@ -40,9 +48,22 @@ func RequestLoggingMiddleware(logger logging.RequestLogger) gin.HandlerFunc {
 		}
 
 		loggerEnabled := logger.IsEnabled()
+		sampled := !loggerEnabled && shouldSampleRequest(logger, c.Request)
+		forceRequested := !loggerEnabled && !sampled && forceLogRequested(logger, c.Request)
+		if sampled {
+			logging.SetGinRequestLogSampled(c)
+		}
+		if forceRequested {
+			// This middleware runs before authentication, so the API key allowlist is checked
+			// lazily once the handler chain has identified the caller.
+			sampler := logger.(logging.SampledRequestLogger)
+			logging.SetGinRequestLogForceCheck(c, func() bool {
+				return sampler.ForceLogAllowed(c.GetString("apiKey"))
+			})
+		}
 
 		// Capture request information
-		requestInfo, err := captureRequestInfo(c, shouldCaptureRequestBody(loggerEnabled, c.Request))
+		requestInfo, err := captureRequestInfo(c, shouldCaptureRequestBody(loggerEnabled || sampled || forceRequested, c.Request))
 		if err != nil {
 			// Log error but continue processing
 			// In a real implementation, you might want to use a proper logger here
@@ -54,6 +75,10 @@ func RequestLoggingMiddleware(logger logging.RequestLogger) gin.HandlerFunc {
 		wrapper := NewResponseWriterWrapper(c.Writer, logger, requestInfo)
 		if !loggerEnabled {
 			wrapper.logOnErrorOnly = true
+			wrapper.sampled = sampled
+			if forceRequested {
+				wrapper.sampledCheck = func() bool { return logging.IsGinRequestLogSampled(c) }
+			}
 		}
 		c.Writer = wrapper
 
@@ -88,6 +113,30 @@ func isResponsesWebsocketUpgrade(req *http.Request) bool {
 	return strings.EqualFold(strings.TrimSpace(req.Header.Get("Upgrade")), "websocket")
 }
 
+// shouldSampleRequest reports whether a request should be captured in full while request
+// logging is disabled, according to the logger's sample rate.
+func shouldSampleRequest(logger logging.RequestLogger, req *http.Request) bool {
+	sampler, ok := logger.(logging.SampledRequestLogger)
+	if !ok {
+		return false
+	}
+	rate := sampler.SampleRate()
+	if rate <= 0 {
+		return false
+	}
+	return sampleRandFloat() < rate
+}
+
+// forceLogRequested reports whether req asks for full logging with the force header. The
+// header only takes effect for API keys the logger allows.
+func forceLogRequested(logger logging.RequestLogger, req *http.Request) bool {
+	if _, ok := logger.(logging.SampledRequestLogger); !ok || req == nil {
+		return false
+	}
+	force, errParse := strconv.ParseBool(strings.TrimSpace(req.Header.Get(forceLogHeader)))
+	return errParse == nil && force
+}
+
 func shouldCaptureRequestBody(loggerEnabled bool, req *http.Request) bool {
 	if loggerEnabled {
 		return true
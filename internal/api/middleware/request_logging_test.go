@@ -3,9 +3,14 @@ package middleware
 import (
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
 	"strings"
 	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 )
 
 func TestShouldSkipMethodForRequestLogging(t *testing.T) {
@@ -136,3 +141,119 @@ func TestShouldCaptureRequestBody(t *testing.T) {
 		}
 	}
 }
+
+func TestShouldSampleRequest(t *testing.T) {
+	originalRand := sampleRandFloat
+	t.Cleanup(func() { sampleRandFloat = originalRand })
+	sampleRandFloat = func() float64 { return 0.5 }
+
+	logger := logging.NewFileRequestLogger(false, t.TempDir(), "", 0)
+	tests := []struct {
+		name string
+		rate float64
+		want bool
+	}{
+		{name: "zero rate never samples", rate: 0, want: false},
+		{name: "roll above rate is skipped", rate: 0.25, want: false},
+		{name: "roll below rate is sampled", rate: 0.75, want: true},
+		{name: "full rate always samples", rate: 1, want: true},
+	}
+
+	for i := range tests {
+		logger.SetSampleRate(tests[i].rate)
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		req.Header.Set(forceLogHeader, "true")
+		if got := shouldSampleRequest(logger, req); got != tests[i].want {
+			t.Fatalf("%s: got sampled=%t, want %t", tests[i].name, got, tests[i].want)
+		}
+	}
+}
+
+func TestForceLogRequested(t *testing.T) {
+	logger := logging.NewFileRequestLogger(false, t.TempDir(), "", 0)
+	for _, tc := range []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: false},
+		{header: "true", want: true},
+		{header: "false", want: false},
+		{header: "yes-please", want: false},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		if tc.header != "" {
+			req.Header.Set(forceLogHeader, tc.header)
+		}
+		if got := forceLogRequested(logger, req); got != tc.want {
+			t.Fatalf("header %q: got %t, want %t", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestRequestLoggingMiddlewareWritesSampledRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	run := func(t *testing.T, rate float64, forceHeader, apiKey string) []os.DirEntry {
+		t.Helper()
+		logsDir := t.TempDir()
+		logger := logging.NewFileRequestLogger(false, logsDir, "", 0)
+		logger.SetSampleRate(rate)
+		logger.SetForceLogAPIKeys([]string{"admin-key"})
+
+		router := gin.New()
+		router.Use(RequestLoggingMiddleware(logger))
+		// Stands in for the auth middleware, which runs after request logging.
+		router.Use(func(c *gin.Context) {
+			if apiKey != "" {
+				c.Set("apiKey", apiKey)
+			}
+		})
+		router.POST("/v1/chat/completions", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"ok": true})
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"m"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if forceHeader != "" {
+			req.Header.Set(forceLogHeader, forceHeader)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		entries, errRead := os.ReadDir(logsDir)
+		if errRead != nil {
+			t.Fatalf("read logs dir: %v", errRead)
+		}
+		return entries
+	}
+
+	for _, tc := range []struct {
+		name        string
+		forceHeader string
+		apiKey      string
+	}{
+		{name: "unsampled"},
+		{name: "forced by unauthenticated caller", forceHeader: "true"},
+		{name: "forced by key outside allowlist", forceHeader: "true", apiKey: "user-key"},
+	} {
+		if entries := run(t, 0, tc.forceHeader, tc.apiKey); len(entries) != 0 {
+			t.Fatalf("%s: wrote %d log files, want 0", tc.name, len(entries))
+		}
+	}
+	for _, tc := range []struct {
+		name        string
+		rate        float64
+		forceHeader string
+		apiKey      string
+	}{
+		{name: "sampled", rate: 1},
+		{name: "forced by allowed key", rate: 0, forceHeader: "true", apiKey: "admin-key"},
+	} {
+		entries := run(t, tc.rate, tc.forceHeader, tc.apiKey)
+		if len(entries) != 1 {
+			t.Fatalf("%s: wrote %d log files, want 1", tc.name, len(entries))
+		}
+		if name := entries[0].Name(); strings.HasPrefix(name, "error-") {
+			t.Fatalf("%s: log file %q uses the error log prefix", tc.name, name)
+		}
+	}
+}
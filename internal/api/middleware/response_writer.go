@@ -40,6 +40,8 @@ type ResponseWriterWrapper struct {
 	statusCode          int                        // statusCode stores the HTTP status code of the response.
 	headers             map[string][]string        // headers stores the response headers.
 	logOnErrorOnly      bool                       // logOnErrorOnly enables logging only when an error response is detected.
+	sampled             bool                       // sampled captures this request in full even though logging is disabled.
+	sampledCheck        func() bool                // sampledCheck promotes the request to sampled once an X-Force-Log caller is allowed.
 	firstChunkTimestamp time.Time                  // firstChunkTimestamp captures TTFB for streaming responses.
 }
 
@@ -97,8 +99,25 @@ func (w *ResponseWriterWrapper) Write(data []byte) (int, error) {
 	return n, err
 }
 
+// fullLoggingEnabled reports whether the request is captured in full, either because
+// request logging is enabled or because the request was sampled.
+func (w *ResponseWriterWrapper) fullLoggingEnabled() bool {
+	if w.logger == nil {
+		return false
+	}
+	return w.isSampled() || w.logger.IsEnabled()
+}
+
+// isSampled reports whether the request is captured in full while logging is disabled.
+func (w *ResponseWriterWrapper) isSampled() bool {
+	if !w.sampled && w.sampledCheck != nil && w.sampledCheck() {
+		w.sampled = true
+	}
+	return w.sampled
+}
+
 func (w *ResponseWriterWrapper) shouldBufferResponseBody() bool {
-	if w.logger != nil && w.logger.IsEnabled() {
+	if w.fullLoggingEnabled() {
 		return true
 	}
 	if !w.logOnErrorOnly {
@@ -157,14 +176,8 @@ func (w *ResponseWriterWrapper) WriteHeader(statusCode int) {
 	w.isStreaming = w.detectStreaming(contentType)
 
 	// If streaming, initialize streaming log writer
-	if w.isStreaming && w.logger.IsEnabled() {
-		streamWriter, err := w.logger.LogStreamingRequest(
-			w.requestInfo.URL,
-			w.requestInfo.Method,
-			w.requestInfo.Headers,
-			w.requestInfo.Body,
-			w.requestInfo.RequestID,
-		)
+	if w.isStreaming && w.fullLoggingEnabled() {
+		streamWriter, err := w.startStreamingLog()
 		if err == nil {
 			w.streamWriter = streamWriter
 			w.chunkChannel = make(chan []byte, 100) // Buffered channel for async writes
@@ -183,6 +196,29 @@ func (w *ResponseWriterWrapper) WriteHeader(statusCode int) {
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
+// startStreamingLog opens the streaming log writer, using the sampled variant when the
+// request was selected for full capture while logging is disabled.
+func (w *ResponseWriterWrapper) startStreamingLog() (logging.StreamingLogWriter, error) {
+	if w.isSampled() && !w.logger.IsEnabled() {
+		if sampler, ok := w.logger.(logging.SampledRequestLogger); ok {
+			return sampler.LogSampledStreamingRequest(
+				w.requestInfo.URL,
+				w.requestInfo.Method,
+				w.requestInfo.Headers,
+				w.requestInfo.Body,
+				w.requestInfo.RequestID,
+			)
+		}
+	}
+	return w.logger.LogStreamingRequest(
+		w.requestInfo.URL,
+		w.requestInfo.Method,
+		w.requestInfo.Headers,
+		w.requestInfo.Body,
+		w.requestInfo.RequestID,
+	)
+}
+
 // ensureHeadersCaptured is a helper function to make sure response headers are captured.
 // It is safe to call this method multiple times; it will always refresh the headers
 // with the latest state from the underlying ResponseWriter.
@@ -277,8 +313,9 @@ func (w *ResponseWriterWrapper) Finalize(c *gin.Context) error {
 	}
 
 	hasAPIError := len(slicesAPIResponseError) > 0 || finalStatusCode >= http.StatusBadRequest
-	forceLog := w.logOnErrorOnly && hasAPIError && !w.logger.IsEnabled()
-	if !w.logger.IsEnabled() && !forceLog {
+	fullLogging := w.fullLoggingEnabled()
+	forceLog := w.logOnErrorOnly && hasAPIError && !fullLogging
+	if !fullLogging && !forceLog {
 		return nil
 	}
 
@@ -389,6 +426,26 @@ func (w *ResponseWriterWrapper) logRequest(requestBody []byte, statusCode int, h
 		return nil
 	}
 
+	if w.isSampled() && !w.logger.IsEnabled() {
+		if sampler, ok := w.logger.(logging.SampledRequestLogger); ok {
+			return sampler.LogSampledRequest(
+				w.requestInfo.URL,
+				w.requestInfo.Method,
+				w.requestInfo.Headers,
+				requestBody,
+				statusCode,
+				headers,
+				body,
+				apiRequestBody,
+				apiResponseBody,
+				apiResponseErrors,
+				w.requestInfo.RequestID,
+				w.requestInfo.Timestamp,
+				apiResponseTimestamp,
+			)
+		}
+	}
+
 	if loggerWithOptions, ok := w.logger.(interface {
 		LogRequestWithOptions(string, string, map[string][]string, []byte, int, map[string][]string, []byte, []byte, []byte, []*interfaces.ErrorMessage, bool, string, time.Time, time.Time) error
 	}); ok {
@@ -13,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -27,6 +28,8 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
@@ -36,6 +39,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/openai"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
@@ -60,7 +64,23 @@ type ServerOption func(*serverOptionConfig)
 func defaultRequestLoggerFactory(cfg *config.Config, configPath string) logging.RequestLogger {
 	configDir := filepath.Dir(configPath)
 	logsDir := logging.ResolveLogDirectory(cfg)
-	return logging.NewFileRequestLogger(cfg.RequestLog, logsDir, configDir, cfg.ErrorLogsMaxFiles)
+	requestLogger := logging.NewFileRequestLogger(cfg.RequestLog, logsDir, configDir, cfg.ErrorLogsMaxFiles)
+	requestLogger.SetSampleRate(cfg.LogSampleRate)
+	requestLogger.SetForceLogAPIKeys(cfg.ForceLogAPIKeys)
+	return requestLogger
+}
+
+// applyTranslationDeadLetterSink installs the sink recording failed translations.
+func applyTranslationDeadLetterSink(cfg *config.Config) {
+	path := strings.TrimSpace(cfg.TranslationDeadLetterFile)
+	if path == "" {
+		sdktranslator.SetDeadLetterSink(nil)
+		return
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(logging.ResolveLogDirectory(cfg), path)
+	}
+	sdktranslator.SetDeadLetterSink(sdktranslator.NewFileDeadLetterSink(path))
 }
 
 // WithMiddleware appends additional Gin middleware during server construction.
@@ -261,6 +281,10 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	}
 	managementasset.SetCurrentConfig(cfg)
 	auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
+	applyTranslationDeadLetterSink(cfg)
+	thinking.SetReasoningEffortMapping(cfg.ReasoningEffortMapping)
+	thinking.SetModelDefaultThinkingSuffix(cfg.ModelDefaultThinkingSuffix)
+	translatorcommon.SetOpenAIPenaltyModels(cfg.OpenAIPenaltyModels)
 	// Initialize management handler
 	s.mgmt = managementHandlers.NewHandler(cfg, configFilePath, authManager)
 	if optionState.localPassword != "" {
@@ -910,10 +934,38 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		}
 	}
 
+	if s.requestLogger != nil && (oldCfg == nil || oldCfg.LogSampleRate != cfg.LogSampleRate) {
+		if setter, ok := s.requestLogger.(interface{ SetSampleRate(float64) }); ok {
+			setter.SetSampleRate(cfg.LogSampleRate)
+		}
+	}
+
+	if s.requestLogger != nil {
+		if setter, ok := s.requestLogger.(interface{ SetForceLogAPIKeys([]string) }); ok {
+			setter.SetForceLogAPIKeys(cfg.ForceLogAPIKeys)
+		}
+	}
+
+	if oldCfg == nil || oldCfg.TranslationDeadLetterFile != cfg.TranslationDeadLetterFile {
+		applyTranslationDeadLetterSink(cfg)
+	}
+
 	if oldCfg == nil || oldCfg.DisableCooling != cfg.DisableCooling {
 		auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 	}
 
+	if oldCfg == nil || !reflect.DeepEqual(oldCfg.ReasoningEffortMapping, cfg.ReasoningEffortMapping) {
+		thinking.SetReasoningEffortMapping(cfg.ReasoningEffortMapping)
+	}
+
+	if oldCfg == nil || !reflect.DeepEqual(oldCfg.ModelDefaultThinkingSuffix, cfg.ModelDefaultThinkingSuffix) {
+		thinking.SetModelDefaultThinkingSuffix(cfg.ModelDefaultThinkingSuffix)
+	}
+
+	if oldCfg == nil || !slices.Equal(oldCfg.OpenAIPenaltyModels, cfg.OpenAIPenaltyModels) {
+		translatorcommon.SetOpenAIPenaltyModels(cfg.OpenAIPenaltyModels)
+	}
+
 	if s.handlers != nil && s.handlers.AuthManager != nil {
 		s.handlers.AuthManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second, cfg.MaxRetryCredentials)
 	}
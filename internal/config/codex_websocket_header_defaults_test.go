@@ -30,3 +30,32 @@ codex-header-defaults:
 		t.Fatalf("BetaFeatures = %q, want %q", got, "feature-a,feature-b")
 	}
 }
+
+func TestLoadConfigOptional_CodexClientIdentity(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	configYAML := []byte(`
+codex:
+  client-version: " 0.200.0 "
+  user-agent: "   "
+  websocket-beta: "not-a-beta"
+`)
+	if err := os.WriteFile(configPath, configYAML, 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfigOptional(configPath, false)
+	if err != nil {
+		t.Fatalf("LoadConfigOptional() error = %v", err)
+	}
+
+	if got := cfg.Codex.EffectiveClientVersion(); got != "0.200.0" {
+		t.Fatalf("EffectiveClientVersion() = %q, want %q", got, "0.200.0")
+	}
+	if got := cfg.Codex.EffectiveUserAgent(); got != DefaultCodexUserAgent {
+		t.Fatalf("EffectiveUserAgent() = %q, want the default for a blank value", got)
+	}
+	if got := cfg.Codex.EffectiveWebsocketBeta(); got != DefaultCodexWebsocketBeta {
+		t.Fatalf("EffectiveWebsocketBeta() = %q, want the default for an invalid value", got)
+	}
+}
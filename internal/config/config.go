@@ -10,6 +10,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"slices"
 	"strings"
 	"syscall"
 
@@ -22,6 +24,49 @@ import (
 const (
 	DefaultPanelGitHubRepository = "https://github.com/router-for-me/Cli-Proxy-API-Management-Center"
 	DefaultPprofAddr             = "127.0.0.1:8316"
+
+	// DefaultPayloadPassthroughPrefix marks client fields carried through translation.
+	DefaultPayloadPassthroughPrefix = "x_passthrough_"
+)
+
+// Built-in Codex client identity, used when the codex section leaves a value empty.
+const (
+	DefaultCodexClientVersion = "0.116.0"
+	DefaultCodexUserAgent     = "codex_cli_rs/0.116.0 (Mac OS 26.0.1; arm64) Apple_Terminal/464"
+	DefaultCodexWebsocketBeta = "responses_websockets=2026-02-06"
+)
+
+// Policies for Codex tool outputs that reference an unknown call_id.
+const (
+	CodexOrphanToolOutputKeep   = "keep"
+	CodexOrphanToolOutputDrop   = "drop"
+	CodexOrphanToolOutputReject = "reject"
+)
+
+// Policies for streamed Codex reasoning summary deltas.
+const (
+	CodexReasoningDeltasForward  = "forward"
+	CodexReasoningDeltasSuppress = "suppress"
+
+	GeminiThoughtPartsForward  = "forward"
+	GeminiThoughtPartsSuppress = "suppress"
+
+	ModelConcurrencyPolicyQueue  = "queue"
+	ModelConcurrencyPolicyReject = "reject"
+)
+
+// Policies for streaming requests that declare tools.
+const (
+	StreamingToolCallPolicyProceed    = "proceed"
+	StreamingToolCallPolicyNonStream  = "non-stream"
+	StreamingToolCallPolicyStripTools = "strip-tools"
+)
+
+// Policies for requests whose estimated prompt exceeds the model's context window.
+const (
+	ContextOverflowPolicyProceed  = "proceed"
+	ContextOverflowPolicyReject   = "reject"
+	ContextOverflowPolicyTruncate = "truncate"
 )
 
 // Config represents the application's configuration, loaded from a YAML file.
@@ -62,6 +107,20 @@ type Config struct {
 	// When exceeded, the oldest error log files are deleted. Default is 10. Set to 0 to disable cleanup.
 	ErrorLogsMaxFiles int `yaml:"error-logs-max-files" json:"error-logs-max-files"`
 
+	// LogSampleRate is the fraction (0.0-1.0) of requests captured in full while request logging
+	// is disabled.
+	LogSampleRate float64 `yaml:"log-sample-rate" json:"log-sample-rate"`
+
+	// ForceLogAPIKeys lists client API keys whose requests carrying "X-Force-Log: true" are
+	// captured in full regardless of LogSampleRate. Empty disables the header.
+	ForceLogAPIKeys []string `yaml:"force-log-api-keys,omitempty" json:"force-log-api-keys,omitempty"`
+
+	// TranslationDeadLetterFile is a JSON Lines file receiving the input of translations that
+	// produced empty or invalid output. Relative paths resolve against the log directory.
+	// Payloads are truncated and the file is rotated to a single backup once it grows large.
+	// Empty only logs a warning.
+	TranslationDeadLetterFile string `yaml:"translation-dead-letter-file" json:"translation-dead-letter-file"`
+
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
@@ -75,6 +134,25 @@ type Config struct {
 	MaxRetryCredentials int `yaml:"max-retry-credentials" json:"max-retry-credentials"`
 	// MaxRetryInterval defines the maximum wait time in seconds before retrying a cooled-down credential.
 	MaxRetryInterval int `yaml:"max-retry-interval" json:"max-retry-interval"`
+	// RetryBudget caps retries per request across credential rotation, cooldown retries,
+	// model fallbacks and connection retries combined.
+	RetryBudget RetryBudgetConfig `yaml:"retry-budget,omitempty" json:"retry-budget,omitempty"`
+
+	// ModelConcurrencyLimits caps in-flight requests per requested model name, independent of
+	// which credential serves them. Non-positive limits are ignored.
+	ModelConcurrencyLimits map[string]int `yaml:"model-concurrency-limits,omitempty" json:"model-concurrency-limits,omitempty"`
+	// ModelConcurrencyPolicy controls requests beyond a model's cap.
+	// Supported values: "queue" (default, wait for a free slot), "reject" (fail with HTTP 429).
+	ModelConcurrencyPolicy string `yaml:"model-concurrency-policy,omitempty" json:"model-concurrency-policy,omitempty"`
+
+	// MaxConcurrencyPerAuth caps in-flight upstream requests per credential. Zero disables the cap.
+	MaxConcurrencyPerAuth int `yaml:"max-concurrency-per-auth,omitempty" json:"max-concurrency-per-auth,omitempty"`
+	// AuthConcurrencyWaitSeconds is how long a request waits for a slot once every candidate
+	// credential is busy, before failing with HTTP 429. Zero fails immediately.
+	AuthConcurrencyWaitSeconds int `yaml:"auth-concurrency-wait-seconds,omitempty" json:"auth-concurrency-wait-seconds,omitempty"`
+
+	// AuthCircuitBreaker stops sending requests through credentials that keep failing.
+	AuthCircuitBreaker AuthCircuitBreakerConfig `yaml:"auth-circuit-breaker,omitempty" json:"auth-circuit-breaker,omitempty"`
 
 	// QuotaExceeded defines the behavior when a quota is exceeded.
 	QuotaExceeded QuotaExceeded `yaml:"quota-exceeded" json:"quota-exceeded"`
@@ -95,6 +173,72 @@ type Config struct {
 	// These are used only when the client does not send its own headers.
 	CodexHeaderDefaults CodexHeaderDefaults `yaml:"codex-header-defaults" json:"codex-header-defaults"`
 
+	// Codex overrides the client version, User-Agent and websocket beta header reported to
+	// the Codex backend.
+	Codex CodexConfig `yaml:"codex,omitempty" json:"codex,omitempty"`
+
+	// TokenCountCache caches CountTokens results for identical payloads.
+	TokenCountCache TokenCountCacheConfig `yaml:"token-count-cache,omitempty" json:"token-count-cache,omitempty"`
+
+	// Limits bounds the size of upstream responses held in memory.
+	Limits LimitsConfig `yaml:"limits,omitempty" json:"limits,omitempty"`
+
+	// HTTPClient tunes connection pooling for upstream HTTP clients.
+	HTTPClient HTTPClientConfig `yaml:"http-client,omitempty" json:"http-client,omitempty"`
+
+	// WarmupConnections opens this many idle keep-alive connections per provider when its
+	// first credential is registered. Non-positive counts are ignored.
+	WarmupConnections map[string]int `yaml:"warmup-connections,omitempty" json:"warmup-connections,omitempty"`
+
+	// CodexWebsocket configures timeouts for the Codex Responses WebSocket transport.
+	CodexWebsocket CodexWebsocketConfig `yaml:"codex-websocket,omitempty" json:"codex-websocket,omitempty"`
+
+	// CodexOrphanToolOutput controls how Codex requests handle function_call_output items whose
+	// call_id has no matching function_call in the same input.
+	// Supported values: "keep" (default, forward unchanged), "drop", "reject".
+	CodexOrphanToolOutput string `yaml:"codex-orphan-tool-output,omitempty" json:"codex-orphan-tool-output,omitempty"`
+
+	// ContextWindows maps a model name to its context window in tokens, used to estimate
+	// prompt overflow before Codex and OpenAI-compatible requests are sent.
+	ContextWindows map[string]int `yaml:"context-windows,omitempty" json:"context-windows,omitempty"`
+	// ContextOverflowPolicy controls requests whose estimated prompt exceeds ContextWindows.
+	// Supported values: "proceed" (default, send unchanged), "reject" (fail with HTTP 400),
+	// "truncate" (drop the oldest non-system messages until the prompt fits).
+	ContextOverflowPolicy string `yaml:"context-overflow-policy,omitempty" json:"context-overflow-policy,omitempty"`
+
+	// Validation configures opt-in pre-flight checks applied to request payloads before they
+	// are sent upstream.
+	Validation ValidationConfig `yaml:"validation,omitempty" json:"validation,omitempty"`
+
+	// CodexReasoningDeltas controls how streamed response.reasoning_summary_text.delta events
+	// are handled. Supported values: "forward" (default, translate into the client's
+	// reasoning delta format), "suppress" (drop them from the stream).
+	CodexReasoningDeltas string `yaml:"codex-reasoning-deltas,omitempty" json:"codex-reasoning-deltas,omitempty"`
+
+	// GeminiThoughtParts controls how Gemini response parts flagged with thought=true are
+	// surfaced to OpenAI chat clients. Supported values: "forward" (default, map them to
+	// reasoning_content), "suppress" (drop them from the response).
+	GeminiThoughtParts string `yaml:"gemini-thought-parts,omitempty" json:"gemini-thought-parts,omitempty"`
+
+	// ReasoningEffortMapping translates abstract reasoning levels (low, medium, high, ...) into a
+	// provider's native value, keyed by provider then level. Integer values are thinking budgets;
+	// other values are provider level names such as Codex efforts.
+	ReasoningEffortMapping map[string]map[string]string `yaml:"reasoning-effort-mapping,omitempty" json:"reasoning-effort-mapping,omitempty"`
+
+	// ModelDefaultThinkingSuffix maps a model name to the thinking suffix (e.g. "high", "8192",
+	// "none") applied when the client sends neither a suffix nor a thinking config.
+	ModelDefaultThinkingSuffix map[string]string `yaml:"model-default-thinking-suffix,omitempty" json:"model-default-thinking-suffix,omitempty"`
+
+	// OpenAIPenaltyModels lists models whose OpenAI presence_penalty/frequency_penalty are
+	// forwarded to Gemini-family upstreams even though the model registry does not declare
+	// support for them. "*" matches every model. Unlisted models have the penalties dropped.
+	OpenAIPenaltyModels []string `yaml:"openai-penalty-models,omitempty" json:"openai-penalty-models,omitempty"`
+
+	// StreamingToolCallPolicy controls, per provider, how streaming requests that declare tools are
+	// executed: "proceed" (default), "non-stream" (execute without streaming and replay the result
+	// as a stream), or "strip-tools" (drop the tool definitions and log a warning).
+	StreamingToolCallPolicy map[string]string `yaml:"streaming-tool-call-policy,omitempty" json:"streaming-tool-call-policy,omitempty"`
+
 	// ClaudeKey defines a list of Claude API key configurations as specified in the YAML configuration file.
 	ClaudeKey []ClaudeKey `yaml:"claude-api-key" json:"claude-api-key"`
 
@@ -109,6 +253,15 @@ type Config struct {
 	// Used for services that use Vertex AI-style paths but with simple API key authentication.
 	VertexCompatAPIKey []VertexCompatKey `yaml:"vertex-api-key" json:"vertex-api-key"`
 
+	// Vertex configures behaviour of Vertex AI service-account credentials.
+	Vertex VertexConfig `yaml:"vertex,omitempty" json:"vertex,omitempty"`
+
+	// Gemini configures behaviour shared by the Gemini API and Vertex AI executors.
+	Gemini GeminiConfig `yaml:"gemini,omitempty" json:"gemini,omitempty"`
+
+	// GeminiCLI configures behaviour of Gemini CLI OAuth credentials.
+	GeminiCLI GeminiCLIConfig `yaml:"gemini-cli,omitempty" json:"gemini-cli,omitempty"`
+
 	// AmpCode contains Amp CLI upstream configuration, management restrictions, and model mappings.
 	AmpCode AmpCode `yaml:"ampcode" json:"ampcode"`
 
@@ -126,6 +279,19 @@ type Config struct {
 	// Payload defines default and override rules for provider payload parameters.
 	Payload PayloadConfig `yaml:"payload" json:"payload"`
 
+	// ShadowTraffic mirrors a sampled fraction of non-streaming requests to a second provider/model.
+	ShadowTraffic ShadowTrafficConfig `yaml:"shadow-traffic" json:"shadow-traffic"`
+
+	// InputContentDenylist lists regular expressions matched against prompt text.
+	// Requests whose text matches any pattern are rejected with 400.
+	InputContentDenylist []string `yaml:"input-content-denylist,omitempty" json:"input-content-denylist,omitempty"`
+
+	// RefusalFallbackMessage replaces the content of non-streaming responses in which the model
+	// refused (OpenAI refusal, Claude refusal stop reason, Gemini safety block or prompt block).
+	// The original refusal is logged. Streaming responses are not rewritten. Empty passes refusals
+	// through unchanged.
+	RefusalFallbackMessage string `yaml:"refusal-fallback-message,omitempty" json:"refusal-fallback-message,omitempty"`
+
 	legacyMigrationPending bool `yaml:"-" json:"-"`
 }
 
@@ -144,6 +310,15 @@ type ClaudeHeaderDefaults struct {
 	StabilizeDeviceProfile *bool  `yaml:"stabilize-device-profile,omitempty" json:"stabilize-device-profile,omitempty"`
 }
 
+// RetryBudgetConfig configures the shared per-request retry budget.
+// Zero or negative values leave the corresponding limit disabled.
+type RetryBudgetConfig struct {
+	// MaxRetries is the total number of retries allowed for one request.
+	MaxRetries int `yaml:"max-retries,omitempty" json:"max-retries,omitempty"`
+	// MaxSeconds bounds how long after the request starts retries may still be attempted.
+	MaxSeconds int `yaml:"max-seconds,omitempty" json:"max-seconds,omitempty"`
+}
+
 // CodexHeaderDefaults configures fallback header values injected into Codex
 // model requests for OAuth/file-backed auth when the client omits them.
 // UserAgent applies to HTTP and websocket requests; BetaFeatures only applies to websockets.
@@ -152,6 +327,117 @@ type CodexHeaderDefaults struct {
 	BetaFeatures string `yaml:"beta-features" json:"beta-features"`
 }
 
+// CodexConfig configures the client identity CLIProxyAPI reports to the Codex backend. Empty
+// values fall back to the built-in defaults, so a Codex CLI version bump only needs a config edit.
+type CodexConfig struct {
+	// ClientVersion is sent as the client_version query parameter.
+	ClientVersion string `yaml:"client-version,omitempty" json:"client-version,omitempty"`
+	// UserAgent is the User-Agent used when neither the client nor codex-header-defaults sets one.
+	UserAgent string `yaml:"user-agent,omitempty" json:"user-agent,omitempty"`
+	// WebsocketBeta is the OpenAI-Beta value sent on Responses websocket connections when the
+	// client does not request a responses_websockets version itself.
+	WebsocketBeta string `yaml:"websocket-beta,omitempty" json:"websocket-beta,omitempty"`
+}
+
+// EffectiveClientVersion returns the configured client version or the built-in default.
+func (c CodexConfig) EffectiveClientVersion() string {
+	if c.ClientVersion != "" {
+		return c.ClientVersion
+	}
+	return DefaultCodexClientVersion
+}
+
+// EffectiveUserAgent returns the configured User-Agent or the built-in default.
+func (c CodexConfig) EffectiveUserAgent() string {
+	if c.UserAgent != "" {
+		return c.UserAgent
+	}
+	return DefaultCodexUserAgent
+}
+
+// EffectiveWebsocketBeta returns the configured websocket beta header or the built-in default.
+func (c CodexConfig) EffectiveWebsocketBeta() string {
+	if c.WebsocketBeta != "" {
+		return c.WebsocketBeta
+	}
+	return DefaultCodexWebsocketBeta
+}
+
+// CodexWebsocketConfig configures timeouts and limits for Codex WebSocket sessions.
+// Nil timeouts keep the built-in defaults; zero disables the corresponding timeout.
+type CodexWebsocketConfig struct {
+	// IdleTimeoutSeconds is the read deadline applied while waiting for upstream messages.
+	// Default is 300 seconds.
+	IdleTimeoutSeconds *int `yaml:"idle-timeout-seconds,omitempty" json:"idle-timeout-seconds,omitempty"`
+	// HandshakeTimeoutSeconds bounds the websocket upgrade handshake. Default is 30 seconds.
+	HandshakeTimeoutSeconds *int `yaml:"handshake-timeout-seconds,omitempty" json:"handshake-timeout-seconds,omitempty"`
+	// MaxSessions caps the number of tracked execution sessions. When exceeded, the least
+	// recently used idle session is closed. Zero means unlimited.
+	MaxSessions int `yaml:"max-sessions,omitempty" json:"max-sessions,omitempty"`
+	// MaxMessageBytes is the read limit for a single upstream message. Zero uses the
+	// built-in default of 64 MiB.
+	MaxMessageBytes int64 `yaml:"max-message-bytes,omitempty" json:"max-message-bytes,omitempty"`
+	// DisableCompression stops negotiating permessage-deflate, for proxies that mangle
+	// compressed frames.
+	DisableCompression bool `yaml:"disable-compression,omitempty" json:"disable-compression,omitempty"`
+	// ReadBufferSize and WriteBufferSize set the dialer I/O buffer sizes in bytes. Zero keeps
+	// the gorilla/websocket default of 4096.
+	ReadBufferSize  int `yaml:"read-buffer-size,omitempty" json:"read-buffer-size,omitempty"`
+	WriteBufferSize int `yaml:"write-buffer-size,omitempty" json:"write-buffer-size,omitempty"`
+	// SessionIDFields lists gjson paths into the client request (e.g. "conversation_id",
+	// "metadata.session") used to derive an execution session when the client did not supply
+	// one. The first non-empty field wins. Derived sessions are scoped to the client API key
+	// and credential, and HTTP requests that derive one are served over the websocket.
+	SessionIDFields []string `yaml:"session-id-fields,omitempty" json:"session-id-fields,omitempty"`
+	// HTTPFallbackAfterFailures is the number of consecutive websocket dial or send failures
+	// after which an execution session is served over HTTP for HTTPFallbackCooldownSeconds.
+	// Zero disables the fallback.
+	HTTPFallbackAfterFailures int `yaml:"http-fallback-after-failures,omitempty" json:"http-fallback-after-failures,omitempty"`
+	// HTTPFallbackCooldownSeconds is how long a degraded session stays on HTTP before the
+	// websocket is tried again. Default is 300 seconds.
+	HTTPFallbackCooldownSeconds int `yaml:"http-fallback-cooldown-seconds,omitempty" json:"http-fallback-cooldown-seconds,omitempty"`
+	// PingIntervalSeconds sends a keepalive ping on execution session connections at this
+	// interval. Zero disables keepalive pings.
+	PingIntervalSeconds int `yaml:"ping-interval-seconds,omitempty" json:"ping-interval-seconds,omitempty"`
+	// PongTimeoutSeconds is how long to wait for the pong answering a keepalive ping before the
+	// connection is treated as dead and closed. Default is 10 seconds.
+	PongTimeoutSeconds int `yaml:"pong-timeout-seconds,omitempty" json:"pong-timeout-seconds,omitempty"`
+}
+
+// TokenCountCacheConfig configures the CountTokens result cache.
+type TokenCountCacheConfig struct {
+	// Size is the maximum number of cached counts. Zero disables the cache.
+	Size int `yaml:"size,omitempty" json:"size,omitempty"`
+	// TTLSeconds is how long a cached count stays valid. Zero uses 30 seconds.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+}
+
+// LimitsConfig bounds upstream response sizes.
+type LimitsConfig struct {
+	// MaxResponseBytes caps non-streaming upstream response bodies. Larger responses fail
+	// with HTTP 502. Zero means unlimited.
+	MaxResponseBytes int64 `yaml:"max-response-bytes,omitempty" json:"max-response-bytes,omitempty"`
+	// StreamScannerBufferBytes is the largest single line accepted from a streaming upstream.
+	// Zero uses the built-in default of 50 MiB.
+	StreamScannerBufferBytes int `yaml:"stream-scanner-buffer-bytes,omitempty" json:"stream-scanner-buffer-bytes,omitempty"`
+}
+
+// HTTPClientConfig tunes the transports used for upstream HTTP requests.
+// Zero values keep Go's net/http defaults.
+type HTTPClientConfig struct {
+	// MaxIdleConnsPerHost caps idle keep-alive connections kept per upstream host.
+	MaxIdleConnsPerHost int `yaml:"max-idle-conns-per-host,omitempty" json:"max-idle-conns-per-host,omitempty"`
+	// IdleConnTimeoutSeconds closes idle connections after this many seconds.
+	IdleConnTimeoutSeconds int `yaml:"idle-conn-timeout-seconds,omitempty" json:"idle-conn-timeout-seconds,omitempty"`
+	// DisableKeepAlives opens a new connection for every request.
+	DisableKeepAlives bool `yaml:"disable-keep-alives,omitempty" json:"disable-keep-alives,omitempty"`
+}
+
+// IsZero reports whether no transport tuning is configured.
+func (c HTTPClientConfig) IsZero() bool {
+	return c == HTTPClientConfig{}
+}
+
 // TLSConfig holds HTTPS server settings.
 type TLSConfig struct {
 	// Enable toggles HTTPS server mode.
@@ -193,6 +479,41 @@ type QuotaExceeded struct {
 	SwitchPreviewModel bool `yaml:"switch-preview-model" json:"switch-preview-model"`
 }
 
+// GeminiConfig configures Gemini API and Vertex AI requests.
+type GeminiConfig struct {
+	// CountTokensCacheSeconds caches countTokens results for identical translated request
+	// bodies per provider and model for this many seconds. Zero disables the cache.
+	CountTokensCacheSeconds int `yaml:"count-tokens-cache-seconds,omitempty" json:"count-tokens-cache-seconds,omitempty"`
+}
+
+// GeminiCLIConfig configures Gemini CLI requests.
+type GeminiCLIConfig struct {
+	// FallbackModels maps a base model to the models tried in order after it answers with 429.
+	// The requested model is always tried first.
+	FallbackModels map[string][]string `yaml:"fallback-models,omitempty" json:"fallback-models,omitempty"`
+
+	// MaxRetryAfterSeconds caps the upstream retry delay honored when the last model in the
+	// chain answers with 429: shorter delays are waited out and the model is retried once.
+	// Zero disables the wait.
+	MaxRetryAfterSeconds int `yaml:"max-retry-after-seconds,omitempty" json:"max-retry-after-seconds,omitempty"`
+
+	// LocalTokenFallback estimates token counts locally when every countTokens attempt fails
+	// with 429 or 5xx. Estimated responses are marked as approximate.
+	LocalTokenFallback bool `yaml:"local-token-fallback,omitempty" json:"local-token-fallback,omitempty"`
+}
+
+// AuthCircuitBreakerConfig configures the per-credential circuit breaker.
+type AuthCircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive 401, 403, 5xx or refresh failures within
+	// WindowSeconds that opens a credential's circuit. Zero disables the breaker.
+	FailureThreshold int `yaml:"failure-threshold,omitempty" json:"failure-threshold,omitempty"`
+	// WindowSeconds bounds how far apart counted failures may be. Defaults to 60.
+	WindowSeconds int `yaml:"window-seconds,omitempty" json:"window-seconds,omitempty"`
+	// CooldownSeconds is how long an open circuit rejects requests before a single trial
+	// request is let through. Defaults to 60.
+	CooldownSeconds int `yaml:"cooldown-seconds,omitempty" json:"cooldown-seconds,omitempty"`
+}
+
 // RoutingConfig configures how credentials are selected for requests.
 type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
@@ -200,6 +521,32 @@ type RoutingConfig struct {
 	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
 }
 
+// ValidationConfig configures pre-flight request payload validation.
+type ValidationConfig struct {
+	// ToolSchemas rejects Codex and OpenAI-compatible requests whose tool parameters are not a
+	// well-formed JSON Schema object with HTTP 400 instead of forwarding them upstream.
+	ToolSchemas bool `yaml:"tool-schemas,omitempty" json:"tool-schemas,omitempty"`
+}
+
+// ShadowTrafficConfig configures shadow requests used to validate a new provider or model.
+// Shadow responses are compared against the primary response and never returned to clients.
+type ShadowTrafficConfig struct {
+	// Enable toggles shadow traffic.
+	Enable bool `yaml:"enable" json:"enable"`
+	// SampleRate is the fraction of eligible requests (0..1) that are mirrored.
+	SampleRate float64 `yaml:"sample-rate" json:"sample-rate"`
+	// Provider is the provider key that serves the shadow request (e.g., "gemini", "openrouter").
+	Provider string `yaml:"provider" json:"provider"`
+	// Model is the model requested from the shadow provider. Empty reuses the primary model.
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+	// MaxInFlight caps concurrent shadow requests; sampled requests beyond the cap are not
+	// mirrored. Values <= 0 use DefaultShadowMaxInFlight.
+	MaxInFlight int `yaml:"max-in-flight,omitempty" json:"max-in-flight,omitempty"`
+}
+
+// DefaultShadowMaxInFlight is the shadow request concurrency cap used when none is configured.
+const DefaultShadowMaxInFlight = 16
+
 // OAuthModelAlias defines a model ID alias for a specific channel.
 // It maps the upstream model name (Name) to the client-visible alias (Alias).
 // When Fork is true, the alias is added as an additional model in listings while
@@ -279,6 +626,29 @@ type PayloadConfig struct {
 	OverrideRaw []PayloadRule `yaml:"override-raw" json:"override-raw"`
 	// Filter defines rules that remove parameters from the payload by JSON path.
 	Filter []PayloadFilterRule `yaml:"filter" json:"filter"`
+	// Passthrough carries prefixed client fields into the translated upstream payload.
+	Passthrough PayloadPassthroughConfig `yaml:"passthrough" json:"passthrough"`
+}
+
+// PayloadPassthroughConfig controls how client extension fields survive translation.
+// Top-level client fields whose name starts with the prefix are copied verbatim into
+// the upstream payload under the name with the prefix removed.
+type PayloadPassthroughConfig struct {
+	// Enable turns on field passthrough.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Prefix marks passthrough fields; empty means DefaultPayloadPassthroughPrefix.
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	// AllowProtocols opts protocols that reject unknown keys (gemini, antigravity, claude,
+	// codex) into passthrough. Other protocols always receive passthrough fields.
+	AllowProtocols []string `yaml:"allow-protocols,omitempty" json:"allow-protocols,omitempty"`
+}
+
+// EffectivePrefix returns the configured passthrough prefix or the built-in default.
+func (c PayloadPassthroughConfig) EffectivePrefix() string {
+	if c.Prefix != "" {
+		return c.Prefix
+	}
+	return DefaultPayloadPassthroughPrefix
 }
 
 // PayloadFilterRule describes a rule to remove specific JSON paths from matching model payloads.
@@ -493,6 +863,20 @@ type OpenAICompatibility struct {
 
 	// Headers optionally adds extra HTTP headers for requests sent to this provider.
 	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// StripFields lists request body paths the provider rejects (e.g. "reasoning_effort",
+	// "logprobs"). Paths use gjson syntax, so nested fields such as
+	// "response_format.json_schema" are supported.
+	StripFields []string `yaml:"strip-fields,omitempty" json:"strip-fields,omitempty"`
+
+	// SupportsLogitBias reports whether the provider accepts the OpenAI logit_bias field.
+	// Defaults to true; when false, "logit_bias" is added to the effective strip fields.
+	SupportsLogitBias *bool `yaml:"supports-logit-bias,omitempty" json:"supports-logit-bias,omitempty"`
+
+	// SupportsParallelToolCalls reports whether the provider accepts the OpenAI
+	// parallel_tool_calls field. Defaults to true; when false, "parallel_tool_calls" is added
+	// to the effective strip fields.
+	SupportsParallelToolCalls *bool `yaml:"supports-parallel-tool-calls,omitempty" json:"supports-parallel-tool-calls,omitempty"`
 }
 
 // OpenAICompatibilityAPIKey represents an API key configuration with optional proxy setting.
@@ -516,6 +900,11 @@ type OpenAICompatibilityModel struct {
 	// Thinking configures the thinking/reasoning capability for this model.
 	// If nil, the model defaults to level-based reasoning with levels ["low", "medium", "high"].
 	Thinking *registry.ThinkingSupport `yaml:"thinking,omitempty" json:"thinking,omitempty"`
+
+	// MaxTokensField selects the output token limit field accepted by the upstream model.
+	// Supported values: "max_tokens", "max_completion_tokens". Empty keeps the client's field
+	// unless the model registry declares which one the model supports.
+	MaxTokensField string `yaml:"max-tokens-field,omitempty" json:"max-tokens-field,omitempty"`
 }
 
 func (m OpenAICompatibilityModel) GetName() string  { return m.Name }
@@ -625,21 +1014,67 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		cfg.ErrorLogsMaxFiles = 10
 	}
 
+	cfg.LogSampleRate = min(max(cfg.LogSampleRate, 0), 1)
+
 	if cfg.MaxRetryCredentials < 0 {
 		cfg.MaxRetryCredentials = 0
 	}
 
+	if cfg.MaxConcurrencyPerAuth < 0 {
+		cfg.MaxConcurrencyPerAuth = 0
+	}
+	if cfg.AuthConcurrencyWaitSeconds < 0 {
+		cfg.AuthConcurrencyWaitSeconds = 0
+	}
+
+	cfg.SanitizeAuthCircuitBreaker()
+
 	// Sanitize Gemini API key configuration and migrate legacy entries.
 	cfg.SanitizeGeminiKeys()
 
 	// Sanitize Vertex-compatible API keys.
 	cfg.SanitizeVertexCompatKeys()
 
+	// Normalize Vertex service-account fallback locations.
+	cfg.SanitizeVertex()
+
+	// Normalize Gemini CLI fallback model chains.
+	cfg.SanitizeGeminiCLI()
+
 	// Sanitize Codex keys: drop entries without base-url
 	cfg.SanitizeCodexKeys()
 
 	// Sanitize Codex header defaults.
 	cfg.SanitizeCodexHeaderDefaults()
+	cfg.SanitizeCodex()
+
+	// Normalize the Codex orphaned tool output policy.
+	cfg.SanitizeCodexOrphanToolOutput()
+
+	// Normalize the context window overflow settings.
+	cfg.SanitizeContextOverflow()
+
+	// Normalize the Codex reasoning delta policy.
+	cfg.SanitizeCodexReasoningDeltas()
+	cfg.SanitizeGeminiThoughtParts()
+	cfg.SanitizeReasoningEffortMapping()
+	cfg.SanitizeModelDefaultThinkingSuffix()
+	cfg.SanitizeOpenAIPenaltyModels()
+	cfg.SanitizeStreamingToolCallPolicy()
+	cfg.SanitizeModelConcurrencyLimits()
+
+	// Reset negative Codex websocket timeouts and limits to their defaults.
+	cfg.SanitizeCodexWebsocket()
+
+	// Reset negative HTTP client pool settings to Go's defaults.
+	cfg.SanitizeHTTPClient()
+	cfg.SanitizeWarmupConnections()
+
+	// Reset negative response size limits to their defaults.
+	cfg.SanitizeLimits()
+
+	// Disable the token count cache for negative sizes.
+	cfg.SanitizeTokenCountCache()
 
 	// Sanitize Claude header defaults.
 	cfg.SanitizeClaudeHeaderDefaults()
@@ -659,6 +1094,12 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
+	// Normalize shadow traffic settings.
+	cfg.SanitizeShadowTraffic()
+
+	// Drop empty or invalid input denylist patterns.
+	cfg.SanitizeInputContentDenylist()
+
 	// NOTE: Legacy migration persistence is intentionally disabled together with
 	// startup legacy migration to keep startup read-only for config.yaml.
 	// Re-enable the block below if automatic startup migration is needed again.
@@ -733,6 +1174,45 @@ func payloadRawString(value any) ([]byte, bool) {
 	}
 }
 
+// SanitizeShadowTraffic trims the shadow target, clamps the sample rate to [0, 1] and
+// defaults the in-flight cap.
+func (cfg *Config) SanitizeShadowTraffic() {
+	if cfg == nil {
+		return
+	}
+	cfg.ShadowTraffic.Provider = strings.ToLower(strings.TrimSpace(cfg.ShadowTraffic.Provider))
+	cfg.ShadowTraffic.Model = strings.TrimSpace(cfg.ShadowTraffic.Model)
+	if cfg.ShadowTraffic.SampleRate < 0 {
+		cfg.ShadowTraffic.SampleRate = 0
+	}
+	if cfg.ShadowTraffic.SampleRate > 1 {
+		cfg.ShadowTraffic.SampleRate = 1
+	}
+	if cfg.ShadowTraffic.MaxInFlight <= 0 {
+		cfg.ShadowTraffic.MaxInFlight = DefaultShadowMaxInFlight
+	}
+}
+
+// SanitizeInputContentDenylist trims denylist patterns and drops empty or invalid expressions.
+func (cfg *Config) SanitizeInputContentDenylist() {
+	if cfg == nil || len(cfg.InputContentDenylist) == 0 {
+		return
+	}
+	out := make([]string, 0, len(cfg.InputContentDenylist))
+	for _, pattern := range cfg.InputContentDenylist {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, errCompile := regexp.Compile(pattern); errCompile != nil {
+			log.Warnf("input-content-denylist: ignoring invalid pattern %q: %v", pattern, errCompile)
+			continue
+		}
+		out = append(out, pattern)
+	}
+	cfg.InputContentDenylist = out
+}
+
 // SanitizeCodexHeaderDefaults trims surrounding whitespace from the
 // configured Codex header fallback values.
 func (cfg *Config) SanitizeCodexHeaderDefaults() {
@@ -743,6 +1223,370 @@ func (cfg *Config) SanitizeCodexHeaderDefaults() {
 	cfg.CodexHeaderDefaults.BetaFeatures = strings.TrimSpace(cfg.CodexHeaderDefaults.BetaFeatures)
 }
 
+// SanitizeCodex trims the Codex client identity overrides and drops values that are blank or
+// not valid header values, so the built-in defaults apply instead.
+func (cfg *Config) SanitizeCodex() {
+	if cfg == nil {
+		return
+	}
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"client-version", &cfg.Codex.ClientVersion},
+		{"user-agent", &cfg.Codex.UserAgent},
+		{"websocket-beta", &cfg.Codex.WebsocketBeta},
+	}
+	for _, field := range fields {
+		*field.value = strings.TrimSpace(*field.value)
+		if strings.ContainsAny(*field.value, "\r\n") {
+			log.WithField("codex."+field.name, *field.value).Warn("invalid codex header value ignored; using the built-in default")
+			*field.value = ""
+		}
+	}
+	if cfg.Codex.WebsocketBeta != "" && !strings.Contains(cfg.Codex.WebsocketBeta, "responses_websockets=") {
+		log.WithField("codex.websocket-beta", cfg.Codex.WebsocketBeta).Warn("codex.websocket-beta must contain responses_websockets=; using the built-in default")
+		cfg.Codex.WebsocketBeta = ""
+	}
+}
+
+// SanitizeTokenCountCache clears negative cache settings so the cache stays disabled and the
+// default TTL applies.
+func (cfg *Config) SanitizeTokenCountCache() {
+	if cfg == nil {
+		return
+	}
+	if cfg.TokenCountCache.Size < 0 {
+		cfg.TokenCountCache.Size = 0
+	}
+	if cfg.TokenCountCache.TTLSeconds < 0 {
+		cfg.TokenCountCache.TTLSeconds = 0
+	}
+}
+
+// SanitizeLimits clears negative size limits so the defaults apply.
+func (cfg *Config) SanitizeLimits() {
+	if cfg == nil {
+		return
+	}
+	if cfg.Limits.MaxResponseBytes < 0 {
+		cfg.Limits.MaxResponseBytes = 0
+	}
+	if cfg.Limits.StreamScannerBufferBytes < 0 {
+		cfg.Limits.StreamScannerBufferBytes = 0
+	}
+}
+
+// SanitizeHTTPClient clears negative pool settings so Go's defaults apply.
+func (cfg *Config) SanitizeHTTPClient() {
+	if cfg == nil {
+		return
+	}
+	if cfg.HTTPClient.MaxIdleConnsPerHost < 0 {
+		cfg.HTTPClient.MaxIdleConnsPerHost = 0
+	}
+	if cfg.HTTPClient.IdleConnTimeoutSeconds < 0 {
+		cfg.HTTPClient.IdleConnTimeoutSeconds = 0
+	}
+}
+
+// SanitizeWarmupConnections normalizes provider names to lower case and drops
+// non-positive connection counts.
+func (cfg *Config) SanitizeWarmupConnections() {
+	if cfg == nil || len(cfg.WarmupConnections) == 0 {
+		return
+	}
+	counts := make(map[string]int, len(cfg.WarmupConnections))
+	for provider, count := range cfg.WarmupConnections {
+		key := strings.ToLower(strings.TrimSpace(provider))
+		if key == "" || count <= 0 {
+			continue
+		}
+		counts[key] = count
+	}
+	if len(counts) == 0 {
+		counts = nil
+	}
+	cfg.WarmupConnections = counts
+}
+
+// SanitizeAuthCircuitBreaker disables the breaker for a non-positive threshold and applies
+// default window and cooldown durations.
+func (cfg *Config) SanitizeAuthCircuitBreaker() {
+	if cfg == nil {
+		return
+	}
+	breaker := &cfg.AuthCircuitBreaker
+	if breaker.FailureThreshold <= 0 {
+		*breaker = AuthCircuitBreakerConfig{}
+		return
+	}
+	if breaker.WindowSeconds <= 0 {
+		breaker.WindowSeconds = 60
+	}
+	if breaker.CooldownSeconds <= 0 {
+		breaker.CooldownSeconds = 60
+	}
+}
+
+// SanitizeGeminiCLI clears a negative retry delay cap, normalizes fallback model keys to
+// lower case and drops empty, duplicate or self-referencing entries from each chain.
+func (cfg *Config) SanitizeGeminiCLI() {
+	if cfg == nil {
+		return
+	}
+	if cfg.GeminiCLI.MaxRetryAfterSeconds < 0 {
+		cfg.GeminiCLI.MaxRetryAfterSeconds = 0
+	}
+	if len(cfg.GeminiCLI.FallbackModels) == 0 {
+		return
+	}
+	chains := make(map[string][]string, len(cfg.GeminiCLI.FallbackModels))
+	for model, fallbacks := range cfg.GeminiCLI.FallbackModels {
+		key := strings.ToLower(strings.TrimSpace(model))
+		if key == "" {
+			continue
+		}
+		seen := map[string]struct{}{key: {}}
+		out := make([]string, 0, len(fallbacks))
+		for _, fallback := range fallbacks {
+			fallback = strings.TrimSpace(fallback)
+			lower := strings.ToLower(fallback)
+			if fallback == "" {
+				continue
+			}
+			if _, ok := seen[lower]; ok {
+				continue
+			}
+			seen[lower] = struct{}{}
+			out = append(out, fallback)
+		}
+		if len(out) > 0 {
+			chains[key] = out
+		}
+	}
+	if len(chains) == 0 {
+		chains = nil
+	}
+	cfg.GeminiCLI.FallbackModels = chains
+}
+
+// SanitizeCodexWebsocket clears negative timeout and message size values so the built-in
+// defaults apply and treats a negative session cap as unlimited.
+func (cfg *Config) SanitizeCodexWebsocket() {
+	if cfg == nil {
+		return
+	}
+	if v := cfg.CodexWebsocket.IdleTimeoutSeconds; v != nil && *v < 0 {
+		cfg.CodexWebsocket.IdleTimeoutSeconds = nil
+	}
+	if v := cfg.CodexWebsocket.HandshakeTimeoutSeconds; v != nil && *v < 0 {
+		cfg.CodexWebsocket.HandshakeTimeoutSeconds = nil
+	}
+	if cfg.CodexWebsocket.MaxSessions < 0 {
+		cfg.CodexWebsocket.MaxSessions = 0
+	}
+	if cfg.CodexWebsocket.MaxMessageBytes < 0 {
+		cfg.CodexWebsocket.MaxMessageBytes = 0
+	}
+}
+
+// SanitizeCodexOrphanToolOutput lower-cases the orphaned tool output policy and
+// falls back to "keep" for unknown values.
+func (cfg *Config) SanitizeCodexOrphanToolOutput() {
+	if cfg == nil {
+		return
+	}
+	policy := strings.ToLower(strings.TrimSpace(cfg.CodexOrphanToolOutput))
+	switch policy {
+	case "", CodexOrphanToolOutputKeep:
+		cfg.CodexOrphanToolOutput = ""
+	case CodexOrphanToolOutputDrop, CodexOrphanToolOutputReject:
+		cfg.CodexOrphanToolOutput = policy
+	default:
+		log.WithField("codex-orphan-tool-output", policy).Warn("unsupported codex-orphan-tool-output ignored")
+		cfg.CodexOrphanToolOutput = ""
+	}
+}
+
+// SanitizeContextOverflow lower-cases context window model names, drops non-positive windows
+// and falls back to "proceed" for unknown overflow policies.
+func (cfg *Config) SanitizeContextOverflow() {
+	if cfg == nil {
+		return
+	}
+	if len(cfg.ContextWindows) > 0 {
+		windows := make(map[string]int, len(cfg.ContextWindows))
+		for model, window := range cfg.ContextWindows {
+			key := strings.ToLower(strings.TrimSpace(model))
+			if key == "" || window <= 0 {
+				continue
+			}
+			windows[key] = window
+		}
+		if len(windows) == 0 {
+			windows = nil
+		}
+		cfg.ContextWindows = windows
+	}
+	policy := strings.ToLower(strings.TrimSpace(cfg.ContextOverflowPolicy))
+	switch policy {
+	case "", ContextOverflowPolicyProceed:
+		cfg.ContextOverflowPolicy = ""
+	case ContextOverflowPolicyReject, ContextOverflowPolicyTruncate:
+		cfg.ContextOverflowPolicy = policy
+	default:
+		log.WithField("context-overflow-policy", policy).Warn("unsupported context-overflow-policy ignored")
+		cfg.ContextOverflowPolicy = ""
+	}
+}
+
+// SanitizeStreamingToolCallPolicy lower-cases provider names and policies and drops
+// entries with unknown policies.
+func (cfg *Config) SanitizeStreamingToolCallPolicy() {
+	if cfg == nil || len(cfg.StreamingToolCallPolicy) == 0 {
+		return
+	}
+	policies := make(map[string]string, len(cfg.StreamingToolCallPolicy))
+	for provider, policy := range cfg.StreamingToolCallPolicy {
+		key := strings.ToLower(strings.TrimSpace(provider))
+		policy = strings.ToLower(strings.TrimSpace(policy))
+		if key == "" {
+			continue
+		}
+		switch policy {
+		case StreamingToolCallPolicyProceed, StreamingToolCallPolicyNonStream, StreamingToolCallPolicyStripTools:
+			policies[key] = policy
+		default:
+			log.WithField("provider", key).WithField("streaming-tool-call-policy", policy).Warn("unsupported streaming-tool-call-policy ignored")
+		}
+	}
+	if len(policies) == 0 {
+		policies = nil
+	}
+	cfg.StreamingToolCallPolicy = policies
+}
+
+// SanitizeCodexReasoningDeltas lower-cases the reasoning delta policy and
+// falls back to "forward" for unknown values.
+func (cfg *Config) SanitizeCodexReasoningDeltas() {
+	if cfg == nil {
+		return
+	}
+	policy := strings.ToLower(strings.TrimSpace(cfg.CodexReasoningDeltas))
+	switch policy {
+	case "", CodexReasoningDeltasForward:
+		cfg.CodexReasoningDeltas = ""
+	case CodexReasoningDeltasSuppress:
+		cfg.CodexReasoningDeltas = policy
+	default:
+		log.WithField("codex-reasoning-deltas", policy).Warn("unsupported codex-reasoning-deltas ignored")
+		cfg.CodexReasoningDeltas = ""
+	}
+}
+
+// SanitizeReasoningEffortMapping lower-cases provider, level and value entries and drops
+// empty ones.
+func (cfg *Config) SanitizeReasoningEffortMapping() {
+	if cfg == nil || len(cfg.ReasoningEffortMapping) == 0 {
+		return
+	}
+	mapping := make(map[string]map[string]string, len(cfg.ReasoningEffortMapping))
+	for provider, levels := range cfg.ReasoningEffortMapping {
+		providerKey := strings.ToLower(strings.TrimSpace(provider))
+		if providerKey == "" {
+			continue
+		}
+		for level, value := range levels {
+			levelKey := strings.ToLower(strings.TrimSpace(level))
+			value = strings.ToLower(strings.TrimSpace(value))
+			if levelKey == "" || value == "" {
+				continue
+			}
+			if mapping[providerKey] == nil {
+				mapping[providerKey] = make(map[string]string, len(levels))
+			}
+			mapping[providerKey][levelKey] = value
+		}
+	}
+	if len(mapping) == 0 {
+		mapping = nil
+	}
+	cfg.ReasoningEffortMapping = mapping
+}
+
+// SanitizeModelDefaultThinkingSuffix lower-cases model names, strips surrounding parentheses
+// from suffixes and drops empty entries.
+func (cfg *Config) SanitizeModelDefaultThinkingSuffix() {
+	if cfg == nil || len(cfg.ModelDefaultThinkingSuffix) == 0 {
+		return
+	}
+	defaults := make(map[string]string, len(cfg.ModelDefaultThinkingSuffix))
+	for model, suffix := range cfg.ModelDefaultThinkingSuffix {
+		key := strings.ToLower(strings.TrimSpace(model))
+		suffix = strings.TrimSpace(suffix)
+		suffix = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(suffix, "("), ")"))
+		if key == "" || suffix == "" {
+			continue
+		}
+		defaults[key] = suffix
+	}
+	if len(defaults) == 0 {
+		defaults = nil
+	}
+	cfg.ModelDefaultThinkingSuffix = defaults
+}
+
+// SanitizeGeminiThoughtParts lower-cases the Gemini thought part policy and
+// falls back to "forward" for unknown values.
+func (cfg *Config) SanitizeGeminiThoughtParts() {
+	if cfg == nil {
+		return
+	}
+	policy := strings.ToLower(strings.TrimSpace(cfg.GeminiThoughtParts))
+	switch policy {
+	case "", GeminiThoughtPartsForward:
+		cfg.GeminiThoughtParts = ""
+	case GeminiThoughtPartsSuppress:
+		cfg.GeminiThoughtParts = policy
+	default:
+		log.WithField("gemini-thought-parts", policy).Warn("unsupported gemini-thought-parts ignored")
+		cfg.GeminiThoughtParts = ""
+	}
+}
+
+// SanitizeModelConcurrencyLimits normalizes model names to lower case, drops
+// non-positive limits and falls back to the "queue" policy for unknown values.
+func (cfg *Config) SanitizeModelConcurrencyLimits() {
+	if cfg == nil {
+		return
+	}
+	if len(cfg.ModelConcurrencyLimits) > 0 {
+		limits := make(map[string]int, len(cfg.ModelConcurrencyLimits))
+		for model, limit := range cfg.ModelConcurrencyLimits {
+			key := strings.ToLower(strings.TrimSpace(model))
+			if key == "" || limit <= 0 {
+				continue
+			}
+			limits[key] = limit
+		}
+		if len(limits) == 0 {
+			limits = nil
+		}
+		cfg.ModelConcurrencyLimits = limits
+	}
+	policy := strings.ToLower(strings.TrimSpace(cfg.ModelConcurrencyPolicy))
+	switch policy {
+	case "", ModelConcurrencyPolicyQueue:
+		cfg.ModelConcurrencyPolicy = ""
+	case ModelConcurrencyPolicyReject:
+		cfg.ModelConcurrencyPolicy = policy
+	default:
+		log.WithField("model-concurrency-policy", policy).Warn("unsupported model-concurrency-policy ignored")
+		cfg.ModelConcurrencyPolicy = ""
+	}
+}
+
 // SanitizeClaudeHeaderDefaults trims surrounding whitespace from the
 // configured Claude fingerprint baseline values.
 func (cfg *Config) SanitizeClaudeHeaderDefaults() {
@@ -813,11 +1657,77 @@ func (cfg *Config) SanitizeOpenAICompatibility() {
 			// Skip providers with no base-url; treated as removed
 			continue
 		}
+		for j := range e.Models {
+			e.Models[j].MaxTokensField = normalizeMaxTokensField(e.Models[j].MaxTokensField)
+		}
 		out = append(out, e)
 	}
 	cfg.OpenAICompatibility = out
 }
 
+// EffectiveStripFields returns StripFields followed by the fields implied by the provider's
+// capability flags, without blanks or duplicates.
+func (e OpenAICompatibility) EffectiveStripFields() []string {
+	fields := make([]string, 0, len(e.StripFields)+2)
+	seen := make(map[string]struct{}, len(e.StripFields)+2)
+	add := func(field string) {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			return
+		}
+		if _, ok := seen[field]; ok {
+			return
+		}
+		seen[field] = struct{}{}
+		fields = append(fields, field)
+	}
+	for _, field := range e.StripFields {
+		add(field)
+	}
+	if e.SupportsLogitBias != nil && !*e.SupportsLogitBias {
+		add("logit_bias")
+	}
+	if e.SupportsParallelToolCalls != nil && !*e.SupportsParallelToolCalls {
+		add("parallel_tool_calls")
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// normalizeMaxTokensField lower-cases a configured output token limit field name and
+// drops values other than "max_tokens" and "max_completion_tokens".
+func normalizeMaxTokensField(field string) string {
+	field = strings.ToLower(strings.TrimSpace(field))
+	switch field {
+	case "", "max_tokens", "max_completion_tokens":
+		return field
+	default:
+		log.WithField("max-tokens-field", field).Warn("openai-compatibility: unsupported max-tokens-field ignored")
+		return ""
+	}
+}
+
+// SanitizeOpenAIPenaltyModels lower-cases model names and drops blank and duplicate entries.
+func (cfg *Config) SanitizeOpenAIPenaltyModels() {
+	if cfg == nil || len(cfg.OpenAIPenaltyModels) == 0 {
+		return
+	}
+	models := make([]string, 0, len(cfg.OpenAIPenaltyModels))
+	for _, model := range cfg.OpenAIPenaltyModels {
+		model = strings.ToLower(strings.TrimSpace(model))
+		if model == "" || slices.Contains(models, model) {
+			continue
+		}
+		models = append(models, model)
+	}
+	if len(models) == 0 {
+		models = nil
+	}
+	cfg.OpenAIPenaltyModels = models
+}
+
 // SanitizeCodexKeys removes Codex API key entries missing a BaseURL.
 // It trims whitespace and preserves order for remaining entries.
 func (cfg *Config) SanitizeCodexKeys() {
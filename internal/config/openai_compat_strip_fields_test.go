@@ -0,0 +1,32 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestOpenAICompatibilityEffectiveStripFields(t *testing.T) {
+	unsupported := false
+	supported := true
+	cases := []struct {
+		name  string
+		entry OpenAICompatibility
+		want  []string
+	}{
+		{name: "none", entry: OpenAICompatibility{}, want: nil},
+		{name: "supported flags add nothing", entry: OpenAICompatibility{StripFields: []string{"logprobs"}, SupportsLogitBias: &supported}, want: []string{"logprobs"}},
+		{
+			name:  "unsupported flags become strip fields",
+			entry: OpenAICompatibility{StripFields: []string{" logprobs ", ""}, SupportsLogitBias: &unsupported, SupportsParallelToolCalls: &unsupported},
+			want:  []string{"logprobs", "logit_bias", "parallel_tool_calls"},
+		},
+		{name: "no duplicates", entry: OpenAICompatibility{StripFields: []string{"logit_bias"}, SupportsLogitBias: &unsupported}, want: []string{"logit_bias"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.entry.EffectiveStripFields(); !slices.Equal(got, tc.want) {
+				t.Fatalf("EffectiveStripFields() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSanitizeOpenAIPenaltyModels(t *testing.T) {
+	cfg := &Config{OpenAIPenaltyModels: []string{" Gemini-2.5-Pro ", "", "gemini-2.5-pro", "*"}}
+	cfg.SanitizeOpenAIPenaltyModels()
+	if want := []string{"gemini-2.5-pro", "*"}; !slices.Equal(cfg.OpenAIPenaltyModels, want) {
+		t.Fatalf("OpenAIPenaltyModels = %q, want %q", cfg.OpenAIPenaltyModels, want)
+	}
+
+	cfg = &Config{OpenAIPenaltyModels: []string{" "}}
+	cfg.SanitizeOpenAIPenaltyModels()
+	if cfg.OpenAIPenaltyModels != nil {
+		t.Fatalf("OpenAIPenaltyModels = %q, want nil", cfg.OpenAIPenaltyModels)
+	}
+}
@@ -24,17 +24,56 @@ type SDKConfig struct {
 	// Default is false (disabled).
 	PassthroughHeaders bool `yaml:"passthrough-headers" json:"passthrough-headers"`
 
+	// NormalizeErrors rewrites upstream error bodies into the client's error format with a
+	// provider-independent type (rate_limit, invalid_request, authentication, server_error,
+	// overloaded), preserving the original message. Default is false (pass through as-is).
+	NormalizeErrors bool `yaml:"normalize-errors,omitempty" json:"normalize-errors,omitempty"`
+
+	// DefaultModel is used for requests that omit the model. When empty, such requests are
+	// rejected with HTTP 400.
+	DefaultModel string `yaml:"default-model,omitempty" json:"default-model,omitempty"`
+
 	// Streaming configures server-side streaming behavior (keep-alives and safe bootstrap retries).
 	Streaming StreamingConfig `yaml:"streaming" json:"streaming"`
 
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// AutoContinueOnLength is the maximum number of follow-up requests issued when a non-streaming
+	// chat completion stops with finish_reason "length". Each follow-up appends the output so far
+	// and the results are concatenated. <= 0 disables auto-continue. Default is 0.
+	AutoContinueOnLength int `yaml:"auto-continue-on-length,omitempty" json:"auto-continue-on-length,omitempty"`
+
+	// PinAuthAPIKeys lists client API keys allowed to send the X-Pin-Auth header, which forces a
+	// request onto a specific credential for debugging. Empty disables the header.
+	PinAuthAPIKeys []string `yaml:"pin-auth-api-keys,omitempty" json:"pin-auth-api-keys,omitempty"`
+
+	// RawPassthroughAPIKeys lists client API keys allowed to send X-Raw-Passthrough: true, which
+	// forwards the request body to the upstream untranslated and returns its native response.
+	// Empty disables the header.
+	RawPassthroughAPIKeys []string `yaml:"raw-passthrough-api-keys,omitempty" json:"raw-passthrough-api-keys,omitempty"`
+
+	// DryRunAPIKeys lists client API keys allowed to send X-CLIProxy-DryRun: true, which returns
+	// the prepared upstream request with credentials redacted instead of sending it. Providers
+	// without dry-run support reject such requests with 501. Empty disables the header.
+	DryRunAPIKeys []string `yaml:"dry-run-api-keys,omitempty" json:"dry-run-api-keys,omitempty"`
+
+	// EchoEffectiveRequestAPIKeys lists client API keys allowed to send
+	// X-Echo-Effective-Request: true, which returns the redacted upstream request body in the
+	// X-Effective-Request response header. Other keys' header is ignored. Empty disables it.
+	EchoEffectiveRequestAPIKeys []string `yaml:"echo-effective-request-api-keys,omitempty" json:"echo-effective-request-api-keys,omitempty"`
+
+	// IdempotencyTTLSeconds caches successful non-streaming responses for requests carrying an
+	// Idempotency-Key header, so a client retry within this many seconds replays the response
+	// instead of calling upstream again. <= 0 disables the cache. Default is 0.
+	IdempotencyTTLSeconds int `yaml:"idempotency-ttl-seconds,omitempty" json:"idempotency-ttl-seconds,omitempty"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
 type StreamingConfig struct {
 	// KeepAliveSeconds controls how often the server emits SSE heartbeats (": keep-alive\n\n").
+	// Vertex streams also emit ": ping\n\n" at this interval until the first upstream chunk.
 	// <= 0 disables keep-alives. Default is 0.
 	KeepAliveSeconds int `yaml:"keepalive-seconds,omitempty" json:"keepalive-seconds,omitempty"`
 
@@ -42,4 +81,15 @@ type StreamingConfig struct {
 	// to allow auth rotation / transient recovery.
 	// <= 0 disables bootstrap retries. Default is 0.
 	BootstrapRetries int `yaml:"bootstrap-retries,omitempty" json:"bootstrap-retries,omitempty"`
+
+	// FirstByteTimeoutSeconds aborts a streaming request with 504 when the upstream sends no payload
+	// within this many seconds. Once data flows no total cap applies.
+	// <= 0 disables the timeout. Default is 0.
+	FirstByteTimeoutSeconds int `yaml:"first-byte-timeout-seconds,omitempty" json:"first-byte-timeout-seconds,omitempty"`
+
+	// EmitFinalUsage writes a final usage chunk before the OpenAI `[DONE]` marker when the
+	// translated stream carried no usage of its own. It applies to OpenAI chat completions
+	// streams only; Claude and Responses streams carry usage in their closing events.
+	// Default is false.
+	EmitFinalUsage bool `yaml:"emit-final-usage,omitempty" json:"emit-final-usage,omitempty"`
 }
@@ -2,6 +2,13 @@ package config
 
 import "strings"
 
+// VertexConfig configures Vertex AI service-account requests.
+type VertexConfig struct {
+	// FallbackLocations lists additional regions tried in order when the credential's
+	// location answers with 429, 500 or 503.
+	FallbackLocations []string `yaml:"fallback-locations,omitempty" json:"fallback-locations,omitempty"`
+}
+
 // VertexCompatKey represents the configuration for Vertex AI-compatible API keys.
 // This supports third-party services that use Vertex AI-style endpoint paths
 // (/publishers/google/models/{model}:streamGenerateContent) but authenticate
@@ -96,3 +103,24 @@ func (cfg *Config) SanitizeVertexCompatKeys() {
 	}
 	cfg.VertexCompatAPIKey = out
 }
+
+// SanitizeVertex trims fallback locations and drops empty or duplicate entries.
+func (cfg *Config) SanitizeVertex() {
+	if cfg == nil || len(cfg.Vertex.FallbackLocations) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(cfg.Vertex.FallbackLocations))
+	out := cfg.Vertex.FallbackLocations[:0]
+	for _, location := range cfg.Vertex.FallbackLocations {
+		location = strings.TrimSpace(location)
+		if location == "" {
+			continue
+		}
+		if _, ok := seen[location]; ok {
+			continue
+		}
+		seen[location] = struct{}{}
+		out = append(out, location)
+	}
+	cfg.Vertex.FallbackLocations = out
+}
@@ -9,6 +9,7 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -72,6 +73,23 @@ type RequestLogger interface {
 	IsEnabled() bool
 }
 
+// SampledRequestLogger is implemented by request loggers that can capture individual
+// requests in full while request logging is otherwise disabled.
+type SampledRequestLogger interface {
+	// SampleRate returns the fraction (0.0-1.0) of requests to capture in full.
+	SampleRate() float64
+
+	// ForceLogAllowed reports whether requests authenticated with apiKey may force full
+	// logging with the X-Force-Log header.
+	ForceLogAllowed(apiKey string) bool
+
+	// LogSampledRequest behaves like LogRequest but writes even when logging is disabled.
+	LogSampledRequest(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error
+
+	// LogSampledStreamingRequest behaves like LogStreamingRequest but writes even when logging is disabled.
+	LogSampledStreamingRequest(url, method string, headers map[string][]string, body []byte, requestID string) (StreamingLogWriter, error)
+}
+
 // StreamingLogWriter handles real-time logging of streaming response chunks.
 // It provides methods for writing streaming response data asynchronously.
 type StreamingLogWriter interface {
@@ -135,6 +153,13 @@ type FileRequestLogger struct {
 
 	// errorLogsMaxFiles limits the number of error log files retained.
 	errorLogsMaxFiles int
+
+	// sampleRateBits holds the math.Float64bits of the fraction of requests captured in full
+	// while logging is disabled. It is updated on config reload while requests read it.
+	sampleRateBits atomic.Uint64
+
+	// forceLogAPIKeys lists client API keys allowed to force full logging.
+	forceLogAPIKeys atomic.Pointer[[]string]
 }
 
 // NewFileRequestLogger creates a new file-based request logger.
@@ -185,6 +210,37 @@ func (l *FileRequestLogger) SetErrorLogsMaxFiles(maxFiles int) {
 	l.errorLogsMaxFiles = maxFiles
 }
 
+// SetSampleRate updates the fraction of requests captured in full while logging is disabled.
+// Values outside 0.0-1.0 are clamped.
+func (l *FileRequestLogger) SetSampleRate(rate float64) {
+	l.sampleRateBits.Store(math.Float64bits(min(max(rate, 0), 1)))
+}
+
+// SampleRate returns the fraction of requests captured in full while logging is disabled.
+func (l *FileRequestLogger) SampleRate() float64 {
+	return math.Float64frombits(l.sampleRateBits.Load())
+}
+
+// SetForceLogAPIKeys updates the client API keys allowed to force full logging.
+func (l *FileRequestLogger) SetForceLogAPIKeys(keys []string) {
+	keys = append([]string(nil), keys...)
+	l.forceLogAPIKeys.Store(&keys)
+}
+
+// ForceLogAllowed reports whether apiKey may force full logging with X-Force-Log.
+func (l *FileRequestLogger) ForceLogAllowed(apiKey string) bool {
+	keys := l.forceLogAPIKeys.Load()
+	if keys == nil || apiKey == "" {
+		return false
+	}
+	for _, allowed := range *keys {
+		if allowed == apiKey {
+			return true
+		}
+	}
+	return false
+}
+
 // LogRequest logs a complete non-streaming request/response cycle to a file.
 //
 // Parameters:
@@ -204,17 +260,23 @@ func (l *FileRequestLogger) SetErrorLogsMaxFiles(maxFiles int) {
 // Returns:
 //   - error: An error if logging fails, nil otherwise
 func (l *FileRequestLogger) LogRequest(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error {
-	return l.logRequest(url, method, requestHeaders, body, statusCode, responseHeaders, response, apiRequest, apiResponse, apiResponseErrors, false, requestID, requestTimestamp, apiResponseTimestamp)
+	return l.logRequest(url, method, requestHeaders, body, statusCode, responseHeaders, response, apiRequest, apiResponse, apiResponseErrors, false, false, requestID, requestTimestamp, apiResponseTimestamp)
+}
+
+// LogSampledRequest logs a request that was selected for full capture by sampling or
+// an explicit force header. It writes a regular log file even when logging is disabled.
+func (l *FileRequestLogger) LogSampledRequest(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error {
+	return l.logRequest(url, method, requestHeaders, body, statusCode, responseHeaders, response, apiRequest, apiResponse, apiResponseErrors, false, true, requestID, requestTimestamp, apiResponseTimestamp)
 }
 
 // LogRequestWithOptions logs a request with optional forced logging behavior.
 // The force flag allows writing error logs even when regular request logging is disabled.
 func (l *FileRequestLogger) LogRequestWithOptions(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error {
-	return l.logRequest(url, method, requestHeaders, body, statusCode, responseHeaders, response, apiRequest, apiResponse, apiResponseErrors, force, requestID, requestTimestamp, apiResponseTimestamp)
+	return l.logRequest(url, method, requestHeaders, body, statusCode, responseHeaders, response, apiRequest, apiResponse, apiResponseErrors, force, false, requestID, requestTimestamp, apiResponseTimestamp)
 }
 
-func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error {
-	if !l.enabled && !force {
+func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, force, sampled bool, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error {
+	if !l.enabled && !force && !sampled {
 		return nil
 	}
 
@@ -225,7 +287,7 @@ func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[st
 
 	// Generate filename with request ID
 	filename := l.generateFilename(url, requestID)
-	if force && !l.enabled {
+	if force && !l.enabled && !sampled {
 		filename = l.generateErrorFilename(url, requestID)
 	}
 	filePath := filepath.Join(l.logsDir, filename)
@@ -302,7 +364,17 @@ func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[st
 //   - StreamingLogWriter: A writer for streaming response chunks
 //   - error: An error if logging initialization fails, nil otherwise
 func (l *FileRequestLogger) LogStreamingRequest(url, method string, headers map[string][]string, body []byte, requestID string) (StreamingLogWriter, error) {
-	if !l.enabled {
+	return l.logStreamingRequest(url, method, headers, body, requestID, false)
+}
+
+// LogSampledStreamingRequest initiates logging for a streaming request that was selected
+// for full capture, writing a regular log file even when logging is disabled.
+func (l *FileRequestLogger) LogSampledStreamingRequest(url, method string, headers map[string][]string, body []byte, requestID string) (StreamingLogWriter, error) {
+	return l.logStreamingRequest(url, method, headers, body, requestID, true)
+}
+
+func (l *FileRequestLogger) logStreamingRequest(url, method string, headers map[string][]string, body []byte, requestID string, sampled bool) (StreamingLogWriter, error) {
+	if !l.enabled && !sampled {
 		return &NoOpStreamingLogWriter{}, nil
 	}
 
@@ -14,6 +14,12 @@ type requestIDKey struct{}
 // ginRequestIDKey is the Gin context key for request IDs.
 const ginRequestIDKey = "__request_id__"
 
+// ginRequestLogSampledKey is the Gin context key marking requests selected for full logging.
+const ginRequestLogSampledKey = "__request_log_sampled__"
+
+// ginRequestLogForceCheckKey is the Gin context key holding the pending X-Force-Log check.
+const ginRequestLogForceCheckKey = "__request_log_force_check__"
+
 // GenerateRequestID creates a new 8-character hex request ID.
 func GenerateRequestID() string {
 	b := make([]byte, 4)
@@ -59,3 +65,40 @@ func GetGinRequestID(c *gin.Context) string {
 	}
 	return ""
 }
+
+// SetGinRequestLogSampled marks the request as selected for full request logging.
+func SetGinRequestLogSampled(c *gin.Context) {
+	if c != nil {
+		c.Set(ginRequestLogSampledKey, true)
+	}
+}
+
+// SetGinRequestLogForceCheck registers check for a request that asked for full logging with
+// X-Force-Log. IsGinRequestLogSampled consults it until it succeeds, so the header can be
+// honoured once authentication has identified the caller.
+func SetGinRequestLogForceCheck(c *gin.Context, check func() bool) {
+	if c != nil && check != nil {
+		c.Set(ginRequestLogForceCheckKey, check)
+	}
+}
+
+// IsGinRequestLogSampled reports whether the request was selected for full request logging
+// while request logging is otherwise disabled.
+func IsGinRequestLogSampled(c *gin.Context) bool {
+	if c == nil {
+		return false
+	}
+	if c.GetBool(ginRequestLogSampledKey) {
+		return true
+	}
+	value, exists := c.Get(ginRequestLogForceCheckKey)
+	if !exists {
+		return false
+	}
+	check, ok := value.(func() bool)
+	if !ok || !check() {
+		return false
+	}
+	c.Set(ginRequestLogSampledKey, true)
+	return true
+}
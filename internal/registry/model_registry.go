@@ -788,40 +788,11 @@ func (r *ModelRegistry) buildAvailableModelsLocked(handlerType string, now time.
 	var expiresAt time.Time
 
 	for _, registration := range r.models {
-		availableClients := registration.Count
-
-		expiredClients := 0
-		for _, quotaTime := range registration.QuotaExceededClients {
-			if quotaTime == nil {
-				continue
-			}
-			recoveryAt := quotaTime.Add(modelQuotaExceededWindow)
-			if now.Before(recoveryAt) {
-				expiredClients++
-				if expiresAt.IsZero() || recoveryAt.Before(expiresAt) {
-					expiresAt = recoveryAt
-				}
-			}
-		}
-
-		cooldownSuspended := 0
-		otherSuspended := 0
-		if registration.SuspendedClients != nil {
-			for _, reason := range registration.SuspendedClients {
-				if strings.EqualFold(reason, "quota") {
-					cooldownSuspended++
-					continue
-				}
-				otherSuspended++
-			}
-		}
-
-		effectiveClients := availableClients - expiredClients - otherSuspended
-		if effectiveClients < 0 {
-			effectiveClients = 0
+		availability, recoveryAt := countModelAvailability(registration, registration.Count, now, nil)
+		if !recoveryAt.IsZero() && (expiresAt.IsZero() || recoveryAt.Before(expiresAt)) {
+			expiresAt = recoveryAt
 		}
-
-		if effectiveClients > 0 || (availableClients > 0 && (expiredClients > 0 || cooldownSuspended > 0) && otherSuspended == 0) {
+		if availability.listed() {
 			model := r.convertModelToMap(registration.Info, handlerType)
 			if model != nil {
 				models = append(models, model)
@@ -832,6 +803,63 @@ func (r *ModelRegistry) buildAvailableModelsLocked(handlerType string, now time.
 	return models, expiresAt
 }
 
+// modelAvailability counts the clients of a model registration and how many of them cannot
+// serve requests right now.
+type modelAvailability struct {
+	total             int
+	quotaExceeded     int
+	cooldownSuspended int
+	otherSuspended    int
+}
+
+// effective returns the number of clients free to serve requests.
+func (a modelAvailability) effective() int {
+	if n := a.total - a.quotaExceeded - a.otherSuspended; n > 0 {
+		return n
+	}
+	return 0
+}
+
+// listed reports whether the model belongs in model lists: a client can serve it, or every
+// unusable client is only cooling down after a quota error.
+func (a modelAvailability) listed() bool {
+	return a.effective() > 0 || (a.total > 0 && (a.quotaExceeded > 0 || a.cooldownSuspended > 0) && a.otherSuspended == 0)
+}
+
+// countModelAvailability tallies the quota-exceeded and suspended clients of registration at
+// now, out of total clients. When include is non-nil, only clients it accepts are counted.
+// It also returns the earliest quota recovery time still ahead, or zero when there is none.
+func countModelAvailability(registration *ModelRegistration, total int, now time.Time, include func(clientID string) bool) (modelAvailability, time.Time) {
+	availability := modelAvailability{total: total}
+	var recoveryAt time.Time
+	if registration == nil {
+		return availability, recoveryAt
+	}
+	for clientID, quotaTime := range registration.QuotaExceededClients {
+		if quotaTime == nil || (include != nil && !include(clientID)) {
+			continue
+		}
+		recovery := quotaTime.Add(modelQuotaExceededWindow)
+		if now.Before(recovery) {
+			availability.quotaExceeded++
+			if recoveryAt.IsZero() || recovery.Before(recoveryAt) {
+				recoveryAt = recovery
+			}
+		}
+	}
+	for clientID, reason := range registration.SuspendedClients {
+		if include != nil && !include(clientID) {
+			continue
+		}
+		if strings.EqualFold(reason, "quota") {
+			availability.cooldownSuspended++
+			continue
+		}
+		availability.otherSuspended++
+	}
+	return availability, recoveryAt
+}
+
 func cloneModelMaps(models []map[string]any) []map[string]any {
 	cloned := make([]map[string]any, 0, len(models))
 	for _, model := range models {
@@ -938,48 +966,11 @@ func (r *ModelRegistry) GetAvailableModelsByProvider(provider string) []*ModelIn
 			continue
 		}
 		registration, ok := r.models[modelID]
-
-		expiredClients := 0
-		cooldownSuspended := 0
-		otherSuspended := 0
-		if ok && registration != nil {
-			if registration.QuotaExceededClients != nil {
-				for clientID, quotaTime := range registration.QuotaExceededClients {
-					if clientID == "" {
-						continue
-					}
-					if p, okProvider := r.clientProviders[clientID]; !okProvider || p != provider {
-						continue
-					}
-					if quotaTime != nil && now.Sub(*quotaTime) < modelQuotaExceededWindow {
-						expiredClients++
-					}
-				}
-			}
-			if registration.SuspendedClients != nil {
-				for clientID, reason := range registration.SuspendedClients {
-					if clientID == "" {
-						continue
-					}
-					if p, okProvider := r.clientProviders[clientID]; !okProvider || p != provider {
-						continue
-					}
-					if strings.EqualFold(reason, "quota") {
-						cooldownSuspended++
-						continue
-					}
-					otherSuspended++
-				}
-			}
-		}
-
-		availableClients := entry.count
-		effectiveClients := availableClients - expiredClients - otherSuspended
-		if effectiveClients < 0 {
-			effectiveClients = 0
-		}
-
-		if effectiveClients > 0 || (availableClients > 0 && (expiredClients > 0 || cooldownSuspended > 0) && otherSuspended == 0) {
+		availability, _ := countModelAvailability(registration, entry.count, now, func(clientID string) bool {
+			p, okProvider := r.clientProviders[clientID]
+			return clientID != "" && okProvider && p == provider
+		})
+		if availability.listed() {
 			if entry.info != nil {
 				result = append(result, cloneModelInfo(entry.info))
 				continue
@@ -993,6 +984,56 @@ func (r *ModelRegistry) GetAvailableModelsByProvider(provider string) []*ModelIn
 	return result
 }
 
+// AggregatedModel describes a model listed once across every provider serving it.
+type AggregatedModel struct {
+	// Info contains the model metadata.
+	Info *ModelInfo
+	// Providers lists the provider identifiers with clients serving the model, sorted.
+	Providers []string
+	// RateLimited is true when every client serving the model is cooling down after a quota error.
+	RateLimited bool
+}
+
+// ListAllModels returns every available model across all providers, deduplicated by model ID
+// and sorted by ID. Each entry is annotated with its providers and rate-limit state.
+//
+// Returns:
+//   - []AggregatedModel: One entry per available model
+func (r *ModelRegistry) ListAllModels() []AggregatedModel {
+	now := time.Now()
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	result := make([]AggregatedModel, 0, len(r.models))
+	for _, registration := range r.models {
+		if registration == nil || registration.Info == nil {
+			continue
+		}
+		availability, _ := countModelAvailability(registration, registration.Count, now, nil)
+		if !availability.listed() {
+			continue
+		}
+
+		providers := make([]string, 0, len(registration.Providers))
+		for provider, count := range registration.Providers {
+			if count > 0 {
+				providers = append(providers, provider)
+			}
+		}
+		sort.Strings(providers)
+
+		result = append(result, AggregatedModel{
+			Info:        cloneModelInfo(registration.Info),
+			Providers:   providers,
+			RateLimited: availability.effective()-availability.cooldownSuspended <= 0,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Info.ID < result[j].Info.ID })
+	return result
+}
+
 // GetModelCount returns the number of available clients for a specific model
 // Parameters:
 //   - modelID: The model ID to check
@@ -0,0 +1,65 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListAllModelsAggregatesProviders(t *testing.T) {
+	r := newTestModelRegistry()
+	r.RegisterClient("claude-1", "claude", []*ModelInfo{{ID: "shared-model", OwnedBy: "anthropic"}, {ID: "claude-only"}})
+	r.RegisterClient("compat-1", "openai-compat", []*ModelInfo{{ID: "shared-model", OwnedBy: "compat"}, {ID: "compat-only"}})
+
+	models := r.ListAllModels()
+	var ids []string
+	for _, model := range models {
+		ids = append(ids, model.Info.ID)
+	}
+	if want := []string{"claude-only", "compat-only", "shared-model"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("model IDs = %v, want %v", ids, want)
+	}
+	shared := models[2]
+	if want := []string{"claude", "openai-compat"}; !reflect.DeepEqual(shared.Providers, want) {
+		t.Fatalf("shared-model providers = %v, want %v", shared.Providers, want)
+	}
+	if shared.RateLimited {
+		t.Fatal("shared-model reported rate-limited with available clients")
+	}
+
+	r.SetModelQuotaExceeded("claude-1", "claude-only")
+	for _, model := range r.ListAllModels() {
+		if model.Info.ID == "claude-only" && !model.RateLimited {
+			t.Fatal("claude-only not reported rate-limited after quota error")
+		}
+	}
+}
+
+func TestCountModelAvailabilityFiltersClients(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-time.Minute)
+	stale := now.Add(-2 * modelQuotaExceededWindow)
+	registration := &ModelRegistration{
+		QuotaExceededClients: map[string]*time.Time{"a": &recent, "b": &stale, "c": &recent},
+		SuspendedClients:     map[string]string{"a": "quota", "c": "manual"},
+	}
+
+	all, recoveryAt := countModelAvailability(registration, 3, now, nil)
+	if want := (modelAvailability{total: 3, quotaExceeded: 2, cooldownSuspended: 1, otherSuspended: 1}); all != want {
+		t.Fatalf("availability = %+v, want %+v", all, want)
+	}
+	if want := recent.Add(modelQuotaExceededWindow); !recoveryAt.Equal(want) {
+		t.Fatalf("recoveryAt = %v, want %v", recoveryAt, want)
+	}
+	if all.effective() != 0 || all.listed() {
+		t.Fatalf("effective = %d listed = %v, want an unlisted model", all.effective(), all.listed())
+	}
+
+	onlyA, _ := countModelAvailability(registration, 1, now, func(clientID string) bool { return clientID == "a" })
+	if want := (modelAvailability{total: 1, quotaExceeded: 1, cooldownSuspended: 1}); onlyA != want {
+		t.Fatalf("filtered availability = %+v, want %+v", onlyA, want)
+	}
+	if !onlyA.listed() {
+		t.Fatal("a model whose only client is cooling down should stay listed")
+	}
+}
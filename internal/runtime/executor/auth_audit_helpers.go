@@ -0,0 +1,49 @@
+package executor
+
+import (
+	"context"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// auditAuthRefresh captures auth's current token fingerprint and returns a function that,
+// deferred with Refresh's named results, emits a token_refreshed audit event when the
+// refresh succeeded with a different token:
+//
+//	defer auditAuthRefresh(ctx, auth)(&refreshed, &err)
+func auditAuthRefresh(ctx context.Context, auth *cliproxyauth.Auth) func(**cliproxyauth.Auth, *error) {
+	if auth == nil {
+		return func(**cliproxyauth.Auth, *error) {}
+	}
+	previous := authTokenFingerprint(auth)
+	return func(refreshed **cliproxyauth.Auth, errPtr *error) {
+		if errPtr != nil && *errPtr != nil || refreshed == nil || *refreshed == nil {
+			return
+		}
+		current := authTokenFingerprint(*refreshed)
+		if current == "" || current == previous {
+			return
+		}
+		cliproxyauth.EmitAuthEvent(ctx, cliproxyauth.AuthEvent{
+			Type:                cliproxyauth.AuthEventTokenRefreshed,
+			AuthID:              auth.ID,
+			Provider:            auth.Provider,
+			OldTokenFingerprint: previous,
+			NewTokenFingerprint: current,
+		})
+	}
+}
+
+// authTokenFingerprint fingerprints the access token stored in auth's metadata, falling back
+// to the API key for providers that exchange cookies for keys.
+func authTokenFingerprint(auth *cliproxyauth.Auth) string {
+	if auth == nil || auth.Metadata == nil {
+		return ""
+	}
+	for _, key := range []string{"access_token", "api_key"} {
+		if v, ok := auth.Metadata[key].(string); ok && v != "" {
+			return cliproxyauth.TokenFingerprint(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuditAuthRefreshEmitsHashedFingerprints(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []cliproxyauth.AuthEvent
+	)
+	cliproxyauth.RegisterAuthEventSink(cliproxyauth.AuthEventSinkFunc(func(_ context.Context, event cliproxyauth.AuthEvent) {
+		if !strings.HasPrefix(event.AuthID, "audit-test-") {
+			return
+		}
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+
+	refresh := func(auth *cliproxyauth.Auth, token string, errRefresh error) (refreshed *cliproxyauth.Auth, err error) {
+		defer auditAuthRefresh(context.Background(), auth)(&refreshed, &err)
+		if errRefresh != nil {
+			return nil, errRefresh
+		}
+		auth.Metadata["access_token"] = token
+		return auth, nil
+	}
+
+	auth := &cliproxyauth.Auth{ID: "audit-test-1", Provider: "codex", Metadata: map[string]any{"access_token": "old-token"}}
+	if _, err := refresh(auth, "new-token", nil); err != nil {
+		t.Fatalf("refresh error = %v", err)
+	}
+	if _, err := refresh(auth, "new-token", nil); err != nil {
+		t.Fatalf("refresh error = %v", err)
+	}
+	if _, err := refresh(auth, "", errors.New("refresh failed")); err == nil {
+		t.Fatal("expected refresh error")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 {
+		t.Fatalf("events = %d, want 1 (unchanged and failed refreshes emit nothing)", len(events))
+	}
+	event := events[0]
+	if event.Type != cliproxyauth.AuthEventTokenRefreshed || event.Provider != "codex" || event.Time.IsZero() {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if event.OldTokenFingerprint != cliproxyauth.TokenFingerprint("old-token") || event.NewTokenFingerprint != cliproxyauth.TokenFingerprint("new-token") {
+		t.Fatalf("fingerprints = %q -> %q", event.OldTokenFingerprint, event.NewTokenFingerprint)
+	}
+	for _, fp := range []string{event.OldTokenFingerprint, event.NewTokenFingerprint} {
+		if !strings.HasPrefix(fp, "sha256:") || strings.Contains(fp, "token") {
+			t.Fatalf("fingerprint %q is not a hash", fp)
+		}
+	}
+}
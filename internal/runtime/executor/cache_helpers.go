@@ -2,6 +2,7 @@ package executor
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,6 +18,37 @@ var (
 	codexCacheMu  sync.RWMutex
 )
 
+// PromptCacheStats counts how Codex prompt cache keys were resolved since startup.
+type PromptCacheStats struct {
+	// KeysCreated counts new cache IDs generated for Claude metadata.user_id sessions.
+	KeysCreated uint64 `json:"keys_created"`
+	// KeyReuses counts Claude requests that reused an unexpired cache ID.
+	KeyReuses uint64 `json:"key_reuses"`
+	// ClientKeys counts Responses requests that supplied their own prompt_cache_key.
+	ClientKeys uint64 `json:"client_keys"`
+	// APIKeyDerived counts chat completions requests keyed by the client API key.
+	APIKeyDerived uint64 `json:"api_key_derived"`
+}
+
+// codexPromptCacheCounters backs CodexPromptCacheStats.
+var codexPromptCacheCounters struct {
+	keysCreated   atomic.Uint64
+	keyReuses     atomic.Uint64
+	clientKeys    atomic.Uint64
+	apiKeyDerived atomic.Uint64
+}
+
+// CodexPromptCacheStats returns a snapshot of the Codex prompt cache counters. The key reuse
+// rate for Claude sessions is KeyReuses / (KeysCreated + KeyReuses).
+func CodexPromptCacheStats() PromptCacheStats {
+	return PromptCacheStats{
+		KeysCreated:   codexPromptCacheCounters.keysCreated.Load(),
+		KeyReuses:     codexPromptCacheCounters.keyReuses.Load(),
+		ClientKeys:    codexPromptCacheCounters.clientKeys.Load(),
+		APIKeyDerived: codexPromptCacheCounters.apiKeyDerived.Load(),
+	}
+}
+
 // codexCacheCleanupInterval controls how often expired entries are purged.
 const codexCacheCleanupInterval = 15 * time.Minute
 
@@ -0,0 +1,136 @@
+package executor
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// authCircuit tracks consecutive failures of a single credential.
+type authCircuit struct {
+	failures     int
+	firstFailure time.Time
+	openUntil    time.Time
+	// probing is set while the single half-open trial request is in flight.
+	probing bool
+}
+
+// authCircuitBreaker holds circuits keyed by auth ID, shared by every executor.
+type authCircuitBreaker struct {
+	mu       sync.Mutex
+	circuits map[string]*authCircuit
+	now      func() time.Time
+}
+
+var authCircuits = &authCircuitBreaker{circuits: make(map[string]*authCircuit), now: time.Now}
+
+// checkAuthCircuit returns a 503 error without dialing upstream while auth's circuit is open.
+// Once the cooldown has elapsed a single trial request is allowed through.
+func checkAuthCircuit(cfg *config.Config, auth *cliproxyauth.Auth) error {
+	if cfg == nil || cfg.AuthCircuitBreaker.FailureThreshold <= 0 || auth == nil || auth.ID == "" {
+		return nil
+	}
+	return authCircuits.allow(auth.ID)
+}
+
+// recordAuthCircuit updates auth's circuit with the outcome of a request.
+func recordAuthCircuit(cfg *config.Config, auth *cliproxyauth.Auth, errPtr *error) {
+	if cfg == nil || cfg.AuthCircuitBreaker.FailureThreshold <= 0 || auth == nil || auth.ID == "" || errPtr == nil {
+		return
+	}
+	err := *errPtr
+	switch {
+	case err == nil:
+		authCircuits.success(auth.ID)
+	case circuitCountsError(err):
+		authCircuits.failure(cfg.AuthCircuitBreaker, auth.ID)
+	default:
+		authCircuits.release(auth.ID)
+	}
+}
+
+// recordAuthRefreshCircuit counts a failed token refresh against auth's circuit.
+func recordAuthRefreshCircuit(cfg *config.Config, auth *cliproxyauth.Auth, errPtr *error) {
+	if cfg == nil || cfg.AuthCircuitBreaker.FailureThreshold <= 0 || auth == nil || auth.ID == "" || errPtr == nil || *errPtr == nil {
+		return
+	}
+	authCircuits.failure(cfg.AuthCircuitBreaker, auth.ID)
+}
+
+// circuitCountsError reports whether err indicates a failing account rather than a bad request.
+// Only statuses returned by the upstream count; errors the proxy raises itself, such as a
+// 502 for a payload that failed translation, say nothing about the credential.
+func circuitCountsError(err error) bool {
+	var sErr statusErr
+	if !errors.As(err, &sErr) || !sErr.upstream {
+		return false
+	}
+	code := sErr.StatusCode()
+	return code == http.StatusUnauthorized || code == http.StatusForbidden || code >= http.StatusInternalServerError
+}
+
+func (b *authCircuitBreaker) allow(authID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	circuit, ok := b.circuits[authID]
+	if !ok || circuit.openUntil.IsZero() {
+		return nil
+	}
+	if b.now().Before(circuit.openUntil) || circuit.probing {
+		return statusErr{code: http.StatusServiceUnavailable, msg: "account circuit open"}
+	}
+	circuit.probing = true
+	return nil
+}
+
+func (b *authCircuitBreaker) success(authID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if circuit, ok := b.circuits[authID]; ok {
+		if !circuit.openUntil.IsZero() {
+			log.Infof("auth circuit closed for %s", authID)
+		}
+		delete(b.circuits, authID)
+	}
+}
+
+func (b *authCircuitBreaker) failure(cfg config.AuthCircuitBreakerConfig, authID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	circuit, ok := b.circuits[authID]
+	if !ok {
+		circuit = &authCircuit{}
+		b.circuits[authID] = circuit
+	}
+	if circuit.probing {
+		circuit.probing = false
+		circuit.openUntil = now.Add(time.Duration(cfg.CooldownSeconds) * time.Second)
+		log.Warnf("auth circuit reopened for %s after failed trial request", authID)
+		return
+	}
+	if circuit.failures == 0 || now.Sub(circuit.firstFailure) > time.Duration(cfg.WindowSeconds)*time.Second {
+		circuit.failures = 0
+		circuit.firstFailure = now
+	}
+	circuit.failures++
+	if circuit.failures >= cfg.FailureThreshold && !now.Before(circuit.openUntil) {
+		circuit.openUntil = now.Add(time.Duration(cfg.CooldownSeconds) * time.Second)
+		log.Warnf("auth circuit opened for %s after %d consecutive failures", authID, circuit.failures)
+	}
+}
+
+// release ends a half-open trial whose outcome says nothing about the account, so the next
+// request may try again.
+func (b *authCircuitBreaker) release(authID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if circuit, ok := b.circuits[authID]; ok {
+		circuit.probing = false
+	}
+}
@@ -0,0 +1,128 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+type unauthorizedTransport struct {
+	calls int
+}
+
+func (t *unauthorizedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls++
+	return &http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"error":{"message":"token revoked"}}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestAuthCircuitBreakerOpensAndRecovers(t *testing.T) {
+	now := time.Unix(1000, 0)
+	breaker := &authCircuitBreaker{circuits: make(map[string]*authCircuit), now: func() time.Time { return now }}
+	cfg := config.AuthCircuitBreakerConfig{FailureThreshold: 2, WindowSeconds: 60, CooldownSeconds: 30}
+
+	breaker.failure(cfg, "a")
+	if err := breaker.allow("a"); err != nil {
+		t.Fatalf("allow after one failure = %v, want nil", err)
+	}
+	breaker.failure(cfg, "a")
+	if err := breaker.allow("a"); err == nil {
+		t.Fatal("allow after threshold = nil, want circuit open")
+	}
+
+	now = now.Add(31 * time.Second)
+	if err := breaker.allow("a"); err != nil {
+		t.Fatalf("half-open trial = %v, want nil", err)
+	}
+	if err := breaker.allow("a"); err == nil {
+		t.Fatal("second request during trial = nil, want circuit open")
+	}
+	breaker.failure(cfg, "a")
+	if err := breaker.allow("a"); err == nil {
+		t.Fatal("allow after failed trial = nil, want circuit reopened")
+	}
+
+	now = now.Add(31 * time.Second)
+	if err := breaker.allow("a"); err != nil {
+		t.Fatalf("second trial = %v, want nil", err)
+	}
+	breaker.success("a")
+	if err := breaker.allow("a"); err != nil {
+		t.Fatalf("allow after successful trial = %v, want nil", err)
+	}
+}
+
+func TestAuthCircuitBreakerResetsOutsideWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	breaker := &authCircuitBreaker{circuits: make(map[string]*authCircuit), now: func() time.Time { return now }}
+	cfg := config.AuthCircuitBreakerConfig{FailureThreshold: 2, WindowSeconds: 10, CooldownSeconds: 30}
+
+	breaker.failure(cfg, "a")
+	now = now.Add(11 * time.Second)
+	breaker.failure(cfg, "a")
+	if err := breaker.allow("a"); err != nil {
+		t.Fatalf("allow with failures outside the window = %v, want nil", err)
+	}
+}
+
+func TestClaudeExecuteShortCircuitsOpenAccount(t *testing.T) {
+	cfg := &config.Config{AuthCircuitBreaker: config.AuthCircuitBreakerConfig{FailureThreshold: 2, WindowSeconds: 60, CooldownSeconds: 60}}
+	transport := &unauthorizedTransport{}
+	ctx := context.WithValue(context.Background(), "cliproxy.roundtripper", http.RoundTripper(transport))
+	exec := NewClaudeExecutor(cfg)
+	auth := &cliproxyauth.Auth{ID: "circuit-test-claude", Provider: "claude", Attributes: map[string]string{"api_key": "key"}}
+	t.Cleanup(func() { authCircuits.success(auth.ID) })
+	req := cliproxyexecutor.Request{Model: "claude-sonnet-4", Payload: []byte(`{"model":"claude-sonnet-4","messages":[{"role":"user","content":"hi"}]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")}
+
+	for i := 0; i < 2; i++ {
+		_, err := exec.Execute(ctx, auth, req, opts)
+		var sErr statusErr
+		if !errors.As(err, &sErr) || sErr.StatusCode() != http.StatusUnauthorized {
+			t.Fatalf("attempt %d error = %v, want 401", i, err)
+		}
+	}
+
+	_, err := exec.Execute(ctx, auth, req, opts)
+	var sErr statusErr
+	if !errors.As(err, &sErr) || sErr.StatusCode() != http.StatusServiceUnavailable || sErr.msg != "account circuit open" {
+		t.Fatalf("open circuit error = %v, want 503 account circuit open", err)
+	}
+	if transport.calls != 2 {
+		t.Fatalf("upstream calls = %d, want 2", transport.calls)
+	}
+}
+
+func TestCircuitCountsOnlyUpstreamErrors(t *testing.T) {
+	translationErr := deadLetterError(context.Background(), sdktranslator.DeadLetterStageRequest, sdktranslator.FromString("openai"), sdktranslator.FromString("claude"), "m", []byte(`{}`), []byte(`{`))
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"upstream 500", statusErr{code: http.StatusInternalServerError, upstream: true}, true},
+		{"upstream 401", statusErr{code: http.StatusUnauthorized, upstream: true}, true},
+		{"upstream 400", statusErr{code: http.StatusBadRequest, upstream: true}, false},
+		{"translation 400", translationErr, false},
+		{"local 500", statusErr{code: http.StatusInternalServerError}, false},
+		{"transport error", errors.New("dial tcp: connection refused"), false},
+	}
+	for _, tc := range cases {
+		if got := circuitCountsError(tc.err); got != tc.want {
+			t.Fatalf("%s: circuitCountsError = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+type codexAccountContextKey struct{}
+
+// resolveCodexAccount reads the requested ChatGPT account from the X-Codex-Account-Id header
+// or, failing that, _cliproxy.account_id in the body, and strips the _cliproxy field from
+// payload so it is never sent upstream. A requested account must be the credential's default
+// account_id or listed in its account_ids metadata; anything else is rejected with 400. Auth
+// selection prefers credentials that own the account, so the 400 is only reached when none
+// does. The returned context carries the selection for applyCodexHeaders and applyCodexWebsocketHeaders.
+func resolveCodexAccount(ctx context.Context, auth *cliproxyauth.Auth, payload []byte) (context.Context, []byte, error) {
+	requested := ""
+	if ginCtx := ginContextFrom(ctx); ginCtx != nil && ginCtx.Request != nil {
+		requested = strings.TrimSpace(ginCtx.GetHeader(cliproxyexecutor.CodexAccountHeader))
+	} else if headers := cliproxyexecutor.ClientHeaders(ctx); headers != nil {
+		requested = strings.TrimSpace(headers.Get(cliproxyexecutor.CodexAccountHeader))
+	}
+	if field := gjson.GetBytes(payload, "_cliproxy"); field.Exists() {
+		if requested == "" {
+			requested = strings.TrimSpace(field.Get("account_id").String())
+		}
+		payload, _ = sjson.DeleteBytes(payload, "_cliproxy")
+	}
+	if requested == "" {
+		return ctx, payload, nil
+	}
+	for _, allowed := range auth.CodexAccountIDs() {
+		if allowed == requested {
+			return context.WithValue(ctx, codexAccountContextKey{}, requested), payload, nil
+		}
+	}
+	errBody := []byte(`{"error":{"message":"","type":"invalid_request_error","param":"_cliproxy.account_id","code":"invalid_account_id"}}`)
+	errBody, _ = sjson.SetBytes(errBody, "error.message", fmt.Sprintf("account %s is not available for this Codex credential", requested))
+	return ctx, payload, statusErr{code: http.StatusBadRequest, msg: string(errBody)}
+}
+
+// codexAccountID returns the account selected by resolveCodexAccount, or the credential's
+// default account_id.
+func codexAccountID(ctx context.Context, auth *cliproxyauth.Auth) string {
+	if ctx != nil {
+		if selected, ok := ctx.Value(codexAccountContextKey{}).(string); ok && selected != "" {
+			return selected
+		}
+	}
+	if auth == nil || auth.Metadata == nil {
+		return ""
+	}
+	accountID, _ := auth.Metadata["account_id"].(string)
+	return strings.TrimSpace(accountID)
+}
@@ -0,0 +1,99 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func TestCodexExecutorSelectsRequestedAccount(t *testing.T) {
+	var gotAccount, gotBody string
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		gotAccount = r.Header.Get("Chatgpt-Account-Id")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"output\":[]}}\n\n"))
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{
+		Attributes: map[string]string{"base_url": server.URL},
+		Metadata: map[string]any{
+			"access_token": "oauth-token",
+			"account_id":   "acct-default",
+			"account_ids":  []any{"acct-team", "acct-other"},
+		},
+	}
+	executor := NewCodexExecutor(&config.Config{})
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai-response")}
+	execute := func(header, payload string) error {
+		t.Helper()
+		ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/responses", nil)
+		if header != "" {
+			ginCtx.Request.Header.Set(cliproxyexecutor.CodexAccountHeader, header)
+		}
+		ctx := context.WithValue(context.Background(), "gin", ginCtx)
+		_, err := executor.Execute(ctx, auth, cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(payload)}, opts)
+		return err
+	}
+
+	cases := []struct {
+		name    string
+		header  string
+		payload string
+		want    string
+	}{
+		{name: "default", payload: `{"model":"gpt-5","input":"hi"}`, want: "acct-default"},
+		{name: "header", header: "acct-team", payload: `{"model":"gpt-5","input":"hi"}`, want: "acct-team"},
+		{name: "body field", payload: `{"model":"gpt-5","input":"hi","_cliproxy":{"account_id":"acct-other"}}`, want: "acct-other"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := execute(tc.header, tc.payload); err != nil {
+				t.Fatalf("Execute error: %v", err)
+			}
+			if gotAccount != tc.want {
+				t.Fatalf("Chatgpt-Account-Id = %q, want %q", gotAccount, tc.want)
+			}
+			if strings.Contains(gotBody, "_cliproxy") {
+				t.Fatalf("upstream body still carries _cliproxy: %s", gotBody)
+			}
+		})
+	}
+
+	calls = 0
+	err := execute("acct-unknown", `{"model":"gpt-5","input":"hi"}`)
+	if status, ok := err.(statusErr); !ok || status.code != http.StatusBadRequest {
+		t.Fatalf("unknown account error = %v, want 400", err)
+	}
+	if calls != 0 {
+		t.Fatalf("upstream called %d times for a rejected account", calls)
+	}
+}
+
+func TestApplyCodexWebsocketHeadersUseSelectedAccount(t *testing.T) {
+	auth := &cliproxyauth.Auth{Metadata: map[string]any{"account_id": "acct-default", "account_ids": []string{"acct-team"}}}
+	ctx := cliproxyexecutor.WithClientHeaders(context.Background(), http.Header{cliproxyexecutor.CodexAccountHeader: []string{"acct-team"}})
+
+	ctx, _, err := resolveCodexAccount(ctx, auth, []byte(`{}`))
+	if err != nil {
+		t.Fatalf("resolveCodexAccount error: %v", err)
+	}
+	headers := applyCodexWebsocketHeaders(ctx, http.Header{}, auth, "token", nil)
+	if got := headers.Get("Chatgpt-Account-Id"); got != "acct-team" {
+		t.Fatalf("Chatgpt-Account-Id = %q, want acct-team", got)
+	}
+}
@@ -62,3 +62,68 @@ func TestCodexExecutorCacheHelper_OpenAIChatCompletions_StablePromptCacheKeyFrom
 		t.Fatalf("prompt_cache_key (second call) = %q, want %q", gotKey2, expectedKey)
 	}
 }
+
+func TestCodexPromptCacheStatsCountKeyResolution(t *testing.T) {
+	executor := &CodexExecutor{}
+	url := "https://example.com/responses"
+	before := CodexPromptCacheStats()
+
+	claudeReq := cliproxyexecutor.Request{
+		Model:   "gpt-5-stats",
+		Payload: []byte(`{"metadata":{"user_id":"` + uuid.NewString() + `"}}`),
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := executor.cacheHelper(context.Background(), sdktranslator.FromString("claude"), url, claudeReq, []byte(`{}`)); err != nil {
+			t.Fatalf("cacheHelper(claude) error: %v", err)
+		}
+	}
+	responsesReq := cliproxyexecutor.Request{Model: "gpt-5-stats", Payload: []byte(`{"prompt_cache_key":"client-key"}`)}
+	if _, err := executor.cacheHelper(context.Background(), sdktranslator.FromString("openai-response"), url, responsesReq, []byte(`{}`)); err != nil {
+		t.Fatalf("cacheHelper(openai-response) error: %v", err)
+	}
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Set("apiKey", "stats-api-key")
+	chatCtx := context.WithValue(context.Background(), "gin", ginCtx)
+	if _, err := executor.cacheHelper(chatCtx, sdktranslator.FromString("openai"), url, cliproxyexecutor.Request{Model: "gpt-5-stats"}, []byte(`{}`)); err != nil {
+		t.Fatalf("cacheHelper(openai) error: %v", err)
+	}
+
+	after := CodexPromptCacheStats()
+	if got := after.KeysCreated - before.KeysCreated; got != 1 {
+		t.Fatalf("KeysCreated delta = %d, want 1", got)
+	}
+	if got := after.KeyReuses - before.KeyReuses; got != 2 {
+		t.Fatalf("KeyReuses delta = %d, want 2", got)
+	}
+	if got := after.ClientKeys - before.ClientKeys; got != 1 {
+		t.Fatalf("ClientKeys delta = %d, want 1", got)
+	}
+	if got := after.APIKeyDerived - before.APIKeyDerived; got != 1 {
+		t.Fatalf("APIKeyDerived delta = %d, want 1", got)
+	}
+}
+
+func TestCodexWebsocketPromptCacheStatsCountKeyResolution(t *testing.T) {
+	before := CodexPromptCacheStats()
+
+	claudeReq := cliproxyexecutor.Request{
+		Model:   "gpt-5-ws-stats",
+		Payload: []byte(`{"metadata":{"user_id":"` + uuid.NewString() + `"}}`),
+	}
+	for i := 0; i < 3; i++ {
+		applyCodexPromptCacheHeaders(sdktranslator.FromString("claude"), claudeReq, []byte(`{}`))
+	}
+	responsesReq := cliproxyexecutor.Request{Model: "gpt-5-ws-stats", Payload: []byte(`{"prompt_cache_key":"client-key"}`)}
+	applyCodexPromptCacheHeaders(sdktranslator.FromString("openai-response"), responsesReq, []byte(`{}`))
+
+	after := CodexPromptCacheStats()
+	if got := after.KeysCreated - before.KeysCreated; got != 1 {
+		t.Fatalf("KeysCreated delta = %d, want 1", got)
+	}
+	if got := after.KeyReuses - before.KeyReuses; got != 2 {
+		t.Fatalf("KeyReuses delta = %d, want 2", got)
+	}
+	if got := after.ClientKeys - before.ClientKeys; got != 1 {
+		t.Fatalf("ClientKeys delta = %d, want 1", got)
+	}
+}
@@ -0,0 +1,21 @@
+package executor
+
+import (
+	"bytes"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+// shouldSuppressCodexReasoningDelta reports whether an upstream SSE line carries a
+// reasoning summary delta that the configured policy drops from the client stream.
+func shouldSuppressCodexReasoningDelta(cfg *config.Config, line []byte) bool {
+	if cfg == nil || cfg.CodexReasoningDeltas != config.CodexReasoningDeltasSuppress {
+		return false
+	}
+	if !bytes.HasPrefix(line, dataTag) {
+		return false
+	}
+	data := bytes.TrimSpace(line[len(dataTag):])
+	return gjson.GetBytes(data, "type").String() == "response.reasoning_summary_text.delta"
+}
@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func TestCodexExecutorReasoningDeltaPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.reasoning_summary_text.delta\",\"delta\":\"thinking\"}\n\n"))
+		_, _ = w.Write([]byte("data: {\"type\":\"response.output_text.delta\",\"delta\":\"answer\"}\n\n"))
+		_, _ = w.Write([]byte("data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp-1\",\"output\":[]}}\n\n"))
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name          string
+		policy        string
+		wantReasoning bool
+	}{
+		{name: "forward by default", policy: "", wantReasoning: true},
+		{name: "forward", policy: config.CodexReasoningDeltasForward, wantReasoning: true},
+		{name: "suppress", policy: config.CodexReasoningDeltasSuppress, wantReasoning: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			executor := NewCodexExecutor(&config.Config{CodexReasoningDeltas: tc.policy})
+			auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "sk-test", "base_url": server.URL}}
+			req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"model":"gpt-5","input":[]}`)}
+
+			result, err := executor.ExecuteStream(context.Background(), auth, req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")})
+			if err != nil {
+				t.Fatalf("ExecuteStream() error = %v", err)
+			}
+			var streamed []byte
+			for chunk := range result.Chunks {
+				if chunk.Err != nil {
+					t.Fatalf("stream error = %v", chunk.Err)
+				}
+				streamed = append(streamed, chunk.Payload...)
+			}
+
+			if got := bytes.Contains(streamed, []byte("response.reasoning_summary_text.delta")); got != tc.wantReasoning {
+				t.Fatalf("reasoning delta present = %v, want %v; stream: %s", got, tc.wantReasoning, streamed)
+			}
+			if !bytes.Contains(streamed, []byte("response.output_text.delta")) {
+				t.Fatalf("expected output text delta to be forwarded, got %s", streamed)
+			}
+		})
+	}
+}
+
+func TestCodexExecutorReasoningDeltaPolicyTranslatesToOpenAI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.created\",\"response\":{\"id\":\"resp-1\",\"created_at\":1700000000,\"model\":\"gpt-5\"}}\n\n"))
+		_, _ = w.Write([]byte("data: {\"type\":\"response.reasoning_summary_text.delta\",\"delta\":\"thinking\"}\n\n"))
+		_, _ = w.Write([]byte("data: {\"type\":\"response.output_text.delta\",\"delta\":\"answer\"}\n\n"))
+		_, _ = w.Write([]byte("data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp-1\",\"output\":[]}}\n\n"))
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name          string
+		policy        string
+		wantReasoning bool
+	}{
+		{name: "forward", policy: config.CodexReasoningDeltasForward, wantReasoning: true},
+		{name: "suppress", policy: config.CodexReasoningDeltasSuppress, wantReasoning: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			executor := NewCodexExecutor(&config.Config{CodexReasoningDeltas: tc.policy})
+			auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "sk-test", "base_url": server.URL}}
+			payload := []byte(`{"model":"gpt-5","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+			req := cliproxyexecutor.Request{Model: "gpt-5", Payload: payload}
+			opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload, Stream: true}
+
+			result, err := executor.ExecuteStream(context.Background(), auth, req, opts)
+			if err != nil {
+				t.Fatalf("ExecuteStream() error = %v", err)
+			}
+			var streamed []byte
+			for chunk := range result.Chunks {
+				if chunk.Err != nil {
+					t.Fatalf("stream error = %v", chunk.Err)
+				}
+				streamed = append(streamed, chunk.Payload...)
+			}
+
+			if got := bytes.Contains(streamed, []byte(`"reasoning_content":"thinking"`)); got != tc.wantReasoning {
+				t.Fatalf("reasoning_content present = %v, want %v; stream: %s", got, tc.wantReasoning, streamed)
+			}
+			if !bytes.Contains(streamed, []byte(`"content":"answer"`)) {
+				t.Fatalf("expected translated answer content, got %s", streamed)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// applyCodexOrphanToolOutputPolicy detects tool outputs in a Codex Responses payload whose
+// call_id does not match any tool call earlier in the input and handles them according to
+// the configured policy. Payloads chained with previous_response_id are left untouched
+// because their tool calls live in the upstream conversation state.
+func applyCodexOrphanToolOutputPolicy(cfg *config.Config, body []byte) ([]byte, error) {
+	policy := config.CodexOrphanToolOutputKeep
+	if cfg != nil && cfg.CodexOrphanToolOutput != "" {
+		policy = cfg.CodexOrphanToolOutput
+	}
+	if policy == config.CodexOrphanToolOutputKeep {
+		return body, nil
+	}
+	if strings.TrimSpace(gjson.GetBytes(body, "previous_response_id").String()) != "" {
+		return body, nil
+	}
+	input := gjson.GetBytes(body, "input")
+	if !input.IsArray() {
+		return body, nil
+	}
+
+	items := input.Array()
+	seen := make(map[string]struct{}, len(items))
+	orphans := make([]int, 0)
+	var firstOrphan string
+	for i := range items {
+		item := items[i]
+		callID := strings.TrimSpace(item.Get("call_id").String())
+		switch item.Get("type").String() {
+		case "function_call", "custom_tool_call":
+			if callID != "" {
+				seen[callID] = struct{}{}
+			}
+		case "function_call_output", "custom_tool_call_output":
+			if _, ok := seen[callID]; ok {
+				continue
+			}
+			if len(orphans) == 0 {
+				firstOrphan = callID
+			}
+			orphans = append(orphans, i)
+		}
+	}
+	if len(orphans) == 0 {
+		return body, nil
+	}
+
+	if policy == config.CodexOrphanToolOutputReject {
+		msg := fmt.Sprintf("No tool call found for function call output with call_id %s.", firstOrphan)
+		errBody := []byte(`{"error":{"message":"","type":"invalid_request_error","param":"input","code":"orphaned_tool_output"}}`)
+		errBody, _ = sjson.SetBytes(errBody, "error.message", msg)
+		return body, statusErr{code: http.StatusBadRequest, msg: string(errBody)}
+	}
+
+	// Delete from the end so earlier indexes stay valid.
+	for i := len(orphans) - 1; i >= 0; i-- {
+		updated, errDel := sjson.DeleteBytes(body, fmt.Sprintf("input.%d", orphans[i]))
+		if errDel != nil {
+			continue
+		}
+		body = updated
+	}
+	log.Debugf("codex executor: dropped %d orphaned tool output item(s)", len(orphans))
+	return body, nil
+}
@@ -0,0 +1,187 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+const codexOrphanToolOutputBody = `{"input":[` +
+	`{"type":"message","role":"user","content":[{"type":"input_text","text":"hi"}]},` +
+	`{"type":"function_call","call_id":"call_1","name":"lookup","arguments":"{}"},` +
+	`{"type":"function_call_output","call_id":"call_1","output":"ok"},` +
+	`{"type":"function_call_output","call_id":"call_missing","output":"stale"}]}`
+
+func TestApplyCodexOrphanToolOutputPolicyDrop(t *testing.T) {
+	cfg := &config.Config{CodexOrphanToolOutput: config.CodexOrphanToolOutputDrop}
+
+	out, err := applyCodexOrphanToolOutputPolicy(cfg, []byte(codexOrphanToolOutputBody))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	items := gjson.GetBytes(out, "input").Array()
+	if len(items) != 3 {
+		t.Fatalf("input length = %d, want 3; body=%s", len(items), out)
+	}
+	if got := items[2].Get("call_id").String(); got != "call_1" {
+		t.Fatalf("remaining output call_id = %q, want %q", got, "call_1")
+	}
+}
+
+func TestApplyCodexOrphanToolOutputPolicyReject(t *testing.T) {
+	cfg := &config.Config{CodexOrphanToolOutput: config.CodexOrphanToolOutputReject}
+
+	_, err := applyCodexOrphanToolOutputPolicy(cfg, []byte(codexOrphanToolOutputBody))
+	if err == nil {
+		t.Fatal("expected error for orphaned function_call_output")
+	}
+	var se statusErr
+	if !errors.As(err, &se) {
+		t.Fatalf("error type = %T, want statusErr", err)
+	}
+	if se.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", se.StatusCode(), http.StatusBadRequest)
+	}
+	if !strings.Contains(se.Error(), "invalid_request_error") || !strings.Contains(se.Error(), "call_missing") {
+		t.Fatalf("unexpected error body: %s", se.Error())
+	}
+}
+
+func TestApplyCodexOrphanToolOutputPolicyKeepsChainedRequests(t *testing.T) {
+	cfg := &config.Config{CodexOrphanToolOutput: config.CodexOrphanToolOutputReject}
+	body := []byte(`{"previous_response_id":"resp_1","input":[{"type":"function_call_output","call_id":"call_prev","output":"ok"}]}`)
+
+	out, err := applyCodexOrphanToolOutputPolicy(cfg, body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != string(body) {
+		t.Fatalf("body changed: %s", out)
+	}
+}
+
+func TestApplyCodexOrphanToolOutputPolicyDefaultKeeps(t *testing.T) {
+	out, err := applyCodexOrphanToolOutputPolicy(&config.Config{}, []byte(codexOrphanToolOutputBody))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != codexOrphanToolOutputBody {
+		t.Fatalf("body changed: %s", out)
+	}
+}
+
+const codexChainedToolOutputBody = `{"model":"gpt-5","previous_response_id":"resp_prev","input":[` +
+	`{"type":"function_call_output","call_id":"call_prev","output":"ok"}]}`
+
+func TestCodexExecutorSkipsOrphanPolicyForChainedRequests(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"output\":[]}}\n\n"))
+	}))
+	defer server.Close()
+
+	executor := NewCodexExecutor(&config.Config{CodexOrphanToolOutput: config.CodexOrphanToolOutputReject})
+	auth := &cliproxyauth.Auth{ID: "codex-orphan-chained", Attributes: map[string]string{"api_key": "sk-test", "base_url": server.URL}}
+	req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(codexChainedToolOutputBody)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")}
+
+	if _, err := executor.Execute(context.Background(), auth, req, opts); err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+	result, err := executor.ExecuteStream(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("execute stream error: %v", err)
+	}
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream chunk error: %v", chunk.Err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 {
+		t.Fatalf("upstream requests = %d, want 2", len(bodies))
+	}
+	for i, body := range bodies {
+		if got := gjson.Get(body, "input.0.call_id").String(); got != "call_prev" {
+			t.Fatalf("request %d lost chained tool output: %s", i, body)
+		}
+		if gjson.Get(body, "previous_response_id").Exists() {
+			t.Fatalf("request %d kept previous_response_id: %s", i, body)
+		}
+	}
+}
+
+func TestCodexWebsocketsExecutorSkipsOrphanPolicyForChainedRequests(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		messages []string
+	)
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_, msg, errRead := conn.ReadMessage()
+		if errRead != nil {
+			return
+		}
+		mu.Lock()
+		messages = append(messages, string(msg))
+		mu.Unlock()
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"response.completed","response":{"id":"resp_1","output":[]}}`))
+	}))
+	defer server.Close()
+
+	executor := NewCodexWebsocketsExecutor(&config.Config{CodexOrphanToolOutput: config.CodexOrphanToolOutputReject})
+	auth := &cliproxyauth.Auth{ID: "codex-ws-orphan-chained", Attributes: map[string]string{"api_key": "sk-test", "base_url": server.URL}}
+	req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(codexChainedToolOutputBody)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")}
+
+	if _, err := executor.Execute(context.Background(), auth, req, opts); err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+	result, err := executor.ExecuteStream(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("execute stream error: %v", err)
+	}
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream chunk error: %v", chunk.Err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(messages) != 2 {
+		t.Fatalf("upstream messages = %d, want 2", len(messages))
+	}
+	for i, msg := range messages {
+		if got := gjson.Get(msg, "input.0.call_id").String(); got != "call_prev" {
+			t.Fatalf("message %d lost chained tool output: %s", i, msg)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// Policies applied when an auth has reached its ws_max_conns limit.
+const (
+	codexWebsocketConnPolicyBlock = "block"
+	codexWebsocketConnPolicyFail  = "fail"
+)
+
+// codexWebsocketConnLimits bounds concurrent upstream websocket connections per auth.
+type codexWebsocketConnLimits struct {
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+	held  map[*websocket.Conn]chan struct{}
+}
+
+// codexWebsocketMaxConns reads the ws_max_conns and ws_max_conns_policy auth attributes.
+// A missing or non-positive limit disables the cap; the policy defaults to block.
+func codexWebsocketMaxConns(auth *cliproxyauth.Auth) (int, string) {
+	if auth == nil || len(auth.Attributes) == 0 {
+		return 0, ""
+	}
+	limit, errParse := strconv.Atoi(strings.TrimSpace(auth.Attributes["ws_max_conns"]))
+	if errParse != nil || limit <= 0 {
+		return 0, ""
+	}
+	policy := strings.ToLower(strings.TrimSpace(auth.Attributes["ws_max_conns_policy"]))
+	if policy != codexWebsocketConnPolicyFail {
+		policy = codexWebsocketConnPolicyBlock
+	}
+	return limit, policy
+}
+
+// acquire reserves a connection slot for auth. It returns a nil slot when no limit applies.
+func (l *codexWebsocketConnLimits) acquire(ctx context.Context, auth *cliproxyauth.Auth) (chan struct{}, error) {
+	limit, policy := codexWebsocketMaxConns(auth)
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	l.mu.Lock()
+	if l.slots == nil {
+		l.slots = make(map[string]chan struct{})
+	}
+	slot := l.slots[auth.ID]
+	if slot == nil || cap(slot) != limit {
+		// Connections holding the previous slot channel release back into it.
+		slot = make(chan struct{}, limit)
+		l.slots[auth.ID] = slot
+	}
+	l.mu.Unlock()
+
+	if policy == codexWebsocketConnPolicyFail {
+		select {
+		case slot <- struct{}{}:
+			return slot, nil
+		default:
+			return nil, statusErr{code: http.StatusTooManyRequests, msg: "codex websockets executor: connection limit reached for auth"}
+		}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case slot <- struct{}{}:
+		return slot, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// track associates a reserved slot with an established connection.
+func (l *codexWebsocketConnLimits) track(conn *websocket.Conn, slot chan struct{}) {
+	if conn == nil || slot == nil {
+		return
+	}
+	l.mu.Lock()
+	if l.held == nil {
+		l.held = make(map[*websocket.Conn]chan struct{})
+	}
+	l.held[conn] = slot
+	l.mu.Unlock()
+}
+
+// release frees the slot held by conn, if any.
+func (l *codexWebsocketConnLimits) release(conn *websocket.Conn) {
+	if conn == nil {
+		return
+	}
+	l.mu.Lock()
+	slot, ok := l.held[conn]
+	delete(l.held, conn)
+	l.mu.Unlock()
+	if ok {
+		<-slot
+	}
+}
+
+// dialLimitedCodexWebsocket dials the upstream after reserving a per-auth connection slot.
+func (e *CodexWebsocketsExecutor) dialLimitedCodexWebsocket(ctx context.Context, auth *cliproxyauth.Auth, wsURL string, headers http.Header) (*websocket.Conn, *http.Response, error) {
+	slot, errAcquire := e.connLimits.acquire(ctx, auth)
+	if errAcquire != nil {
+		return nil, nil, errAcquire
+	}
+	conn, resp, errDial := e.dialCodexWebsocket(ctx, auth, wsURL, headers)
+	if errDial != nil || conn == nil {
+		if slot != nil {
+			<-slot
+		}
+		return conn, resp, errDial
+	}
+	e.connLimits.track(conn, slot)
+	return conn, resp, nil
+}
+
+// closeUpstreamConn closes conn and frees its per-auth connection slot.
+func (e *CodexWebsocketsExecutor) closeUpstreamConn(conn *websocket.Conn) {
+	if conn == nil {
+		return
+	}
+	e.connLimits.release(conn)
+	if errClose := conn.Close(); errClose != nil {
+		log.Errorf("codex websockets executor: close websocket error: %v", errClose)
+	}
+}
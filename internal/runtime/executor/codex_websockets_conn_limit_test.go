@@ -0,0 +1,103 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func newPersistentCodexWebsocketServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		for {
+			if _, _, errRead := conn.ReadMessage(); errRead != nil {
+				return
+			}
+			if errWrite := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"response.completed","response":{"id":"resp_1","output":[]}}`)); errWrite != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCodexWebsocketsExecutorEnforcesPerAuthConnectionCap(t *testing.T) {
+	server := newPersistentCodexWebsocketServer(t)
+	executor := NewCodexWebsocketsExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "codex-conn-cap", Attributes: map[string]string{
+		"api_key":             "sk-test",
+		"base_url":            server.URL,
+		"ws_max_conns":        "1",
+		"ws_max_conns_policy": "fail",
+	}}
+	req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"model":"gpt-5","input":[]}`)}
+	optsFor := func(sessionID string) cliproxyexecutor.Options {
+		return cliproxyexecutor.Options{
+			SourceFormat: sdktranslator.FromString("codex"),
+			Metadata:     map[string]any{cliproxyexecutor.ExecutionSessionMetadataKey: sessionID},
+		}
+	}
+	defer executor.CloseExecutionSession(cliproxyauth.CloseAllExecutionSessionsID)
+
+	if _, err := executor.Execute(context.Background(), auth, req, optsFor("session-a")); err != nil {
+		t.Fatalf("first session: execute error: %v", err)
+	}
+
+	_, err := executor.Execute(context.Background(), auth, req, optsFor("session-b"))
+	var se statusErr
+	if !errors.As(err, &se) || se.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("second session: error = %v, want 429 connection limit error", err)
+	}
+
+	// Closing the first session frees its slot for the next connection.
+	executor.CloseExecutionSession("session-a")
+	if _, err = executor.Execute(context.Background(), auth, req, optsFor("session-b")); err != nil {
+		t.Fatalf("second session after release: execute error: %v", err)
+	}
+}
+
+func TestCodexWebsocketsExecutorBlocksAtPerAuthConnectionCap(t *testing.T) {
+	server := newPersistentCodexWebsocketServer(t)
+	executor := NewCodexWebsocketsExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "codex-conn-block", Attributes: map[string]string{
+		"api_key":      "sk-test",
+		"base_url":     server.URL,
+		"ws_max_conns": "1",
+	}}
+	req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"model":"gpt-5","input":[]}`)}
+	defer executor.CloseExecutionSession(cliproxyauth.CloseAllExecutionSessionsID)
+
+	first := cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("codex"),
+		Metadata:     map[string]any{cliproxyexecutor.ExecutionSessionMetadataKey: "session-a"},
+	}
+	if _, err := executor.Execute(context.Background(), auth, req, first); err != nil {
+		t.Fatalf("first session: execute error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	second := cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("codex"),
+		Metadata:     map[string]any{cliproxyexecutor.ExecutionSessionMetadataKey: "session-b"},
+	}
+	if _, err := executor.Execute(ctx, auth, req, second); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second session: error = %v, want context deadline while waiting for a slot", err)
+	}
+}
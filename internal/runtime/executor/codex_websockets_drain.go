@@ -0,0 +1,66 @@
+package executor
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// codexWebsocketDrainPollInterval is how often Drain re-checks a busy session.
+const codexWebsocketDrainPollInterval = 20 * time.Millisecond
+
+// Drain prepares the executor for shutdown. It stops handing out execution sessions, so new
+// requests use one-shot connections, waits for in-flight session requests to finish and then
+// closes every session's upstream connection. Sessions still busy when ctx is done are
+// force-closed with reason "drain_timeout".
+func (e *CodexWebsocketsExecutor) Drain(ctx context.Context) {
+	if e == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	e.sessMu.Lock()
+	e.draining = true
+	sessions := make([]*codexWebsocketSession, 0, len(e.sessions))
+	for sessionID, sess := range e.sessions {
+		delete(e.sessions, sessionID)
+		if sess != nil {
+			sess.removed = true
+			sessions = append(sessions, sess)
+		}
+	}
+	e.sessMu.Unlock()
+
+	ticker := time.NewTicker(codexWebsocketDrainPollInterval)
+	defer ticker.Stop()
+	timedOut := false
+	for _, sess := range sessions {
+		idle := sess.reqMu.TryLock()
+		for !idle && !timedOut {
+			select {
+			case <-ctx.Done():
+				timedOut = true
+			case <-ticker.C:
+				idle = sess.reqMu.TryLock()
+			}
+		}
+		if !idle {
+			log.Warnf("codex websockets: session %s still busy at drain deadline, closing", sess.sessionID)
+			e.closeExecutionSession(sess, "drain_timeout")
+			continue
+		}
+		e.closeExecutionSession(sess, "drained")
+		sess.reqMu.Unlock()
+	}
+}
+
+// Drain drains the websocket sessions of the underlying websocket executor.
+func (e *CodexAutoExecutor) Drain(ctx context.Context) {
+	if e == nil || e.wsExec == nil {
+		return
+	}
+	e.wsExec.Drain(ctx)
+}
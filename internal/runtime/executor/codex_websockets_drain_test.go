@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// attachTestWebsocketConn gives sess a live upstream connection to a server that holds it open.
+func attachTestWebsocketConn(t *testing.T, sess *codexWebsocketSession) {
+	t.Helper()
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		for {
+			if _, _, errRead := conn.ReadMessage(); errRead != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial test websocket: %v", err)
+	}
+	closeHTTPResponseBody(resp, "close test handshake body")
+	sess.connMu.Lock()
+	sess.conn = conn
+	sess.connMu.Unlock()
+}
+
+func TestCodexWebsocketsExecutorDrainWaitsForInFlightRequests(t *testing.T) {
+	executor := NewCodexWebsocketsExecutor(&config.Config{})
+	sess := executor.getOrCreateSession("session-busy")
+	attachTestWebsocketConn(t, sess)
+	sess.reqMu.Lock()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		sess.reqMu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	executor.Drain(ctx)
+
+	if got := executor.SessionStats().Disconnects; got["drained"] != 1 || got["drain_timeout"] != 0 {
+		t.Fatalf("disconnects = %v, want one drained", got)
+	}
+	if executor.getOrCreateSession("session-new") != nil {
+		t.Fatal("expected no new sessions while draining")
+	}
+}
+
+func TestCodexWebsocketsExecutorDrainForceClosesAtDeadline(t *testing.T) {
+	executor := NewCodexWebsocketsExecutor(&config.Config{})
+	busy := executor.getOrCreateSession("session-busy")
+	attachTestWebsocketConn(t, busy)
+	busy.reqMu.Lock()
+	defer busy.reqMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	executor.Drain(ctx)
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Drain took %v, want it to stop at the deadline", elapsed)
+	}
+	if got := executor.SessionStats().Disconnects; got["drain_timeout"] != 1 {
+		t.Fatalf("disconnects = %v, want one drain_timeout", got)
+	}
+	busy.connMu.Lock()
+	conn := busy.conn
+	busy.connMu.Unlock()
+	if conn != nil {
+		t.Fatal("expected busy session connection to be closed")
+	}
+}
+
+func TestManagerDrainExecutionSessionsDrainsCodexAutoExecutor(t *testing.T) {
+	executor := NewCodexAutoExecutor(&config.Config{})
+	manager := cliproxyauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	sess := executor.wsExec.getOrCreateSession("session-auto")
+	attachTestWebsocketConn(t, sess)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	manager.DrainExecutionSessions(ctx)
+
+	if got := executor.SessionStats().Disconnects; got["drained"] != 1 {
+		t.Fatalf("disconnects = %v, want one drained", got)
+	}
+	if executor.wsExec.getOrCreateSession("session-new") != nil {
+		t.Fatal("expected no new sessions while draining")
+	}
+}
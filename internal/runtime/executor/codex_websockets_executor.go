@@ -5,14 +5,17 @@ package executor
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"io"
 	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -32,9 +35,21 @@ import (
 )
 
 const (
-	codexResponsesWebsocketBetaHeaderValue = "responses_websockets=2026-02-06"
 	codexResponsesWebsocketIdleTimeout     = 5 * time.Minute
 	codexResponsesWebsocketHandshakeTO     = 30 * time.Second
+	codexResponsesWebsocketMaxMessageBytes = 64 << 20
+
+	// codexTransportHeader reports which transport served a Codex request.
+	codexTransportHeader = "X-Codex-Transport"
+	// codexTransportFallbackReasonHeader explains why a websocket request was served over HTTP.
+	codexTransportFallbackReasonHeader = "X-Codex-Transport-Fallback-Reason"
+
+	codexTransportWebsocket = "websocket"
+	codexTransportHTTP      = "http"
+
+	codexFallbackReasonCompact         = "compact_unsupported"
+	codexFallbackReasonUpgradeRequired = "upgrade_required"
+	codexFallbackReasonDegraded        = "websocket_degraded"
 )
 
 // CodexWebsocketsExecutor executes Codex Responses requests using a WebSocket transport.
@@ -46,6 +61,11 @@ type CodexWebsocketsExecutor struct {
 
 	sessMu   sync.Mutex
 	sessions map[string]*codexWebsocketSession
+	// draining is set by Drain; guarded by sessMu. No sessions are handed out while set.
+	draining bool
+
+	stats      codexWebsocketStats
+	connLimits codexWebsocketConnLimits
 }
 
 type codexWebsocketSession struct {
@@ -57,6 +77,8 @@ type codexWebsocketSession struct {
 	conn   *websocket.Conn
 	wsURL  string
 	authID string
+	// accountID is the Chatgpt-Account-Id the current connection was opened with.
+	accountID string
 
 	writeMu sync.Mutex
 
@@ -66,6 +88,29 @@ type codexWebsocketSession struct {
 	activeCancel context.CancelFunc
 
 	readerConn *websocket.Conn
+
+	// turnState is the last x-codex-turn-state returned by the upstream handshake.
+	// It is re-sent on reconnects so multi-turn sessions keep their routing state.
+	turnState string
+
+	// connected records whether the session has held an upstream connection before.
+	connected bool
+
+	// lastUsed is guarded by the executor's sessMu and drives LRU eviction.
+	lastUsed time.Time
+
+	// removed is set, under the executor's sessMu, once the session is dropped from the
+	// sessions map. Callers re-check it after taking reqMu so they never dial on a session
+	// that Drain, eviction and CloseExecutionSession no longer track.
+	removed bool
+
+	// sendFailures counts consecutive websocket dial/send failures and httpDegradedUntil ends
+	// the current HTTP fallback window. Both are guarded by reqMu.
+	sendFailures      int
+	httpDegradedUntil time.Time
+
+	// lastPong is the UnixNano time of the last pong received on the current connection.
+	lastPong atomic.Int64
 }
 
 func NewCodexWebsocketsExecutor(cfg *config.Config) *CodexWebsocketsExecutor {
@@ -129,6 +174,36 @@ func (s *codexWebsocketSession) writeMessage(conn *websocket.Conn, msgType int,
 	return conn.WriteMessage(msgType, payload)
 }
 
+// captureTurnState remembers the turn state returned by the upstream handshake response.
+func (s *codexWebsocketSession) captureTurnState(resp *http.Response) {
+	if s == nil || resp == nil {
+		return
+	}
+	turnState := strings.TrimSpace(resp.Header.Get("x-codex-turn-state"))
+	if turnState == "" {
+		return
+	}
+	s.connMu.Lock()
+	s.turnState = turnState
+	s.connMu.Unlock()
+}
+
+// applyTurnState sets the captured turn state on headers unless the client supplied one.
+func (s *codexWebsocketSession) applyTurnState(headers http.Header) {
+	if s == nil || headers == nil {
+		return
+	}
+	if strings.TrimSpace(headers.Get("x-codex-turn-state")) != "" {
+		return
+	}
+	s.connMu.Lock()
+	turnState := s.turnState
+	s.connMu.Unlock()
+	if turnState != "" {
+		headers.Set("x-codex-turn-state", turnState)
+	}
+}
+
 func (s *codexWebsocketSession) configureConn(conn *websocket.Conn) {
 	if s == nil || conn == nil {
 		return
@@ -139,17 +214,35 @@ func (s *codexWebsocketSession) configureConn(conn *websocket.Conn) {
 		// Reply pongs from the same write lock to avoid concurrent writes.
 		return conn.WriteControl(websocket.PongMessage, []byte(appData), time.Now().Add(10*time.Second))
 	})
+	conn.SetPongHandler(func(string) error {
+		s.lastPong.Store(time.Now().UnixNano())
+		return nil
+	})
 }
 
 func (e *CodexWebsocketsExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	// Dry runs are described by the HTTP executor; a websocket turn would be sent upstream.
+	if dryRunRequested(opts) {
+		return e.CodexExecutor.Execute(ctx, auth, req, opts)
+	}
+	if err = checkAuthCircuit(e.cfg, auth); err != nil {
+		return resp, err
+	}
+	defer recordAuthCircuit(e.cfg, auth, &err)
 	if ctx == nil {
 		ctx = context.Background()
 	}
 	if opts.Alt == "responses/compact" {
-		return e.CodexExecutor.executeCompact(ctx, auth, req, opts)
+		resp, err = e.CodexExecutor.executeCompact(ctx, auth, req, opts)
+		resp.Headers = withCodexTransportHeaders(resp.Headers, codexTransportHTTP, codexFallbackReasonCompact)
+		return resp, err
 	}
 
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	ctx, req.Payload, err = resolveCodexAccount(ctx, auth, req.Payload)
+	if err != nil {
+		return resp, err
+	}
 	apiKey, baseURL := codexCreds(auth)
 	if baseURL == "" {
 		baseURL = "https://chatgpt.com/backend-api/codex"
@@ -158,7 +251,7 @@ func (e *CodexWebsocketsExecutor) Execute(ctx context.Context, auth *cliproxyaut
 	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
 	defer reporter.trackFailure(ctx, &err)
 
-	from := opts.SourceFormat
+	from := requestSourceFormat(opts)
 	to := sdktranslator.FromString("codex")
 	originalPayloadSource := req.Payload
 	if len(opts.OriginalRequest) > 0 {
@@ -166,7 +259,10 @@ func (e *CodexWebsocketsExecutor) Execute(ctx context.Context, auth *cliproxyaut
 	}
 	originalPayload := originalPayloadSource
 	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, false)
-	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	body, err := translateRequestChecked(ctx, from, to, baseModel, req.Payload, false)
+	if err != nil {
+		return resp, err
+	}
 
 	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -175,14 +271,28 @@ func (e *CodexWebsocketsExecutor) Execute(ctx context.Context, auth *cliproxyaut
 
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	body = applyPayloadPassthrough(e.cfg, baseModel, to.String(), "", body, req.Payload, requestedModel)
 	body, _ = sjson.SetBytes(body, "model", baseModel)
 	body, _ = sjson.SetBytes(body, "stream", true)
-	body, _ = sjson.DeleteBytes(body, "previous_response_id")
 	body, _ = sjson.DeleteBytes(body, "prompt_cache_retention")
 	body, _ = sjson.DeleteBytes(body, "safety_identifier")
 	if !gjson.GetBytes(body, "instructions").Exists() {
 		body, _ = sjson.SetBytes(body, "instructions", "")
 	}
+	body, err = applyContextOverflowPolicy(e.cfg, "codex", baseModel, body)
+	if err != nil {
+		return resp, err
+	}
+	if err = validateToolSchemas(e.cfg, body); err != nil {
+		return resp, err
+	}
+	body, err = applyCodexOrphanToolOutputPolicy(e.cfg, body)
+	if err != nil {
+		return resp, err
+	}
+	// Strip previous_response_id only after the orphan policy has seen it, so chained
+	// requests stay exempt from the check.
+	body, _ = sjson.DeleteBytes(body, "previous_response_id")
 
 	httpURL := strings.TrimSuffix(baseURL, "/") + "/responses"
 	wsURL, err := buildCodexResponsesWebsocketURL(httpURL)
@@ -200,12 +310,19 @@ func (e *CodexWebsocketsExecutor) Execute(ctx context.Context, auth *cliproxyaut
 		authType, authValue = auth.AccountInfo()
 	}
 
-	executionSessionID := executionSessionIDFromOptions(opts)
+	executionSessionID := executionSessionIDFromOptions(ctx, e.cfg, auth, opts)
 	var sess *codexWebsocketSession
 	if executionSessionID != "" {
-		sess = e.getOrCreateSession(executionSessionID)
-		sess.reqMu.Lock()
-		defer sess.reqMu.Unlock()
+		sess = e.lockSession(executionSessionID)
+		if sess != nil {
+			defer sess.reqMu.Unlock()
+			if sess.httpDegraded(time.Now()) {
+				resp, err = e.CodexExecutor.Execute(ctx, auth, req, opts)
+				resp.Headers = withCodexTransportHeaders(resp.Headers, codexTransportHTTP, codexFallbackReasonDegraded)
+				return resp, err
+			}
+			sess.applyTurnState(wsHeaders)
+		}
 	}
 
 	wsReqBody := buildCodexWebsocketRequestBody(body)
@@ -222,19 +339,24 @@ func (e *CodexWebsocketsExecutor) Execute(ctx context.Context, auth *cliproxyaut
 	})
 
 	conn, respHS, errDial := e.ensureUpstreamConn(ctx, auth, sess, authID, wsURL, wsHeaders)
+	var upstreamHeaders http.Header
 	if respHS != nil {
+		upstreamHeaders = respHS.Header.Clone()
 		recordAPIResponseMetadata(ctx, e.cfg, respHS.StatusCode, respHS.Header.Clone())
 	}
 	if errDial != nil {
-		bodyErr := websocketHandshakeBody(respHS)
+		bodyErr := websocketHandshakeBody(e.cfg, respHS)
 		if len(bodyErr) > 0 {
 			appendAPIResponseChunk(ctx, e.cfg, bodyErr)
 		}
 		if respHS != nil && respHS.StatusCode == http.StatusUpgradeRequired {
-			return e.CodexExecutor.Execute(ctx, auth, req, opts)
+			resp, err = e.CodexExecutor.Execute(ctx, auth, req, opts)
+			resp.Headers = withCodexTransportHeaders(resp.Headers, codexTransportHTTP, codexFallbackReasonUpgradeRequired)
+			return resp, err
 		}
+		sess.recordWebsocketFailure(e.cfg, time.Now())
 		if respHS != nil && respHS.StatusCode > 0 {
-			return resp, statusErr{code: respHS.StatusCode, msg: string(bodyErr)}
+			return resp, statusErr{code: respHS.StatusCode, msg: upstreamErrorMessage(respHS.StatusCode, respHS.Header.Get("Content-Type"), bodyErr), upstream: true}
 		}
 		recordAPIResponseError(ctx, e.cfg, errDial)
 		return resp, errDial
@@ -248,9 +370,7 @@ func (e *CodexWebsocketsExecutor) Execute(ctx context.Context, auth *cliproxyaut
 				reason = "error"
 			}
 			logCodexWebsocketDisconnected(executionSessionID, authID, wsURL, reason, err)
-			if errClose := conn.Close(); errClose != nil {
-				log.Errorf("codex websockets executor: close websocket error: %v", errClose)
-			}
+			e.closeUpstreamConn(conn)
 		}()
 	}
 
@@ -264,6 +384,11 @@ func (e *CodexWebsocketsExecutor) Execute(ctx context.Context, auth *cliproxyaut
 	if errSend := writeCodexWebsocketMessage(sess, conn, wsReqBody); errSend != nil {
 		if sess != nil {
 			e.invalidateUpstreamConn(sess, conn, "send_error", errSend)
+			if !cliproxyexecutor.RetryBudgetFromContext(ctx).Consume() {
+				sess.recordWebsocketFailure(e.cfg, time.Now())
+				recordAPIResponseError(ctx, e.cfg, errSend)
+				return resp, errSend
+			}
 
 			// Retry once with a fresh websocket connection. This is mainly to handle
 			// upstream closing the socket between sequential requests within the same
@@ -287,10 +412,12 @@ func (e *CodexWebsocketsExecutor) Execute(ctx context.Context, auth *cliproxyaut
 					wsReqBody = wsReqBodyRetry
 				} else {
 					e.invalidateUpstreamConn(sess, connRetry, "send_error", errSendRetry)
+					sess.recordWebsocketFailure(e.cfg, time.Now())
 					recordAPIResponseError(ctx, e.cfg, errSendRetry)
 					return resp, errSendRetry
 				}
 			} else {
+				sess.recordWebsocketFailure(e.cfg, time.Now())
 				recordAPIResponseError(ctx, e.cfg, errDialRetry)
 				return resp, errDialRetry
 			}
@@ -299,12 +426,14 @@ func (e *CodexWebsocketsExecutor) Execute(ctx context.Context, auth *cliproxyaut
 			return resp, errSend
 		}
 	}
+	sess.recordWebsocketSuccess()
+	sentAt := time.Now()
 
 	for {
 		if ctx != nil && ctx.Err() != nil {
 			return resp, ctx.Err()
 		}
-		msgType, payload, errRead := readCodexWebsocketMessage(ctx, sess, conn, readCh)
+		msgType, payload, errRead := readCodexWebsocketMessage(ctx, sess, conn, readCh, codexWebsocketIdleTimeout(e.cfg))
 		if errRead != nil {
 			recordAPIResponseError(ctx, e.cfg, errRead)
 			return resp, errRead
@@ -338,18 +467,31 @@ func (e *CodexWebsocketsExecutor) Execute(ctx context.Context, auth *cliproxyaut
 		payload = normalizeCodexWebsocketCompletion(payload)
 		eventType := gjson.GetBytes(payload, "type").String()
 		if eventType == "response.completed" {
+			reporter.upstreamLatency = time.Since(sentAt)
+			logCodexWebsocketResponseCompleted(executionSessionID, authID, baseModel, reporter.upstreamLatency)
 			if detail, ok := parseCodexUsage(payload); ok {
 				reporter.publish(ctx, detail)
 			}
 			var param any
-			out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, originalPayload, body, payload, &param)
-			resp = cliproxyexecutor.Response{Payload: out}
+			out, errTranslate := translateNonStreamChecked(ctx, to, from, req.Model, originalPayload, body, payload, &param)
+			if errTranslate != nil {
+				err = errTranslate
+				return resp, err
+			}
+			resp = cliproxyexecutor.Response{Payload: out, Headers: withCodexTransportHeaders(upstreamHeaders, codexTransportWebsocket, "")}
 			return resp, nil
 		}
 	}
 }
 
 func (e *CodexWebsocketsExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	if dryRunRequested(opts) {
+		return e.CodexExecutor.ExecuteStream(ctx, auth, req, opts)
+	}
+	if err = checkAuthCircuit(e.cfg, auth); err != nil {
+		return nil, err
+	}
+	defer recordAuthCircuit(e.cfg, auth, &err)
 	log.Debugf("Executing Codex Websockets stream request with auth ID: %s, model: %s", auth.ID, req.Model)
 	if ctx == nil {
 		ctx = context.Background()
@@ -359,6 +501,10 @@ func (e *CodexWebsocketsExecutor) ExecuteStream(ctx context.Context, auth *clipr
 	}
 
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	ctx, req.Payload, err = resolveCodexAccount(ctx, auth, req.Payload)
+	if err != nil {
+		return nil, err
+	}
 	apiKey, baseURL := codexCreds(auth)
 	if baseURL == "" {
 		baseURL = "https://chatgpt.com/backend-api/codex"
@@ -367,7 +513,7 @@ func (e *CodexWebsocketsExecutor) ExecuteStream(ctx context.Context, auth *clipr
 	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
 	defer reporter.trackFailure(ctx, &err)
 
-	from := opts.SourceFormat
+	from := requestSourceFormat(opts)
 	to := sdktranslator.FromString("codex")
 	body := req.Payload
 
@@ -378,6 +524,18 @@ func (e *CodexWebsocketsExecutor) ExecuteStream(ctx context.Context, auth *clipr
 
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, body, requestedModel)
+	body = applyPayloadPassthrough(e.cfg, baseModel, to.String(), "", body, req.Payload, requestedModel)
+	body, err = applyContextOverflowPolicy(e.cfg, "codex", baseModel, body)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateToolSchemas(e.cfg, body); err != nil {
+		return nil, err
+	}
+	body, err = applyCodexOrphanToolOutputPolicy(e.cfg, body)
+	if err != nil {
+		return nil, err
+	}
 
 	httpURL := strings.TrimSuffix(baseURL, "/") + "/responses"
 	wsURL, err := buildCodexResponsesWebsocketURL(httpURL)
@@ -393,12 +551,20 @@ func (e *CodexWebsocketsExecutor) ExecuteStream(ctx context.Context, auth *clipr
 	authLabel = auth.Label
 	authType, authValue = auth.AccountInfo()
 
-	executionSessionID := executionSessionIDFromOptions(opts)
+	executionSessionID := executionSessionIDFromOptions(ctx, e.cfg, auth, opts)
 	var sess *codexWebsocketSession
 	if executionSessionID != "" {
-		sess = e.getOrCreateSession(executionSessionID)
+		sess = e.lockSession(executionSessionID)
 		if sess != nil {
-			sess.reqMu.Lock()
+			if sess.httpDegraded(time.Now()) {
+				sess.reqMu.Unlock()
+				result, errStream := e.CodexExecutor.ExecuteStream(ctx, auth, req, opts)
+				if result != nil {
+					result.Headers = withCodexTransportHeaders(result.Headers, codexTransportHTTP, codexFallbackReasonDegraded)
+				}
+				return result, errStream
+			}
+			sess.applyTurnState(wsHeaders)
 		}
 	}
 
@@ -422,20 +588,28 @@ func (e *CodexWebsocketsExecutor) ExecuteStream(ctx context.Context, auth *clipr
 		recordAPIResponseMetadata(ctx, e.cfg, respHS.StatusCode, respHS.Header.Clone())
 	}
 	if errDial != nil {
-		bodyErr := websocketHandshakeBody(respHS)
+		bodyErr := websocketHandshakeBody(e.cfg, respHS)
 		if len(bodyErr) > 0 {
 			appendAPIResponseChunk(ctx, e.cfg, bodyErr)
 		}
 		if respHS != nil && respHS.StatusCode == http.StatusUpgradeRequired {
-			return e.CodexExecutor.ExecuteStream(ctx, auth, req, opts)
-		}
-		if respHS != nil && respHS.StatusCode > 0 {
-			return nil, statusErr{code: respHS.StatusCode, msg: string(bodyErr)}
+			if sess != nil {
+				sess.reqMu.Unlock()
+			}
+			result, errStream := e.CodexExecutor.ExecuteStream(ctx, auth, req, opts)
+			if result != nil {
+				result.Headers = withCodexTransportHeaders(result.Headers, codexTransportHTTP, codexFallbackReasonUpgradeRequired)
+			}
+			return result, errStream
 		}
-		recordAPIResponseError(ctx, e.cfg, errDial)
 		if sess != nil {
+			sess.recordWebsocketFailure(e.cfg, time.Now())
 			sess.reqMu.Unlock()
 		}
+		if respHS != nil && respHS.StatusCode > 0 {
+			return nil, statusErr{code: respHS.StatusCode, msg: upstreamErrorMessage(respHS.StatusCode, respHS.Header.Get("Content-Type"), bodyErr), upstream: true}
+		}
+		recordAPIResponseError(ctx, e.cfg, errDial)
 		return nil, errDial
 	}
 	closeHTTPResponseBody(respHS, "codex websockets executor: close handshake response body error")
@@ -454,11 +628,18 @@ func (e *CodexWebsocketsExecutor) ExecuteStream(ctx context.Context, auth *clipr
 		recordAPIResponseError(ctx, e.cfg, errSend)
 		if sess != nil {
 			e.invalidateUpstreamConn(sess, conn, "send_error", errSend)
+			if !cliproxyexecutor.RetryBudgetFromContext(ctx).Consume() {
+				sess.recordWebsocketFailure(e.cfg, time.Now())
+				sess.clearActive(readCh)
+				sess.reqMu.Unlock()
+				return nil, errSend
+			}
 
 			// Retry once with a new websocket connection for the same execution session.
 			connRetry, _, errDialRetry := e.ensureUpstreamConn(ctx, auth, sess, authID, wsURL, wsHeaders)
 			if errDialRetry != nil || connRetry == nil {
 				recordAPIResponseError(ctx, e.cfg, errDialRetry)
+				sess.recordWebsocketFailure(e.cfg, time.Now())
 				sess.clearActive(readCh)
 				sess.reqMu.Unlock()
 				return nil, errDialRetry
@@ -478,6 +659,7 @@ func (e *CodexWebsocketsExecutor) ExecuteStream(ctx context.Context, auth *clipr
 			if errSendRetry := writeCodexWebsocketMessage(sess, connRetry, wsReqBodyRetry); errSendRetry != nil {
 				recordAPIResponseError(ctx, e.cfg, errSendRetry)
 				e.invalidateUpstreamConn(sess, connRetry, "send_error", errSendRetry)
+				sess.recordWebsocketFailure(e.cfg, time.Now())
 				sess.clearActive(readCh)
 				sess.reqMu.Unlock()
 				return nil, errSendRetry
@@ -486,12 +668,12 @@ func (e *CodexWebsocketsExecutor) ExecuteStream(ctx context.Context, auth *clipr
 			wsReqBody = wsReqBodyRetry
 		} else {
 			logCodexWebsocketDisconnected(executionSessionID, authID, wsURL, "send_error", errSend)
-			if errClose := conn.Close(); errClose != nil {
-				log.Errorf("codex websockets executor: close websocket error: %v", errClose)
-			}
+			e.closeUpstreamConn(conn)
 			return nil, errSend
 		}
 	}
+	sess.recordWebsocketSuccess()
+	sentAt := time.Now()
 
 	out := make(chan cliproxyexecutor.StreamChunk)
 	go func() {
@@ -506,9 +688,7 @@ func (e *CodexWebsocketsExecutor) ExecuteStream(ctx context.Context, auth *clipr
 				return
 			}
 			logCodexWebsocketDisconnected(executionSessionID, authID, wsURL, terminateReason, terminateErr)
-			if errClose := conn.Close(); errClose != nil {
-				log.Errorf("codex websockets executor: close websocket error: %v", errClose)
-			}
+			e.closeUpstreamConn(conn)
 		}()
 
 		send := func(chunk cliproxyexecutor.StreamChunk) bool {
@@ -532,7 +712,7 @@ func (e *CodexWebsocketsExecutor) ExecuteStream(ctx context.Context, auth *clipr
 				_ = send(cliproxyexecutor.StreamChunk{Err: ctx.Err()})
 				return
 			}
-			msgType, payload, errRead := readCodexWebsocketMessage(ctx, sess, conn, readCh)
+			msgType, payload, errRead := readCodexWebsocketMessage(ctx, sess, conn, readCh, codexWebsocketIdleTimeout(e.cfg))
 			if errRead != nil {
 				if sess != nil && ctx != nil && ctx.Err() != nil {
 					terminateReason = "context_done"
@@ -584,12 +764,17 @@ func (e *CodexWebsocketsExecutor) ExecuteStream(ctx context.Context, auth *clipr
 			payload = normalizeCodexWebsocketCompletion(payload)
 			eventType := gjson.GetBytes(payload, "type").String()
 			if eventType == "response.completed" || eventType == "response.done" {
+				reporter.upstreamLatency = time.Since(sentAt)
+				logCodexWebsocketResponseCompleted(executionSessionID, authID, baseModel, reporter.upstreamLatency)
 				if detail, ok := parseCodexUsage(payload); ok {
 					reporter.publish(ctx, detail)
 				}
 			}
 
 			line := encodeCodexWebsocketAsSSE(payload)
+			if shouldSuppressCodexReasoningDelta(e.cfg, line) {
+				continue
+			}
 			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, body, body, line, &param)
 			for i := range chunks {
 				if !send(cliproxyexecutor.StreamChunk{Payload: chunks[i]}) {
@@ -604,21 +789,49 @@ func (e *CodexWebsocketsExecutor) ExecuteStream(ctx context.Context, auth *clipr
 		}
 	}()
 
-	return &cliproxyexecutor.StreamResult{Headers: upstreamHeaders, Chunks: out}, nil
+	return &cliproxyexecutor.StreamResult{Headers: withCodexTransportHeaders(upstreamHeaders, codexTransportWebsocket, ""), Chunks: out}, nil
+}
+
+// withCodexTransportHeaders annotates response headers with the transport that served the
+// request and, for HTTP fallbacks, a machine-readable reason.
+func withCodexTransportHeaders(headers http.Header, transport, fallbackReason string) http.Header {
+	if headers == nil {
+		headers = http.Header{}
+	}
+	headers.Set(codexTransportHeader, transport)
+	if fallbackReason != "" {
+		headers.Set(codexTransportFallbackReasonHeader, fallbackReason)
+	} else {
+		headers.Del(codexTransportFallbackReasonHeader)
+	}
+	return headers
 }
 
 func (e *CodexWebsocketsExecutor) dialCodexWebsocket(ctx context.Context, auth *cliproxyauth.Auth, wsURL string, headers http.Header) (*websocket.Conn, *http.Response, error) {
 	dialer := newProxyAwareWebsocketDialer(e.cfg, auth)
-	dialer.HandshakeTimeout = codexResponsesWebsocketHandshakeTO
-	dialer.EnableCompression = true
+	dialer.HandshakeTimeout = codexWebsocketHandshakeTimeout(e.cfg)
+	compression := e.cfg == nil || !e.cfg.CodexWebsocket.DisableCompression
+	dialer.EnableCompression = compression
+	if e.cfg != nil {
+		if e.cfg.CodexWebsocket.ReadBufferSize > 0 {
+			dialer.ReadBufferSize = e.cfg.CodexWebsocket.ReadBufferSize
+		}
+		if e.cfg.CodexWebsocket.WriteBufferSize > 0 {
+			dialer.WriteBufferSize = e.cfg.CodexWebsocket.WriteBufferSize
+		}
+	}
 	if ctx == nil {
 		ctx = context.Background()
 	}
 	conn, resp, err := dialer.DialContext(ctx, wsURL, headers)
+	e.stats.recordDial(resp)
 	if conn != nil {
-		// Avoid gorilla/websocket flate tail validation issues on some upstreams/Go versions.
-		// Negotiating permessage-deflate is fine; we just don't compress outbound messages.
-		conn.EnableWriteCompression(false)
+		if compression {
+			// Avoid gorilla/websocket flate tail validation issues on some upstreams/Go versions.
+			// Negotiating permessage-deflate is fine; we just don't compress outbound messages.
+			conn.EnableWriteCompression(false)
+		}
+		conn.SetReadLimit(codexWebsocketMaxMessageBytes(e.cfg))
 	}
 	return conn, resp, err
 }
@@ -650,12 +863,53 @@ func buildCodexWebsocketRequestBody(body []byte) []byte {
 	return fallback
 }
 
-func readCodexWebsocketMessage(ctx context.Context, sess *codexWebsocketSession, conn *websocket.Conn, readCh chan codexWebsocketRead) (int, []byte, error) {
+// codexWebsocketIdleTimeout returns the configured read idle timeout; zero disables the deadline.
+func codexWebsocketIdleTimeout(cfg *config.Config) time.Duration {
+	if cfg == nil || cfg.CodexWebsocket.IdleTimeoutSeconds == nil {
+		return codexResponsesWebsocketIdleTimeout
+	}
+	return time.Duration(*cfg.CodexWebsocket.IdleTimeoutSeconds) * time.Second
+}
+
+// codexWebsocketHandshakeTimeout returns the configured handshake timeout; zero disables it.
+func codexWebsocketHandshakeTimeout(cfg *config.Config) time.Duration {
+	if cfg == nil || cfg.CodexWebsocket.HandshakeTimeoutSeconds == nil {
+		return codexResponsesWebsocketHandshakeTO
+	}
+	return time.Duration(*cfg.CodexWebsocket.HandshakeTimeoutSeconds) * time.Second
+}
+
+// codexWebsocketMaxSessions returns the configured session cap, or 0 when unlimited.
+func codexWebsocketMaxSessions(cfg *config.Config) int {
+	if cfg == nil || cfg.CodexWebsocket.MaxSessions <= 0 {
+		return 0
+	}
+	return cfg.CodexWebsocket.MaxSessions
+}
+
+// codexWebsocketMaxMessageBytes returns the read limit applied to upstream connections.
+func codexWebsocketMaxMessageBytes(cfg *config.Config) int64 {
+	if cfg == nil || cfg.CodexWebsocket.MaxMessageBytes <= 0 {
+		return codexResponsesWebsocketMaxMessageBytes
+	}
+	return cfg.CodexWebsocket.MaxMessageBytes
+}
+
+// setCodexWebsocketReadDeadline arms the idle read deadline, or clears it when idle is zero.
+func setCodexWebsocketReadDeadline(conn *websocket.Conn, idle time.Duration) {
+	if idle <= 0 {
+		_ = conn.SetReadDeadline(time.Time{})
+		return
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(idle))
+}
+
+func readCodexWebsocketMessage(ctx context.Context, sess *codexWebsocketSession, conn *websocket.Conn, readCh chan codexWebsocketRead, idle time.Duration) (int, []byte, error) {
 	if sess == nil {
 		if conn == nil {
 			return 0, nil, fmt.Errorf("codex websockets executor: websocket conn is nil")
 		}
-		_ = conn.SetReadDeadline(time.Now().Add(codexResponsesWebsocketIdleTimeout))
+		setCodexWebsocketReadDeadline(conn, idle)
 		msgType, payload, errRead := conn.ReadMessage()
 		return msgType, payload, errRead
 	}
@@ -735,11 +989,12 @@ func newProxyAwareWebsocketDialer(cfg *config.Config, auth *cliproxyauth.Auth) *
 			return dialer
 		}
 		dialer.Proxy = nil
-		dialer.NetDialContext = func(_ context.Context, network, addr string) (net.Conn, error) {
+		dialer.NetDialContext = wrapProxyDial("websocket", setting.URL, func(_ context.Context, network, addr string) (net.Conn, error) {
 			return socksDialer.Dial(network, addr)
-		}
+		})
 	case "http", "https":
-		dialer.Proxy = http.ProxyURL(setting.URL)
+		dialer.Proxy = nil
+		dialer.NetDialContext = wrapProxyDial("websocket", setting.URL, httpProxyConnectDial(setting.URL, dialer.NetDialContext))
 	default:
 		log.Errorf("codex websockets executor: unsupported proxy scheme: %s", setting.URL.Scheme)
 	}
@@ -774,17 +1029,20 @@ func applyCodexPromptCacheHeaders(from sdktranslator.Format, req cliproxyexecuto
 			key := fmt.Sprintf("%s-%s", req.Model, userIDResult.String())
 			if cached, ok := getCodexCache(key); ok {
 				cache = cached
+				codexPromptCacheCounters.keyReuses.Add(1)
 			} else {
 				cache = codexCache{
 					ID:     uuid.New().String(),
 					Expire: time.Now().Add(1 * time.Hour),
 				}
 				setCodexCache(key, cache)
+				codexPromptCacheCounters.keysCreated.Add(1)
 			}
 		}
 	} else if from == "openai-response" {
 		if promptCacheKey := gjson.GetBytes(req.Payload, "prompt_cache_key"); promptCacheKey.Exists() {
 			cache.ID = promptCacheKey.String()
+			codexPromptCacheCounters.clientKeys.Add(1)
 		}
 	}
 
@@ -805,10 +1063,7 @@ func applyCodexWebsocketHeaders(ctx context.Context, headers http.Header, auth *
 		headers.Set("Authorization", "Bearer "+token)
 	}
 
-	var ginHeaders http.Header
-	if ginCtx := ginContextFrom(ctx); ginCtx != nil && ginCtx.Request != nil {
-		ginHeaders = ginCtx.Request.Header
-	}
+	ginHeaders := clientRequestHeaders(ctx)
 
 	cfgUserAgent, cfgBetaFeatures := codexHeaderDefaults(cfg, auth)
 	ensureHeaderWithPriority(headers, ginHeaders, "x-codex-beta-features", cfgBetaFeatures, "")
@@ -823,11 +1078,11 @@ func applyCodexWebsocketHeaders(ctx context.Context, headers http.Header, auth *
 		betaHeader = strings.TrimSpace(ginHeaders.Get("OpenAI-Beta"))
 	}
 	if betaHeader == "" || !strings.Contains(betaHeader, "responses_websockets=") {
-		betaHeader = codexResponsesWebsocketBetaHeaderValue
+		betaHeader = codexClientIdentity(cfg).EffectiveWebsocketBeta()
 	}
 	headers.Set("OpenAI-Beta", betaHeader)
 	misc.EnsureHeader(headers, ginHeaders, "Session_id", uuid.NewString())
-	ensureHeaderWithConfigPrecedence(headers, ginHeaders, "User-Agent", cfgUserAgent, codexUserAgent)
+	ensureHeaderWithConfigPrecedence(headers, ginHeaders, "User-Agent", cfgUserAgent, codexClientIdentity(cfg).EffectiveUserAgent())
 
 	isAPIKey := false
 	if auth != nil && auth.Attributes != nil {
@@ -841,12 +1096,8 @@ func applyCodexWebsocketHeaders(ctx context.Context, headers http.Header, auth *
 		headers.Set("Originator", codexOriginator)
 	}
 	if !isAPIKey {
-		if auth != nil && auth.Metadata != nil {
-			if accountID, ok := auth.Metadata["account_id"].(string); ok {
-				if trimmed := strings.TrimSpace(accountID); trimmed != "" {
-					headers.Set("Chatgpt-Account-Id", trimmed)
-				}
-			}
+		if accountID := codexAccountID(ctx, auth); accountID != "" {
+			headers.Set("Chatgpt-Account-Id", accountID)
 		}
 	}
 
@@ -854,7 +1105,11 @@ func applyCodexWebsocketHeaders(ctx context.Context, headers http.Header, auth *
 	if auth != nil {
 		attrs = auth.Attributes
 	}
-	util.ApplyCustomHeadersFromAttrs(&http.Request{Header: headers}, attrs)
+	customHeaderReq := &http.Request{Header: headers}
+	if ctx != nil {
+		customHeaderReq = customHeaderReq.WithContext(ctx)
+	}
+	util.ApplyCustomHeadersFromAttrs(customHeaderReq, attrs)
 
 	return headers
 }
@@ -955,12 +1210,59 @@ func parseCodexWebsocketError(payload []byte) (error, bool) {
 	}
 
 	headers := parseCodexWebsocketErrorHeaders(payload)
+	errStatus := statusErr{code: status, msg: string(out), upstream: true}
+	if status == http.StatusTooManyRequests {
+		errStatus.retryAfter = parseCodexWebsocketRetryAfter(payload, headers, time.Now())
+	}
 	return statusErrWithHeaders{
-		statusErr: statusErr{code: status, msg: string(out)},
+		statusErr: errStatus,
 		headers:   headers,
 	}, true
 }
 
+// parseCodexWebsocketRetryAfter extracts a retry delay from a websocket error frame. It checks a
+// retry_after field (top-level or under error), then a Retry-After entry in the embedded headers,
+// and finally the usage-limit reset hints shared with the HTTP transport. Values may be
+// delay-seconds or an HTTP date; nil is returned when nothing parses.
+func parseCodexWebsocketRetryAfter(payload []byte, headers http.Header, now time.Time) *time.Duration {
+	for _, path := range []string{"retry_after", "error.retry_after"} {
+		if value := gjson.GetBytes(payload, path); value.Exists() {
+			if retryAfter := parseRetryAfterValue(value.String(), now); retryAfter != nil {
+				return retryAfter
+			}
+		}
+	}
+	if headers != nil {
+		if retryAfter := parseRetryAfterValue(headers.Get("Retry-After"), now); retryAfter != nil {
+			return retryAfter
+		}
+	}
+	return parseCodexRetryAfter(http.StatusTooManyRequests, payload, now)
+}
+
+// parseRetryAfterValue parses a Retry-After value given as delay-seconds or an HTTP date.
+func parseRetryAfterValue(raw string, now time.Time) *time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	if seconds, errParse := strconv.ParseFloat(raw, 64); errParse == nil {
+		if seconds < 0 {
+			return nil
+		}
+		retryAfter := time.Duration(seconds * float64(time.Second))
+		return &retryAfter
+	}
+	if at, errParse := http.ParseTime(raw); errParse == nil {
+		if !at.After(now) {
+			return nil
+		}
+		retryAfter := at.Sub(now)
+		return &retryAfter
+	}
+	return nil
+}
+
 func parseCodexWebsocketErrorHeaders(payload []byte) http.Header {
 	headersNode := gjson.GetBytes(payload, "headers")
 	if !headersNode.Exists() || !headersNode.IsObject() {
@@ -1011,11 +1313,11 @@ func encodeCodexWebsocketAsSSE(payload []byte) []byte {
 	return line
 }
 
-func websocketHandshakeBody(resp *http.Response) []byte {
+func websocketHandshakeBody(cfg *config.Config, resp *http.Response) []byte {
 	if resp == nil || resp.Body == nil {
 		return nil
 	}
-	body, _ := io.ReadAll(resp.Body)
+	body, _ := readUpstreamBody(cfg, resp.Body)
 	closeHTTPResponseBody(resp, "codex websockets executor: close handshake response body error")
 	if len(body) == 0 {
 		return nil
@@ -1032,22 +1334,58 @@ func closeHTTPResponseBody(resp *http.Response, logPrefix string) {
 	}
 }
 
-func executionSessionIDFromOptions(opts cliproxyexecutor.Options) string {
-	if len(opts.Metadata) == 0 {
-		return ""
+// executionSessionIDFromOptions returns the explicit execution session ID from the request
+// metadata or, when absent, one derived from the first non-empty configured session ID field
+// of the client request.
+func executionSessionIDFromOptions(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, opts cliproxyexecutor.Options) string {
+	if raw, ok := opts.Metadata[cliproxyexecutor.ExecutionSessionMetadataKey]; ok && raw != nil {
+		switch v := raw.(type) {
+		case string:
+			return strings.TrimSpace(v)
+		case []byte:
+			return strings.TrimSpace(string(v))
+		default:
+			return ""
+		}
 	}
-	raw, ok := opts.Metadata[cliproxyexecutor.ExecutionSessionMetadataKey]
-	if !ok || raw == nil {
-		return ""
+	return derivedExecutionSessionID(cfg, derivedExecutionSessionScope(ctx, auth), opts.OriginalRequest)
+}
+
+// derivedExecutionSessionScope keys derived sessions by client API key and credential, so two
+// clients sending the same conversation value never share an upstream session.
+func derivedExecutionSessionScope(ctx context.Context, auth *cliproxyauth.Auth) string {
+	scope := apiKeyFromContext(ctx)
+	if auth != nil {
+		scope += "\x00" + auth.ID
 	}
-	switch v := raw.(type) {
-	case string:
-		return strings.TrimSpace(v)
-	case []byte:
-		return strings.TrimSpace(string(v))
-	default:
+	return scope
+}
+
+// derivedExecutionSessionID maps a conversation identifier in the client request to a stable
+// execution session ID within scope. Object values such as OpenAI's conversation {"id": ...}
+// use their id.
+func derivedExecutionSessionID(cfg *config.Config, scope string, original []byte) string {
+	if cfg == nil || len(cfg.CodexWebsocket.SessionIDFields) == 0 || len(original) == 0 {
 		return ""
 	}
+	for _, path := range cfg.CodexWebsocket.SessionIDFields {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		value := gjson.GetBytes(original, path)
+		if value.IsObject() {
+			value = value.Get("id")
+		}
+		if value.Type != gjson.String && value.Type != gjson.Number {
+			continue
+		}
+		if id := strings.TrimSpace(value.String()); id != "" {
+			sum := sha256.Sum256([]byte(scope + "\x00" + path + "\x00" + id))
+			return "derived:" + hex.EncodeToString(sum[:16])
+		}
+	}
+	return ""
 }
 
 func (e *CodexWebsocketsExecutor) getOrCreateSession(sessionID string) *codexWebsocketSession {
@@ -1055,28 +1393,98 @@ func (e *CodexWebsocketsExecutor) getOrCreateSession(sessionID string) *codexWeb
 	if sessionID == "" {
 		return nil
 	}
+	now := time.Now()
 	e.sessMu.Lock()
-	defer e.sessMu.Unlock()
+	if e.draining {
+		e.sessMu.Unlock()
+		return nil
+	}
 	if e.sessions == nil {
 		e.sessions = make(map[string]*codexWebsocketSession)
 	}
 	if sess, ok := e.sessions[sessionID]; ok && sess != nil {
+		sess.lastUsed = now
+		e.sessMu.Unlock()
 		return sess
 	}
-	sess := &codexWebsocketSession{sessionID: sessionID}
+	evicted := e.evictIdleSessionsLocked(codexWebsocketMaxSessions(e.cfg) - 1)
+	sess := &codexWebsocketSession{sessionID: sessionID, lastUsed: now}
 	e.sessions[sessionID] = sess
+	e.sessMu.Unlock()
+
+	for i := range evicted {
+		e.closeExecutionSession(evicted[i], "session_evicted")
+	}
 	return sess
 }
 
+// lockSession returns the session for sessionID with its reqMu held, or nil when sessions are
+// unavailable. A session removed between lookup and locking is skipped and looked up again.
+func (e *CodexWebsocketsExecutor) lockSession(sessionID string) *codexWebsocketSession {
+	for {
+		sess := e.getOrCreateSession(sessionID)
+		if sess == nil {
+			return nil
+		}
+		sess.reqMu.Lock()
+		e.sessMu.Lock()
+		removed := sess.removed
+		e.sessMu.Unlock()
+		if !removed {
+			return sess
+		}
+		sess.reqMu.Unlock()
+	}
+}
+
+// evictIdleSessionsLocked removes least recently used sessions until at most limit remain.
+// Sessions with an in-flight request (reqMu held) are skipped. A negative limit disables
+// eviction. Callers must hold sessMu and close the returned sessions after releasing it.
+func (e *CodexWebsocketsExecutor) evictIdleSessionsLocked(limit int) []*codexWebsocketSession {
+	if limit < 0 || len(e.sessions) <= limit {
+		return nil
+	}
+	candidates := make([]*codexWebsocketSession, 0, len(e.sessions))
+	for _, sess := range e.sessions {
+		if sess != nil {
+			candidates = append(candidates, sess)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].lastUsed.Before(candidates[j].lastUsed)
+	})
+
+	var evicted []*codexWebsocketSession
+	for _, sess := range candidates {
+		if len(e.sessions) <= limit {
+			break
+		}
+		if !sess.reqMu.TryLock() {
+			continue
+		}
+		delete(e.sessions, sess.sessionID)
+		sess.removed = true
+		sess.reqMu.Unlock()
+		evicted = append(evicted, sess)
+	}
+	return evicted
+}
+
 func (e *CodexWebsocketsExecutor) ensureUpstreamConn(ctx context.Context, auth *cliproxyauth.Auth, sess *codexWebsocketSession, authID string, wsURL string, headers http.Header) (*websocket.Conn, *http.Response, error) {
 	if sess == nil {
-		return e.dialCodexWebsocket(ctx, auth, wsURL, headers)
+		return e.dialLimitedCodexWebsocket(ctx, auth, wsURL, headers)
 	}
 
+	accountID := headers.Get("Chatgpt-Account-Id")
 	sess.connMu.Lock()
 	conn := sess.conn
 	readerConn := sess.readerConn
+	connAccountID := sess.accountID
 	sess.connMu.Unlock()
+	if conn != nil && connAccountID != accountID {
+		e.invalidateUpstreamConn(sess, conn, "account_changed", nil)
+		conn = nil
+	}
 	if conn != nil {
 		if readerConn != conn {
 			sess.connMu.Lock()
@@ -1088,7 +1496,7 @@ func (e *CodexWebsocketsExecutor) ensureUpstreamConn(ctx context.Context, auth *
 		return conn, nil, nil
 	}
 
-	conn, resp, errDial := e.dialCodexWebsocket(ctx, auth, wsURL, headers)
+	conn, resp, errDial := e.dialLimitedCodexWebsocket(ctx, auth, wsURL, headers)
 	if errDial != nil {
 		return nil, resp, errDial
 	}
@@ -1097,19 +1505,26 @@ func (e *CodexWebsocketsExecutor) ensureUpstreamConn(ctx context.Context, auth *
 	if sess.conn != nil {
 		previous := sess.conn
 		sess.connMu.Unlock()
-		if errClose := conn.Close(); errClose != nil {
-			log.Errorf("codex websockets executor: close websocket error: %v", errClose)
-		}
+		e.closeUpstreamConn(conn)
 		return previous, nil, nil
 	}
 	sess.conn = conn
 	sess.wsURL = wsURL
 	sess.authID = authID
+	sess.accountID = accountID
 	sess.readerConn = conn
+	if sess.connected {
+		e.stats.reconnects.Add(1)
+	}
+	sess.connected = true
 	sess.connMu.Unlock()
+	sess.captureTurnState(resp)
 
 	sess.configureConn(conn)
 	go e.readUpstreamLoop(sess, conn)
+	if interval, pongTimeout := codexWebsocketKeepalive(e.cfg); interval > 0 {
+		go e.keepaliveUpstreamConn(sess, conn, interval, pongTimeout)
+	}
 	logCodexWebsocketConnected(sess.sessionID, authID, wsURL)
 	return conn, resp, nil
 }
@@ -1118,8 +1533,9 @@ func (e *CodexWebsocketsExecutor) readUpstreamLoop(sess *codexWebsocketSession,
 	if e == nil || sess == nil || conn == nil {
 		return
 	}
+	idle := codexWebsocketIdleTimeout(e.cfg)
 	for {
-		_ = conn.SetReadDeadline(time.Now().Add(codexResponsesWebsocketIdleTimeout))
+		setCodexWebsocketReadDeadline(conn, idle)
 		msgType, payload, errRead := conn.ReadMessage()
 		if errRead != nil {
 			sess.activeMu.Lock()
@@ -1195,10 +1611,9 @@ func (e *CodexWebsocketsExecutor) invalidateUpstreamConn(sess *codexWebsocketSes
 	}
 	sess.connMu.Unlock()
 
+	e.stats.recordDisconnect(reason)
 	logCodexWebsocketDisconnected(sessionID, authID, wsURL, reason, err)
-	if errClose := conn.Close(); errClose != nil {
-		log.Errorf("codex websockets executor: close websocket error: %v", errClose)
-	}
+	e.closeUpstreamConn(conn)
 }
 
 func (e *CodexWebsocketsExecutor) CloseExecutionSession(sessionID string) {
@@ -1217,6 +1632,9 @@ func (e *CodexWebsocketsExecutor) CloseExecutionSession(sessionID string) {
 	e.sessMu.Lock()
 	sess := e.sessions[sessionID]
 	delete(e.sessions, sessionID)
+	if sess != nil {
+		sess.removed = true
+	}
 	e.sessMu.Unlock()
 
 	e.closeExecutionSession(sess, "session_closed")
@@ -1232,6 +1650,7 @@ func (e *CodexWebsocketsExecutor) closeAllExecutionSessions(reason string) {
 	for sessionID, sess := range e.sessions {
 		delete(e.sessions, sessionID)
 		if sess != nil {
+			sess.removed = true
 			sessions = append(sessions, sess)
 		}
 	}
@@ -1265,10 +1684,9 @@ func (e *CodexWebsocketsExecutor) closeExecutionSession(sess *codexWebsocketSess
 	if conn == nil {
 		return
 	}
+	e.stats.recordDisconnect(reason)
 	logCodexWebsocketDisconnected(sessionID, authID, wsURL, reason, nil)
-	if errClose := conn.Close(); errClose != nil {
-		log.Errorf("codex websockets executor: close websocket error: %v", errClose)
-	}
+	e.closeUpstreamConn(conn)
 }
 
 func logCodexWebsocketConnected(sessionID string, authID string, wsURL string) {
@@ -1283,6 +1701,17 @@ func logCodexWebsocketDisconnected(sessionID string, authID string, wsURL string
 	log.Infof("codex websockets: upstream disconnected session=%s auth=%s url=%s reason=%s", strings.TrimSpace(sessionID), strings.TrimSpace(authID), strings.TrimSpace(wsURL), strings.TrimSpace(reason))
 }
 
+// logCodexWebsocketResponseCompleted records the upstream latency of one websocket request,
+// measured from sending response.create to receiving its completion event.
+func logCodexWebsocketResponseCompleted(sessionID string, authID string, model string, latency time.Duration) {
+	log.WithFields(log.Fields{
+		"session":    strings.TrimSpace(sessionID),
+		"auth":       strings.TrimSpace(authID),
+		"model":      model,
+		"latency_ms": latency.Milliseconds(),
+	}).Info("codex websockets: response completed")
+}
+
 // CodexAutoExecutor routes Codex requests to the websocket transport only when:
 //  1. The downstream transport is websocket, and
 //  2. The selected auth enables websockets.
@@ -1302,6 +1731,9 @@ func NewCodexAutoExecutor(cfg *config.Config) *CodexAutoExecutor {
 
 func (e *CodexAutoExecutor) Identifier() string { return "codex" }
 
+// SupportsDryRun reports that the executor returns the prepared request for dry runs.
+func (e *CodexAutoExecutor) SupportsDryRun() bool { return true }
+
 func (e *CodexAutoExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
 	if e == nil || e.httpExec == nil {
 		return nil
@@ -1320,7 +1752,7 @@ func (e *CodexAutoExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 	if e == nil || e.httpExec == nil || e.wsExec == nil {
 		return cliproxyexecutor.Response{}, fmt.Errorf("codex auto executor: executor is nil")
 	}
-	if cliproxyexecutor.DownstreamWebsocket(ctx) && codexWebsocketsEnabled(auth) {
+	if e.useWebsocket(ctx, auth, opts) {
 		return e.wsExec.Execute(ctx, auth, req, opts)
 	}
 	return e.httpExec.Execute(ctx, auth, req, opts)
@@ -1330,12 +1762,25 @@ func (e *CodexAutoExecutor) ExecuteStream(ctx context.Context, auth *cliproxyaut
 	if e == nil || e.httpExec == nil || e.wsExec == nil {
 		return nil, fmt.Errorf("codex auto executor: executor is nil")
 	}
-	if cliproxyexecutor.DownstreamWebsocket(ctx) && codexWebsocketsEnabled(auth) {
+	if e.useWebsocket(ctx, auth, opts) {
 		return e.wsExec.ExecuteStream(ctx, auth, req, opts)
 	}
 	return e.httpExec.ExecuteStream(ctx, auth, req, opts)
 }
 
+// useWebsocket reports whether a request goes over the upstream websocket: downstream
+// websocket requests always do, and HTTP requests do when a configured session ID field
+// maps them to a reusable execution session.
+func (e *CodexAutoExecutor) useWebsocket(ctx context.Context, auth *cliproxyauth.Auth, opts cliproxyexecutor.Options) bool {
+	if !codexWebsocketsEnabled(auth) {
+		return false
+	}
+	if cliproxyexecutor.DownstreamWebsocket(ctx) {
+		return true
+	}
+	return derivedExecutionSessionID(e.wsExec.cfg, derivedExecutionSessionScope(ctx, auth), opts.OriginalRequest) != ""
+}
+
 func (e *CodexAutoExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
 	if e == nil || e.httpExec == nil {
 		return nil, fmt.Errorf("codex auto executor: http executor is nil")
@@ -1343,6 +1788,13 @@ func (e *CodexAutoExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth
 	return e.httpExec.Refresh(ctx, auth)
 }
 
+func (e *CodexAutoExecutor) Ping(ctx context.Context, auth *cliproxyauth.Auth) error {
+	if e == nil || e.httpExec == nil {
+		return fmt.Errorf("codex auto executor: http executor is nil")
+	}
+	return e.httpExec.Ping(ctx, auth)
+}
+
 func (e *CodexAutoExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	if e == nil || e.httpExec == nil {
 		return cliproxyexecutor.Response{}, fmt.Errorf("codex auto executor: http executor is nil")
@@ -1357,6 +1809,14 @@ func (e *CodexAutoExecutor) CloseExecutionSession(sessionID string) {
 	e.wsExec.CloseExecutionSession(sessionID)
 }
 
+// SessionStats returns websocket session counters from the underlying websocket executor.
+func (e *CodexAutoExecutor) SessionStats() CodexWebsocketSessionStats {
+	if e == nil {
+		return CodexWebsocketSessionStats{Disconnects: map[string]int64{}}
+	}
+	return e.wsExec.SessionStats()
+}
+
 func codexWebsocketsEnabled(auth *cliproxyauth.Auth) bool {
 	if auth == nil {
 		return false
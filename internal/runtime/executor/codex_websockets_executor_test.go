@@ -2,14 +2,25 @@ package executor
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 	"github.com/tidwall/gjson"
 )
 
@@ -32,14 +43,59 @@ func TestBuildCodexWebsocketRequestBodyPreservesPreviousResponseID(t *testing.T)
 	}
 }
 
+func TestApplyCodexHeadersUseConfiguredClientIdentity(t *testing.T) {
+	cfg := &config.Config{Codex: config.CodexConfig{
+		UserAgent:     "codex_cli_rs/0.200.0",
+		WebsocketBeta: "responses_websockets=2026-09-01",
+	}}
+
+	headers := applyCodexWebsocketHeaders(context.Background(), http.Header{}, nil, "", cfg)
+	if got := headers.Get("OpenAI-Beta"); got != "responses_websockets=2026-09-01" {
+		t.Fatalf("OpenAI-Beta = %s, want configured websocket beta", got)
+	}
+	if got := headers.Get("User-Agent"); got != "codex_cli_rs/0.200.0" {
+		t.Fatalf("websocket User-Agent = %s, want configured user agent", got)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "https://example.com/responses", nil)
+	applyCodexHeaders(req, nil, "token", false, cfg)
+	if got := req.Header.Get("User-Agent"); got != "codex_cli_rs/0.200.0" {
+		t.Fatalf("HTTP User-Agent = %s, want configured user agent", got)
+	}
+}
+
+func TestApplyCodexHeadersUseClientHeadersWithoutGinContext(t *testing.T) {
+	ctx := cliproxyexecutor.WithClientHeaders(context.Background(), http.Header{
+		"Originator":            []string{"background-agent"},
+		"X-Codex-Turn-Metadata": []string{"turn-1"},
+	})
+
+	headers := applyCodexWebsocketHeaders(ctx, http.Header{}, nil, "", nil)
+	if got := headers.Get("Originator"); got != "background-agent" {
+		t.Fatalf("websocket Originator = %q, want background-agent", got)
+	}
+	if got := headers.Get("X-Codex-Turn-Metadata"); got != "turn-1" {
+		t.Fatalf("websocket X-Codex-Turn-Metadata = %q, want turn-1", got)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "https://example.com/responses", nil).WithContext(ctx)
+	applyCodexHeaders(req, nil, "token", false, nil)
+	if got := req.Header.Get("Originator"); got != "background-agent" {
+		t.Fatalf("HTTP Originator = %q, want background-agent", got)
+	}
+	if got := req.Header.Get("X-Codex-Turn-Metadata"); got != "turn-1" {
+		t.Fatalf("HTTP X-Codex-Turn-Metadata = %q, want turn-1", got)
+	}
+}
+
 func TestApplyCodexWebsocketHeadersDefaultsToCurrentResponsesBeta(t *testing.T) {
 	headers := applyCodexWebsocketHeaders(context.Background(), http.Header{}, nil, "", nil)
 
-	if got := headers.Get("OpenAI-Beta"); got != codexResponsesWebsocketBetaHeaderValue {
-		t.Fatalf("OpenAI-Beta = %s, want %s", got, codexResponsesWebsocketBetaHeaderValue)
+	if got := headers.Get("OpenAI-Beta"); got != config.DefaultCodexWebsocketBeta {
+		t.Fatalf("OpenAI-Beta = %s, want %s", got, config.DefaultCodexWebsocketBeta)
 	}
-	if got := headers.Get("User-Agent"); got != codexUserAgent {
-		t.Fatalf("User-Agent = %s, want %s", got, codexUserAgent)
+	if got := headers.Get("User-Agent"); got != config.DefaultCodexUserAgent {
+		t.Fatalf("User-Agent = %s, want %s", got, config.DefaultCodexUserAgent)
 	}
 	if got := headers.Get("Version"); got != "" {
 		t.Fatalf("Version = %q, want empty", got)
@@ -103,8 +159,8 @@ func TestApplyCodexWebsocketHeadersUsesConfigDefaultsForOAuth(t *testing.T) {
 	if got := headers.Get("x-codex-beta-features"); got != "feature-a,feature-b" {
 		t.Fatalf("x-codex-beta-features = %s, want %s", got, "feature-a,feature-b")
 	}
-	if got := headers.Get("OpenAI-Beta"); got != codexResponsesWebsocketBetaHeaderValue {
-		t.Fatalf("OpenAI-Beta = %s, want %s", got, codexResponsesWebsocketBetaHeaderValue)
+	if got := headers.Get("OpenAI-Beta"); got != config.DefaultCodexWebsocketBeta {
+		t.Fatalf("OpenAI-Beta = %s, want %s", got, config.DefaultCodexWebsocketBeta)
 	}
 }
 
@@ -177,8 +233,8 @@ func TestApplyCodexWebsocketHeadersIgnoresConfigForAPIKeyAuth(t *testing.T) {
 
 	headers := applyCodexWebsocketHeaders(context.Background(), http.Header{}, auth, "sk-test", cfg)
 
-	if got := headers.Get("User-Agent"); got != codexUserAgent {
-		t.Fatalf("User-Agent = %s, want %s", got, codexUserAgent)
+	if got := headers.Get("User-Agent"); got != config.DefaultCodexUserAgent {
+		t.Fatalf("User-Agent = %s, want %s", got, config.DefaultCodexUserAgent)
 	}
 	if got := headers.Get("x-codex-beta-features"); got != "" {
 		t.Fatalf("x-codex-beta-features = %q, want empty", got)
@@ -265,6 +321,48 @@ func TestApplyCodexHeadersDoesNotInjectClientOnlyHeadersByDefault(t *testing.T)
 	}
 }
 
+func TestApplyCodexHeadersSkipsPassthroughWhenDisabledByClient(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/responses", nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	auth := &cliproxyauth.Auth{
+		Provider:   "codex",
+		Attributes: map[string]string{"header:X-Custom": "custom-value"},
+		Metadata:   map[string]any{"email": "user@example.com"},
+	}
+	ctx := contextWithGinHeaders(map[string]string{
+		"Originator":                   "Codex Desktop",
+		"Version":                      "0.115.0-alpha.27",
+		"User-Agent":                   "client-ua",
+		util.NoHeaderPassthroughHeader: "true",
+	})
+	req = req.WithContext(util.WithHeaderPassthroughDisabled(ctx))
+
+	applyCodexHeaders(req, auth, "oauth-token", true, nil)
+
+	if got := req.Header.Get("X-Custom"); got != "" {
+		t.Fatalf("X-Custom = %q, want empty", got)
+	}
+	if got := req.Header.Get("Version"); got != "" {
+		t.Fatalf("Version = %q, want empty", got)
+	}
+	if got := req.Header.Get("Originator"); got != codexOriginator {
+		t.Fatalf("Originator = %q, want %q", got, codexOriginator)
+	}
+	if got := req.Header.Get("User-Agent"); got != config.DefaultCodexUserAgent {
+		t.Fatalf("User-Agent = %q, want %q", got, config.DefaultCodexUserAgent)
+	}
+
+	wsHeaders := applyCodexWebsocketHeaders(req.Context(), http.Header{}, auth, "oauth-token", nil)
+	if got := wsHeaders.Get("X-Custom"); got != "" {
+		t.Fatalf("websocket X-Custom = %q, want empty", got)
+	}
+	if got := wsHeaders.Get("Version"); got != "" {
+		t.Fatalf("websocket Version = %q, want empty", got)
+	}
+}
+
 func contextWithGinHeaders(headers map[string]string) context.Context {
 	gin.SetMode(gin.TestMode)
 	recorder := httptest.NewRecorder()
@@ -289,3 +387,598 @@ func TestNewProxyAwareWebsocketDialerDirectDisablesProxy(t *testing.T) {
 		t.Fatal("expected websocket proxy function to be nil for direct mode")
 	}
 }
+
+func TestCodexWebsocketsExecutorResendsUpstreamTurnState(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Header.Get("x-codex-turn-state"))
+		turn := len(received)
+		mu.Unlock()
+
+		respHeader := http.Header{}
+		respHeader.Set("x-codex-turn-state", fmt.Sprintf("turn-state-%d", turn))
+		conn, err := upgrader.Upgrade(w, r, respHeader)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		if _, _, errRead := conn.ReadMessage(); errRead != nil {
+			return
+		}
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"response.completed","response":{"id":"resp_1","output":[]}}`))
+	}))
+	defer server.Close()
+
+	executor := NewCodexWebsocketsExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "codex-turn-state", Attributes: map[string]string{
+		"api_key":  "sk-test",
+		"base_url": server.URL,
+	}}
+	req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"model":"gpt-5","input":[]}`)}
+	opts := cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("codex"),
+		Metadata:     map[string]any{cliproxyexecutor.ExecutionSessionMetadataKey: "session-turn-state"},
+	}
+	defer executor.CloseExecutionSession("session-turn-state")
+
+	for turn := 1; turn <= 2; turn++ {
+		if _, err := executor.Execute(context.Background(), auth, req, opts); err != nil {
+			t.Fatalf("turn %d: execute error: %v", turn, err)
+		}
+		// The upstream closes the socket after each response; wait for the session to notice
+		// so the next turn dials a fresh connection.
+		sess := executor.getOrCreateSession("session-turn-state")
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			sess.connMu.Lock()
+			closed := sess.conn == nil
+			sess.connMu.Unlock()
+			if closed {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("turn %d: upstream connection was not released", turn)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("handshakes = %d, want 2", len(received))
+	}
+	if received[0] != "" {
+		t.Fatalf("first request turn state = %q, want empty", received[0])
+	}
+	if received[1] != "turn-state-1" {
+		t.Fatalf("second request turn state = %q, want %q", received[1], "turn-state-1")
+	}
+}
+
+func TestCodexWebsocketsExecutorReportsWebsocketTransport(t *testing.T) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		if _, _, errRead := conn.ReadMessage(); errRead != nil {
+			return
+		}
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"response.completed","response":{"id":"resp_1","output":[]}}`))
+	}))
+	defer server.Close()
+
+	executor := NewCodexWebsocketsExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "codex-ws-transport", Attributes: map[string]string{"api_key": "sk-test", "base_url": server.URL}}
+	req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"model":"gpt-5","input":[]}`)}
+
+	resp, err := executor.Execute(context.Background(), auth, req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")})
+	if err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+	if got := resp.Headers.Get(codexTransportHeader); got != codexTransportWebsocket {
+		t.Fatalf("%s = %q, want %q", codexTransportHeader, got, codexTransportWebsocket)
+	}
+	if got := resp.Headers.Get(codexTransportFallbackReasonHeader); got != "" {
+		t.Fatalf("%s = %q, want empty", codexTransportFallbackReasonHeader, got)
+	}
+}
+
+func TestCodexWebsocketsExecutorReportsHTTPFallbackTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			w.WriteHeader(http.StatusUpgradeRequired)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"output\":[]}}\n\n"))
+	}))
+	defer server.Close()
+
+	executor := NewCodexWebsocketsExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "codex-http-transport", Attributes: map[string]string{"api_key": "sk-test", "base_url": server.URL}}
+	req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"model":"gpt-5","input":[]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")}
+
+	resp, err := executor.Execute(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+	if got := resp.Headers.Get(codexTransportHeader); got != codexTransportHTTP {
+		t.Fatalf("%s = %q, want %q", codexTransportHeader, got, codexTransportHTTP)
+	}
+	if got := resp.Headers.Get(codexTransportFallbackReasonHeader); got != codexFallbackReasonUpgradeRequired {
+		t.Fatalf("%s = %q, want %q", codexTransportFallbackReasonHeader, got, codexFallbackReasonUpgradeRequired)
+	}
+
+	result, err := executor.ExecuteStream(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("execute stream error: %v", err)
+	}
+	for range result.Chunks {
+	}
+	if got := result.Headers.Get(codexTransportHeader); got != codexTransportHTTP {
+		t.Fatalf("stream %s = %q, want %q", codexTransportHeader, got, codexTransportHTTP)
+	}
+	if got := result.Headers.Get(codexTransportFallbackReasonHeader); got != codexFallbackReasonUpgradeRequired {
+		t.Fatalf("stream %s = %q, want %q", codexTransportFallbackReasonHeader, got, codexFallbackReasonUpgradeRequired)
+	}
+}
+
+func TestCodexWebsocketTimeoutsFromConfig(t *testing.T) {
+	zero, custom := 0, 12
+	cases := []struct {
+		name          string
+		cfg           *config.Config
+		wantIdle      time.Duration
+		wantHandshake time.Duration
+	}{
+		{name: "nil config", cfg: nil, wantIdle: codexResponsesWebsocketIdleTimeout, wantHandshake: codexResponsesWebsocketHandshakeTO},
+		{name: "unset", cfg: &config.Config{}, wantIdle: codexResponsesWebsocketIdleTimeout, wantHandshake: codexResponsesWebsocketHandshakeTO},
+		{name: "zero disables", cfg: &config.Config{CodexWebsocket: config.CodexWebsocketConfig{IdleTimeoutSeconds: &zero, HandshakeTimeoutSeconds: &zero}}, wantIdle: 0, wantHandshake: 0},
+		{name: "custom", cfg: &config.Config{CodexWebsocket: config.CodexWebsocketConfig{IdleTimeoutSeconds: &custom, HandshakeTimeoutSeconds: &custom}}, wantIdle: 12 * time.Second, wantHandshake: 12 * time.Second},
+	}
+	for _, tc := range cases {
+		if got := codexWebsocketIdleTimeout(tc.cfg); got != tc.wantIdle {
+			t.Fatalf("%s: idle timeout = %v, want %v", tc.name, got, tc.wantIdle)
+		}
+		if got := codexWebsocketHandshakeTimeout(tc.cfg); got != tc.wantHandshake {
+			t.Fatalf("%s: handshake timeout = %v, want %v", tc.name, got, tc.wantHandshake)
+		}
+	}
+}
+
+func TestCodexWebsocketsExecutorDisableCompression(t *testing.T) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	extensions := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		extensions <- r.Header.Get("Sec-WebSocket-Extensions")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = conn.Close()
+	}))
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	for _, disable := range []bool{false, true} {
+		executor := NewCodexWebsocketsExecutor(&config.Config{CodexWebsocket: config.CodexWebsocketConfig{
+			DisableCompression: disable,
+			ReadBufferSize:     1 << 16,
+			WriteBufferSize:    1 << 16,
+		}})
+		conn, resp, err := executor.dialCodexWebsocket(context.Background(), nil, wsURL, http.Header{})
+		if err != nil {
+			t.Fatalf("disable=%v: dial error = %v", disable, err)
+		}
+		closeHTTPResponseBody(resp, "close test handshake body")
+		_ = conn.Close()
+		got := <-extensions
+		if offered := strings.Contains(got, "permessage-deflate"); offered == disable {
+			t.Fatalf("disable=%v: Sec-WebSocket-Extensions = %q", disable, got)
+		}
+	}
+}
+
+func TestCodexWebsocketsExecutorHonorsIdleTimeout(t *testing.T) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	release := make(chan struct{})
+	defer close(release)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_, _, _ = conn.ReadMessage()
+		<-release
+	}))
+	defer server.Close()
+
+	idle := 1
+	executor := NewCodexWebsocketsExecutor(&config.Config{CodexWebsocket: config.CodexWebsocketConfig{IdleTimeoutSeconds: &idle}})
+	auth := &cliproxyauth.Auth{ID: "codex-idle-timeout", Attributes: map[string]string{"api_key": "sk-test", "base_url": server.URL}}
+	req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"model":"gpt-5","input":[]}`)}
+
+	start := time.Now()
+	_, err := executor.Execute(context.Background(), auth, req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")})
+	if err == nil {
+		t.Fatal("expected idle timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("idle timeout took %v, want about 1s", elapsed)
+	}
+}
+
+func TestCodexWebsocketsExecutorAppliesMaxMessageBytes(t *testing.T) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	largeText := strings.Repeat("x", 2<<20)
+	completed := `{"type":"response.completed","response":{"id":"resp_1","object":"response","model":"gpt-5","status":"completed","output":[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"` + largeText + `"}]}]}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		if _, _, err = conn.ReadMessage(); err != nil {
+			return
+		}
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(completed))
+		_, _, _ = conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{ID: "codex-max-message", Attributes: map[string]string{"api_key": "sk-test", "base_url": server.URL}}
+	req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"model":"gpt-5","input":[]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")}
+
+	if got := codexWebsocketMaxMessageBytes(nil); got != codexResponsesWebsocketMaxMessageBytes {
+		t.Fatalf("default max message bytes = %d, want %d", got, codexResponsesWebsocketMaxMessageBytes)
+	}
+
+	resp, err := NewCodexWebsocketsExecutor(&config.Config{}).Execute(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("Execute with default limit: %v", err)
+	}
+	if !strings.Contains(string(resp.Payload), largeText) {
+		t.Fatal("expected large completion to be received within the default limit")
+	}
+
+	small := NewCodexWebsocketsExecutor(&config.Config{CodexWebsocket: config.CodexWebsocketConfig{MaxMessageBytes: 1 << 20}})
+	if _, err = small.Execute(context.Background(), auth, req, opts); err == nil {
+		t.Fatal("expected message above the configured limit to fail")
+	}
+}
+
+func TestCodexWebsocketsExecutorEvictsLeastRecentlyUsedSession(t *testing.T) {
+	executor := NewCodexWebsocketsExecutor(&config.Config{CodexWebsocket: config.CodexWebsocketConfig{MaxSessions: 2}})
+
+	first := executor.getOrCreateSession("session-a")
+	time.Sleep(time.Millisecond)
+	executor.getOrCreateSession("session-b")
+	time.Sleep(time.Millisecond)
+	// Touch session-a so session-b becomes the least recently used.
+	if got := executor.getOrCreateSession("session-a"); got != first {
+		t.Fatal("expected existing session to be reused")
+	}
+	time.Sleep(time.Millisecond)
+	executor.getOrCreateSession("session-c")
+
+	executor.sessMu.Lock()
+	_, hasA := executor.sessions["session-a"]
+	_, hasB := executor.sessions["session-b"]
+	_, hasC := executor.sessions["session-c"]
+	count := len(executor.sessions)
+	executor.sessMu.Unlock()
+	if count != 2 || !hasA || hasB || !hasC {
+		t.Fatalf("sessions after eviction: a=%v b=%v c=%v count=%d, want a and c only", hasA, hasB, hasC, count)
+	}
+}
+
+func TestCodexWebsocketsExecutorSkipsEvictingBusySession(t *testing.T) {
+	executor := NewCodexWebsocketsExecutor(&config.Config{CodexWebsocket: config.CodexWebsocketConfig{MaxSessions: 1}})
+
+	busy := executor.getOrCreateSession("session-busy")
+	busy.reqMu.Lock()
+	defer busy.reqMu.Unlock()
+
+	executor.getOrCreateSession("session-new")
+
+	executor.sessMu.Lock()
+	_, hasBusy := executor.sessions["session-busy"]
+	_, hasNew := executor.sessions["session-new"]
+	executor.sessMu.Unlock()
+	if !hasBusy || !hasNew {
+		t.Fatalf("busy=%v new=%v, want both sessions kept while the busy one is in flight", hasBusy, hasNew)
+	}
+}
+
+func TestCodexWebsocketsExecutorLockSessionSkipsEvictedSession(t *testing.T) {
+	executor := NewCodexWebsocketsExecutor(&config.Config{CodexWebsocket: config.CodexWebsocketConfig{MaxSessions: 1}})
+
+	// Simulate a caller that looked the session up but was evicted before taking reqMu.
+	stale := executor.getOrCreateSession("session-a")
+	executor.getOrCreateSession("session-b")
+	executor.sessMu.Lock()
+	_, tracked := executor.sessions["session-a"]
+	executor.sessMu.Unlock()
+	if tracked || !stale.removed {
+		t.Fatalf("session-a tracked=%v removed=%v, want evicted", tracked, stale.removed)
+	}
+
+	sess := executor.lockSession("session-a")
+	if sess == nil {
+		t.Fatal("lockSession returned nil")
+	}
+	defer sess.reqMu.Unlock()
+	if sess == stale {
+		t.Fatal("lockSession returned the evicted session")
+	}
+	executor.sessMu.Lock()
+	current := executor.sessions["session-a"]
+	executor.sessMu.Unlock()
+	if current != sess {
+		t.Fatal("locked session is not the tracked one")
+	}
+}
+
+func TestParseCodexWebsocketErrorRetryAfter(t *testing.T) {
+	now := time.Now()
+	retryAt := now.Add(90 * time.Second).UTC().Format(http.TimeFormat)
+
+	cases := []struct {
+		name    string
+		payload string
+		want    time.Duration
+		wantNil bool
+	}{
+		{name: "numeric retry_after", payload: `{"type":"error","status":429,"retry_after":12,"error":{"type":"rate_limit_exceeded"}}`, want: 12 * time.Second},
+		{name: "nested retry_after", payload: `{"type":"error","status":429,"error":{"type":"rate_limit_exceeded","retry_after":"3"}}`, want: 3 * time.Second},
+		{name: "header seconds", payload: `{"type":"error","status":429,"headers":{"Retry-After":"7"}}`, want: 7 * time.Second},
+		{name: "header http date", payload: `{"type":"error","status":429,"headers":{"retry-after":"` + retryAt + `"}}`, want: 90 * time.Second},
+		{name: "unparseable", payload: `{"type":"error","status":429,"retry_after":"soon","headers":{"Retry-After":"later"}}`, wantNil: true},
+		{name: "not rate limited", payload: `{"type":"error","status":500,"retry_after":5}`, wantNil: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err, ok := parseCodexWebsocketError([]byte(tc.payload))
+			if !ok {
+				t.Fatalf("expected payload to parse as error")
+			}
+			var se statusErrWithHeaders
+			if !errors.As(err, &se) {
+				t.Fatalf("error type = %T, want statusErrWithHeaders", err)
+			}
+			retryAfter := se.RetryAfter()
+			if tc.wantNil {
+				if retryAfter != nil {
+					t.Fatalf("RetryAfter() = %v, want nil", *retryAfter)
+				}
+				return
+			}
+			if retryAfter == nil {
+				t.Fatalf("RetryAfter() = nil, want %v", tc.want)
+			}
+			// HTTP dates have second precision, so allow a small tolerance.
+			if diff := *retryAfter - tc.want; diff > 2*time.Second || diff < -2*time.Second {
+				t.Fatalf("RetryAfter() = %v, want about %v", *retryAfter, tc.want)
+			}
+		})
+	}
+}
+
+type upstreamLatencyPlugin struct {
+	authID  string
+	records chan usage.Record
+}
+
+func (p *upstreamLatencyPlugin) HandleUsage(_ context.Context, record usage.Record) {
+	if record.AuthID == p.authID {
+		p.records <- record
+	}
+}
+
+func TestCodexWebsocketsExecutorReportsUpstreamLatency(t *testing.T) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		if _, _, errRead := conn.ReadMessage(); errRead != nil {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"response.completed","response":{"id":"resp_1","output":[],"usage":{"input_tokens":3,"output_tokens":2,"total_tokens":5}}}`))
+	}))
+	defer server.Close()
+
+	plugin := &upstreamLatencyPlugin{authID: "codex-ws-latency", records: make(chan usage.Record, 1)}
+	usage.RegisterPlugin(plugin)
+
+	executor := NewCodexWebsocketsExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: plugin.authID, Attributes: map[string]string{"api_key": "sk-test", "base_url": server.URL}}
+	req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"model":"gpt-5","input":[]}`)}
+	if _, err := executor.Execute(context.Background(), auth, req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")}); err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+
+	select {
+	case record := <-plugin.records:
+		if record.UpstreamLatency < 50*time.Millisecond || record.UpstreamLatency > record.Latency {
+			t.Fatalf("upstream latency = %v (total %v), want >= 50ms and <= total", record.UpstreamLatency, record.Latency)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for usage record")
+	}
+}
+
+func sessionKeyContext(apiKey string) context.Context {
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Set("apiKey", apiKey)
+	return context.WithValue(context.Background(), "gin", ginCtx)
+}
+
+func TestExecutionSessionIDFromOptionsDerivesFromConversationID(t *testing.T) {
+	cfg := &config.Config{CodexWebsocket: config.CodexWebsocketConfig{SessionIDFields: []string{"conversation_id", "metadata.session"}}}
+	ctx := sessionKeyContext("client-a")
+	auth := &cliproxyauth.Auth{ID: "codex-a"}
+	first := cliproxyexecutor.Options{OriginalRequest: []byte(`{"conversation_id":"conv-123","input":"hi"}`)}
+	second := cliproxyexecutor.Options{OriginalRequest: []byte(`{"conversation_id":"conv-123","input":"and again"}`)}
+
+	id := executionSessionIDFromOptions(ctx, cfg, auth, first)
+	if id == "" || id != executionSessionIDFromOptions(ctx, cfg, auth, second) {
+		t.Fatalf("session IDs = %q and %q, want the same non-empty ID", id, executionSessionIDFromOptions(ctx, cfg, auth, second))
+	}
+	executor := NewCodexWebsocketsExecutor(cfg)
+	if executor.getOrCreateSession(id) != executor.getOrCreateSession(executionSessionIDFromOptions(ctx, cfg, auth, second)) {
+		t.Fatal("expected requests with the same conversation_id to share an execution session")
+	}
+
+	other := cliproxyexecutor.Options{OriginalRequest: []byte(`{"metadata":{"session":"conv-456"}}`)}
+	if got := executionSessionIDFromOptions(ctx, cfg, auth, other); got == "" || got == id {
+		t.Fatalf("session ID for metadata.session = %q, want a distinct non-empty ID", got)
+	}
+	explicit := cliproxyexecutor.Options{
+		OriginalRequest: first.OriginalRequest,
+		Metadata:        map[string]any{cliproxyexecutor.ExecutionSessionMetadataKey: "explicit"},
+	}
+	if got := executionSessionIDFromOptions(ctx, cfg, auth, explicit); got != "explicit" {
+		t.Fatalf("session ID = %q, want explicit metadata key to win", got)
+	}
+	if got := executionSessionIDFromOptions(ctx, &config.Config{}, auth, first); got != "" {
+		t.Fatalf("session ID without configured fields = %q, want empty", got)
+	}
+}
+
+func TestExecutionSessionIDFromOptionsScopesDerivedIDs(t *testing.T) {
+	cfg := &config.Config{CodexWebsocket: config.CodexWebsocketConfig{SessionIDFields: []string{"conversation_id"}}}
+	opts := cliproxyexecutor.Options{OriginalRequest: []byte(`{"conversation_id":"conv-123"}`)}
+	auth := &cliproxyauth.Auth{ID: "codex-a"}
+
+	id := executionSessionIDFromOptions(sessionKeyContext("client-a"), cfg, auth, opts)
+	if got := executionSessionIDFromOptions(sessionKeyContext("client-b"), cfg, auth, opts); got == id {
+		t.Fatalf("clients with different API keys share derived session %q", got)
+	}
+	if got := executionSessionIDFromOptions(sessionKeyContext("client-a"), cfg, &cliproxyauth.Auth{ID: "codex-b"}, opts); got == id {
+		t.Fatalf("credentials share derived session %q", got)
+	}
+}
+
+func TestCodexAutoExecutorRoutesDerivedSessionsOverWebsocket(t *testing.T) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	var httpCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !websocket.IsWebSocketUpgrade(r) {
+			httpCalls.Add(1)
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_http\",\"output\":[]}}\n\n"))
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		for {
+			if _, _, errRead := conn.ReadMessage(); errRead != nil {
+				return
+			}
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"response.completed","response":{"id":"resp_ws","output":[]}}`))
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{CodexWebsocket: config.CodexWebsocketConfig{SessionIDFields: []string{"conversation_id"}}}
+	executor := NewCodexAutoExecutor(cfg)
+	auth := &cliproxyauth.Auth{ID: "codex-auto-derived", Attributes: map[string]string{"api_key": "sk-test", "base_url": server.URL, "websockets": "true"}}
+
+	withSession := []byte(`{"model":"gpt-5","conversation_id":"conv-1","input":[]}`)
+	resp, err := executor.Execute(sessionKeyContext("client-a"), auth, cliproxyexecutor.Request{Model: "gpt-5", Payload: withSession}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex"), OriginalRequest: withSession})
+	if err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+	if got := resp.Headers.Get(codexTransportHeader); got != codexTransportWebsocket {
+		t.Fatalf("%s = %q, want %q for a derived session", codexTransportHeader, got, codexTransportWebsocket)
+	}
+	if got := httpCalls.Load(); got != 0 {
+		t.Fatalf("http calls = %d, want 0", got)
+	}
+
+	withoutSession := []byte(`{"model":"gpt-5","input":[]}`)
+	if _, err = executor.Execute(sessionKeyContext("client-a"), auth, cliproxyexecutor.Request{Model: "gpt-5", Payload: withoutSession}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex"), OriginalRequest: withoutSession}); err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+	if got := httpCalls.Load(); got != 1 {
+		t.Fatalf("http calls = %d, want requests without a session field to stay on HTTP", got)
+	}
+}
+
+func TestCodexWebsocketsExecutorFallsBackToHTTPAfterRepeatedFailures(t *testing.T) {
+	var mu sync.Mutex
+	wsAttempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			mu.Lock()
+			wsAttempts++
+			mu.Unlock()
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"output\":[]}}\n\n"))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{CodexWebsocket: config.CodexWebsocketConfig{HTTPFallbackAfterFailures: 2, HTTPFallbackCooldownSeconds: 60}}
+	executor := NewCodexWebsocketsExecutor(cfg)
+	auth := &cliproxyauth.Auth{ID: "codex-ws-degraded", Attributes: map[string]string{"api_key": "sk-test", "base_url": server.URL}}
+	req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"model":"gpt-5","input":[]}`)}
+	opts := cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("codex"),
+		Metadata:     map[string]any{cliproxyexecutor.ExecutionSessionMetadataKey: "session-degraded"},
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := executor.Execute(context.Background(), auth, req, opts); err == nil {
+			t.Fatalf("attempt %d: expected websocket failure", i+1)
+		}
+	}
+	resp, err := executor.Execute(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("execute after degradation: %v", err)
+	}
+	if got := resp.Headers.Get(codexTransportFallbackReasonHeader); got != codexFallbackReasonDegraded {
+		t.Fatalf("%s = %q, want %q", codexTransportFallbackReasonHeader, got, codexFallbackReasonDegraded)
+	}
+	result, err := executor.ExecuteStream(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("execute stream after degradation: %v", err)
+	}
+	for range result.Chunks {
+	}
+	if got := result.Headers.Get(codexTransportFallbackReasonHeader); got != codexFallbackReasonDegraded {
+		t.Fatalf("stream %s = %q, want %q", codexTransportFallbackReasonHeader, got, codexFallbackReasonDegraded)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if wsAttempts != 2 {
+		t.Fatalf("websocket attempts = %d, want 2", wsAttempts)
+	}
+
+	sess := executor.getOrCreateSession("session-degraded")
+	sess.recordWebsocketSuccess()
+	if sess.httpDegraded(time.Now()) {
+		t.Fatal("expected a successful websocket send to clear the HTTP fallback window")
+	}
+}
@@ -0,0 +1,58 @@
+package executor
+
+import (
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// codexWebsocketHTTPFallbackCooldown is the default time a degraded session stays on HTTP.
+const codexWebsocketHTTPFallbackCooldown = 300 * time.Second
+
+// codexWebsocketHTTPFallbackPolicy returns the consecutive failure threshold after which a
+// session falls back to HTTP, or 0 when disabled, and the fallback cooldown.
+func codexWebsocketHTTPFallbackPolicy(cfg *config.Config) (int, time.Duration) {
+	if cfg == nil || cfg.CodexWebsocket.HTTPFallbackAfterFailures <= 0 {
+		return 0, 0
+	}
+	cooldown := codexWebsocketHTTPFallbackCooldown
+	if cfg.CodexWebsocket.HTTPFallbackCooldownSeconds > 0 {
+		cooldown = time.Duration(cfg.CodexWebsocket.HTTPFallbackCooldownSeconds) * time.Second
+	}
+	return cfg.CodexWebsocket.HTTPFallbackAfterFailures, cooldown
+}
+
+// httpDegraded reports whether the session is inside an HTTP fallback window. Callers hold reqMu.
+func (s *codexWebsocketSession) httpDegraded(now time.Time) bool {
+	return s != nil && now.Before(s.httpDegradedUntil)
+}
+
+// recordWebsocketFailure counts a websocket dial or send failure and starts an HTTP fallback
+// window once the configured threshold of consecutive failures is reached. Callers hold reqMu.
+func (s *codexWebsocketSession) recordWebsocketFailure(cfg *config.Config, now time.Time) {
+	if s == nil {
+		return
+	}
+	threshold, cooldown := codexWebsocketHTTPFallbackPolicy(cfg)
+	if threshold <= 0 {
+		return
+	}
+	s.sendFailures++
+	if s.sendFailures < threshold {
+		return
+	}
+	s.sendFailures = 0
+	s.httpDegradedUntil = now.Add(cooldown)
+	log.Warnf("codex websockets: session %s degraded to HTTP for %s after %d consecutive websocket failures", s.sessionID, cooldown, threshold)
+}
+
+// recordWebsocketSuccess resets the failure count after a successful websocket send. Callers
+// hold reqMu.
+func (s *codexWebsocketSession) recordWebsocketSuccess() {
+	if s == nil {
+		return
+	}
+	s.sendFailures = 0
+	s.httpDegradedUntil = time.Time{}
+}
@@ -0,0 +1,64 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// codexWebsocketPongTimeout is the default time allowed for a pong to answer a keepalive ping.
+const codexWebsocketPongTimeout = 10 * time.Second
+
+// codexWebsocketKeepalive returns the keepalive ping interval, or 0 when disabled, and the
+// pong timeout.
+func codexWebsocketKeepalive(cfg *config.Config) (time.Duration, time.Duration) {
+	if cfg == nil || cfg.CodexWebsocket.PingIntervalSeconds <= 0 {
+		return 0, 0
+	}
+	timeout := codexWebsocketPongTimeout
+	if cfg.CodexWebsocket.PongTimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.CodexWebsocket.PongTimeoutSeconds) * time.Second
+	}
+	return time.Duration(cfg.CodexWebsocket.PingIntervalSeconds) * time.Second, timeout
+}
+
+// keepaliveUpstreamConn pings conn every interval while it remains the session's connection.
+// When a ping cannot be written, or no pong arrives within pongTimeout, the connection is
+// invalidated so the next request reconnects instead of waiting on a dead peer. Pongs are
+// recorded by the handler installed in configureConn, which runs on the read loop.
+func (e *CodexWebsocketsExecutor) keepaliveUpstreamConn(sess *codexWebsocketSession, conn *websocket.Conn, interval, pongTimeout time.Duration) {
+	if e == nil || sess == nil || conn == nil || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !sess.isCurrentConn(conn) {
+			return
+		}
+		sentAt := time.Now()
+		sess.writeMu.Lock()
+		errPing := conn.WriteControl(websocket.PingMessage, nil, sentAt.Add(pongTimeout))
+		sess.writeMu.Unlock()
+		if errPing != nil {
+			e.invalidateUpstreamConn(sess, conn, "ping_error", errPing)
+			return
+		}
+		time.Sleep(pongTimeout)
+		if !sess.isCurrentConn(conn) {
+			return
+		}
+		if sess.lastPong.Load() < sentAt.UnixNano() {
+			e.invalidateUpstreamConn(sess, conn, "pong_timeout", fmt.Errorf("codex websockets executor: no pong within %s", pongTimeout))
+			return
+		}
+	}
+}
+
+func (s *codexWebsocketSession) isCurrentConn(conn *websocket.Conn) bool {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	return s.conn == conn
+}
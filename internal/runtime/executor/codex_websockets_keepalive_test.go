@@ -0,0 +1,102 @@
+package executor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// dialKeepaliveTestPeer connects to a websocket peer that answers pings only when respond is
+// true; a silent peer never reads, so its pong replies are never sent.
+func dialKeepaliveTestPeer(t *testing.T, respond bool) *websocket.Conn {
+	t.Helper()
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		if !respond {
+			<-release
+			return
+		}
+		for {
+			if _, _, errRead := conn.ReadMessage(); errRead != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial test websocket: %v", err)
+	}
+	closeHTTPResponseBody(resp, "close test handshake body")
+	return conn
+}
+
+func startKeepaliveTestSession(t *testing.T, executor *CodexWebsocketsExecutor, respond bool) (*codexWebsocketSession, *websocket.Conn) {
+	t.Helper()
+	sess := executor.getOrCreateSession("session-keepalive")
+	conn := dialKeepaliveTestPeer(t, respond)
+	sess.connMu.Lock()
+	sess.conn = conn
+	sess.readerConn = conn
+	sess.connMu.Unlock()
+	sess.configureConn(conn)
+	go executor.readUpstreamLoop(sess, conn)
+	go executor.keepaliveUpstreamConn(sess, conn, 20*time.Millisecond, 100*time.Millisecond)
+	return sess, conn
+}
+
+func TestCodexWebsocketsKeepaliveClosesConnWithoutPong(t *testing.T) {
+	executor := NewCodexWebsocketsExecutor(&config.Config{})
+	sess, conn := startKeepaliveTestSession(t, executor, false)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for sess.isCurrentConn(conn) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the silent peer's connection to be invalidated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := executor.SessionStats().Disconnects["pong_timeout"]; got != 1 {
+		t.Fatalf("pong_timeout disconnects = %d, want 1", got)
+	}
+}
+
+func TestCodexWebsocketsKeepaliveKeepsResponsivePeer(t *testing.T) {
+	executor := NewCodexWebsocketsExecutor(&config.Config{})
+	sess, conn := startKeepaliveTestSession(t, executor, true)
+
+	time.Sleep(400 * time.Millisecond)
+	if !sess.isCurrentConn(conn) {
+		t.Fatalf("responsive peer was disconnected: %v", executor.SessionStats().Disconnects)
+	}
+	if sess.lastPong.Load() == 0 {
+		t.Fatal("expected a pong to be recorded")
+	}
+	executor.invalidateUpstreamConn(sess, conn, "test_done", nil)
+}
+
+func TestCodexWebsocketKeepaliveFromConfig(t *testing.T) {
+	if interval, _ := codexWebsocketKeepalive(&config.Config{}); interval != 0 {
+		t.Fatalf("interval = %v, want keepalive disabled by default", interval)
+	}
+	cfg := &config.Config{CodexWebsocket: config.CodexWebsocketConfig{PingIntervalSeconds: 30}}
+	interval, timeout := codexWebsocketKeepalive(cfg)
+	if interval != 30*time.Second || timeout != codexWebsocketPongTimeout {
+		t.Fatalf("keepalive = %v/%v, want 30s/%v", interval, timeout, codexWebsocketPongTimeout)
+	}
+}
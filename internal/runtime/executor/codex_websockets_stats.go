@@ -0,0 +1,87 @@
+package executor
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// CodexWebsocketSessionStats is a point-in-time snapshot of Codex websocket session activity.
+type CodexWebsocketSessionStats struct {
+	// ActiveSessions is the number of execution sessions holding a live upstream connection.
+	ActiveSessions int `json:"active_sessions"`
+	// Dials is the total number of upstream websocket dial attempts.
+	Dials int64 `json:"dials"`
+	// Reconnects counts successful dials for sessions that were connected before.
+	Reconnects int64 `json:"reconnects"`
+	// Disconnects counts upstream disconnects keyed by reason.
+	Disconnects map[string]int64 `json:"disconnects"`
+	// UpgradeRequiredFallbacks counts handshakes answered with 426 Upgrade Required.
+	UpgradeRequiredFallbacks int64 `json:"upgrade_required_fallbacks"`
+}
+
+type codexWebsocketStats struct {
+	dials           atomic.Int64
+	reconnects      atomic.Int64
+	upgradeRequired atomic.Int64
+
+	disconnectMu sync.Mutex
+	disconnects  map[string]int64
+}
+
+func (s *codexWebsocketStats) recordDial(resp *http.Response) {
+	s.dials.Add(1)
+	if resp != nil && resp.StatusCode == http.StatusUpgradeRequired {
+		s.upgradeRequired.Add(1)
+	}
+}
+
+func (s *codexWebsocketStats) recordDisconnect(reason string) {
+	s.disconnectMu.Lock()
+	if s.disconnects == nil {
+		s.disconnects = make(map[string]int64)
+	}
+	s.disconnects[reason]++
+	s.disconnectMu.Unlock()
+}
+
+// SessionStats returns a snapshot of websocket session counters. It is safe to call
+// concurrently with active traffic.
+func (e *CodexWebsocketsExecutor) SessionStats() CodexWebsocketSessionStats {
+	if e == nil {
+		return CodexWebsocketSessionStats{Disconnects: map[string]int64{}}
+	}
+
+	e.sessMu.Lock()
+	sessions := make([]*codexWebsocketSession, 0, len(e.sessions))
+	for _, sess := range e.sessions {
+		if sess != nil {
+			sessions = append(sessions, sess)
+		}
+	}
+	e.sessMu.Unlock()
+
+	active := 0
+	for _, sess := range sessions {
+		sess.connMu.Lock()
+		if sess.conn != nil {
+			active++
+		}
+		sess.connMu.Unlock()
+	}
+
+	e.stats.disconnectMu.Lock()
+	disconnects := make(map[string]int64, len(e.stats.disconnects))
+	for reason, count := range e.stats.disconnects {
+		disconnects[reason] = count
+	}
+	e.stats.disconnectMu.Unlock()
+
+	return CodexWebsocketSessionStats{
+		ActiveSessions:           active,
+		Dials:                    e.stats.dials.Load(),
+		Reconnects:               e.stats.reconnects.Load(),
+		Disconnects:              disconnects,
+		UpgradeRequiredFallbacks: e.stats.upgradeRequired.Load(),
+	}
+}
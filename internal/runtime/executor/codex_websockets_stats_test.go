@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func TestCodexWebsocketsExecutorSessionStatsCountsReconnects(t *testing.T) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		if _, _, errRead := conn.ReadMessage(); errRead != nil {
+			return
+		}
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"response.completed","response":{"id":"resp_1","output":[]}}`))
+	}))
+	defer server.Close()
+
+	executor := NewCodexWebsocketsExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "codex-stats", Attributes: map[string]string{"api_key": "sk-test", "base_url": server.URL}}
+	req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"model":"gpt-5","input":[]}`)}
+	opts := cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("codex"),
+		Metadata:     map[string]any{cliproxyexecutor.ExecutionSessionMetadataKey: "session-stats"},
+	}
+	defer executor.CloseExecutionSession("session-stats")
+
+	for turn := 1; turn <= 2; turn++ {
+		if _, err := executor.Execute(context.Background(), auth, req, opts); err != nil {
+			t.Fatalf("turn %d: execute error: %v", turn, err)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for executor.SessionStats().ActiveSessions != 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("turn %d: upstream connection was not released", turn)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	stats := executor.SessionStats()
+	if stats.Dials != 2 {
+		t.Fatalf("Dials = %d, want 2", stats.Dials)
+	}
+	if stats.Reconnects != 1 {
+		t.Fatalf("Reconnects = %d, want 1", stats.Reconnects)
+	}
+	if got := stats.Disconnects["upstream_disconnected"]; got != 2 {
+		t.Fatalf("Disconnects[upstream_disconnected] = %d, want 2 (all: %v)", got, stats.Disconnects)
+	}
+	if stats.UpgradeRequiredFallbacks != 0 {
+		t.Fatalf("UpgradeRequiredFallbacks = %d, want 0", stats.UpgradeRequiredFallbacks)
+	}
+}
+
+func TestCodexWebsocketsExecutorSessionStatsCountsUpgradeRequired(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			w.WriteHeader(http.StatusUpgradeRequired)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"output\":[]}}\n\n"))
+	}))
+	defer server.Close()
+
+	executor := NewCodexWebsocketsExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "codex-stats-426", Attributes: map[string]string{"api_key": "sk-test", "base_url": server.URL}}
+	req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"model":"gpt-5","input":[]}`)}
+
+	if _, err := executor.Execute(context.Background(), auth, req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")}); err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+
+	stats := executor.SessionStats()
+	if stats.Dials != 1 || stats.UpgradeRequiredFallbacks != 1 {
+		t.Fatalf("stats = %+v, want 1 dial and 1 upgrade-required fallback", stats)
+	}
+}
@@ -0,0 +1,106 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// connectionWarmupTimeout bounds each warmup request so an unreachable upstream
+// cannot hold goroutines for long.
+const connectionWarmupTimeout = 10 * time.Second
+
+// defaultWarmupBaseURLs lists upstream hosts for providers whose credentials do not
+// usually carry a base_url attribute.
+var defaultWarmupBaseURLs = map[string]string{
+	"claude":     "https://api.anthropic.com",
+	"codex":      "https://chatgpt.com/backend-api/codex",
+	"gemini":     glEndpoint,
+	"gemini-cli": codeAssistEndpoint,
+	"vertex":     "https://aiplatform.googleapis.com",
+	"qwen":       "https://portal.qwen.ai/v1",
+}
+
+// warmupBaseURL returns the upstream URL used to pre-open connections for auth.
+func warmupBaseURL(auth *cliproxyauth.Auth) string {
+	if auth == nil {
+		return ""
+	}
+	if auth.Attributes != nil {
+		if v := strings.TrimSpace(auth.Attributes["base_url"]); v != "" {
+			return v
+		}
+	}
+	provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+	if provider == "antigravity" {
+		if baseURLs := antigravityBaseURLFallbackOrder(auth); len(baseURLs) > 0 {
+			return baseURLs[0]
+		}
+	}
+	return defaultWarmupBaseURLs[provider]
+}
+
+// WarmupConnections opens the configured number of keep-alive connections to the
+// upstream serving auth's provider. The requests run concurrently so each one dials its
+// own connection; responses are drained so the connections return to the same shared
+// transport the executors use for auth, whose idle pool is sized to hold them.
+//
+// Returns the number of warmup requests that completed.
+func WarmupConnections(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth) int {
+	if cfg == nil || auth == nil || len(cfg.WarmupConnections) == 0 {
+		return 0
+	}
+	provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+	count := cfg.WarmupConnections[provider]
+	if count <= 0 {
+		return 0
+	}
+	target := warmupBaseURL(auth)
+	if target == "" {
+		log.Debugf("connection warmup skipped for provider %s: no upstream URL", provider)
+		return 0
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	httpClient := newProxyAwareHTTPClient(ctx, cfg, auth, connectionWarmupTimeout)
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		completed int
+	)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, errReq := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+			if errReq != nil {
+				log.Debugf("connection warmup request for provider %s failed: %v", provider, errReq)
+				return
+			}
+			resp, errDo := httpClient.Do(req)
+			if errDo != nil {
+				log.Debugf("connection warmup for provider %s failed: %v", provider, errDo)
+				return
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			if errClose := resp.Body.Close(); errClose != nil {
+				log.Errorf("connection warmup: close response body error: %v", errClose)
+			}
+			mu.Lock()
+			completed++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	log.Debugf("connection warmup for provider %s: %d/%d connections ready", provider, completed, count)
+	return completed
+}
@@ -0,0 +1,141 @@
+package executor
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func newWarmupTestServer(t *testing.T, release <-chan struct{}) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var dials atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("method = %s, want HEAD", r.Method)
+		}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			dials.Add(1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	return server, &dials
+}
+
+func TestWarmupConnectionsDialsConfiguredProviders(t *testing.T) {
+	release := make(chan struct{})
+	claudeServer, claudeDials := newWarmupTestServer(t, release)
+	codexServer, codexDials := newWarmupTestServer(t, release)
+
+	cfg := &config.Config{WarmupConnections: map[string]int{"claude": 3}}
+	claudeAuth := &cliproxyauth.Auth{Provider: "claude", Attributes: map[string]string{"base_url": claudeServer.URL}}
+	codexAuth := &cliproxyauth.Auth{Provider: "codex", Attributes: map[string]string{"base_url": codexServer.URL}}
+
+	var wg sync.WaitGroup
+	var completed int
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		completed = WarmupConnections(context.Background(), cfg, claudeAuth)
+	}()
+	// Hold responses until every request is in flight so each one needs its own connection.
+	for claudeDials.Load() < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if completed != 3 {
+		t.Fatalf("completed = %d, want 3", completed)
+	}
+	if got := claudeDials.Load(); got != 3 {
+		t.Fatalf("claude dials = %d, want 3", got)
+	}
+	if got := WarmupConnections(context.Background(), cfg, codexAuth); got != 0 {
+		t.Fatalf("codex warmup completed = %d, want 0", got)
+	}
+	if got := codexDials.Load(); got != 0 {
+		t.Fatalf("codex dials = %d, want 0", got)
+	}
+}
+
+func TestWarmupBaseURLFallsBackToProviderDefault(t *testing.T) {
+	if got := warmupBaseURL(&cliproxyauth.Auth{Provider: "claude"}); got != "https://api.anthropic.com" {
+		t.Fatalf("warmupBaseURL(claude) = %q", got)
+	}
+	if got := warmupBaseURL(&cliproxyauth.Auth{Provider: "unknown"}); got != "" {
+		t.Fatalf("warmupBaseURL(unknown) = %q, want empty", got)
+	}
+}
+
+func TestWarmupConnectionsAreKeptForExecutorRequests(t *testing.T) {
+	const warm = 4
+	var dials, arrived atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Release requests in batches of warm so every batch needs warm connections at once.
+		n := arrived.Add(1)
+		for batch := ((n-1)/warm + 1) * warm; arrived.Load() < batch; {
+			time.Sleep(time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			dials.Add(1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{WarmupConnections: map[string]int{"claude": warm}}
+	auth := &cliproxyauth.Auth{Provider: "claude", Attributes: map[string]string{"base_url": server.URL}}
+	if got := WarmupConnections(context.Background(), cfg, auth); got != warm {
+		t.Fatalf("completed = %d, want %d", got, warm)
+	}
+
+	client := newProxyAwareHTTPClient(context.Background(), cfg, auth, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < warm; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, errDo := client.Get(server.URL)
+			if errDo != nil {
+				t.Errorf("request: %v", errDo)
+				return
+			}
+			_ = resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if got := dials.Load(); got != warm {
+		t.Fatalf("dials = %d, want %d warmed connections reused", got, warm)
+	}
+}
+
+func TestHTTPClientTuningForRaisesIdlePoolToWarmupCount(t *testing.T) {
+	cfg := &config.Config{WarmupConnections: map[string]int{"claude": 8, "codex": 1}}
+	if got := httpClientTuningFor(cfg, &cliproxyauth.Auth{Provider: "claude"}).MaxIdleConnsPerHost; got != 8 {
+		t.Fatalf("claude MaxIdleConnsPerHost = %d, want 8", got)
+	}
+	if got := httpClientTuningFor(cfg, &cliproxyauth.Auth{Provider: "codex"}); !got.IsZero() {
+		t.Fatalf("codex tuning = %+v, want default", got)
+	}
+	cfg.HTTPClient.MaxIdleConnsPerHost = 16
+	if got := httpClientTuningFor(cfg, &cliproxyauth.Auth{Provider: "claude"}).MaxIdleConnsPerHost; got != 16 {
+		t.Fatalf("claude MaxIdleConnsPerHost = %d, want configured 16", got)
+	}
+}
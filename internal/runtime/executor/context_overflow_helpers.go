@@ -0,0 +1,161 @@
+package executor
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// applyContextOverflowPolicy estimates the prompt tokens of an OpenAI chat ("openai") or Codex
+// Responses ("codex") payload and, when the estimate exceeds the context window configured for
+// model, handles the request according to the configured overflow policy. Models without a
+// configured window and other formats are returned unchanged.
+func applyContextOverflowPolicy(cfg *config.Config, format, model string, body []byte) ([]byte, error) {
+	if cfg == nil || len(cfg.ContextWindows) == 0 {
+		return body, nil
+	}
+	window, ok := cfg.ContextWindows[strings.ToLower(strings.TrimSpace(model))]
+	if !ok {
+		return body, nil
+	}
+
+	var (
+		count func([]byte) (int64, error)
+		path  string
+	)
+	switch format {
+	case "codex":
+		enc, errEnc := tokenizerForCodexModel(model)
+		if errEnc != nil {
+			return body, nil
+		}
+		count = func(payload []byte) (int64, error) { return countCodexInputTokens(enc, payload) }
+		path = "input"
+	case "openai":
+		enc, errEnc := tokenizerForModel(model)
+		if errEnc != nil {
+			return body, nil
+		}
+		count = func(payload []byte) (int64, error) { return countOpenAIChatTokens(enc, payload) }
+		path = "messages"
+	default:
+		return body, nil
+	}
+
+	tokens, errCount := count(body)
+	if errCount != nil {
+		log.Debugf("context overflow check skipped for model %s: %v", model, errCount)
+		return body, nil
+	}
+	limit := int64(window)
+	if tokens <= limit {
+		return body, nil
+	}
+
+	switch cfg.ContextOverflowPolicy {
+	case config.ContextOverflowPolicyReject:
+		return body, contextOverflowError(model, tokens, limit)
+	case config.ContextOverflowPolicyTruncate:
+		// Each entry is counted once; dropping it subtracts its share from the estimate.
+		items := gjson.GetBytes(body, path).Array()
+		costs := make([]int64, len(items))
+		for i, item := range items {
+			costs[i] = countContextItem(count, path, item)
+		}
+		removed := make([]bool, len(items))
+		dropped := 0
+		for tokens > limit {
+			indexes := oldestDroppableMessages(items, removed)
+			if len(indexes) == 0 {
+				return body, contextOverflowError(model, tokens, limit)
+			}
+			for _, i := range indexes {
+				removed[i] = true
+				tokens -= costs[i]
+			}
+			dropped += len(indexes)
+		}
+		kept := make([]string, 0, len(items)-dropped)
+		for i, item := range items {
+			if !removed[i] {
+				kept = append(kept, item.Raw)
+			}
+		}
+		updated, errSet := sjson.SetRawBytes(body, path, []byte("["+strings.Join(kept, ",")+"]"))
+		if errSet != nil {
+			return body, nil
+		}
+		log.Debugf("context overflow: dropped %d oldest message(s) for model %s to fit %d tokens", dropped, model, limit)
+		return updated, nil
+	default:
+		log.Warnf("context overflow: estimated %d prompt tokens exceed the %d token window of model %s", tokens, limit, model)
+		return body, nil
+	}
+}
+
+// oldestDroppableMessages returns the indexes of the oldest entry of items that is neither a
+// system message nor already removed, along with tool results that followed it and would be
+// left without their call. The latest entry is always kept.
+func oldestDroppableMessages(items []gjson.Result, removed []bool) []int {
+	last := len(items) - 1
+	index := -1
+	for i := 0; i < last; i++ {
+		if removed[i] {
+			continue
+		}
+		switch items[i].Get("role").String() {
+		case "system", "developer":
+			continue
+		}
+		index = i
+		break
+	}
+	if index < 0 {
+		return nil
+	}
+
+	indexes := []int{index}
+	for next := index + 1; next < last && isToolResultItem(items[next]); next++ {
+		indexes = append(indexes, next)
+	}
+	return indexes
+}
+
+// countContextItem estimates the tokens contributed by a single entry of the array at path.
+func countContextItem(count func([]byte) (int64, error), path string, item gjson.Result) int64 {
+	single, errSet := sjson.SetRawBytes([]byte(`{}`), path, []byte("["+item.Raw+"]"))
+	if errSet != nil {
+		return 0
+	}
+	tokens, errCount := count(single)
+	if errCount != nil {
+		return 0
+	}
+	return tokens
+}
+
+// isToolResultItem reports whether item is an OpenAI tool message or a Codex tool output.
+func isToolResultItem(item gjson.Result) bool {
+	if item.Get("role").String() == "tool" {
+		return true
+	}
+	switch item.Get("type").String() {
+	case "function_call_output", "custom_tool_call_output":
+		return true
+	default:
+		return false
+	}
+}
+
+// contextOverflowError builds the HTTP 400 returned for prompts exceeding the context window.
+func contextOverflowError(model string, tokens, window int64) error {
+	msg := fmt.Sprintf("This model's maximum context length is %d tokens, but the request is estimated at %d tokens (model %s).", window, tokens, model)
+	errBody := []byte(`{"error":{"message":"","type":"invalid_request_error","param":"messages","code":"context_length_exceeded"}}`)
+	errBody, _ = sjson.SetBytes(errBody, "error.message", msg)
+	return statusErr{code: http.StatusBadRequest, msg: string(errBody)}
+}
@@ -0,0 +1,141 @@
+package executor
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func contextOverflowChatBody(t *testing.T) []byte {
+	t.Helper()
+	long := strings.Repeat("hello world ", 200)
+	body := []byte(`{"model":"gpt-4o","messages":[{"role":"system","content":"be brief"}]}`)
+	for _, msg := range []struct{ role, content string }{
+		{"user", long},
+		{"assistant", long},
+		{"user", "latest question"},
+	} {
+		var err error
+		body, err = sjson.SetBytes(body, "messages.-1", map[string]string{"role": msg.role, "content": msg.content})
+		if err != nil {
+			t.Fatalf("build body: %v", err)
+		}
+	}
+	return body
+}
+
+func TestApplyContextOverflowPolicyReject(t *testing.T) {
+	cfg := &config.Config{
+		ContextWindows:        map[string]int{"gpt-4o": 100},
+		ContextOverflowPolicy: config.ContextOverflowPolicyReject,
+	}
+
+	_, err := applyContextOverflowPolicy(cfg, "openai", "gpt-4o", contextOverflowChatBody(t))
+	var se statusErr
+	if !errors.As(err, &se) {
+		t.Fatalf("error = %v, want statusErr", err)
+	}
+	if se.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", se.StatusCode(), http.StatusBadRequest)
+	}
+	if !strings.Contains(se.Error(), "context_length_exceeded") {
+		t.Fatalf("unexpected error body: %s", se.Error())
+	}
+}
+
+func TestApplyContextOverflowPolicyTruncate(t *testing.T) {
+	cfg := &config.Config{
+		ContextWindows:        map[string]int{"gpt-4o": 100},
+		ContextOverflowPolicy: config.ContextOverflowPolicyTruncate,
+	}
+
+	out, err := applyContextOverflowPolicy(cfg, "openai", "gpt-4o", contextOverflowChatBody(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 2 {
+		t.Fatalf("messages length = %d, want 2; body=%s", len(messages), out)
+	}
+	if messages[0].Get("role").String() != "system" || messages[1].Get("content").String() != "latest question" {
+		t.Fatalf("unexpected remaining messages: %s", out)
+	}
+}
+
+func TestApplyContextOverflowPolicyTruncateDropsCodexToolOutputs(t *testing.T) {
+	cfg := &config.Config{
+		ContextWindows:        map[string]int{"gpt-5": 100},
+		ContextOverflowPolicy: config.ContextOverflowPolicyTruncate,
+	}
+	long := strings.Repeat("hello world ", 200)
+	body := []byte(`{"input":[` +
+		`{"type":"function_call","call_id":"call_1","name":"lookup","arguments":"{}"},` +
+		`{"type":"function_call_output","call_id":"call_1","output":""},` +
+		`{"type":"message","role":"user","content":[{"type":"input_text","text":"latest question"}]}]}`)
+	body, _ = sjson.SetBytes(body, "input.1.output", long)
+
+	out, err := applyContextOverflowPolicy(cfg, "codex", "gpt-5", body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	items := gjson.GetBytes(out, "input").Array()
+	if len(items) != 1 || items[0].Get("type").String() != "message" {
+		t.Fatalf("unexpected remaining input: %s", out)
+	}
+}
+
+func TestApplyContextOverflowPolicySkipsUnconfiguredModels(t *testing.T) {
+	cfg := &config.Config{
+		ContextWindows:        map[string]int{"other": 1},
+		ContextOverflowPolicy: config.ContextOverflowPolicyReject,
+	}
+	body := contextOverflowChatBody(t)
+
+	out, err := applyContextOverflowPolicy(cfg, "openai", "gpt-4o", body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != string(body) {
+		t.Fatalf("body changed for unconfigured model: %s", out)
+	}
+}
+
+func TestApplyContextOverflowPolicyTruncateDropsOnlyWhatIsNeeded(t *testing.T) {
+	enc, err := tokenizerForModel("gpt-4o")
+	if err != nil {
+		t.Fatalf("tokenizer: %v", err)
+	}
+	body := []byte(`{"model":"gpt-4o","messages":[{"role":"system","content":"be brief"}]}`)
+	for i := 0; i < 20; i++ {
+		body, _ = sjson.SetBytes(body, "messages.-1", map[string]string{"role": "user", "content": strings.Repeat("hello world ", 50)})
+	}
+	body, _ = sjson.SetBytes(body, "messages.-1", map[string]string{"role": "user", "content": "latest question"})
+	total, err := countOpenAIChatTokens(enc, body)
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	cfg := &config.Config{
+		ContextWindows:        map[string]int{"gpt-4o": int(total / 2)},
+		ContextOverflowPolicy: config.ContextOverflowPolicyTruncate,
+	}
+
+	out, err := applyContextOverflowPolicy(cfg, "openai", "gpt-4o", body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	messages := gjson.GetBytes(out, "messages").Array()
+	if got := len(messages); got < 10 || got > 13 {
+		t.Fatalf("messages length = %d, want about half of the history kept; body=%s", got, out)
+	}
+	if messages[0].Get("role").String() != "system" || messages[len(messages)-1].Get("content").String() != "latest question" {
+		t.Fatalf("system prompt and latest message must be kept: %s", out)
+	}
+	if remaining, _ := countOpenAIChatTokens(enc, out); remaining > total/2 {
+		t.Fatalf("remaining tokens = %d, want at most %d", remaining, total/2)
+	}
+}
@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"net/http"
+	"strings"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// dryRunRedactedHeaders lists credential-bearing headers masked in dry-run output. Custom
+// headers configured on the auth are masked as well.
+var dryRunRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "X-Goog-Api-Key", "X-Api-Key", "Cookie", "Chatgpt-Account-Id"}
+
+// dryRunRequested reports whether the handler marked the request as a dry run. The handler
+// only does so for client API keys listed in dry-run-api-keys.
+func dryRunRequested(opts cliproxyexecutor.Options) bool {
+	dryRun, _ := opts.Metadata[cliproxyexecutor.DryRunMetadataKey].(bool)
+	return dryRun
+}
+
+// dryRunRedacted reports whether the header name must be masked for auth.
+func dryRunRedacted(auth *cliproxyauth.Auth, name string) bool {
+	for _, key := range dryRunRedactedHeaders {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	if auth == nil {
+		return false
+	}
+	for attr := range auth.Attributes {
+		if custom, ok := strings.CutPrefix(attr, "header:"); ok && strings.EqualFold(strings.TrimSpace(custom), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// dryRunResponse describes the upstream request that would have been sent: its URL, method,
+// credential-redacted headers and body. JSON bodies are embedded as-is.
+func dryRunResponse(auth *cliproxyauth.Auth, httpReq *http.Request, body []byte) cliproxyexecutor.Response {
+	headers := make(map[string]string, len(httpReq.Header))
+	for key, values := range httpReq.Header {
+		if dryRunRedacted(auth, key) {
+			headers[key] = "[REDACTED]"
+			continue
+		}
+		headers[key] = strings.Join(values, ", ")
+	}
+	requestURL := *httpReq.URL
+	if query := requestURL.Query(); query.Has("key") {
+		query.Set("key", "[REDACTED]")
+		requestURL.RawQuery = query.Encode()
+	}
+
+	out := []byte(`{"dry_run":true}`)
+	out, _ = sjson.SetBytes(out, "url", requestURL.String())
+	out, _ = sjson.SetBytes(out, "method", httpReq.Method)
+	out, _ = sjson.SetBytes(out, "headers", headers)
+	if gjson.ValidBytes(body) {
+		out, _ = sjson.SetRawBytes(out, "body", body)
+	} else {
+		out, _ = sjson.SetBytes(out, "body", string(body))
+	}
+	return cliproxyexecutor.Response{Payload: out}
+}
+
+// dryRunStreamResult delivers a dry-run description as a single-chunk stream.
+func dryRunStreamResult(auth *cliproxyauth.Auth, httpReq *http.Request, body []byte) *cliproxyexecutor.StreamResult {
+	out := make(chan cliproxyexecutor.StreamChunk, 1)
+	out <- cliproxyexecutor.StreamChunk{Payload: dryRunResponse(auth, httpReq, body).Payload}
+	close(out)
+	return &cliproxyexecutor.StreamResult{Chunks: out}
+}
@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func dryRunMetadata() map[string]any {
+	return map[string]any{cliproxyexecutor.DryRunMetadataKey: true}
+}
+
+func failOnUpstreamCall(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("dry run sent an upstream request to %s", r.URL.Path)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCodexExecutorDryRunReturnsPreparedRequest(t *testing.T) {
+	server := failOnUpstreamCall(t)
+	executor := NewCodexExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": server.URL, "api_key": "sk-secret", "header:X-Tenant-Token": "tenant-secret"}}
+	req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"model":"gpt-5","input":[{"role":"user","content":"hi"}]}`)}
+
+	resp, err := executor.Execute(context.Background(), auth, req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex"), Metadata: dryRunMetadata()})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	out := gjson.ParseBytes(resp.Payload)
+	if out.Get("url").String() != server.URL+"/responses" || out.Get("method").String() != http.MethodPost {
+		t.Fatalf("dry run url/method = %s %s", out.Get("method").String(), out.Get("url").String())
+	}
+	if got := out.Get("headers.Authorization").String(); got != "[REDACTED]" {
+		t.Fatalf("Authorization = %q, want redacted", got)
+	}
+	if got := out.Get("headers.X-Tenant-Token").String(); got != "[REDACTED]" {
+		t.Fatalf("X-Tenant-Token = %q, want configured headers redacted", got)
+	}
+	if strings.Contains(string(resp.Payload), "sk-secret") || strings.Contains(string(resp.Payload), "tenant-secret") {
+		t.Fatalf("dry run leaked the credential: %s", resp.Payload)
+	}
+	if out.Get("body.model").String() != "gpt-5" || !out.Get("body.stream").Bool() {
+		t.Fatalf("dry run body = %s, want the normalized Codex request", out.Get("body").Raw)
+	}
+}
+
+func TestGeminiVertexExecutorDryRunRedactsAPIKey(t *testing.T) {
+	server := failOnUpstreamCall(t)
+	executor := NewGeminiVertexExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": server.URL, "api_key": "vertex-secret"}}
+	req := cliproxyexecutor.Request{Model: "gemini-2.5-pro", Payload: []byte(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`)}
+
+	result, err := executor.ExecuteStream(context.Background(), auth, req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("gemini"), Stream: true, Metadata: dryRunMetadata()})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var chunks [][]byte
+	for chunk := range result.Chunks {
+		chunks = append(chunks, chunk.Payload)
+	}
+	if len(chunks) != 1 {
+		t.Fatalf("chunks = %d, want a single dry run description", len(chunks))
+	}
+	out := gjson.ParseBytes(chunks[0])
+	if got := out.Get("headers.X-Goog-Api-Key").String(); got != "[REDACTED]" {
+		t.Fatalf("X-Goog-Api-Key = %q, want redacted", got)
+	}
+	if !strings.Contains(out.Get("url").String(), "gemini-2.5-pro:streamGenerateContent") {
+		t.Fatalf("dry run url = %s", out.Get("url").String())
+	}
+	if out.Get("body.contents.0.parts.0.text").String() != "hi" {
+		t.Fatalf("dry run body = %s", out.Get("body").Raw)
+	}
+}
+
+func TestDryRunResponseRedactsAccountHeader(t *testing.T) {
+	httpReq := httptest.NewRequest(http.MethodPost, "https://chatgpt.com/backend-api/codex/responses", nil)
+	httpReq.Header.Set("Chatgpt-Account-Id", "acct-secret")
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	out := gjson.ParseBytes(dryRunResponse(nil, httpReq, []byte(`{}`)).Payload)
+	if got := out.Get("headers.Chatgpt-Account-Id").String(); got != "[REDACTED]" {
+		t.Fatalf("Chatgpt-Account-Id = %q, want redacted", got)
+	}
+	if got := out.Get("headers.Content-Type").String(); got != "application/json" {
+		t.Fatalf("Content-Type = %q, want it kept", got)
+	}
+}
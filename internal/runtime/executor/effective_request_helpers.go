@@ -0,0 +1,61 @@
+package executor
+
+import (
+	"encoding/base64"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+const (
+	// echoEffectiveRequestHeader is the client opt-in for echoing the upstream request body.
+	echoEffectiveRequestHeader = "X-Echo-Effective-Request"
+	// effectiveRequestHeader carries the base64-encoded, redacted upstream request body.
+	effectiveRequestHeader = "X-Effective-Request"
+	// effectiveRequestTruncatedHeader replaces effectiveRequestHeader with the body size in
+	// bytes when the encoded body exceeds maxEffectiveRequestHeaderBytes.
+	effectiveRequestTruncatedHeader = "X-Effective-Request-Truncated"
+	// maxEffectiveRequestHeaderBytes caps the encoded header so it stays within common proxy
+	// and client header limits.
+	maxEffectiveRequestHeaderBytes = 16 << 10
+)
+
+// echoEffectiveRequest exposes the final upstream body on the client response when the
+// client opted in with an API key listed in echo-effective-request-api-keys. Credentials in
+// the body are masked. Retries overwrite the header, so it reflects the last attempt sent
+// before the response was written.
+func echoEffectiveRequest(ginCtx *gin.Context, cfg *config.Config, body []byte) {
+	if ginCtx == nil || ginCtx.Request == nil || len(body) == 0 {
+		return
+	}
+	if !strings.EqualFold(strings.TrimSpace(ginCtx.Request.Header.Get(echoEffectiveRequestHeader)), "true") {
+		return
+	}
+	if !echoEffectiveRequestAllowed(cfg, ginCtx.GetString("apiKey")) {
+		return
+	}
+	if ginCtx.Writer.Written() {
+		return
+	}
+	redacted := util.MaskSensitiveJSON(append([]byte(nil), body...))
+	header := ginCtx.Writer.Header()
+	if base64.StdEncoding.EncodedLen(len(redacted)) > maxEffectiveRequestHeaderBytes {
+		header.Del(effectiveRequestHeader)
+		header.Set(effectiveRequestTruncatedHeader, strconv.Itoa(len(redacted)))
+		return
+	}
+	header.Del(effectiveRequestTruncatedHeader)
+	header.Set(effectiveRequestHeader, base64.StdEncoding.EncodeToString(redacted))
+}
+
+// echoEffectiveRequestAllowed reports whether apiKey may receive the effective request.
+func echoEffectiveRequestAllowed(cfg *config.Config, apiKey string) bool {
+	if cfg == nil || apiKey == "" {
+		return false
+	}
+	return slices.Contains(cfg.EchoEffectiveRequestAPIKeys, apiKey)
+}
@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestOpenAICompatExecutorEchoesEffectiveRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.EchoEffectiveRequestAPIKeys = []string{"admin-key"}
+	executor := NewOpenAICompatExecutor("openai-compatibility", cfg)
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"base_url":     server.URL + "/v1",
+		"api_key":      "test",
+		"strip_fields": "logprobs",
+	}}
+	payload := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],"logprobs":true,"metadata":{"api_key":"sk-secret-value-1234"}}`)
+
+	run := func(echo bool, apiKey string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(recorder)
+		ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		ginCtx.Set("apiKey", apiKey)
+		if echo {
+			ginCtx.Request.Header.Set(echoEffectiveRequestHeader, "true")
+		}
+		ctx := context.WithValue(context.Background(), "gin", ginCtx)
+		_, err := executor.Execute(ctx, auth, cliproxyexecutor.Request{Model: "openai/gpt-4o", Payload: payload}, cliproxyexecutor.Options{
+			SourceFormat: sdktranslator.FromString("openai"),
+		})
+		if err != nil {
+			t.Fatalf("Execute error: %v", err)
+		}
+		return recorder
+	}
+
+	if got := run(false, "admin-key").Header().Get(effectiveRequestHeader); got != "" {
+		t.Fatalf("%s = %q without opt-in, want empty", effectiveRequestHeader, got)
+	}
+	if got := run(true, "client-key").Header().Get(effectiveRequestHeader); got != "" {
+		t.Fatalf("%s = %q for a key outside echo-effective-request-api-keys, want empty", effectiveRequestHeader, got)
+	}
+
+	encoded := run(true, "admin-key").Header().Get(effectiveRequestHeader)
+	echoed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("decode %s: %v", effectiveRequestHeader, err)
+	}
+	if got := gjson.GetBytes(echoed, "model").String(); got != "openai/gpt-4o" {
+		t.Fatalf("echoed model = %q, want %q", got, "openai/gpt-4o")
+	}
+	if gjson.GetBytes(echoed, "logprobs").Exists() {
+		t.Fatalf("echoed request should reflect stripped fields: %s", echoed)
+	}
+	if got := gjson.GetBytes(echoed, "metadata.api_key").String(); got != "sk-s...1234" {
+		t.Fatalf("echoed metadata.api_key = %q, want masked value", got)
+	}
+}
+
+func TestEchoEffectiveRequestMarksOversizedBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.EchoEffectiveRequestAPIKeys = []string{"admin-key"}
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ginCtx.Request.Header.Set(echoEffectiveRequestHeader, "true")
+	ginCtx.Set("apiKey", "admin-key")
+
+	body := []byte(`{"prompt":"` + strings.Repeat("a", maxEffectiveRequestHeaderBytes) + `"}`)
+	echoEffectiveRequest(ginCtx, cfg, body)
+
+	if got := recorder.Header().Get(effectiveRequestHeader); got != "" {
+		t.Fatalf("%s set for an oversized body (%d bytes)", effectiveRequestHeader, len(got))
+	}
+	if got := recorder.Header().Get(effectiveRequestTruncatedHeader); got != strconv.Itoa(len(body)) {
+		t.Fatalf("%s = %q, want %d", effectiveRequestTruncatedHeader, got, len(body))
+	}
+}
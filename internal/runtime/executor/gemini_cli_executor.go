@@ -9,7 +9,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -102,9 +101,14 @@ func (e *GeminiCLIExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.
 
 // Execute performs a non-streaming request to the Gemini CLI API.
 func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if err = checkAuthCircuit(e.cfg, auth); err != nil {
+		return resp, err
+	}
+	defer recordAuthCircuit(e.cfg, auth, &err)
 	if opts.Alt == "responses/compact" {
 		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
 	}
+	ctx = withGeminiThoughtPolicy(ctx, e.cfg)
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
 	tokenSource, baseTokenData, err := prepareGeminiCLITokenSource(ctx, e.cfg, auth)
@@ -115,7 +119,7 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
 	defer reporter.trackFailure(ctx, &err)
 
-	from := opts.SourceFormat
+	from := requestSourceFormat(opts)
 	to := sdktranslator.FromString("gemini-cli")
 
 	originalPayloadSource := req.Payload
@@ -124,7 +128,10 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 	}
 	originalPayload := originalPayloadSource
 	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, false)
-	basePayload := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	basePayload, err := translateRequestChecked(ctx, from, to, baseModel, req.Payload, false)
+	if err != nil {
+		return resp, err
+	}
 
 	basePayload, err = thinking.ApplyThinking(basePayload, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -134,6 +141,7 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 	basePayload = fixGeminiCLIImageAspectRatio(baseModel, basePayload)
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	basePayload = applyPayloadConfigWithRoot(e.cfg, baseModel, "gemini", "request", basePayload, originalTranslated, requestedModel)
+	basePayload = applyPayloadPassthrough(e.cfg, baseModel, "gemini", "request", basePayload, req.Payload, requestedModel)
 
 	action := "generateContent"
 	if req.Metadata != nil {
@@ -143,10 +151,7 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 	}
 
 	projectID := resolveGeminiProjectID(auth)
-	models := cliPreviewFallbackOrder(baseModel)
-	if len(models) == 0 || models[0] != baseModel {
-		models = append([]string{baseModel}, models...)
-	}
+	models := geminiCLIModelOrder(e.cfg, baseModel)
 
 	httpClient := newHTTPClient(ctx, e.cfg, auth, 0)
 	respCtx := context.WithValue(ctx, "alt", opts.Alt)
@@ -159,7 +164,9 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 	var lastStatus int
 	var lastBody []byte
 
-	for idx, attemptModel := range models {
+	retriedAfterDelay := false
+	for idx := 0; idx < len(models); idx++ {
+		attemptModel := models[idx]
 		payload := append([]byte(nil), basePayload...)
 		if action == "countTokens" {
 			payload = deleteJSONField(payload, "project")
@@ -209,7 +216,7 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 			return resp, err
 		}
 
-		data, errRead := io.ReadAll(httpResp.Body)
+		data, errRead := readUpstreamBody(e.cfg, httpResp.Body)
 		if errClose := httpResp.Body.Close(); errClose != nil {
 			log.Errorf("gemini cli executor: close response body error: %v", errClose)
 		}
@@ -221,9 +228,17 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 		}
 		appendAPIResponseChunk(ctx, e.cfg, data)
 		if httpResp.StatusCode >= 200 && httpResp.StatusCode < 300 {
+			if errJSON := nonJSONResponseError(httpResp.StatusCode, httpResp.Header.Get("Content-Type"), data); errJSON != nil {
+				err = errJSON
+				return resp, err
+			}
 			reporter.publish(ctx, parseGeminiCLIUsage(data))
 			var param any
-			out := sdktranslator.TranslateNonStream(respCtx, to, from, attemptModel, opts.OriginalRequest, payload, data, &param)
+			out, errTranslate := translateNonStreamChecked(respCtx, to, from, attemptModel, opts.OriginalRequest, payload, data, &param)
+			if errTranslate != nil {
+				err = errTranslate
+				return resp, err
+			}
 			resp = cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}
 			return resp, nil
 		}
@@ -233,7 +248,19 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), data))
 		if httpResp.StatusCode == 429 {
 			if idx+1 < len(models) {
+				if !cliproxyexecutor.RetryBudgetFromContext(ctx).Consume() {
+					log.Debug("gemini cli executor: rate limited, retry budget exhausted")
+					break
+				}
 				log.Debugf("gemini cli executor: rate limited, retrying with next model: %s", models[idx+1])
+			} else if delay, ok := geminiCLIRetryAfterDelay(e.cfg, data); ok && !retriedAfterDelay && cliproxyexecutor.RetryBudgetFromContext(ctx).Consume() {
+				log.Debugf("gemini cli executor: rate limited, retrying %s after %s", attemptModel, delay)
+				if errWait := waitRetryDelay(ctx, delay); errWait != nil {
+					err = errWait
+					return resp, err
+				}
+				retriedAfterDelay = true
+				idx--
 			} else {
 				log.Debug("gemini cli executor: rate limited, no additional fallback model")
 			}
@@ -256,9 +283,14 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 
 // ExecuteStream performs a streaming request to the Gemini CLI API.
 func (e *GeminiCLIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	if err = checkAuthCircuit(e.cfg, auth); err != nil {
+		return nil, err
+	}
+	defer recordAuthCircuit(e.cfg, auth, &err)
 	if opts.Alt == "responses/compact" {
 		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
 	}
+	ctx = withGeminiThoughtPolicy(ctx, e.cfg)
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
 	tokenSource, baseTokenData, err := prepareGeminiCLITokenSource(ctx, e.cfg, auth)
@@ -269,7 +301,7 @@ func (e *GeminiCLIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyaut
 	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
 	defer reporter.trackFailure(ctx, &err)
 
-	from := opts.SourceFormat
+	from := requestSourceFormat(opts)
 	to := sdktranslator.FromString("gemini-cli")
 
 	originalPayloadSource := req.Payload
@@ -278,7 +310,10 @@ func (e *GeminiCLIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyaut
 	}
 	originalPayload := originalPayloadSource
 	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, true)
-	basePayload := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, true)
+	basePayload, err := translateRequestChecked(ctx, from, to, baseModel, req.Payload, true)
+	if err != nil {
+		return nil, err
+	}
 
 	basePayload, err = thinking.ApplyThinking(basePayload, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -288,13 +323,11 @@ func (e *GeminiCLIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyaut
 	basePayload = fixGeminiCLIImageAspectRatio(baseModel, basePayload)
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	basePayload = applyPayloadConfigWithRoot(e.cfg, baseModel, "gemini", "request", basePayload, originalTranslated, requestedModel)
+	basePayload = applyPayloadPassthrough(e.cfg, baseModel, "gemini", "request", basePayload, req.Payload, requestedModel)
 
 	projectID := resolveGeminiProjectID(auth)
 
-	models := cliPreviewFallbackOrder(baseModel)
-	if len(models) == 0 || models[0] != baseModel {
-		models = append([]string{baseModel}, models...)
-	}
+	models := geminiCLIModelOrder(e.cfg, baseModel)
 
 	httpClient := newHTTPClient(ctx, e.cfg, auth, 0)
 	respCtx := context.WithValue(ctx, "alt", opts.Alt)
@@ -307,7 +340,9 @@ func (e *GeminiCLIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyaut
 	var lastStatus int
 	var lastBody []byte
 
-	for idx, attemptModel := range models {
+	retriedAfterDelay := false
+	for idx := 0; idx < len(models); idx++ {
+		attemptModel := models[idx]
 		payload := append([]byte(nil), basePayload...)
 		payload = setJSONField(payload, "project", projectID)
 		payload = setJSONField(payload, "model", attemptModel)
@@ -355,7 +390,7 @@ func (e *GeminiCLIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyaut
 		}
 		recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
 		if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
-			data, errRead := io.ReadAll(httpResp.Body)
+			data, errRead := readUpstreamBody(e.cfg, httpResp.Body)
 			if errClose := httpResp.Body.Close(); errClose != nil {
 				log.Errorf("gemini cli executor: close response body error: %v", errClose)
 			}
@@ -370,7 +405,19 @@ func (e *GeminiCLIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyaut
 			logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), data))
 			if httpResp.StatusCode == 429 {
 				if idx+1 < len(models) {
+					if !cliproxyexecutor.RetryBudgetFromContext(ctx).Consume() {
+						log.Debug("gemini cli executor: rate limited, retry budget exhausted")
+						break
+					}
 					log.Debugf("gemini cli executor: rate limited, retrying with next model: %s", models[idx+1])
+				} else if delay, ok := geminiCLIRetryAfterDelay(e.cfg, data); ok && !retriedAfterDelay && cliproxyexecutor.RetryBudgetFromContext(ctx).Consume() {
+					log.Debugf("gemini cli executor: rate limited, retrying %s after %s", attemptModel, delay)
+					if errWait := waitRetryDelay(ctx, delay); errWait != nil {
+						err = errWait
+						return nil, err
+					}
+					retriedAfterDelay = true
+					idx--
 				} else {
 					log.Debug("gemini cli executor: rate limited, no additional fallback model")
 				}
@@ -390,7 +437,7 @@ func (e *GeminiCLIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyaut
 			}()
 			if opts.Alt == "" {
 				scanner := bufio.NewScanner(resp.Body)
-				scanner.Buffer(nil, streamScannerBuffer)
+				scanner.Buffer(nil, streamScannerBufferSize(e.cfg))
 				var param any
 				for scanner.Scan() {
 					line := scanner.Bytes()
@@ -418,7 +465,7 @@ func (e *GeminiCLIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyaut
 				return
 			}
 
-			data, errRead := io.ReadAll(resp.Body)
+			data, errRead := readUpstreamBody(e.cfg, resp.Body)
 			if errRead != nil {
 				recordAPIResponseError(ctx, e.cfg, errRead)
 				reporter.publishFailure(ctx)
@@ -461,13 +508,10 @@ func (e *GeminiCLIExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.
 		return cliproxyexecutor.Response{}, err
 	}
 
-	from := opts.SourceFormat
+	from := requestSourceFormat(opts)
 	to := sdktranslator.FromString("gemini-cli")
 
-	models := cliPreviewFallbackOrder(baseModel)
-	if len(models) == 0 || models[0] != baseModel {
-		models = append([]string{baseModel}, models...)
-	}
+	models := geminiCLIModelOrder(e.cfg, baseModel)
 
 	httpClient := newHTTPClient(ctx, e.cfg, auth, 0)
 	respCtx := context.WithValue(ctx, "alt", opts.Alt)
@@ -481,6 +525,7 @@ func (e *GeminiCLIExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.
 
 	var lastStatus int
 	var lastBody []byte
+	var lastPayload []byte
 
 	// The loop variable attemptModel is only used as the concrete model id sent to the upstream
 	// Gemini CLI endpoint when iterating fallback variants.
@@ -496,6 +541,7 @@ func (e *GeminiCLIExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.
 		payload = deleteJSONField(payload, "model")
 		payload = deleteJSONField(payload, "request.safetySettings")
 		payload = fixGeminiCLIImageAspectRatio(baseModel, payload)
+		lastPayload = payload
 
 		tok, errTok := tokenSource.Token()
 		if errTok != nil {
@@ -533,7 +579,7 @@ func (e *GeminiCLIExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.
 			recordAPIResponseError(ctx, e.cfg, errDo)
 			return cliproxyexecutor.Response{}, errDo
 		}
-		data, errRead := io.ReadAll(resp.Body)
+		data, errRead := readUpstreamBody(e.cfg, resp.Body)
 		_ = resp.Body.Close()
 		recordAPIResponseMetadata(ctx, e.cfg, resp.StatusCode, resp.Header.Clone())
 		if errRead != nil {
@@ -549,12 +595,29 @@ func (e *GeminiCLIExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.
 		lastStatus = resp.StatusCode
 		lastBody = append([]byte(nil), data...)
 		if resp.StatusCode == 429 {
+			if !cliproxyexecutor.RetryBudgetFromContext(ctx).Consume() {
+				log.Debug("gemini cli executor: rate limited, retry budget exhausted")
+				break
+			}
 			log.Debugf("gemini cli executor: rate limited, retrying with next model")
 			continue
 		}
 		break
 	}
 
+	if geminiCLILocalTokenFallbackApplies(e.cfg, lastStatus) && lastPayload != nil {
+		count, errCount := countGeminiCLIInputTokensLocally(lastPayload)
+		if errCount == nil {
+			log.Warnf("gemini cli executor: countTokens failed with status %d, returning local estimate of %d tokens", lastStatus, count)
+			data := []byte(fmt.Sprintf(`{"totalTokens":%d}`, count))
+			translated := sdktranslator.TranslateTokenCount(respCtx, to, from, count, data)
+			translated, _ = sjson.SetBytes(translated, "approximate", true)
+			headers := http.Header{}
+			headers.Set(approximateTokenCountHeader, "true")
+			return cliproxyexecutor.Response{Payload: translated, Headers: headers}, nil
+		}
+		log.Debugf("gemini cli executor: local token estimate failed: %v", errCount)
+	}
 	if lastStatus == 0 {
 		lastStatus = 429
 	}
@@ -744,6 +807,28 @@ func applyGeminiCLIHeaders(r *http.Request, model string) {
 	r.Header.Set("X-Goog-Api-Client", misc.GeminiCLIApiClientHeader)
 }
 
+// geminiCLIModelOrder returns the models tried for a request: the base model first, then
+// the operator-configured fallback chain, then any preview candidates, without duplicates.
+func geminiCLIModelOrder(cfg *config.Config, baseModel string) []string {
+	models := []string{baseModel}
+	seen := map[string]struct{}{strings.ToLower(baseModel): {}}
+	appendModels := func(candidates []string) {
+		for _, candidate := range candidates {
+			key := strings.ToLower(candidate)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			models = append(models, candidate)
+		}
+	}
+	if cfg != nil {
+		appendModels(cfg.GeminiCLI.FallbackModels[strings.ToLower(strings.TrimSpace(baseModel))])
+	}
+	appendModels(cliPreviewFallbackOrder(baseModel))
+	return models
+}
+
 // cliPreviewFallbackOrder returns preview model candidates for a base model.
 func cliPreviewFallbackOrder(model string) []string {
 	switch model {
@@ -833,7 +918,7 @@ func fixGeminiCLIImageAspectRatio(modelName string, rawJSON []byte) []byte {
 }
 
 func newGeminiStatusErr(statusCode int, body []byte) statusErr {
-	err := statusErr{code: statusCode, msg: string(body)}
+	err := statusErr{code: statusCode, msg: upstreamErrorMessage(statusCode, "", body), upstream: true}
 	if statusCode == http.StatusTooManyRequests {
 		if retryAfter, parseErr := parseRetryDelay(body); parseErr == nil && retryAfter != nil {
 			err.retryAfter = retryAfter
@@ -842,6 +927,34 @@ func newGeminiStatusErr(statusCode int, body []byte) statusErr {
 	return err
 }
 
+// geminiCLIRetryAfterDelay returns the upstream retry delay from a 429 body when it is within
+// the configured gemini-cli max-retry-after-seconds cap.
+func geminiCLIRetryAfterDelay(cfg *config.Config, body []byte) (time.Duration, bool) {
+	if cfg == nil || cfg.GeminiCLI.MaxRetryAfterSeconds <= 0 {
+		return 0, false
+	}
+	delay, errParse := parseRetryDelay(body)
+	if errParse != nil || delay == nil || *delay < 0 {
+		return 0, false
+	}
+	if *delay > time.Duration(cfg.GeminiCLI.MaxRetryAfterSeconds)*time.Second {
+		return 0, false
+	}
+	return *delay, true
+}
+
+// waitRetryDelay sleeps for delay or until ctx is done.
+func waitRetryDelay(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 // parseRetryDelay extracts the retry delay from a Google API 429 error response.
 // The error response contains a RetryInfo.retryDelay field in the format "0.847655010s".
 // Returns the parsed duration or an error if it cannot be determined.
@@ -0,0 +1,143 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestGeminiCLIModelOrder(t *testing.T) {
+	cfg := &config.Config{GeminiCLI: config.GeminiCLIConfig{FallbackModels: map[string][]string{
+		"gemini-2.5-pro": {"gemini-2.5-flash", "gemini-2.5-pro", "gemini-2.5-flash", "gemini-2.5-flash-lite"},
+	}}}
+	cfg.SanitizeGeminiCLI()
+
+	tests := []struct {
+		name  string
+		cfg   *config.Config
+		model string
+		want  []string
+	}{
+		{name: "no config keeps base model only", cfg: nil, model: "gemini-2.5-pro", want: []string{"gemini-2.5-pro"}},
+		{name: "configured chain follows base model", cfg: cfg, model: "gemini-2.5-pro", want: []string{"gemini-2.5-pro", "gemini-2.5-flash", "gemini-2.5-flash-lite"}},
+		{name: "unconfigured model keeps base model only", cfg: cfg, model: "gemini-2.5-flash", want: []string{"gemini-2.5-flash"}},
+	}
+	for _, tc := range tests {
+		if got := geminiCLIModelOrder(tc.cfg, tc.model); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+type geminiCLIRateLimitTransport struct {
+	mu         sync.Mutex
+	retryDelay string
+	limited    int
+	models     []string
+}
+
+func (t *geminiCLIRateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, _ := io.ReadAll(req.Body)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.models = append(t.models, gjson.GetBytes(body, "model").String())
+	status, payload := http.StatusOK, `{"response":{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}]}}`
+	if t.limited != 0 {
+		t.limited--
+		status = http.StatusTooManyRequests
+		payload = `{"error":{"code":429,"message":"rate limited","details":[{"@type":"type.googleapis.com/google.rpc.RetryInfo","retryDelay":"` + t.retryDelay + `"}]}}`
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(payload)),
+		Request:    req,
+	}, nil
+}
+
+func TestGeminiCLIExecuteHonorsRetryAfterOnLastModel(t *testing.T) {
+	auth := &cliproxyauth.Auth{ID: "gemini-cli-1", Provider: "gemini-cli", Metadata: map[string]any{
+		"access_token": "token",
+		"expiry":       time.Now().Add(time.Hour).Format(time.RFC3339),
+		"project_id":   "project-1",
+	}}
+	req := cliproxyexecutor.Request{Model: "gemini-2.5-pro", Payload: []byte(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("gemini")}
+
+	tests := []struct {
+		name       string
+		maxSeconds int
+		retryDelay string
+		limited    int
+		wantCalls  int
+		wantStatus int
+	}{
+		{name: "disabled by default", maxSeconds: 0, retryDelay: "0.01s", limited: 1, wantCalls: 1, wantStatus: http.StatusTooManyRequests},
+		{name: "short delay retries same model", maxSeconds: 1, retryDelay: "0.01s", limited: 1, wantCalls: 2},
+		{name: "delay above cap is not waited", maxSeconds: 1, retryDelay: "5s", limited: 1, wantCalls: 1, wantStatus: http.StatusTooManyRequests},
+		{name: "retries only once", maxSeconds: 1, retryDelay: "0.01s", limited: 2, wantCalls: 2, wantStatus: http.StatusTooManyRequests},
+	}
+	for _, tc := range tests {
+		transport := &geminiCLIRateLimitTransport{retryDelay: tc.retryDelay, limited: tc.limited}
+		ctx := context.WithValue(context.Background(), "cliproxy.roundtripper", http.RoundTripper(transport))
+		executor := NewGeminiCLIExecutor(&config.Config{GeminiCLI: config.GeminiCLIConfig{MaxRetryAfterSeconds: tc.maxSeconds}})
+
+		_, err := executor.Execute(ctx, auth, req, opts)
+		if tc.wantStatus == 0 && err != nil {
+			t.Fatalf("%s: Execute() error = %v", tc.name, err)
+		}
+		if tc.wantStatus != 0 {
+			var se statusErr
+			if !errors.As(err, &se) || se.StatusCode() != tc.wantStatus {
+				t.Fatalf("%s: Execute() error = %v, want status %d", tc.name, err, tc.wantStatus)
+			}
+		}
+		if len(transport.models) != tc.wantCalls {
+			t.Fatalf("%s: upstream calls = %v, want %d", tc.name, transport.models, tc.wantCalls)
+		}
+		for _, model := range transport.models {
+			if model != "gemini-2.5-pro" {
+				t.Fatalf("%s: attempted model %q, want gemini-2.5-pro", tc.name, model)
+			}
+		}
+	}
+}
+
+func TestGeminiCLICountTokensLocalFallback(t *testing.T) {
+	auth := &cliproxyauth.Auth{ID: "gemini-cli-count", Provider: "gemini-cli", Metadata: map[string]any{
+		"access_token": "token",
+		"expiry":       time.Now().Add(time.Hour).Format(time.RFC3339),
+		"project_id":   "project-1",
+	}}
+	req := cliproxyexecutor.Request{Model: "gemini-2.5-pro", Payload: []byte(`{"contents":[{"role":"user","parts":[{"text":"how many tokens is this sentence?"}]}]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("gemini")}
+	ctx := context.WithValue(context.Background(), "cliproxy.roundtripper", http.RoundTripper(&geminiCLIRateLimitTransport{retryDelay: "1s", limited: 10}))
+
+	if _, err := NewGeminiCLIExecutor(&config.Config{}).CountTokens(ctx, auth, req, opts); err == nil {
+		t.Fatal("expected rate-limited countTokens to fail without local fallback")
+	}
+
+	executor := NewGeminiCLIExecutor(&config.Config{GeminiCLI: config.GeminiCLIConfig{LocalTokenFallback: true}})
+	resp, err := executor.CountTokens(ctx, auth, req, opts)
+	if err != nil {
+		t.Fatalf("CountTokens() error = %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "totalTokens").Int(); got <= 0 {
+		t.Fatalf("totalTokens = %d, want a positive estimate (payload %s)", got, resp.Payload)
+	}
+	if !gjson.GetBytes(resp.Payload, "approximate").Bool() || resp.Headers.Get(approximateTokenCountHeader) != "true" {
+		t.Fatalf("estimate not marked approximate: payload %s, headers %v", resp.Payload, resp.Headers)
+	}
+}
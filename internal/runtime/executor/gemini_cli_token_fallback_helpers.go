@@ -0,0 +1,72 @@
+package executor
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// approximateTokenCountHeader marks token count responses that were estimated locally.
+const approximateTokenCountHeader = "X-Token-Count-Approximate"
+
+// geminiCLILocalTokenFallbackApplies reports whether a failed countTokens call with the given
+// final status should be answered with a local estimate.
+func geminiCLILocalTokenFallbackApplies(cfg *config.Config, status int) bool {
+	if cfg == nil || !cfg.GeminiCLI.LocalTokenFallback {
+		return false
+	}
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// countGeminiCLIInputTokensLocally approximates the prompt tokens of a Gemini CLI request
+// envelope. Gemini's tokenizer is not available locally, so o200k_base is used and the result
+// is only an estimate.
+func countGeminiCLIInputTokensLocally(payload []byte) (int64, error) {
+	enc, err := tokenizer.Get(tokenizer.O200kBase)
+	if err != nil {
+		return 0, fmt.Errorf("tokenizer init failed: %w", err)
+	}
+	request := gjson.GetBytes(payload, "request")
+	if !request.Exists() {
+		request = gjson.ParseBytes(payload)
+	}
+
+	segments := make([]string, 0, 32)
+	collectGeminiParts(request.Get("systemInstruction.parts"), &segments)
+	request.Get("contents").ForEach(func(_, content gjson.Result) bool {
+		addIfNotEmpty(&segments, content.Get("role").String())
+		collectGeminiParts(content.Get("parts"), &segments)
+		return true
+	})
+	if tools := request.Get("tools"); tools.Exists() {
+		addIfNotEmpty(&segments, tools.Raw)
+	}
+
+	joined := strings.TrimSpace(strings.Join(segments, "\n"))
+	if joined == "" {
+		return 0, nil
+	}
+	count, err := enc.Count(joined)
+	if err != nil {
+		return 0, err
+	}
+	return int64(count), nil
+}
+
+func collectGeminiParts(parts gjson.Result, segments *[]string) {
+	parts.ForEach(func(_, part gjson.Result) bool {
+		switch {
+		case part.Get("text").Exists():
+			addIfNotEmpty(segments, part.Get("text").String())
+		case part.Get("functionCall").Exists():
+			addIfNotEmpty(segments, part.Get("functionCall").Raw)
+		case part.Get("functionResponse").Exists():
+			addIfNotEmpty(segments, part.Get("functionResponse").Raw)
+		}
+		return true
+	})
+}
@@ -0,0 +1,158 @@
+package executor
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// imagenMaxSampleCount is the largest number of images Imagen returns per request.
+const imagenMaxSampleCount = 8
+
+// geminiImageParams holds image generation options collected from a Gemini-style request.
+type geminiImageParams struct {
+	AspectRatio      string
+	ImageSize        string
+	PersonGeneration string
+	NumberOfImages   int64
+	Seed             *int64
+}
+
+// resolveGeminiImageParams reads image generation options from the top level (Imagen style),
+// generationConfig (Gemini style) or OpenAI-style n and image_config. Earlier sources win.
+func resolveGeminiImageParams(payload []byte) geminiImageParams {
+	firstString := func(paths ...string) string {
+		for _, path := range paths {
+			if v := gjson.GetBytes(payload, path); v.Exists() && v.String() != "" {
+				return v.String()
+			}
+		}
+		return ""
+	}
+	firstPositive := func(paths ...string) int64 {
+		for _, path := range paths {
+			if v := gjson.GetBytes(payload, path); v.Exists() && v.Int() > 0 {
+				return v.Int()
+			}
+		}
+		return 0
+	}
+	params := geminiImageParams{
+		AspectRatio:      firstString("aspectRatio", "generationConfig.imageConfig.aspectRatio", "image_config.aspect_ratio"),
+		ImageSize:        firstString("sampleImageSize", "imageSize", "generationConfig.imageConfig.imageSize", "image_config.image_size"),
+		PersonGeneration: firstString("personGeneration", "generationConfig.imageConfig.personGeneration"),
+		NumberOfImages: firstPositive(
+			"sampleCount",
+			"numberOfImages",
+			"generationConfig.imageConfig.numberOfImages",
+			"generationConfig.candidateCount",
+			"n",
+		),
+	}
+	for _, path := range []string{"seed", "generationConfig.seed"} {
+		if v := gjson.GetBytes(payload, path); v.Exists() && v.Type == gjson.Number {
+			seed := v.Int()
+			params.Seed = &seed
+			break
+		}
+	}
+	return params
+}
+
+// imagenInputImage is an inline image attached to an Imagen edit request.
+type imagenInputImage struct {
+	MimeType string
+	Data     string
+}
+
+// imagenInstanceImage renders the image in the Imagen instance format.
+func (img *imagenInputImage) imagenInstanceImage() map[string]any {
+	return map[string]any{
+		"bytesBase64Encoded": img.Data,
+		"mimeType":           img.MimeType,
+	}
+}
+
+// resolveImagenInputImages collects inline images for Imagen edit requests from Gemini-style
+// contents (inlineData parts) or OpenAI-style messages (image_url data URLs). The first image
+// is the source image; a part carrying "mask": true supplies the edit mask.
+func resolveImagenInputImages(payload []byte) (image, mask *imagenInputImage, err error) {
+	add := func(part gjson.Result, img *imagenInputImage) {
+		switch {
+		case part.Get("mask").Bool():
+			if mask == nil {
+				mask = img
+			}
+		case image == nil:
+			image = img
+		}
+	}
+	for _, content := range gjson.GetBytes(payload, "contents").Array() {
+		for _, part := range content.Get("parts").Array() {
+			inline := part.Get("inlineData")
+			if !inline.Exists() {
+				inline = part.Get("inline_data")
+			}
+			if !inline.Exists() {
+				continue
+			}
+			mimeType := inline.Get("mimeType").String()
+			if mimeType == "" {
+				mimeType = inline.Get("mime_type").String()
+			}
+			img, errImg := parseImagenInputImage(mimeType, inline.Get("data").String())
+			if errImg != nil {
+				return nil, nil, errImg
+			}
+			add(part, img)
+		}
+	}
+	for _, message := range gjson.GetBytes(payload, "messages").Array() {
+		for _, part := range message.Get("content").Array() {
+			if part.Get("type").String() != "image_url" {
+				continue
+			}
+			url := part.Get("image_url.url").String()
+			if !strings.HasPrefix(url, "data:") {
+				return nil, nil, fmt.Errorf("imagen: image_url must be a base64 data URL")
+			}
+			img, errImg := parseImagenInputImage("", url)
+			if errImg != nil {
+				return nil, nil, errImg
+			}
+			add(part, img)
+		}
+	}
+	if mask != nil && image == nil {
+		return nil, nil, fmt.Errorf("imagen: mask image requires a source image")
+	}
+	return image, mask, nil
+}
+
+// parseImagenInputImage validates inline image data, given as raw base64 or a data URL.
+func parseImagenInputImage(mimeType, data string) (*imagenInputImage, error) {
+	data = strings.TrimSpace(data)
+	if rest, ok := strings.CutPrefix(data, "data:"); ok {
+		header, encoded, found := strings.Cut(rest, ",")
+		if !found || !strings.HasSuffix(header, ";base64") {
+			return nil, fmt.Errorf("imagen: inline image must be a base64 data URL")
+		}
+		if mimeType == "" {
+			mimeType = strings.TrimSuffix(header, ";base64")
+		}
+		data = encoded
+	}
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	if !strings.HasPrefix(mimeType, "image/") {
+		return nil, fmt.Errorf("imagen: unsupported inline data mime type %q, expected an image", mimeType)
+	}
+	if data == "" {
+		return nil, fmt.Errorf("imagen: inline image data is empty")
+	}
+	if _, errDecode := base64.StdEncoding.DecodeString(data); errDecode != nil {
+		return nil, fmt.Errorf("imagen: inline image data is not valid base64: %w", errDecode)
+	}
+	return &imagenInputImage{MimeType: mimeType, Data: data}, nil
+}
@@ -0,0 +1,167 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertToImagenRequestMapsImageParams(t *testing.T) {
+	cases := []struct {
+		name    string
+		payload string
+	}{
+		{
+			name:    "gemini generationConfig",
+			payload: `{"contents":[{"role":"user","parts":[{"text":"a cat"}]}],"generationConfig":{"candidateCount":3,"imageConfig":{"aspectRatio":"16:9","imageSize":"2K","personGeneration":"dont_allow"}}}`,
+		},
+		{
+			name:    "imagen top level",
+			payload: `{"prompt":"a cat","sampleCount":3,"aspectRatio":"16:9","sampleImageSize":"2K","personGeneration":"dont_allow"}`,
+		},
+		{
+			name:    "openai image_config",
+			payload: `{"messages":[{"role":"user","content":"a cat"}],"n":3,"image_config":{"aspect_ratio":"16:9","image_size":"2K"},"personGeneration":"dont_allow"}`,
+		},
+	}
+	for _, tc := range cases {
+		out, err := convertToImagenRequest([]byte(tc.payload))
+		if err != nil {
+			t.Fatalf("%s: convertToImagenRequest error: %v", tc.name, err)
+		}
+		params := gjson.GetBytes(out, "parameters")
+		if got := params.Get("sampleCount").Int(); got != 3 {
+			t.Fatalf("%s: sampleCount = %d, want 3", tc.name, got)
+		}
+		if got := params.Get("aspectRatio").String(); got != "16:9" {
+			t.Fatalf("%s: aspectRatio = %q, want 16:9", tc.name, got)
+		}
+		if got := params.Get("sampleImageSize").String(); got != "2K" {
+			t.Fatalf("%s: sampleImageSize = %q, want 2K", tc.name, got)
+		}
+		if got := params.Get("personGeneration").String(); got != "dont_allow" {
+			t.Fatalf("%s: personGeneration = %q, want dont_allow", tc.name, got)
+		}
+	}
+}
+
+func TestFixGeminiImageAspectRatioKeepsNumberOfImages(t *testing.T) {
+	payload := []byte(`{"contents":[{"role":"user","parts":[{"text":"a cat"}]}],"generationConfig":{"imageConfig":{"aspectRatio":"16:9","numberOfImages":2}}}`)
+	out := fixGeminiImageAspectRatio("gemini-2.5-flash-image-preview", payload)
+
+	if gjson.GetBytes(out, "generationConfig.imageConfig").Exists() {
+		t.Fatalf("imageConfig should be removed for the preview model: %s", out)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.candidateCount").Int(); got != 2 {
+		t.Fatalf("candidateCount = %d, want 2", got)
+	}
+
+	other := fixGeminiImageAspectRatio("gemini-3-pro-image-preview", payload)
+	if got := gjson.GetBytes(other, "generationConfig.imageConfig.numberOfImages").Int(); got != 2 {
+		t.Fatalf("imageConfig should pass through for other models: %s", other)
+	}
+}
+
+func TestConvertToImagenRequestClampsSampleCountAndMapsSeed(t *testing.T) {
+	out, err := convertToImagenRequest([]byte(`{"contents":[{"role":"user","parts":[{"text":"a cat"}]}],"generationConfig":{"candidateCount":12,"seed":42}}`))
+	if err != nil {
+		t.Fatalf("convertToImagenRequest error: %v", err)
+	}
+	params := gjson.GetBytes(out, "parameters")
+	if got := params.Get("sampleCount").Int(); got != imagenMaxSampleCount {
+		t.Fatalf("sampleCount = %d, want %d", got, imagenMaxSampleCount)
+	}
+	if got := params.Get("seed"); !got.Exists() || got.Int() != 42 {
+		t.Fatalf("seed = %s, want 42", got.Raw)
+	}
+	if got := params.Get("addWatermark"); !got.Exists() || got.Bool() {
+		t.Fatalf("addWatermark = %s, want false", got.Raw)
+	}
+
+	out, err = convertToImagenRequest([]byte(`{"prompt":"a cat"}`))
+	if err != nil {
+		t.Fatalf("convertToImagenRequest error: %v", err)
+	}
+	if gjson.GetBytes(out, "parameters.seed").Exists() || gjson.GetBytes(out, "parameters.addWatermark").Exists() {
+		t.Fatalf("unexpected seed parameters: %s", out)
+	}
+}
+
+func TestConvertImagenToGeminiResponseReturnsEveryPrediction(t *testing.T) {
+	data := []byte(`{"predictions":[{"bytesBase64Encoded":"AAA","mimeType":"image/png"},{"bytesBase64Encoded":"BBB","mimeType":"image/jpeg"},{"raiFilteredReason":"blocked"}]}`)
+	out := convertImagenToGeminiResponse(data, "imagen-4.0-generate-001")
+
+	parts := gjson.GetBytes(out, "candidates.0.content.parts").Array()
+	if len(parts) != 2 {
+		t.Fatalf("parts = %d, want 2: %s", len(parts), out)
+	}
+	if parts[0].Get("inlineData.data").String() != "AAA" || parts[1].Get("inlineData.mimeType").String() != "image/jpeg" {
+		t.Fatalf("unexpected parts: %s", out)
+	}
+}
+
+// testPNGBase64 is a 1x1 transparent PNG.
+const testPNGBase64 = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg=="
+
+func TestConvertToImagenRequestAddsEditImageAndMask(t *testing.T) {
+	cases := []struct {
+		name    string
+		payload string
+	}{
+		{
+			name: "gemini inlineData",
+			payload: `{"contents":[{"role":"user","parts":[` +
+				`{"inlineData":{"mimeType":"image/png","data":"` + testPNGBase64 + `"}},` +
+				`{"inlineData":{"mime_type":"image/png","data":"` + testPNGBase64 + `"},"mask":true},` +
+				`{"text":"replace the sky"}]}]}`,
+		},
+		{
+			name: "openai data url",
+			payload: `{"messages":[{"role":"user","content":[` +
+				`{"type":"text","text":"replace the sky"},` +
+				`{"type":"image_url","image_url":{"url":"data:image/png;base64,` + testPNGBase64 + `"}},` +
+				`{"type":"image_url","image_url":{"url":"data:image/png;base64,` + testPNGBase64 + `"},"mask":true}]}]}`,
+		},
+	}
+	for _, tc := range cases {
+		out, err := convertToImagenRequest([]byte(tc.payload))
+		if err != nil {
+			t.Fatalf("%s: convertToImagenRequest error: %v", tc.name, err)
+		}
+		instance := gjson.GetBytes(out, "instances.0")
+		if got := instance.Get("prompt").String(); got != "replace the sky" {
+			t.Fatalf("%s: prompt = %q", tc.name, got)
+		}
+		if got := instance.Get("image.bytesBase64Encoded").String(); got != testPNGBase64 {
+			t.Fatalf("%s: image = %q", tc.name, got)
+		}
+		if got := instance.Get("image.mimeType").String(); got != "image/png" {
+			t.Fatalf("%s: image mimeType = %q", tc.name, got)
+		}
+		if got := instance.Get("mask.image.bytesBase64Encoded").String(); got != testPNGBase64 {
+			t.Fatalf("%s: mask = %q", tc.name, got)
+		}
+	}
+
+	out, err := convertToImagenRequest([]byte(`{"prompt":"a cat"}`))
+	if err != nil {
+		t.Fatalf("convertToImagenRequest error: %v", err)
+	}
+	if gjson.GetBytes(out, "instances.0.image").Exists() || gjson.GetBytes(out, "instances.0.mask").Exists() {
+		t.Fatalf("unexpected edit fields: %s", out)
+	}
+}
+
+func TestConvertToImagenRequestRejectsInvalidInlineData(t *testing.T) {
+	cases := map[string]string{
+		"non-image mime": `{"contents":[{"parts":[{"text":"edit"},{"inlineData":{"mimeType":"application/pdf","data":"` + testPNGBase64 + `"}}]}]}`,
+		"invalid base64": `{"contents":[{"parts":[{"text":"edit"},{"inlineData":{"mimeType":"image/png","data":"not base64!"}}]}]}`,
+		"remote url":     `{"messages":[{"role":"user","content":[{"type":"text","text":"edit"},{"type":"image_url","image_url":{"url":"https://example.com/cat.png"}}]}]}`,
+		"mask only":      `{"contents":[{"parts":[{"text":"edit"},{"inlineData":{"mimeType":"image/png","data":"` + testPNGBase64 + `"},"mask":true}]}]}`,
+	}
+	for name, payload := range cases {
+		if _, err := convertToImagenRequest([]byte(payload)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package executor
+
+import (
+	"context"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+)
+
+// withGeminiThoughtPolicy marks ctx so the Gemini response translators drop thought
+// parts when the configured policy suppresses them.
+func withGeminiThoughtPolicy(ctx context.Context, cfg *config.Config) context.Context {
+	if cfg == nil || cfg.GeminiThoughtParts != config.GeminiThoughtPartsSuppress {
+		return ctx
+	}
+	return translatorcommon.WithReasoningSuppressed(ctx)
+}
@@ -9,7 +9,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -108,25 +107,34 @@ func convertImagenToGeminiResponse(data []byte, model string) []byte {
 
 // convertToImagenRequest converts a Gemini-style request to Imagen API format.
 // Imagen API uses a different structure: instances[].prompt instead of contents[].
+// Inline images become the instance image (and mask) of an edit request.
 func convertToImagenRequest(payload []byte) ([]byte, error) {
 	// Extract prompt from Gemini-style contents
 	prompt := ""
 
-	// Try to get prompt from contents[0].parts[0].text
-	contentsText := gjson.GetBytes(payload, "contents.0.parts.0.text")
-	if contentsText.Exists() {
-		prompt = contentsText.String()
+	// Try to get prompt from the first text part of Gemini-style contents
+	for _, part := range gjson.GetBytes(payload, "contents.#.parts|@flatten").Array() {
+		if text := part.Get("text").String(); text != "" {
+			prompt = text
+			break
+		}
 	}
 
 	// If no contents, try messages format (OpenAI-compatible)
 	if prompt == "" {
-		messagesText := gjson.GetBytes(payload, "messages.#.content")
-		if messagesText.Exists() && messagesText.IsArray() {
-			for _, msg := range messagesText.Array() {
-				if msg.String() != "" {
-					prompt = msg.String()
-					break
+		for _, msg := range gjson.GetBytes(payload, "messages.#.content").Array() {
+			if msg.IsArray() {
+				for _, item := range msg.Array() {
+					if item.Get("type").String() == "text" && item.Get("text").String() != "" {
+						prompt = item.Get("text").String()
+						break
+					}
 				}
+			} else if msg.String() != "" {
+				prompt = msg.String()
+			}
+			if prompt != "" {
+				break
 			}
 		}
 	}
@@ -155,20 +163,52 @@ func convertToImagenRequest(payload []byte) ([]byte, error) {
 		},
 	}
 
-	// Extract optional parameters
-	if aspectRatio := gjson.GetBytes(payload, "aspectRatio"); aspectRatio.Exists() {
-		imagenReq["parameters"].(map[string]any)["aspectRatio"] = aspectRatio.String()
+	// Extract optional parameters from Imagen-style fields or Gemini generationConfig
+	params := resolveGeminiImageParams(payload)
+	parameters := imagenReq["parameters"].(map[string]any)
+	if params.AspectRatio != "" {
+		parameters["aspectRatio"] = params.AspectRatio
+	}
+	if params.NumberOfImages > 0 {
+		parameters["sampleCount"] = int(min(params.NumberOfImages, imagenMaxSampleCount))
+	}
+	if params.ImageSize != "" {
+		parameters["sampleImageSize"] = params.ImageSize
+	}
+	if params.PersonGeneration != "" {
+		parameters["personGeneration"] = params.PersonGeneration
 	}
-	if sampleCount := gjson.GetBytes(payload, "sampleCount"); sampleCount.Exists() {
-		imagenReq["parameters"].(map[string]any)["sampleCount"] = int(sampleCount.Int())
+	if params.Seed != nil {
+		parameters["seed"] = *params.Seed
+		// Imagen only honors a seed when watermarking is off.
+		parameters["addWatermark"] = false
+	}
+	if addWatermark := gjson.GetBytes(payload, "addWatermark"); addWatermark.IsBool() {
+		parameters["addWatermark"] = addWatermark.Bool()
 	}
 	if negativePrompt := gjson.GetBytes(payload, "negativePrompt"); negativePrompt.Exists() {
 		imagenReq["instances"].([]map[string]any)[0]["negativePrompt"] = negativePrompt.String()
 	}
 
+	// Inline images turn the request into an image edit, optionally restricted by a mask.
+	image, mask, errImages := resolveImagenInputImages(payload)
+	if errImages != nil {
+		return nil, errImages
+	}
+	if image != nil {
+		instance := imagenReq["instances"].([]map[string]any)[0]
+		instance["image"] = image.imagenInstanceImage()
+		if mask != nil {
+			instance["mask"] = map[string]any{"image": mask.imagenInstanceImage()}
+		}
+	}
+
 	return json.Marshal(imagenReq)
 }
 
+// vertexPingModel is the model used for Vertex credential health checks.
+const vertexPingModel = "gemini-2.5-flash"
+
 // GeminiVertexExecutor sends requests to Vertex AI Gemini endpoints using service account credentials.
 type GeminiVertexExecutor struct {
 	cfg *config.Config
@@ -188,6 +228,9 @@ func NewGeminiVertexExecutor(cfg *config.Config) *GeminiVertexExecutor {
 // Identifier returns the executor identifier.
 func (e *GeminiVertexExecutor) Identifier() string { return "vertex" }
 
+// SupportsDryRun reports that the executor returns the prepared request for dry runs.
+func (e *GeminiVertexExecutor) SupportsDryRun() bool { return true }
+
 // PrepareRequest injects Vertex credentials into the outgoing HTTP request.
 func (e *GeminiVertexExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
 	if req == nil {
@@ -231,11 +274,28 @@ func (e *GeminiVertexExecutor) HttpRequest(ctx context.Context, auth *cliproxyau
 	return httpClient.Do(httpReq)
 }
 
+// Ping verifies the Vertex credentials with a one-token countTokens call, which also
+// exercises service account token minting.
+func (e *GeminiVertexExecutor) Ping(ctx context.Context, auth *cliproxyauth.Auth) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	_, err := e.CountTokens(ctx, auth, cliproxyexecutor.Request{
+		Model:   vertexPingModel,
+		Payload: []byte(`{"contents":[{"role":"user","parts":[{"text":"ping"}]}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("gemini")})
+	return err
+}
+
 // Execute performs a non-streaming request to the Vertex AI API.
 func (e *GeminiVertexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if err = checkAuthCircuit(e.cfg, auth); err != nil {
+		return resp, err
+	}
+	defer recordAuthCircuit(e.cfg, auth, &err)
 	if opts.Alt == "responses/compact" {
 		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
 	}
+	ctx = withGeminiThoughtPolicy(ctx, e.cfg)
 	// Try API key authentication first
 	apiKey, baseURL := vertexAPICreds(auth)
 
@@ -253,10 +313,15 @@ func (e *GeminiVertexExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 }
 
 // ExecuteStream performs a streaming request to the Vertex AI API.
-func (e *GeminiVertexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+func (e *GeminiVertexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	if err = checkAuthCircuit(e.cfg, auth); err != nil {
+		return nil, err
+	}
+	defer recordAuthCircuit(e.cfg, auth, &err)
 	if opts.Alt == "responses/compact" {
 		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
 	}
+	ctx = withGeminiThoughtPolicy(ctx, e.cfg)
 	// Try API key authentication first
 	apiKey, baseURL := vertexAPICreds(auth)
 
@@ -310,12 +375,12 @@ func (e *GeminiVertexExecutor) executeWithServiceAccount(ctx context.Context, au
 	if isImagenModel(baseModel) {
 		imagenBody, errImagen := convertToImagenRequest(req.Payload)
 		if errImagen != nil {
-			return resp, errImagen
+			return resp, statusErr{code: http.StatusBadRequest, msg: errImagen.Error()}
 		}
 		body = imagenBody
 	} else {
 		// Standard Gemini translation flow
-		from := opts.SourceFormat
+		from := requestSourceFormat(opts)
 		to := sdktranslator.FromString("gemini")
 
 		originalPayloadSource := req.Payload
@@ -324,7 +389,10 @@ func (e *GeminiVertexExecutor) executeWithServiceAccount(ctx context.Context, au
 		}
 		originalPayload := originalPayloadSource
 		originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, false)
-		body = sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+		body, err = translateRequestChecked(ctx, from, to, baseModel, req.Payload, false)
+		if err != nil {
+			return resp, err
+		}
 
 		body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
 		if err != nil {
@@ -334,6 +402,7 @@ func (e *GeminiVertexExecutor) executeWithServiceAccount(ctx context.Context, au
 		body = fixGeminiImageAspectRatio(baseModel, body)
 		requestedModel := payloadRequestedModel(opts, req.Model)
 		body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+		body = applyPayloadPassthrough(e.cfg, baseModel, to.String(), "", body, req.Payload, requestedModel)
 		body, _ = sjson.SetBytes(body, "model", baseModel)
 	}
 
@@ -343,69 +412,48 @@ func (e *GeminiVertexExecutor) executeWithServiceAccount(ctx context.Context, au
 			action = "countTokens"
 		}
 	}
-	baseURL := vertexBaseURL(location)
-	url := fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/google/models/%s:%s", baseURL, vertexAPIVersion, projectID, location, baseModel, action)
-	if opts.Alt != "" && action != "countTokens" {
-		url = url + fmt.Sprintf("?$alt=%s", opts.Alt)
+	endpoint := func(location string) string {
+		url := vertexServiceAccountEndpoint(projectID, location, baseModel, action)
+		if opts.Alt != "" && action != "countTokens" {
+			url = url + fmt.Sprintf("?$alt=%s", opts.Alt)
+		}
+		return url
 	}
 	body, _ = sjson.DeleteBytes(body, "session_id")
 
-	httpReq, errNewReq := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-	if errNewReq != nil {
-		return resp, errNewReq
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	if token, errTok := vertexAccessToken(ctx, e.cfg, auth, saJSON); errTok == nil && token != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+token)
-	} else if errTok != nil {
+	token, errTok := vertexAccessToken(ctx, e.cfg, auth, saJSON)
+	if errTok != nil {
 		log.Errorf("vertex executor: access token error: %v", errTok)
 		return resp, statusErr{code: 500, msg: "internal server error"}
 	}
-	applyGeminiHeaders(httpReq, auth)
 
-	var authID, authLabel, authType, authValue string
-	if auth != nil {
-		authID = auth.ID
-		authLabel = auth.Label
-		authType, authValue = auth.AccountInfo()
+	if dryRunRequested(opts) {
+		httpReq, errReq := e.newServiceAccountRequest(ctx, auth, token, endpoint(location), body)
+		if errReq != nil {
+			return resp, errReq
+		}
+		return dryRunResponse(auth, httpReq, body), nil
 	}
-	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
-		URL:       url,
-		Method:    http.MethodPost,
-		Headers:   httpReq.Header.Clone(),
-		Body:      body,
-		Provider:  e.Identifier(),
-		AuthID:    authID,
-		AuthLabel: authLabel,
-		AuthType:  authType,
-		AuthValue: authValue,
-	})
-
-	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
-	httpResp, errDo := httpClient.Do(httpReq)
+	httpResp, errDo := e.doServiceAccountRequest(ctx, auth, token, vertexServiceAccountLocations(e.cfg, location), body, endpoint)
 	if errDo != nil {
-		recordAPIResponseError(ctx, e.cfg, errDo)
-		return resp, errDo
+		err = errDo
+		return resp, err
 	}
 	defer func() {
 		if errClose := httpResp.Body.Close(); errClose != nil {
 			log.Errorf("vertex executor: close response body error: %v", errClose)
 		}
 	}()
-	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
-	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
-		b, _ := io.ReadAll(httpResp.Body)
-		appendAPIResponseChunk(ctx, e.cfg, b)
-		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
-		err = statusErr{code: httpResp.StatusCode, msg: string(b)}
-		return resp, err
-	}
-	data, errRead := io.ReadAll(httpResp.Body)
+	data, errRead := readUpstreamBody(e.cfg, httpResp.Body)
 	if errRead != nil {
 		recordAPIResponseError(ctx, e.cfg, errRead)
 		return resp, errRead
 	}
 	appendAPIResponseChunk(ctx, e.cfg, data)
+	if errJSON := nonJSONResponseError(httpResp.StatusCode, httpResp.Header.Get("Content-Type"), data); errJSON != nil {
+		err = errJSON
+		return resp, err
+	}
 	reporter.publish(ctx, parseGeminiUsage(data))
 
 	// For Imagen models, convert response to Gemini format before translation
@@ -415,10 +463,14 @@ func (e *GeminiVertexExecutor) executeWithServiceAccount(ctx context.Context, au
 	}
 
 	// Standard Gemini translation (works for both Gemini and converted Imagen responses)
-	from := opts.SourceFormat
+	from := requestSourceFormat(opts)
 	to := sdktranslator.FromString("gemini")
 	var param any
-	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, body, data, &param)
+	out, errTranslate := translateNonStreamChecked(ctx, to, from, req.Model, opts.OriginalRequest, body, data, &param)
+	if errTranslate != nil {
+		err = errTranslate
+		return resp, err
+	}
 	resp = cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}
 	return resp, nil
 }
@@ -430,7 +482,7 @@ func (e *GeminiVertexExecutor) executeWithAPIKey(ctx context.Context, auth *clip
 	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
 	defer reporter.trackFailure(ctx, &err)
 
-	from := opts.SourceFormat
+	from := requestSourceFormat(opts)
 	to := sdktranslator.FromString("gemini")
 
 	originalPayloadSource := req.Payload
@@ -439,7 +491,10 @@ func (e *GeminiVertexExecutor) executeWithAPIKey(ctx context.Context, auth *clip
 	}
 	originalPayload := originalPayloadSource
 	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, false)
-	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	body, err := translateRequestChecked(ctx, from, to, baseModel, req.Payload, false)
+	if err != nil {
+		return resp, err
+	}
 
 	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -449,6 +504,7 @@ func (e *GeminiVertexExecutor) executeWithAPIKey(ctx context.Context, auth *clip
 	body = fixGeminiImageAspectRatio(baseModel, body)
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	body = applyPayloadPassthrough(e.cfg, baseModel, to.String(), "", body, req.Payload, requestedModel)
 	body, _ = sjson.SetBytes(body, "model", baseModel)
 
 	action := getVertexAction(baseModel, false)
@@ -477,6 +533,9 @@ func (e *GeminiVertexExecutor) executeWithAPIKey(ctx context.Context, auth *clip
 		httpReq.Header.Set("x-goog-api-key", apiKey)
 	}
 	applyGeminiHeaders(httpReq, auth)
+	if dryRunRequested(opts) {
+		return dryRunResponse(auth, httpReq, body), nil
+	}
 
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
@@ -509,21 +568,29 @@ func (e *GeminiVertexExecutor) executeWithAPIKey(ctx context.Context, auth *clip
 	}()
 	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
-		b, _ := io.ReadAll(httpResp.Body)
+		b, _ := readUpstreamBody(e.cfg, httpResp.Body)
 		appendAPIResponseChunk(ctx, e.cfg, b)
 		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
-		err = statusErr{code: httpResp.StatusCode, msg: string(b)}
+		err = statusErr{code: httpResp.StatusCode, msg: upstreamErrorMessage(httpResp.StatusCode, httpResp.Header.Get("Content-Type"), b), upstream: true}
 		return resp, err
 	}
-	data, errRead := io.ReadAll(httpResp.Body)
+	data, errRead := readUpstreamBody(e.cfg, httpResp.Body)
 	if errRead != nil {
 		recordAPIResponseError(ctx, e.cfg, errRead)
 		return resp, errRead
 	}
 	appendAPIResponseChunk(ctx, e.cfg, data)
+	if errJSON := nonJSONResponseError(httpResp.StatusCode, httpResp.Header.Get("Content-Type"), data); errJSON != nil {
+		err = errJSON
+		return resp, err
+	}
 	reporter.publish(ctx, parseGeminiUsage(data))
 	var param any
-	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, body, data, &param)
+	out, errTranslate := translateNonStreamChecked(ctx, to, from, req.Model, opts.OriginalRequest, body, data, &param)
+	if errTranslate != nil {
+		err = errTranslate
+		return resp, err
+	}
 	resp = cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}
 	return resp, nil
 }
@@ -535,7 +602,7 @@ func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Conte
 	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
 	defer reporter.trackFailure(ctx, &err)
 
-	from := opts.SourceFormat
+	from := requestSourceFormat(opts)
 	to := sdktranslator.FromString("gemini")
 
 	originalPayloadSource := req.Payload
@@ -544,7 +611,10 @@ func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Conte
 	}
 	originalPayload := originalPayloadSource
 	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, true)
-	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, true)
+	body, err := translateRequestChecked(ctx, from, to, baseModel, req.Payload, true)
+	if err != nil {
+		return nil, err
+	}
 
 	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -554,68 +624,43 @@ func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Conte
 	body = fixGeminiImageAspectRatio(baseModel, body)
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	body = applyPayloadPassthrough(e.cfg, baseModel, to.String(), "", body, req.Payload, requestedModel)
 	body, _ = sjson.SetBytes(body, "model", baseModel)
 
 	action := getVertexAction(baseModel, true)
-	baseURL := vertexBaseURL(location)
-	url := fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/google/models/%s:%s", baseURL, vertexAPIVersion, projectID, location, baseModel, action)
-	// Imagen models don't support streaming, skip SSE params
-	if !isImagenModel(baseModel) {
-		if opts.Alt == "" {
-			url = url + "?alt=sse"
-		} else {
-			url = url + fmt.Sprintf("?$alt=%s", opts.Alt)
+	endpoint := func(location string) string {
+		url := vertexServiceAccountEndpoint(projectID, location, baseModel, action)
+		// Imagen models don't support streaming, skip SSE params
+		if !isImagenModel(baseModel) {
+			if opts.Alt == "" {
+				url = url + "?alt=sse"
+			} else {
+				url = url + fmt.Sprintf("?$alt=%s", opts.Alt)
+			}
 		}
+		return url
 	}
 	body, _ = sjson.DeleteBytes(body, "session_id")
 
-	httpReq, errNewReq := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-	if errNewReq != nil {
-		return nil, errNewReq
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	if token, errTok := vertexAccessToken(ctx, e.cfg, auth, saJSON); errTok == nil && token != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+token)
-	} else if errTok != nil {
+	token, errTok := vertexAccessToken(ctx, e.cfg, auth, saJSON)
+	if errTok != nil {
 		log.Errorf("vertex executor: access token error: %v", errTok)
 		return nil, statusErr{code: 500, msg: "internal server error"}
 	}
-	applyGeminiHeaders(httpReq, auth)
 
-	var authID, authLabel, authType, authValue string
-	if auth != nil {
-		authID = auth.ID
-		authLabel = auth.Label
-		authType, authValue = auth.AccountInfo()
+	// Region failover happens before the stream is handed to the caller, so no output
+	// has been written when a fallback location is tried.
+	if dryRunRequested(opts) {
+		httpReq, errReq := e.newServiceAccountRequest(ctx, auth, token, endpoint(location), body)
+		if errReq != nil {
+			return nil, errReq
+		}
+		return dryRunStreamResult(auth, httpReq, body), nil
 	}
-	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
-		URL:       url,
-		Method:    http.MethodPost,
-		Headers:   httpReq.Header.Clone(),
-		Body:      body,
-		Provider:  e.Identifier(),
-		AuthID:    authID,
-		AuthLabel: authLabel,
-		AuthType:  authType,
-		AuthValue: authValue,
-	})
-
-	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
-	httpResp, errDo := httpClient.Do(httpReq)
+	httpResp, errDo := e.doServiceAccountRequest(ctx, auth, token, vertexServiceAccountLocations(e.cfg, location), body, endpoint)
 	if errDo != nil {
-		recordAPIResponseError(ctx, e.cfg, errDo)
 		return nil, errDo
 	}
-	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
-	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
-		b, _ := io.ReadAll(httpResp.Body)
-		appendAPIResponseChunk(ctx, e.cfg, b)
-		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
-		if errClose := httpResp.Body.Close(); errClose != nil {
-			log.Errorf("vertex executor: close response body error: %v", errClose)
-		}
-		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
-	}
 
 	out := make(chan cliproxyexecutor.StreamChunk)
 	go func() {
@@ -625,8 +670,12 @@ func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Conte
 				log.Errorf("vertex executor: close response body error: %v", errClose)
 			}
 		}()
+		// Heartbeats keep slow first tokens from idling out intermediaries; they stop once
+		// translated data is sent.
+		stopKeepAlive := startStreamKeepAlive(ctx, streamKeepAliveInterval(e.cfg, opts), out)
+		defer stopKeepAlive()
 		scanner := bufio.NewScanner(httpResp.Body)
-		scanner.Buffer(nil, streamScannerBuffer)
+		scanner.Buffer(nil, streamScannerBufferSize(e.cfg))
 		var param any
 		for scanner.Scan() {
 			line := scanner.Bytes()
@@ -635,10 +684,14 @@ func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Conte
 				reporter.publish(ctx, detail)
 			}
 			lines := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, body, bytes.Clone(line), &param)
+			if len(lines) > 0 {
+				stopKeepAlive()
+			}
 			for i := range lines {
 				out <- cliproxyexecutor.StreamChunk{Payload: lines[i]}
 			}
 		}
+		stopKeepAlive()
 		lines := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, body, []byte("[DONE]"), &param)
 		for i := range lines {
 			out <- cliproxyexecutor.StreamChunk{Payload: lines[i]}
@@ -659,7 +712,7 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
 	defer reporter.trackFailure(ctx, &err)
 
-	from := opts.SourceFormat
+	from := requestSourceFormat(opts)
 	to := sdktranslator.FromString("gemini")
 
 	originalPayloadSource := req.Payload
@@ -668,7 +721,10 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 	}
 	originalPayload := originalPayloadSource
 	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, true)
-	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, true)
+	body, err := translateRequestChecked(ctx, from, to, baseModel, req.Payload, true)
+	if err != nil {
+		return nil, err
+	}
 
 	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -678,6 +734,7 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 	body = fixGeminiImageAspectRatio(baseModel, body)
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	body = applyPayloadPassthrough(e.cfg, baseModel, to.String(), "", body, req.Payload, requestedModel)
 	body, _ = sjson.SetBytes(body, "model", baseModel)
 
 	action := getVertexAction(baseModel, true)
@@ -705,6 +762,9 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 		httpReq.Header.Set("x-goog-api-key", apiKey)
 	}
 	applyGeminiHeaders(httpReq, auth)
+	if dryRunRequested(opts) {
+		return dryRunStreamResult(auth, httpReq, body), nil
+	}
 
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
@@ -732,13 +792,13 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 	}
 	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
-		b, _ := io.ReadAll(httpResp.Body)
+		b, _ := readUpstreamBody(e.cfg, httpResp.Body)
 		appendAPIResponseChunk(ctx, e.cfg, b)
 		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
 		if errClose := httpResp.Body.Close(); errClose != nil {
 			log.Errorf("vertex executor: close response body error: %v", errClose)
 		}
-		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+		return nil, statusErr{code: httpResp.StatusCode, msg: upstreamErrorMessage(httpResp.StatusCode, httpResp.Header.Get("Content-Type"), b), upstream: true}
 	}
 
 	out := make(chan cliproxyexecutor.StreamChunk)
@@ -749,8 +809,12 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 				log.Errorf("vertex executor: close response body error: %v", errClose)
 			}
 		}()
+		// Heartbeats keep slow first tokens from idling out intermediaries; they stop once
+		// translated data is sent.
+		stopKeepAlive := startStreamKeepAlive(ctx, streamKeepAliveInterval(e.cfg, opts), out)
+		defer stopKeepAlive()
 		scanner := bufio.NewScanner(httpResp.Body)
-		scanner.Buffer(nil, streamScannerBuffer)
+		scanner.Buffer(nil, streamScannerBufferSize(e.cfg))
 		var param any
 		for scanner.Scan() {
 			line := scanner.Bytes()
@@ -759,10 +823,14 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 				reporter.publish(ctx, detail)
 			}
 			lines := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, body, bytes.Clone(line), &param)
+			if len(lines) > 0 {
+				stopKeepAlive()
+			}
 			for i := range lines {
 				out <- cliproxyexecutor.StreamChunk{Payload: lines[i]}
 			}
 		}
+		stopKeepAlive()
 		lines := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, body, []byte("[DONE]"), &param)
 		for i := range lines {
 			out <- cliproxyexecutor.StreamChunk{Payload: lines[i]}
@@ -780,7 +848,7 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 func (e *GeminiVertexExecutor) countTokensWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location string, saJSON []byte) (cliproxyexecutor.Response, error) {
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
-	from := opts.SourceFormat
+	from := requestSourceFormat(opts)
 	to := sdktranslator.FromString("gemini")
 
 	translatedReq := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
@@ -797,6 +865,10 @@ func (e *GeminiVertexExecutor) countTokensWithServiceAccount(ctx context.Context
 	translatedReq, _ = sjson.DeleteBytes(translatedReq, "generationConfig")
 	translatedReq, _ = sjson.DeleteBytes(translatedReq, "safetySettings")
 
+	if total, ok := cachedGeminiTokenCount(e.cfg, e.Identifier(), baseModel, translatedReq); ok {
+		return cliproxyexecutor.Response{Payload: sdktranslator.TranslateTokenCount(ctx, to, from, total, geminiTokenCountBody(total))}, nil
+	}
+
 	baseURL := vertexBaseURL(location)
 	url := fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/google/models/%s:%s", baseURL, vertexAPIVersion, projectID, location, baseModel, "countTokens")
 
@@ -844,18 +916,19 @@ func (e *GeminiVertexExecutor) countTokensWithServiceAccount(ctx context.Context
 	}()
 	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
-		b, _ := io.ReadAll(httpResp.Body)
+		b, _ := readUpstreamBody(e.cfg, httpResp.Body)
 		appendAPIResponseChunk(ctx, e.cfg, b)
 		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
-		return cliproxyexecutor.Response{}, statusErr{code: httpResp.StatusCode, msg: string(b)}
+		return cliproxyexecutor.Response{}, statusErr{code: httpResp.StatusCode, msg: upstreamErrorMessage(httpResp.StatusCode, httpResp.Header.Get("Content-Type"), b), upstream: true}
 	}
-	data, errRead := io.ReadAll(httpResp.Body)
+	data, errRead := readUpstreamBody(e.cfg, httpResp.Body)
 	if errRead != nil {
 		recordAPIResponseError(ctx, e.cfg, errRead)
 		return cliproxyexecutor.Response{}, errRead
 	}
 	appendAPIResponseChunk(ctx, e.cfg, data)
 	count := gjson.GetBytes(data, "totalTokens").Int()
+	storeGeminiTokenCount(e.cfg, e.Identifier(), baseModel, translatedReq, count)
 	out := sdktranslator.TranslateTokenCount(ctx, to, from, count, data)
 	return cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}, nil
 }
@@ -864,7 +937,7 @@ func (e *GeminiVertexExecutor) countTokensWithServiceAccount(ctx context.Context
 func (e *GeminiVertexExecutor) countTokensWithAPIKey(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, apiKey, baseURL string) (cliproxyexecutor.Response, error) {
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
-	from := opts.SourceFormat
+	from := requestSourceFormat(opts)
 	to := sdktranslator.FromString("gemini")
 
 	translatedReq := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
@@ -881,6 +954,10 @@ func (e *GeminiVertexExecutor) countTokensWithAPIKey(ctx context.Context, auth *
 	translatedReq, _ = sjson.DeleteBytes(translatedReq, "generationConfig")
 	translatedReq, _ = sjson.DeleteBytes(translatedReq, "safetySettings")
 
+	if total, ok := cachedGeminiTokenCount(e.cfg, e.Identifier(), baseModel, translatedReq); ok {
+		return cliproxyexecutor.Response{Payload: sdktranslator.TranslateTokenCount(ctx, to, from, total, geminiTokenCountBody(total))}, nil
+	}
+
 	// For API key auth, use simpler URL format without project/location
 	if baseURL == "" {
 		baseURL = "https://aiplatform.googleapis.com"
@@ -928,18 +1005,19 @@ func (e *GeminiVertexExecutor) countTokensWithAPIKey(ctx context.Context, auth *
 	}()
 	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
-		b, _ := io.ReadAll(httpResp.Body)
+		b, _ := readUpstreamBody(e.cfg, httpResp.Body)
 		appendAPIResponseChunk(ctx, e.cfg, b)
 		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
-		return cliproxyexecutor.Response{}, statusErr{code: httpResp.StatusCode, msg: string(b)}
+		return cliproxyexecutor.Response{}, statusErr{code: httpResp.StatusCode, msg: upstreamErrorMessage(httpResp.StatusCode, httpResp.Header.Get("Content-Type"), b), upstream: true}
 	}
-	data, errRead := io.ReadAll(httpResp.Body)
+	data, errRead := readUpstreamBody(e.cfg, httpResp.Body)
 	if errRead != nil {
 		recordAPIResponseError(ctx, e.cfg, errRead)
 		return cliproxyexecutor.Response{}, errRead
 	}
 	appendAPIResponseChunk(ctx, e.cfg, data)
 	count := gjson.GetBytes(data, "totalTokens").Int()
+	storeGeminiTokenCount(e.cfg, e.Identifier(), baseModel, translatedReq, count)
 	out := sdktranslator.TranslateTokenCount(ctx, to, from, count, data)
 	return cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}, nil
 }
@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// vertexServiceAccountEndpoint builds the publisher model URL for a project location.
+func vertexServiceAccountEndpoint(projectID, location, model, action string) string {
+	return fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/google/models/%s:%s", vertexBaseURL(location), vertexAPIVersion, projectID, location, model, action)
+}
+
+// vertexServiceAccountLocations returns the primary location followed by the configured
+// fallback locations.
+func vertexServiceAccountLocations(cfg *config.Config, primary string) []string {
+	locations := []string{primary}
+	if cfg == nil {
+		return locations
+	}
+	for _, location := range cfg.Vertex.FallbackLocations {
+		location = strings.TrimSpace(location)
+		if location == "" || location == primary {
+			continue
+		}
+		locations = append(locations, location)
+	}
+	return locations
+}
+
+// vertexRegionRetryable reports whether a status code warrants trying the next region.
+func vertexRegionRetryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// vertexTransportRetryable reports whether a transport error warrants trying the next region:
+// dial failures, DNS errors and timeouts, as long as the request itself was not canceled.
+func vertexTransportRetryable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	if opErr, ok := errors.AsType[*net.OpError](err); ok && opErr.Op == "dial" {
+		return true
+	}
+	if _, ok := errors.AsType[*net.DNSError](err); ok {
+		return true
+	}
+	if netErr, ok := errors.AsType[net.Error](err); ok && netErr.Timeout() {
+		return true
+	}
+	return false
+}
+
+// newServiceAccountRequest builds the POST of body to url authorized with a service account token.
+func (e *GeminiVertexExecutor) newServiceAccountRequest(ctx context.Context, auth *cliproxyauth.Auth, token, url string, body []byte) (*http.Request, error) {
+	httpReq, errNewReq := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if errNewReq != nil {
+		return nil, errNewReq
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+	applyGeminiHeaders(httpReq, auth)
+	return httpReq, nil
+}
+
+// doServiceAccountRequest posts body to each location in turn until one answers with a
+// non-retryable status. Dial, DNS and timeout errors also move on to the next location. It
+// returns the open successful response, or the error from the last location tried when every
+// region fails.
+func (e *GeminiVertexExecutor) doServiceAccountRequest(ctx context.Context, auth *cliproxyauth.Auth, token string, locations []string, body []byte, endpoint func(location string) string) (*http.Response, error) {
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+
+	var lastErr error
+	for i, location := range locations {
+		url := endpoint(location)
+		httpReq, errNewReq := e.newServiceAccountRequest(ctx, auth, token, url, body)
+		if errNewReq != nil {
+			return nil, errNewReq
+		}
+
+		recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+			URL:       url,
+			Method:    http.MethodPost,
+			Headers:   httpReq.Header.Clone(),
+			Body:      body,
+			Provider:  e.Identifier(),
+			AuthID:    authID,
+			AuthLabel: authLabel,
+			AuthType:  authType,
+			AuthValue: authValue,
+		})
+
+		httpResp, errDo := httpClient.Do(httpReq)
+		if errDo != nil {
+			recordAPIResponseError(ctx, e.cfg, errDo)
+			lastErr = errDo
+			if !vertexTransportRetryable(ctx, errDo) || i == len(locations)-1 {
+				return nil, lastErr
+			}
+			logWithRequestID(ctx).Infof("vertex executor: location %s unreachable (%v), trying %s", location, errDo, locations[i+1])
+			continue
+		}
+		recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+		if httpResp.StatusCode >= 200 && httpResp.StatusCode < 300 {
+			return httpResp, nil
+		}
+
+		b, _ := readUpstreamBody(e.cfg, httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("vertex executor: close response body error: %v", errClose)
+		}
+		lastErr = statusErr{code: httpResp.StatusCode, msg: upstreamErrorMessage(httpResp.StatusCode, httpResp.Header.Get("Content-Type"), b), upstream: true}
+		if !vertexRegionRetryable(httpResp.StatusCode) || i == len(locations)-1 {
+			return nil, lastErr
+		}
+		logWithRequestID(ctx).Infof("vertex executor: location %s returned %d, trying %s", location, httpResp.StatusCode, locations[i+1])
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,130 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+type vertexRegionTransport struct {
+	mu       sync.Mutex
+	statuses map[string]int
+	errs     map[string]error
+	hosts    []string
+}
+
+func (t *vertexRegionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.hosts = append(t.hosts, req.URL.Host)
+	status, ok := t.statuses[req.URL.Host]
+	errRoundTrip := t.errs[req.URL.Host]
+	t.mu.Unlock()
+	if errRoundTrip != nil {
+		return nil, errRoundTrip
+	}
+	if !ok {
+		status = http.StatusOK
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"host":"` + req.URL.Host + `"}`)),
+		Request:    req,
+	}, nil
+}
+
+func doVertexRegionRequest(t *testing.T, transport *vertexRegionTransport, fallbacks ...string) (*http.Response, error) {
+	t.Helper()
+	executor := NewGeminiVertexExecutor(&config.Config{Vertex: config.VertexConfig{FallbackLocations: fallbacks}})
+	ctx := context.WithValue(context.Background(), "cliproxy.roundtripper", http.RoundTripper(transport))
+	locations := vertexServiceAccountLocations(executor.cfg, "us-central1")
+	endpoint := func(location string) string {
+		return vertexServiceAccountEndpoint("project-1", location, "gemini-2.5-pro", "generateContent")
+	}
+	return executor.doServiceAccountRequest(ctx, nil, "token", locations, []byte(`{}`), endpoint)
+}
+
+func TestVertexServiceAccountRequestFailsOverToNextRegion(t *testing.T) {
+	transport := &vertexRegionTransport{statuses: map[string]int{
+		"us-central1-aiplatform.googleapis.com": http.StatusServiceUnavailable,
+		"us-east5-aiplatform.googleapis.com":    http.StatusTooManyRequests,
+	}}
+
+	resp, err := doVertexRegionRequest(t, transport, "us-east5", "europe-west4")
+	if err != nil {
+		t.Fatalf("doServiceAccountRequest() error = %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	want := []string{"us-central1-aiplatform.googleapis.com", "us-east5-aiplatform.googleapis.com", "europe-west4-aiplatform.googleapis.com"}
+	if strings.Join(transport.hosts, ",") != strings.Join(want, ",") {
+		t.Fatalf("hosts = %v, want %v", transport.hosts, want)
+	}
+}
+
+func TestVertexServiceAccountRequestReturnsLastRegionError(t *testing.T) {
+	transport := &vertexRegionTransport{statuses: map[string]int{
+		"us-central1-aiplatform.googleapis.com": http.StatusTooManyRequests,
+		"us-east5-aiplatform.googleapis.com":    http.StatusServiceUnavailable,
+	}}
+
+	_, err := doVertexRegionRequest(t, transport, "us-east5")
+	var se statusErr
+	if !errors.As(err, &se) || se.StatusCode() != http.StatusServiceUnavailable {
+		t.Fatalf("error = %v, want the last region's 503", err)
+	}
+	if !strings.Contains(se.Error(), "us-east5") {
+		t.Fatalf("error body = %q, want last region response", se.Error())
+	}
+}
+
+func TestVertexServiceAccountRequestDoesNotFailOverOnClientError(t *testing.T) {
+	transport := &vertexRegionTransport{statuses: map[string]int{
+		"us-central1-aiplatform.googleapis.com": http.StatusBadRequest,
+	}}
+
+	_, err := doVertexRegionRequest(t, transport, "us-east5")
+	var se statusErr
+	if !errors.As(err, &se) || se.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("error = %v, want 400", err)
+	}
+	if len(transport.hosts) != 1 {
+		t.Fatalf("hosts = %v, want a single attempt", transport.hosts)
+	}
+}
+
+func TestVertexServiceAccountRequestFailsOverOnDialError(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	transport := &vertexRegionTransport{errs: map[string]error{
+		"us-central1-aiplatform.googleapis.com": dialErr,
+	}}
+
+	resp, err := doVertexRegionRequest(t, transport, "us-east5")
+	if err != nil {
+		t.Fatalf("doServiceAccountRequest() error = %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if len(transport.hosts) != 2 || transport.hosts[1] != "us-east5-aiplatform.googleapis.com" {
+		t.Fatalf("hosts = %v, want failover to us-east5", transport.hosts)
+	}
+}
+
+func TestVertexServiceAccountRequestDoesNotFailOverOnOtherTransportErrors(t *testing.T) {
+	transport := &vertexRegionTransport{errs: map[string]error{
+		"us-central1-aiplatform.googleapis.com": errors.New("malformed HTTP response"),
+	}}
+
+	if _, err := doVertexRegionRequest(t, transport, "us-east5"); err == nil {
+		t.Fatal("expected the transport error")
+	}
+	if len(transport.hosts) != 1 {
+		t.Fatalf("hosts = %v, want a single attempt", transport.hosts)
+	}
+}
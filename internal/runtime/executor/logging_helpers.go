@@ -14,6 +14,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 )
@@ -49,12 +50,14 @@ type upstreamAttempt struct {
 	errorWritten         bool
 }
 
-// recordAPIRequest stores the upstream request metadata in Gin context for request logging.
+// recordAPIRequest stores the upstream request metadata in Gin context for request logging
+// and echoes the body to the client when it asked for the effective request.
 func recordAPIRequest(ctx context.Context, cfg *config.Config, info upstreamRequestLog) {
-	if cfg == nil || !cfg.RequestLog {
+	ginCtx := ginContextFrom(ctx)
+	echoEffectiveRequest(ginCtx, cfg, info.Body)
+	if !requestLogEnabled(cfg, ginCtx) {
 		return
 	}
-	ginCtx := ginContextFrom(ctx)
 	if ginCtx == nil {
 		return
 	}
@@ -96,13 +99,12 @@ func recordAPIRequest(ctx context.Context, cfg *config.Config, info upstreamRequ
 	updateAggregatedRequest(ginCtx, attempts)
 }
 
-// recordAPIResponseMetadata captures upstream response status/header information for the latest attempt.
+// recordAPIResponseMetadata counts the upstream response status and captures status/header
+// information for the latest attempt.
 func recordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status int, headers http.Header) {
-	if cfg == nil || !cfg.RequestLog {
-		return
-	}
+	recordResponseStatus(ctx, status)
 	ginCtx := ginContextFrom(ctx)
-	if ginCtx == nil {
+	if !requestLogEnabled(cfg, ginCtx) || ginCtx == nil {
 		return
 	}
 	attempts, attempt := ensureAttempt(ginCtx)
@@ -124,11 +126,11 @@ func recordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status i
 
 // recordAPIResponseError adds an error entry for the latest attempt when no HTTP response is available.
 func recordAPIResponseError(ctx context.Context, cfg *config.Config, err error) {
-	if cfg == nil || !cfg.RequestLog || err == nil {
+	if err == nil {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
-	if ginCtx == nil {
+	if !requestLogEnabled(cfg, ginCtx) || ginCtx == nil {
 		return
 	}
 	attempts, attempt := ensureAttempt(ginCtx)
@@ -149,17 +151,14 @@ func recordAPIResponseError(ctx context.Context, cfg *config.Config, err error)
 
 // appendAPIResponseChunk appends an upstream response chunk to Gin context for request logging.
 func appendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byte) {
-	if cfg == nil || !cfg.RequestLog {
+	ginCtx := ginContextFrom(ctx)
+	if !requestLogEnabled(cfg, ginCtx) || ginCtx == nil {
 		return
 	}
 	data := bytes.TrimSpace(chunk)
 	if len(data) == 0 {
 		return
 	}
-	ginCtx := ginContextFrom(ctx)
-	if ginCtx == nil {
-		return
-	}
 	attempts, attempt := ensureAttempt(ginCtx)
 	ensureResponseIntro(attempt)
 
@@ -182,11 +181,33 @@ func appendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byt
 	updateAggregatedResponse(ginCtx, attempts)
 }
 
+// requestLogEnabled reports whether upstream traffic should be recorded for the request,
+// either because request logging is enabled or because the request was sampled.
+func requestLogEnabled(cfg *config.Config, ginCtx *gin.Context) bool {
+	if cfg == nil {
+		return false
+	}
+	return cfg.RequestLog || logging.IsGinRequestLogSampled(ginCtx)
+}
+
 func ginContextFrom(ctx context.Context) *gin.Context {
 	ginCtx, _ := ctx.Value("gin").(*gin.Context)
 	return ginCtx
 }
 
+// clientRequestHeaders returns the inbound client request headers for ctx, falling back to
+// headers supplied with cliproxyexecutor.WithClientHeaders when there is no gin request. It
+// returns nil when there are none or the client disabled header passthrough for this request.
+func clientRequestHeaders(ctx context.Context) http.Header {
+	if ctx == nil || util.HeaderPassthroughDisabled(ctx) {
+		return nil
+	}
+	if ginCtx := ginContextFrom(ctx); ginCtx != nil && ginCtx.Request != nil {
+		return ginCtx.Request.Header
+	}
+	return cliproxyexecutor.ClientHeaders(ctx)
+}
+
 func getAttempts(ginCtx *gin.Context) []*upstreamAttempt {
 	if ginCtx == nil {
 		return nil
@@ -322,14 +343,7 @@ func formatAuthInfo(info upstreamRequestLog) string {
 }
 
 func summarizeErrorBody(contentType string, body []byte) string {
-	isHTML := strings.Contains(strings.ToLower(contentType), "text/html")
-	if !isHTML {
-		trimmed := bytes.TrimSpace(bytes.ToLower(body))
-		if bytes.HasPrefix(trimmed, []byte("<!doctype html")) || bytes.HasPrefix(trimmed, []byte("<html")) {
-			isHTML = true
-		}
-	}
-	if isHTML {
+	if isHTMLBody(contentType, body) {
 		if title := extractHTMLTitle(body); title != "" {
 			return title
 		}
@@ -344,6 +358,16 @@ func summarizeErrorBody(contentType string, body []byte) string {
 	return string(body)
 }
 
+// isHTMLBody reports whether an upstream body is an HTML page, judged by the content
+// type or the leading markup.
+func isHTMLBody(contentType string, body []byte) bool {
+	if strings.Contains(strings.ToLower(contentType), "text/html") {
+		return true
+	}
+	trimmed := bytes.TrimSpace(bytes.ToLower(body))
+	return bytes.HasPrefix(trimmed, []byte("<!doctype html")) || bytes.HasPrefix(trimmed, []byte("<html"))
+}
+
 func extractHTMLTitle(body []byte) string {
 	lower := bytes.ToLower(body)
 	start := bytes.Index(lower, []byte("<title"))
@@ -0,0 +1,90 @@
+package executor
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Output token limit fields used by OpenAI Chat Completions. Newer models only accept
+// max_completion_tokens, while many OpenAI-compatible upstreams still only accept max_tokens.
+const (
+	maxTokensFieldLegacy     = "max_tokens"
+	maxTokensFieldCompletion = "max_completion_tokens"
+)
+
+// resolveMaxTokensField returns the output token limit field accepted by the upstream model.
+// An explicit max-tokens-field on the matching compatibility model wins; otherwise the
+// registry's supported parameters decide. An empty result leaves the payload unchanged.
+func resolveMaxTokensField(compat *config.OpenAICompatibility, model string) string {
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return ""
+	}
+	if compat != nil {
+		for i := range compat.Models {
+			entry := &compat.Models[i]
+			if entry.MaxTokensField == "" {
+				continue
+			}
+			if strings.EqualFold(entry.Name, model) || strings.EqualFold(entry.Alias, model) {
+				return entry.MaxTokensField
+			}
+		}
+	}
+	info := registry.LookupModelInfo(model)
+	if info == nil {
+		return ""
+	}
+	var legacy, completion bool
+	for _, param := range info.SupportedParameters {
+		switch strings.ToLower(strings.TrimSpace(param)) {
+		case maxTokensFieldLegacy:
+			legacy = true
+		case maxTokensFieldCompletion:
+			completion = true
+		}
+	}
+	switch {
+	case completion && !legacy:
+		return maxTokensFieldCompletion
+	case legacy && !completion:
+		return maxTokensFieldLegacy
+	default:
+		return ""
+	}
+}
+
+// applyMaxTokensField moves the output token limit to the field named by target so that
+// only one of max_tokens/max_completion_tokens reaches the upstream. When both are present
+// the value already stored under target is kept.
+func applyMaxTokensField(payload []byte, target string) []byte {
+	var source string
+	switch target {
+	case maxTokensFieldLegacy:
+		source = maxTokensFieldCompletion
+	case maxTokensFieldCompletion:
+		source = maxTokensFieldLegacy
+	default:
+		return payload
+	}
+	value := gjson.GetBytes(payload, source)
+	if !value.Exists() {
+		return payload
+	}
+	out := payload
+	if !gjson.GetBytes(out, target).Exists() {
+		updated, errSet := sjson.SetRawBytes(out, target, []byte(value.Raw))
+		if errSet != nil {
+			return payload
+		}
+		out = updated
+	}
+	if updated, errDel := sjson.DeleteBytes(out, source); errDel == nil {
+		out = updated
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func executeOpenAICompatForMaxTokens(t *testing.T, field string, payload []byte) []byte {
+	t.Helper()
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl_1","object":"chat.completion","choices":[]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{OpenAICompatibility: []config.OpenAICompatibility{{
+		Name:    "test-compat",
+		BaseURL: server.URL,
+		Models:  []config.OpenAICompatibilityModel{{Name: "upstream-model", Alias: "client-model", MaxTokensField: field}},
+	}}}
+	executor := NewOpenAICompatExecutor("test-compat", cfg)
+	auth := &cliproxyauth.Auth{Provider: "test-compat", Attributes: map[string]string{
+		"base_url":    server.URL,
+		"api_key":     "test",
+		"compat_name": "test-compat",
+	}}
+	_, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "upstream-model",
+		Payload: payload,
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	return gotBody
+}
+
+func TestOpenAICompatExecutorMapsMaxTokensToMaxCompletionTokens(t *testing.T) {
+	body := executeOpenAICompatForMaxTokens(t, "max_completion_tokens",
+		[]byte(`{"model":"upstream-model","max_tokens":256,"messages":[{"role":"user","content":"hi"}]}`))
+
+	if got := gjson.GetBytes(body, "max_completion_tokens").Int(); got != 256 {
+		t.Fatalf("max_completion_tokens = %d, want 256; body=%s", got, body)
+	}
+	if gjson.GetBytes(body, "max_tokens").Exists() {
+		t.Fatalf("unexpected max_tokens in body: %s", body)
+	}
+}
+
+func TestOpenAICompatExecutorMapsMaxCompletionTokensToMaxTokens(t *testing.T) {
+	body := executeOpenAICompatForMaxTokens(t, "max_tokens",
+		[]byte(`{"model":"upstream-model","max_completion_tokens":512,"messages":[{"role":"user","content":"hi"}]}`))
+
+	if got := gjson.GetBytes(body, "max_tokens").Int(); got != 512 {
+		t.Fatalf("max_tokens = %d, want 512; body=%s", got, body)
+	}
+	if gjson.GetBytes(body, "max_completion_tokens").Exists() {
+		t.Fatalf("unexpected max_completion_tokens in body: %s", body)
+	}
+}
+
+func TestApplyMaxTokensFieldKeepsExplicitTarget(t *testing.T) {
+	out := applyMaxTokensField([]byte(`{"max_tokens":100,"max_completion_tokens":200}`), maxTokensFieldCompletion)
+
+	if got := gjson.GetBytes(out, "max_completion_tokens").Int(); got != 200 {
+		t.Fatalf("max_completion_tokens = %d, want 200", got)
+	}
+	if gjson.GetBytes(out, "max_tokens").Exists() {
+		t.Fatalf("unexpected max_tokens in %s", out)
+	}
+}
+
+func TestApplyMaxTokensFieldWithoutTargetIsNoop(t *testing.T) {
+	payload := []byte(`{"max_tokens":100}`)
+	if out := applyMaxTokensField(payload, ""); string(out) != string(payload) {
+		t.Fatalf("payload changed: %s", out)
+	}
+}
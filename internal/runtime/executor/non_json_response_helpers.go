@@ -0,0 +1,54 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// nonJSONSnippetMaxBytes caps the upstream body excerpt included in non-JSON errors.
+const nonJSONSnippetMaxBytes = 256
+
+// describeNonJSONResponse builds a client-facing message for an upstream body that is not
+// JSON, e.g. a CDN block page.
+func describeNonJSONResponse(statusCode int, contentType string, body []byte) string {
+	contentType = strings.TrimSpace(contentType)
+	if contentType == "" {
+		contentType = "unknown"
+	}
+	snippet := strings.TrimSpace(summarizeErrorBody(contentType, body))
+	if len(snippet) > nonJSONSnippetMaxBytes {
+		cut := nonJSONSnippetMaxBytes
+		for cut > 0 && !utf8.RuneStart(snippet[cut]) {
+			cut--
+		}
+		snippet = snippet[:cut] + "..."
+	}
+	return fmt.Sprintf("upstream returned non-JSON response (status %d, content-type %s): %s", statusCode, contentType, snippet)
+}
+
+// nonJSONResponseError returns a 502 error when a successful upstream response is not
+// JSON, so clients do not receive an empty translation of an HTML page. Empty bodies and
+// event streams are left to the caller.
+func nonJSONResponseError(statusCode int, contentType string, body []byte) error {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || json.Valid(trimmed) {
+		return nil
+	}
+	if strings.Contains(strings.ToLower(contentType), "text/event-stream") {
+		return nil
+	}
+	return statusErr{code: http.StatusBadGateway, msg: describeNonJSONResponse(statusCode, contentType, body), upstream: true}
+}
+
+// upstreamErrorMessage returns the error message for a failed upstream response. HTML
+// error pages are summarized; other bodies pass through so JSON errors reach clients as-is.
+func upstreamErrorMessage(statusCode int, contentType string, body []byte) string {
+	if isHTMLBody(contentType, body) {
+		return describeNonJSONResponse(statusCode, contentType, body)
+	}
+	return string(body)
+}
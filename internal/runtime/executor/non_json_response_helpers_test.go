@@ -0,0 +1,85 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+const cloudflareBlockPage = `<!DOCTYPE html>
+<html><head><title>Attention Required! | Cloudflare</title></head>
+<body><h1>Sorry, you have been blocked</h1></body></html>`
+
+func TestNonJSONResponseError(t *testing.T) {
+	if err := nonJSONResponseError(http.StatusOK, "application/json", []byte(`{"ok":true}`)); err != nil {
+		t.Fatalf("valid JSON: unexpected error %v", err)
+	}
+	if err := nonJSONResponseError(http.StatusOK, "", nil); err != nil {
+		t.Fatalf("empty body: unexpected error %v", err)
+	}
+	if err := nonJSONResponseError(http.StatusOK, "text/event-stream", []byte("data: {}\n\n")); err != nil {
+		t.Fatalf("event stream: unexpected error %v", err)
+	}
+
+	err := nonJSONResponseError(http.StatusOK, "text/html; charset=UTF-8", []byte(cloudflareBlockPage))
+	var se statusErr
+	if !errors.As(err, &se) {
+		t.Fatalf("HTML body: error = %v, want statusErr", err)
+	}
+	if se.code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want %d", se.code, http.StatusBadGateway)
+	}
+	if !strings.Contains(se.msg, "status 200") || !strings.Contains(se.msg, "Attention Required! | Cloudflare") {
+		t.Fatalf("unexpected message %q", se.msg)
+	}
+
+	err = nonJSONResponseError(http.StatusOK, "text/plain", []byte(strings.Repeat("x", 1000)))
+	if !errors.As(err, &se) || !strings.HasSuffix(se.msg, "...") || len(se.msg) > 400 {
+		t.Fatalf("plain text body: unexpected error %v", err)
+	}
+}
+
+func TestUpstreamErrorMessageSummarizesHTML(t *testing.T) {
+	jsonBody := []byte(`{"error":{"message":"bad"}}`)
+	if got := upstreamErrorMessage(http.StatusForbidden, "application/json", jsonBody); got != string(jsonBody) {
+		t.Fatalf("JSON error body changed: %q", got)
+	}
+	got := upstreamErrorMessage(http.StatusForbidden, "", []byte(cloudflareBlockPage))
+	if strings.Contains(got, "<html") || !strings.Contains(got, "status 403") {
+		t.Fatalf("unexpected HTML error message %q", got)
+	}
+}
+
+func TestOpenAICompatExecutorRejectsHTMLSuccessResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(cloudflareBlockPage))
+	}))
+	defer server.Close()
+
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"base_url": server.URL + "/v1",
+		"api_key":  "test",
+	}}
+	_, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "gpt-4o",
+		Payload: []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	var se statusErr
+	if !errors.As(err, &se) {
+		t.Fatalf("Execute error = %v, want statusErr", err)
+	}
+	if se.code != http.StatusBadGateway || !strings.Contains(se.msg, "non-JSON") {
+		t.Fatalf("unexpected error %d %q", se.code, se.msg)
+	}
+}
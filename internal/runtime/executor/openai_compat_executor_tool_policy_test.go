@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestOpenAICompatExecutorNonStreamToolPolicyRequestsJSON(t *testing.T) {
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		if gjson.GetBytes(gotBody, "stream").Bool() {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {\"id\":\"chatcmpl-1\",\"object\":\"chat.completion.chunk\",\"choices\":[]}\n\ndata: [DONE]\n\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"compat-model","choices":[{"index":0,"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"lookup","arguments":"{}"}}]},"finish_reason":"tool_calls"}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{StreamingToolCallPolicy: map[string]string{"compat-policy": config.StreamingToolCallPolicyNonStream}}
+	manager := cliproxyauth.NewManager(nil, nil, nil)
+	manager.SetConfig(cfg)
+	manager.RegisterExecutor(NewOpenAICompatExecutor("compat-policy", cfg))
+	auth := &cliproxyauth.Auth{ID: "compat-policy-auth", Provider: "compat-policy", Attributes: map[string]string{
+		"base_url": server.URL,
+		"api_key":  "sk-test",
+	}}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(auth.ID, "compat-policy", []*registry.ModelInfo{{ID: "compat-model"}})
+	t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+
+	payload := []byte(`{"model":"compat-model","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"hi"}],"tools":[{"type":"function","function":{"name":"lookup","parameters":{"type":"object"}}}]}`)
+	req := cliproxyexecutor.Request{Model: "compat-model", Payload: payload}
+	opts := cliproxyexecutor.Options{Stream: true, SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload}
+
+	result, err := manager.ExecuteStream(context.Background(), []string{"compat-policy"}, req, opts)
+	if err != nil {
+		t.Fatalf("execute stream error: %v", err)
+	}
+	var chunks [][]byte
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("chunk error: %v", chunk.Err)
+		}
+		chunks = append(chunks, chunk.Payload)
+	}
+
+	if gjson.GetBytes(gotBody, "stream").Bool() {
+		t.Fatalf("upstream request still streams: %s", gotBody)
+	}
+	if gjson.GetBytes(gotBody, "stream_options").Exists() {
+		t.Fatalf("upstream request kept stream_options: %s", gotBody)
+	}
+	if len(chunks) != 1 {
+		t.Fatalf("chunks = %d, want 1", len(chunks))
+	}
+	if got := gjson.GetBytes(chunks[0], "choices.0.delta.tool_calls.0.function.name").String(); got != "lookup" {
+		t.Fatalf("replayed tool call name = %q, want lookup; chunk=%s", got, chunks[0])
+	}
+}
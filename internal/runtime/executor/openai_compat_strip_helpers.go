@@ -0,0 +1,49 @@
+package executor
+
+import (
+	"strings"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// openAICompatStripFieldsAttr names the auth attribute listing request body paths that an
+// OpenAI-compatible provider rejects, separated by commas.
+const openAICompatStripFieldsAttr = "strip_fields"
+
+// openAICompatStripFields returns the body paths to remove before sending a request to the
+// provider. Paths use gjson/sjson syntax, so nested fields such as
+// "response_format.json_schema" are addressed with dots.
+func openAICompatStripFields(auth *cliproxyauth.Auth) []string {
+	if auth == nil || auth.Attributes == nil {
+		return nil
+	}
+	raw := strings.TrimSpace(auth.Attributes[openAICompatStripFieldsAttr])
+	if raw == "" {
+		return nil
+	}
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// stripPayloadFields deletes each path from payload, ignoring paths that are absent. Every
+// removed field is logged at debug level with the provider and model it was stripped for.
+func stripPayloadFields(payload []byte, fields []string, provider, model string) []byte {
+	for _, field := range fields {
+		if !gjson.GetBytes(payload, field).Exists() {
+			continue
+		}
+		if updated, errDelete := sjson.DeleteBytes(payload, field); errDelete == nil {
+			payload = updated
+			log.Debugf("openai compat executor: removed %s unsupported by provider %s for model %s", field, provider, model)
+		}
+	}
+	return payload
+}
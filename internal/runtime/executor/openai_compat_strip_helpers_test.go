@@ -0,0 +1,82 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/tidwall/gjson"
+)
+
+func TestOpenAICompatExecutorStripsConfiguredFields(t *testing.T) {
+	hook := test.NewLocal(log.StandardLogger())
+	defer hook.Reset()
+	previousLevel := log.GetLevel()
+	log.SetLevel(log.DebugLevel)
+	defer log.SetLevel(previousLevel)
+
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	auth := &cliproxyauth.Auth{Provider: "compat-provider", Attributes: map[string]string{
+		"base_url":     server.URL + "/v1",
+		"api_key":      "test",
+		"strip_fields": "logprobs, response_format.json_schema.strict,missing.path",
+	}}
+	payload := []byte(`{"model":"compat-model","messages":[{"role":"user","content":"hi"}],"logprobs":true,"response_format":{"type":"json_schema","json_schema":{"name":"out","strict":true}}}`)
+	_, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "compat-model",
+		Payload: payload,
+	}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("openai"),
+	})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gjson.GetBytes(gotBody, "logprobs").Exists() {
+		t.Fatalf("logprobs should be stripped: %s", gotBody)
+	}
+	if gjson.GetBytes(gotBody, "response_format.json_schema.strict").Exists() {
+		t.Fatalf("nested strict should be stripped: %s", gotBody)
+	}
+	if got := gjson.GetBytes(gotBody, "response_format.json_schema.name").String(); got != "out" {
+		t.Fatalf("response_format.json_schema.name = %q, want %q", got, "out")
+	}
+	if !gjson.GetBytes(gotBody, "messages").Exists() {
+		t.Fatalf("messages should be kept: %s", gotBody)
+	}
+
+	for _, field := range []string{"logprobs", "response_format.json_schema.strict"} {
+		want := "openai compat executor: removed " + field + " unsupported by provider compat-provider for model compat-model"
+		found := false
+		for _, entry := range hook.AllEntries() {
+			if entry.Level == log.DebugLevel && entry.Message == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("expected debug log %q", want)
+		}
+	}
+	for _, entry := range hook.AllEntries() {
+		if strings.Contains(entry.Message, "missing.path") {
+			t.Fatalf("absent field should not be logged: %q", entry.Message)
+		}
+	}
+}
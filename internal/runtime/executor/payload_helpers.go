@@ -151,6 +151,89 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 	return out
 }
 
+// payloadPassthroughStrictProtocols lists upstream protocols that reject unknown keys;
+// passthrough fields are stripped for them unless payload.passthrough.allow-protocols
+// opts them in.
+var payloadPassthroughStrictProtocols = map[string]struct{}{
+	"gemini":      {},
+	"antigravity": {},
+	"claude":      {},
+	"codex":       {},
+}
+
+// applyPayloadPassthrough carries top-level source fields that start with the configured
+// passthrough prefix into payload (relative to root), renamed without the prefix and with
+// their raw JSON value untouched. Prefixed keys a translator copied through verbatim are
+// always removed. Passthrough fields never replace keys already present in payload and
+// never re-add a field a matching payload filter rule removes, so translated fields and
+// payload rules win over client extensions.
+func applyPayloadPassthrough(cfg *config.Config, model, protocol, root string, payload, source []byte, requestedModel string) []byte {
+	if cfg == nil || !cfg.Payload.Passthrough.Enable || len(payload) == 0 || len(source) == 0 {
+		return payload
+	}
+	passthrough := cfg.Payload.Passthrough
+	prefix := passthrough.EffectivePrefix()
+	allowed := payloadPassthroughAllowed(passthrough, protocol)
+	filtered := payloadFilteredPaths(cfg.Payload.Filter, protocol, root, payloadModelCandidates(strings.TrimSpace(model), strings.TrimSpace(requestedModel)))
+	out := payload
+	gjson.ParseBytes(source).ForEach(func(key, value gjson.Result) bool {
+		name := key.String()
+		if !strings.HasPrefix(name, prefix) {
+			return true
+		}
+		if updated, errDel := sjson.DeleteBytes(out, buildPayloadPath(root, gjson.Escape(name))); errDel == nil {
+			out = updated
+		}
+		field := strings.TrimPrefix(name, prefix)
+		if !allowed || field == "" {
+			return true
+		}
+		fullPath := buildPayloadPath(root, gjson.Escape(field))
+		if _, ok := filtered[fullPath]; ok {
+			return true
+		}
+		if gjson.GetBytes(out, fullPath).Exists() {
+			return true
+		}
+		if updated, errSet := sjson.SetRawBytes(out, fullPath, []byte(value.Raw)); errSet == nil {
+			out = updated
+		}
+		return true
+	})
+	return out
+}
+
+// payloadFilteredPaths returns the full paths removed by filter rules matching the model
+// candidates and protocol.
+func payloadFilteredPaths(rules []config.PayloadFilterRule, protocol, root string, candidates []string) map[string]struct{} {
+	paths := make(map[string]struct{})
+	for i := range rules {
+		rule := &rules[i]
+		if !payloadModelRulesMatch(rule.Models, protocol, candidates) {
+			continue
+		}
+		for _, path := range rule.Params {
+			if fullPath := buildPayloadPath(root, path); fullPath != "" {
+				paths[fullPath] = struct{}{}
+			}
+		}
+	}
+	return paths
+}
+
+func payloadPassthroughAllowed(passthrough config.PayloadPassthroughConfig, protocol string) bool {
+	protocol = strings.ToLower(strings.TrimSpace(protocol))
+	if _, strict := payloadPassthroughStrictProtocols[protocol]; !strict {
+		return true
+	}
+	for _, allowed := range passthrough.AllowProtocols {
+		if strings.EqualFold(strings.TrimSpace(allowed), protocol) {
+			return true
+		}
+	}
+	return false
+}
+
 func payloadModelRulesMatch(rules []config.PayloadModelRule, protocol string, models []string) bool {
 	if len(rules) == 0 || len(models) == 0 {
 		return false
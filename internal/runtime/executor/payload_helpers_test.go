@@ -0,0 +1,84 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func passthroughConfig(allow ...string) *config.Config {
+	return &config.Config{Payload: config.PayloadConfig{Passthrough: config.PayloadPassthroughConfig{
+		Enable:         true,
+		AllowProtocols: allow,
+	}}}
+}
+
+func TestApplyPayloadPassthroughCopiesPrefixedFields(t *testing.T) {
+	source := []byte(`{"model":"m","x_passthrough_metadata":{"tenant":"a","tags":[1,2]},"x_passthrough_store":false}`)
+	payload := []byte(`{"model":"m","x_passthrough_store":false}`)
+
+	out := applyPayloadPassthrough(passthroughConfig(), "m", "openai", "", payload, source, "")
+
+	if got := gjson.GetBytes(out, "metadata").Raw; got != `{"tenant":"a","tags":[1,2]}` {
+		t.Fatalf("metadata = %s, want verbatim copy; out=%s", got, out)
+	}
+	if got := gjson.GetBytes(out, "store"); !got.Exists() || got.Bool() {
+		t.Fatalf("store = %s, want false; out=%s", got.Raw, out)
+	}
+	if gjson.GetBytes(out, "x_passthrough_store").Exists() {
+		t.Fatalf("prefixed key left in payload: %s", out)
+	}
+}
+
+func TestApplyPayloadPassthroughKeepsExistingFields(t *testing.T) {
+	source := []byte(`{"x_passthrough_model":"evil"}`)
+	payload := []byte(`{"model":"m"}`)
+
+	out := applyPayloadPassthrough(passthroughConfig(), "m", "openai", "", payload, source, "")
+
+	if got := gjson.GetBytes(out, "model").String(); got != "m" {
+		t.Fatalf("model = %q, want m", got)
+	}
+}
+
+func TestApplyPayloadPassthroughSkipsFilteredFields(t *testing.T) {
+	cfg := passthroughConfig()
+	cfg.Payload.Filter = []config.PayloadFilterRule{{
+		Models: []config.PayloadModelRule{{Name: "m"}},
+		Params: []string{"store"},
+	}}
+	source := []byte(`{"model":"m","store":true,"x_passthrough_store":true,"x_passthrough_metadata":{"tenant":"a"}}`)
+	payload := applyPayloadConfigWithRoot(cfg, "m", "openai", "", []byte(`{"model":"m","store":true}`), nil, "")
+
+	out := applyPayloadPassthrough(cfg, "m", "openai", "", payload, source, "")
+	if gjson.GetBytes(out, "store").Exists() {
+		t.Fatalf("passthrough re-added a filtered field: %s", out)
+	}
+	if got := gjson.GetBytes(out, "metadata.tenant").String(); got != "a" {
+		t.Fatalf("unfiltered passthrough field lost: %s", out)
+	}
+}
+
+func TestApplyPayloadPassthroughStrictProtocols(t *testing.T) {
+	source := []byte(`{"x_passthrough_metadata":{"tenant":"a"}}`)
+	payload := []byte(`{"request":{"contents":[],"x_passthrough_metadata":{"tenant":"a"}}}`)
+
+	out := applyPayloadPassthrough(passthroughConfig(), "m", "gemini", "request", payload, source, "")
+	if gjson.GetBytes(out, "request.metadata").Exists() || gjson.GetBytes(out, "request.x_passthrough_metadata").Exists() {
+		t.Fatalf("passthrough field reached strict protocol: %s", out)
+	}
+
+	out = applyPayloadPassthrough(passthroughConfig("gemini"), "m", "gemini", "request", payload, source, "")
+	if got := gjson.GetBytes(out, "request.metadata.tenant").String(); got != "a" {
+		t.Fatalf("allow-listed protocol lost passthrough field: %s", out)
+	}
+}
+
+func TestApplyPayloadPassthroughDisabled(t *testing.T) {
+	payload := []byte(`{"model":"m"}`)
+	out := applyPayloadPassthrough(&config.Config{}, "m", "openai", "", payload, []byte(`{"x_passthrough_metadata":{}}`), "")
+	if string(out) != string(payload) {
+		t.Fatalf("payload changed while passthrough disabled: %s", out)
+	}
+}
@@ -0,0 +1,53 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// pingTimeout bounds a credential health check.
+const pingTimeout = 30 * time.Second
+
+// pingErrorBodyLimit caps how much of a failed ping response is read for the error message.
+const pingErrorBodyLimit = 64 << 10
+
+// httpRequester is satisfied by executors that inject credentials into raw HTTP requests.
+type httpRequester interface {
+	HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error)
+}
+
+// newPingRequest builds the bodyless GET used by credential health checks.
+func newPingRequest(ctx context.Context, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
+
+// pingEndpoint sends req through exec and maps a non-2xx response to a statusErr.
+func pingEndpoint(ctx context.Context, exec httpRequester, auth *cliproxyauth.Auth, req *http.Request) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	resp, err := exec.HttpRequest(ctx, auth, req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("ping: close response body error: %v", errClose)
+		}
+	}()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, pingErrorBodyLimit))
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, pingErrorBodyLimit))
+	return statusErr{code: resp.StatusCode, msg: upstreamErrorMessage(resp.StatusCode, resp.Header.Get("Content-Type"), body), upstream: true}
+}
@@ -0,0 +1,64 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestOpenAICompatExecutorPing(t *testing.T) {
+	var gotPath, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		if gotAuth != "Bearer good" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"message":"invalid api key"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	var _ cliproxyauth.Pinger = executor
+
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": server.URL + "/v1", "api_key": "good"}}
+	if err := executor.Ping(context.Background(), auth); err != nil {
+		t.Fatalf("Ping error = %v", err)
+	}
+	if gotPath != "/v1/models" {
+		t.Fatalf("path = %q, want /v1/models", gotPath)
+	}
+
+	auth.Attributes["api_key"] = "bad"
+	err := executor.Ping(context.Background(), auth)
+	var se statusErr
+	if !errors.As(err, &se) || se.code != http.StatusUnauthorized {
+		t.Fatalf("Ping error = %v, want 401 statusErr", err)
+	}
+}
+
+func TestExecutorsImplementPinger(t *testing.T) {
+	cfg := &config.Config{}
+	pingers := []cliproxyauth.ProviderExecutor{
+		NewCodexAutoExecutor(cfg),
+		NewCodexExecutor(cfg),
+		NewGeminiExecutor(cfg),
+		NewGeminiVertexExecutor(cfg),
+		NewKimiExecutor(cfg),
+		NewIFlowExecutor(cfg),
+		NewOpenAICompatExecutor("openai-compatibility", cfg),
+	}
+	for _, exec := range pingers {
+		if _, ok := exec.(cliproxyauth.Pinger); !ok {
+			t.Fatalf("%T does not implement Pinger", exec)
+		}
+	}
+}
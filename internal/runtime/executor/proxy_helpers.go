@@ -1,9 +1,16 @@
 package executor
 
 import (
+	"bufio"
+	"container/list"
 	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
@@ -17,6 +24,8 @@ import (
 // 2. Use cfg.ProxyURL if auth proxy is not configured
 // 3. Use RoundTripper from context if neither are configured
 //
+// Proxy transports are shared per proxy URL and cfg.HTTPClient pool settings.
+//
 // Parameters:
 //   - ctx: The context containing optional RoundTripper
 //   - cfg: The application configuration
@@ -42,9 +51,11 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 		proxyURL = strings.TrimSpace(cfg.ProxyURL)
 	}
 
+	tuning := httpClientTuningFor(cfg, auth)
+
 	// If we have a proxy URL configured, set up the transport
 	if proxyURL != "" {
-		transport := buildProxyTransport(proxyURL)
+		transport := cachedProxyTransport(proxyURL, tuning)
 		if transport != nil {
 			httpClient.Transport = transport
 			return httpClient
@@ -56,11 +67,189 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 	// Priority 3: Use RoundTripper from context (typically from RoundTripperFor)
 	if rt, ok := ctx.Value("cliproxy.roundtripper").(http.RoundTripper); ok && rt != nil {
 		httpClient.Transport = rt
+		return httpClient
+	}
+
+	// Without a proxy or context transport, only replace the default transport when pool
+	// tuning is configured.
+	if !tuning.IsZero() {
+		httpClient.Transport = cachedProxyTransport("", tuning)
 	}
 
 	return httpClient
 }
 
+// httpClientTuningFor returns the pool settings for requests made with auth. The idle pool
+// per host is raised to the provider's warmup-connections count so warmed connections
+// are kept instead of being closed by the default limit of two.
+func httpClientTuningFor(cfg *config.Config, auth *cliproxyauth.Auth) config.HTTPClientConfig {
+	if cfg == nil {
+		return config.HTTPClientConfig{}
+	}
+	tuning := cfg.HTTPClient
+	if auth == nil || len(cfg.WarmupConnections) == 0 || tuning.DisableKeepAlives {
+		return tuning
+	}
+	limit := tuning.MaxIdleConnsPerHost
+	if limit <= 0 {
+		limit = http.DefaultMaxIdleConnsPerHost
+	}
+	if warm := cfg.WarmupConnections[strings.ToLower(strings.TrimSpace(auth.Provider))]; warm > limit {
+		tuning.MaxIdleConnsPerHost = warm
+	}
+	return tuning
+}
+
+// proxyTransportKey identifies a shared transport by proxy URL and pool settings.
+type proxyTransportKey struct {
+	proxyURL string
+	tuning   config.HTTPClientConfig
+}
+
+// maxCachedProxyTransports bounds the shared transport cache so per-auth proxy URLs cannot
+// grow it without limit.
+const maxCachedProxyTransports = 64
+
+type proxyTransportEntry struct {
+	key       proxyTransportKey
+	transport *http.Transport
+	// http11 is the HTTP/1.1-only clone of transport used by Antigravity, built on first use.
+	http11 *http.Transport
+}
+
+// closeIdleConnections closes the idle connections of the entry's transports.
+func (e *proxyTransportEntry) closeIdleConnections() {
+	e.transport.CloseIdleConnections()
+	if e.http11 != nil {
+		e.http11.CloseIdleConnections()
+	}
+}
+
+// proxyTransportLRU holds shared transports, evicting the least recently used one beyond its
+// size. Evicted and reset transports have their idle connections closed; requests still in
+// flight on them finish normally.
+type proxyTransportLRU struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[proxyTransportKey]*list.Element
+}
+
+func newProxyTransportLRU(size int) *proxyTransportLRU {
+	return &proxyTransportLRU{size: size, order: list.New(), entries: make(map[proxyTransportKey]*list.Element)}
+}
+
+var proxyTransports = newProxyTransportLRU(maxCachedProxyTransports)
+
+func (c *proxyTransportLRU) get(key proxyTransportKey) *http.Transport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*proxyTransportEntry).transport
+}
+
+// add stores transport under key unless another caller stored one first, and returns the
+// transport to use.
+func (c *proxyTransportLRU) add(key proxyTransportKey, transport *http.Transport) *http.Transport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*proxyTransportEntry).transport
+	}
+	c.entries[key] = c.order.PushFront(&proxyTransportEntry{key: key, transport: transport})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		entry := oldest.Value.(*proxyTransportEntry)
+		delete(c.entries, entry.key)
+		entry.closeIdleConnections()
+	}
+	return transport
+}
+
+// http11 returns the HTTP/1.1 clone of a cached transport, building it with clone on first
+// use. The clone lives in the transport's entry, so eviction and reset close it as well. ok is
+// false when transport is not in the cache.
+func (c *proxyTransportLRU) http11(transport *http.Transport, clone func(*http.Transport) *http.Transport) (_ *http.Transport, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, elem := range c.entries {
+		entry := elem.Value.(*proxyTransportEntry)
+		if entry.transport != transport {
+			continue
+		}
+		if entry.http11 == nil {
+			entry.http11 = clone(transport)
+		}
+		return entry.http11, true
+	}
+	return nil, false
+}
+
+// reset drops every cached transport and closes its idle connections.
+func (c *proxyTransportLRU) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, elem := range c.entries {
+		elem.Value.(*proxyTransportEntry).closeIdleConnections()
+	}
+	c.order.Init()
+	c.entries = make(map[proxyTransportKey]*list.Element)
+}
+
+// ResetProxyTransports drops the shared upstream transports and closes their idle connections,
+// so the next requests build transports from the current proxy and http-client settings. It is
+// called when a config reload changes those settings.
+func ResetProxyTransports() {
+	proxyTransports.reset()
+}
+
+// cachedProxyTransport returns a shared transport for the proxy URL and pool settings so
+// connections are reused across requests instead of being reopened per client. An empty
+// proxy URL yields a tuned clone of http.DefaultTransport.
+func cachedProxyTransport(proxyURL string, tuning config.HTTPClientConfig) *http.Transport {
+	key := proxyTransportKey{proxyURL: proxyURL, tuning: tuning}
+	if transport := proxyTransports.get(key); transport != nil {
+		return transport
+	}
+
+	var transport *http.Transport
+	if proxyURL == "" {
+		if base, ok := http.DefaultTransport.(*http.Transport); ok && base != nil {
+			transport = base.Clone()
+		} else {
+			transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+		}
+	} else {
+		transport = buildProxyTransport(proxyURL)
+		if transport == nil {
+			return nil
+		}
+	}
+	applyHTTPClientTuning(transport, tuning)
+	return proxyTransports.add(key, transport)
+}
+
+// applyHTTPClientTuning copies the configured pool settings onto transport, leaving
+// unset values at their defaults.
+func applyHTTPClientTuning(transport *http.Transport, tuning config.HTTPClientConfig) {
+	if transport == nil {
+		return
+	}
+	if tuning.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = tuning.MaxIdleConnsPerHost
+	}
+	if tuning.IdleConnTimeoutSeconds > 0 {
+		transport.IdleConnTimeout = time.Duration(tuning.IdleConnTimeoutSeconds) * time.Second
+	}
+	transport.DisableKeepAlives = tuning.DisableKeepAlives
+}
+
 // buildProxyTransport creates an HTTP transport configured for the given proxy URL.
 // It supports SOCKS5, HTTP, and HTTPS proxy protocols.
 //
@@ -70,10 +259,86 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 // Returns:
 //   - *http.Transport: A configured transport, or nil if the proxy URL is invalid
 func buildProxyTransport(proxyURL string) *http.Transport {
-	transport, _, errBuild := proxyutil.BuildHTTPTransport(proxyURL)
+	transport, mode, errBuild := proxyutil.BuildHTTPTransport(proxyURL)
 	if errBuild != nil {
 		log.Errorf("%v", errBuild)
 		return nil
 	}
+	if transport != nil && mode == proxyutil.ModeProxy {
+		if setting, errParse := proxyutil.Parse(proxyURL); errParse == nil {
+			dial := transport.DialContext
+			if dial == nil {
+				dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+			}
+			transport.DialContext = wrapProxyDial("http", setting.URL, dial)
+		}
+	}
 	return transport
 }
+
+// wrapProxyDial prefixes errors from dial, which connects through the proxy, with the proxy
+// scheme and host so proxy misconfiguration is distinguishable from upstream outages. The
+// proxy credentials are never included.
+func wrapProxyDial(kind string, proxyURL *url.URL, dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if proxyURL == nil || dial == nil {
+		return dial
+	}
+	via := proxyURL.Scheme + "://" + proxyURL.Host
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, errDial := dial(ctx, network, addr)
+		if errDial != nil {
+			return nil, fmt.Errorf("%s proxy dial failed via %s: %w", kind, via, errDial)
+		}
+		return conn, nil
+	}
+}
+
+// httpProxyConnectDial returns a dial function that tunnels to addr through an HTTP proxy
+// with CONNECT. Unlike the websocket library's built-in proxy support, a rejected CONNECT
+// (for example 407) is returned from the dial itself, so wrapProxyDial can name the proxy.
+func httpProxyConnectDial(proxyURL *url.URL, dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, errDial := dial(ctx, network, proxyAddr)
+		if errDial != nil {
+			return nil, errDial
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+		connectReq := &http.Request{
+			Method: http.MethodConnect,
+			URL:    &url.URL{Opaque: addr},
+			Host:   addr,
+			Header: make(http.Header),
+		}
+		if user := proxyURL.User; user != nil {
+			password, _ := user.Password()
+			credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+			connectReq.Header.Set("Proxy-Authorization", "Basic "+credentials)
+		}
+		if errWrite := connectReq.Write(conn); errWrite != nil {
+			_ = conn.Close()
+			return nil, errWrite
+		}
+		resp, errRead := http.ReadResponse(bufio.NewReader(conn), connectReq)
+		if errRead != nil {
+			_ = conn.Close()
+			return nil, errRead
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			_ = conn.Close()
+			return nil, fmt.Errorf("CONNECT %s rejected: %s", addr, resp.Status)
+		}
+		_ = conn.SetDeadline(time.Time{})
+		return conn, nil
+	}
+}
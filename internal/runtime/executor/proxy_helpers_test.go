@@ -1,10 +1,17 @@
 package executor
 
 import (
+	"bufio"
 	"context"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
@@ -28,3 +35,288 @@ func TestNewProxyAwareHTTPClientDirectBypassesGlobalProxy(t *testing.T) {
 		t.Fatal("expected direct transport to disable proxy function")
 	}
 }
+
+func TestNewProxyAwareHTTPClientReusesProxyTransport(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{}
+	auth := &cliproxyauth.Auth{ProxyURL: "http://reuse-proxy.example.com:8080"}
+	first := newProxyAwareHTTPClient(context.Background(), cfg, auth, 0)
+	second := newProxyAwareHTTPClient(context.Background(), cfg, auth, 0)
+	if first.Transport == nil || first.Transport != second.Transport {
+		t.Fatalf("transports = %p and %p, want the same shared transport", first.Transport, second.Transport)
+	}
+
+	other := newProxyAwareHTTPClient(context.Background(), cfg, &cliproxyauth.Auth{ProxyURL: "http://other-proxy.example.com:8080"}, 0)
+	if other.Transport == first.Transport {
+		t.Fatal("expected a different transport for a different proxy URL")
+	}
+}
+
+func TestNewProxyAwareHTTPClientAppliesPoolTuning(t *testing.T) {
+	t.Parallel()
+
+	untuned := newProxyAwareHTTPClient(context.Background(), &config.Config{}, nil, 0)
+	if untuned.Transport != nil {
+		t.Fatalf("transport = %T, want nil to keep http.DefaultTransport", untuned.Transport)
+	}
+
+	tuning := config.HTTPClientConfig{MaxIdleConnsPerHost: 32, IdleConnTimeoutSeconds: 45, DisableKeepAlives: true}
+	for _, auth := range []*cliproxyauth.Auth{nil, {ProxyURL: "http://tuned-proxy.example.com:8080"}} {
+		client := newProxyAwareHTTPClient(context.Background(), &config.Config{HTTPClient: tuning}, auth, 0)
+		transport, ok := client.Transport.(*http.Transport)
+		if !ok {
+			t.Fatalf("transport type = %T, want *http.Transport", client.Transport)
+		}
+		if transport.MaxIdleConnsPerHost != 32 {
+			t.Fatalf("MaxIdleConnsPerHost = %d, want 32", transport.MaxIdleConnsPerHost)
+		}
+		if transport.IdleConnTimeout != 45*time.Second {
+			t.Fatalf("IdleConnTimeout = %v, want 45s", transport.IdleConnTimeout)
+		}
+		if !transport.DisableKeepAlives {
+			t.Fatal("expected keep-alives to be disabled")
+		}
+	}
+}
+
+func closedProxyAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+	return addr
+}
+
+func TestProxyDialErrorsNameProxyWithoutCredentials(t *testing.T) {
+	addr := closedProxyAddr(t)
+	auth := &cliproxyauth.Auth{ProxyURL: "socks5://user:s3cret@" + addr}
+
+	client := newProxyAwareHTTPClient(context.Background(), &config.Config{}, auth, 5*time.Second)
+	resp, errHTTP := client.Get("http://upstream.invalid/")
+	if resp != nil {
+		_ = resp.Body.Close()
+	}
+	dialer := newProxyAwareWebsocketDialer(&config.Config{}, auth)
+	conn, wsResp, errWS := dialer.Dial("ws://upstream.invalid/", nil)
+	if conn != nil {
+		_ = conn.Close()
+	}
+	closeHTTPResponseBody(wsResp, "close test handshake body")
+
+	for kind, err := range map[string]error{"http": errHTTP, "websocket": errWS} {
+		if err == nil {
+			t.Fatalf("%s: expected dial error", kind)
+		}
+		want := kind + " proxy dial failed via socks5://" + addr
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: error = %q, want it to contain %q", kind, err, want)
+		}
+		if strings.Contains(err.Error(), "s3cret") {
+			t.Fatalf("%s: error leaks proxy credentials: %q", kind, err)
+		}
+	}
+}
+
+func TestWebsocketProxyConnectRejectionNamesProxy(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+	go func() {
+		conn, errAccept := listener.Accept()
+		if errAccept != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		req, errRead := http.ReadRequest(bufio.NewReader(conn))
+		if errRead != nil || req.Method != http.MethodConnect {
+			return
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\nContent-Length: 0\r\n\r\n"))
+	}()
+	addr := listener.Addr().String()
+	auth := &cliproxyauth.Auth{ProxyURL: "http://user:s3cret@" + addr}
+
+	dialer := newProxyAwareWebsocketDialer(&config.Config{}, auth)
+	conn, resp, errWS := dialer.Dial("ws://upstream.invalid/", nil)
+	if conn != nil {
+		_ = conn.Close()
+	}
+	closeHTTPResponseBody(resp, "close test handshake body")
+
+	if errWS == nil {
+		t.Fatal("expected CONNECT rejection error")
+	}
+	for _, want := range []string{"websocket proxy dial failed via http://" + addr, "407"} {
+		if !strings.Contains(errWS.Error(), want) {
+			t.Fatalf("error = %q, want it to contain %q", errWS, want)
+		}
+	}
+	if strings.Contains(errWS.Error(), "s3cret") {
+		t.Fatalf("error leaks proxy credentials: %q", errWS)
+	}
+}
+
+func TestWebsocketDialerTunnelsThroughHTTPProxy(t *testing.T) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		msgType, payload, errRead := conn.ReadMessage()
+		if errRead != nil {
+			return
+		}
+		_ = conn.WriteMessage(msgType, payload)
+	}))
+	defer upstream.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+	proxyAuth := make(chan string, 1)
+	go func() {
+		client, errAccept := listener.Accept()
+		if errAccept != nil {
+			return
+		}
+		defer func() { _ = client.Close() }()
+		req, errRead := http.ReadRequest(bufio.NewReader(client))
+		if errRead != nil || req.Method != http.MethodConnect {
+			return
+		}
+		proxyAuth <- req.Header.Get("Proxy-Authorization")
+		target, errDial := net.Dial("tcp", req.Host)
+		if errDial != nil {
+			return
+		}
+		defer func() { _ = target.Close() }()
+		_, _ = client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+		go func() { _, _ = io.Copy(target, client) }()
+		_, _ = io.Copy(client, target)
+	}()
+
+	auth := &cliproxyauth.Auth{ProxyURL: "http://user:pass@" + listener.Addr().String()}
+	dialer := newProxyAwareWebsocketDialer(&config.Config{}, auth)
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(upstream.URL, "http")+"/", nil)
+	closeHTTPResponseBody(resp, "close test handshake body")
+	if err != nil {
+		t.Fatalf("dial through proxy: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if got := <-proxyAuth; got != "Basic dXNlcjpwYXNz" {
+		t.Fatalf("Proxy-Authorization = %q, want basic credentials", got)
+	}
+	if err = conn.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, payload, errRead := conn.ReadMessage(); errRead != nil || string(payload) != "ping" {
+		t.Fatalf("echo = %q, %v", payload, errRead)
+	}
+}
+
+func TestProxyTransportLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	cache := newProxyTransportLRU(2)
+	keys := []proxyTransportKey{{proxyURL: "http://a:1"}, {proxyURL: "http://b:1"}, {proxyURL: "http://c:1"}}
+	first := cache.add(keys[0], &http.Transport{})
+	cache.add(keys[1], &http.Transport{})
+	if cache.get(keys[0]) != first {
+		t.Fatal("expected cached transport for the first key")
+	}
+	cache.add(keys[2], &http.Transport{})
+
+	if cache.get(keys[1]) != nil {
+		t.Fatal("expected the least recently used transport to be evicted")
+	}
+	if cache.get(keys[0]) != first || cache.get(keys[2]) == nil {
+		t.Fatal("expected recently used transports to stay cached")
+	}
+	if again := cache.add(keys[0], &http.Transport{}); again != first {
+		t.Fatal("expected add to keep the transport stored first")
+	}
+
+	cache.reset()
+	if cache.get(keys[0]) != nil || cache.order.Len() != 0 {
+		t.Fatal("expected reset to drop every transport")
+	}
+}
+
+func TestProxyTransportLRUClosesIdleConnectionsOnEviction(t *testing.T) {
+	t.Parallel()
+
+	closed := make(chan struct{}, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed <- struct{}{}
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+
+	cache := newProxyTransportLRU(1)
+	transport := cache.add(proxyTransportKey{proxyURL: "http://a:1"}, &http.Transport{})
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	cache.add(proxyTransportKey{proxyURL: "http://b:1"}, &http.Transport{})
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the evicted transport's idle connection to be closed")
+	}
+}
+
+func TestProxyTransportLRUClosesHTTP11CloneOnEviction(t *testing.T) {
+	t.Parallel()
+
+	closed := make(chan struct{}, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed <- struct{}{}
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+
+	cache := newProxyTransportLRU(1)
+	base := cache.add(proxyTransportKey{proxyURL: "http://a:1"}, &http.Transport{})
+	clone, ok := cache.http11(base, cloneTransportWithHTTP11)
+	if !ok || clone == nil || clone == base {
+		t.Fatalf("http11 clone = %p (cached %v), want a distinct clone of the cached transport", clone, ok)
+	}
+	if again, _ := cache.http11(base, cloneTransportWithHTTP11); again != clone {
+		t.Fatal("expected the HTTP/1.1 clone to be reused")
+	}
+	if _, ok = cache.http11(&http.Transport{}, cloneTransportWithHTTP11); ok {
+		t.Fatal("expected no clone for a transport outside the cache")
+	}
+
+	resp, err := (&http.Client{Transport: clone}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	cache.add(proxyTransportKey{proxyURL: "http://b:1"}, &http.Transport{})
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the evicted HTTP/1.1 clone's idle connection to be closed")
+	}
+}
@@ -0,0 +1,25 @@
+package executor
+
+import (
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// rawPassthroughFormat is the source format used for raw passthrough requests. No translators
+// are registered for it, so request and response payloads cross the executor untranslated.
+const rawPassthroughFormat = sdktranslator.Format("raw")
+
+// requestSourceFormat returns the format the client payload is translated from, or
+// rawPassthroughFormat when the handler marked the request for raw passthrough.
+func requestSourceFormat(opts cliproxyexecutor.Options) sdktranslator.Format {
+	if raw, _ := opts.Metadata[cliproxyexecutor.RawPassthroughMetadataKey].(bool); raw {
+		return rawPassthroughFormat
+	}
+	return opts.SourceFormat
+}
+
+// translatesFromForeignFormat reports whether a from payload is translated into to. Raw
+// passthrough payloads are already in the upstream format and count as the same format.
+func translatesFromForeignFormat(from, to sdktranslator.Format) bool {
+	return from != to && from != rawPassthroughFormat
+}
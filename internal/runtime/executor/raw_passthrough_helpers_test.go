@@ -0,0 +1,115 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestOpenAICompatExecutorRawPassthroughSkipsTranslation(t *testing.T) {
+	clientFormat := sdktranslator.FromString("raw-passthrough-test-client")
+	sdktranslator.Register(clientFormat, sdktranslator.FromString("openai"),
+		func(_ string, _ []byte, _ bool) []byte { return []byte(`{"translated":"request"}`) },
+		sdktranslator.ResponseTransform{
+			NonStream: func(context.Context, string, []byte, []byte, []byte, *any) []byte {
+				return []byte(`{"translated":"response"}`)
+			},
+		})
+
+	upstreamResponse := `{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"hi"}}]}`
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(upstreamResponse))
+	}))
+	defer server.Close()
+
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": server.URL + "/v1", "api_key": "test"}}
+	payload := []byte(`{"model":"gpt-raw","messages":[{"role":"user","content":"hello"}]}`)
+	execute := func(raw bool) cliproxyexecutor.Response {
+		t.Helper()
+		opts := cliproxyexecutor.Options{SourceFormat: clientFormat, OriginalRequest: payload}
+		if raw {
+			opts.Metadata = map[string]any{cliproxyexecutor.RawPassthroughMetadataKey: true}
+		}
+		resp, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "gpt-raw", Payload: payload}, opts)
+		if err != nil {
+			t.Fatalf("Execute(raw=%v) error: %v", raw, err)
+		}
+		return resp
+	}
+
+	resp := execute(false)
+	if gjson.GetBytes(gotBody, "translated").String() != "request" || string(resp.Payload) != `{"translated":"response"}` {
+		t.Fatalf("translated request = %s, response = %s; want test translators applied", gotBody, resp.Payload)
+	}
+
+	resp = execute(true)
+	if gjson.GetBytes(gotBody, "translated").Exists() || gjson.GetBytes(gotBody, "messages.0.content").String() != "hello" {
+		t.Fatalf("raw request body = %s, want client body forwarded untranslated", gotBody)
+	}
+	if string(resp.Payload) != upstreamResponse {
+		t.Fatalf("raw response = %s, want upstream response %s", resp.Payload, upstreamResponse)
+	}
+}
+
+func TestClaudeExecutorRawPassthroughNonStreamPublishesUsage(t *testing.T) {
+	// A pretty-printed body only parses as one JSON document, not as SSE lines.
+	upstreamResponse := "{\n  \"id\": \"msg_1\",\n  \"type\": \"message\",\n  \"content\": [{\"type\": \"text\", \"text\": \"hi\"}],\n  \"usage\": {\n    \"input_tokens\": 7,\n    \"output_tokens\": 3\n  }\n}"
+	html := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if html {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html>gateway</html>"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(upstreamResponse))
+	}))
+	defer server.Close()
+
+	plugin := &upstreamLatencyPlugin{authID: "claude-raw-usage", records: make(chan usage.Record, 1)}
+	usage.RegisterPlugin(plugin)
+
+	executor := NewClaudeExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: plugin.authID, Provider: "claude", Attributes: map[string]string{"api_key": "key", "base_url": server.URL}}
+	payload := []byte(`{"model":"claude-sonnet-4","max_tokens":16,"messages":[{"role":"user","content":"hello"}]}`)
+	opts := cliproxyexecutor.Options{
+		SourceFormat:    sdktranslator.FromString("openai"),
+		OriginalRequest: payload,
+		Metadata:        map[string]any{cliproxyexecutor.RawPassthroughMetadataKey: true},
+	}
+	resp, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "claude-sonnet-4", Payload: payload}, opts)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if string(resp.Payload) != upstreamResponse {
+		t.Fatalf("raw response = %s, want upstream response", resp.Payload)
+	}
+
+	select {
+	case record := <-plugin.records:
+		if record.Detail.InputTokens != 7 || record.Detail.OutputTokens != 3 {
+			t.Fatalf("usage = %+v, want 7 input and 3 output tokens", record.Detail)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for usage record")
+	}
+
+	html = true
+	if _, err = executor.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "claude-sonnet-4", Payload: payload}, opts); err == nil {
+		t.Fatal("expected a non-JSON upstream response to fail")
+	}
+}
@@ -0,0 +1,37 @@
+package executor
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// readUpstreamBody reads an upstream response body, enforcing cfg.Limits.MaxResponseBytes.
+// When the body exceeds the limit it returns the bytes read so far with a 502 statusErr.
+func readUpstreamBody(cfg *config.Config, body io.Reader) ([]byte, error) {
+	var limit int64
+	if cfg != nil {
+		limit = cfg.Limits.MaxResponseBytes
+	}
+	if limit <= 0 {
+		return io.ReadAll(body)
+	}
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return data, err
+	}
+	if int64(len(data)) > limit {
+		return data[:limit], statusErr{code: http.StatusBadGateway, msg: fmt.Sprintf("upstream response exceeded max bytes (%d)", limit)}
+	}
+	return data, nil
+}
+
+// streamScannerBufferSize returns the maximum line size for streaming scanners.
+func streamScannerBufferSize(cfg *config.Config) int {
+	if cfg == nil || cfg.Limits.StreamScannerBufferBytes <= 0 {
+		return streamScannerBuffer
+	}
+	return cfg.Limits.StreamScannerBufferBytes
+}
@@ -0,0 +1,101 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func TestGeminiExecutorEnforcesMaxResponseBytes(t *testing.T) {
+	body := `{"candidates":[{"content":{"role":"model","parts":[{"text":"` + strings.Repeat("x", 4096) + `"}]},"finishReason":"STOP"}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "test", "base_url": server.URL}}
+	req := cliproxyexecutor.Request{Model: "gemini-2.5-pro", Payload: []byte(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("gemini")}
+
+	within := NewGeminiExecutor(&config.Config{Limits: config.LimitsConfig{MaxResponseBytes: int64(len(body))}})
+	if _, err := within.Execute(context.Background(), auth, req, opts); err != nil {
+		t.Fatalf("Execute within limit: %v", err)
+	}
+
+	limited := NewGeminiExecutor(&config.Config{Limits: config.LimitsConfig{MaxResponseBytes: 1024}})
+	_, err := limited.Execute(context.Background(), auth, req, opts)
+	var se statusErr
+	if !errors.As(err, &se) || se.StatusCode() != http.StatusBadGateway {
+		t.Fatalf("error = %v, want 502 statusErr", err)
+	}
+	if !strings.Contains(se.Error(), "upstream response exceeded max bytes") {
+		t.Fatalf("error message = %q, want size limit message", se.Error())
+	}
+}
+
+func TestExecutorsEnforceMaxResponseBytes(t *testing.T) {
+	text := strings.Repeat("x", 4096)
+	cases := []struct {
+		name    string
+		body    string
+		execute func(cfg *config.Config, baseURL string) error
+	}{
+		{
+			name: "openai-compat",
+			body: `{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"` + text + `"},"finish_reason":"stop"}]}`,
+			execute: func(cfg *config.Config, baseURL string) error {
+				auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": baseURL, "api_key": "sk-test"}}
+				req := cliproxyexecutor.Request{Model: "compat-model", Payload: []byte(`{"model":"compat-model","messages":[{"role":"user","content":"hi"}]}`)}
+				_, err := NewOpenAICompatExecutor("compat", cfg).Execute(context.Background(), auth, req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+				return err
+			},
+		},
+		{
+			name: "claude",
+			body: `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"` + text + `"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`,
+			execute: func(cfg *config.Config, baseURL string) error {
+				auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": baseURL, "api_key": "sk-ant-test"}}
+				req := cliproxyexecutor.Request{Model: "claude-sonnet-4-5", Payload: []byte(`{"model":"claude-sonnet-4-5","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}`)}
+				_, err := NewClaudeExecutor(cfg).Execute(context.Background(), auth, req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")})
+				return err
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			if err := tc.execute(&config.Config{Limits: config.LimitsConfig{MaxResponseBytes: int64(len(tc.body))}}, server.URL); err != nil {
+				t.Fatalf("Execute within limit: %v", err)
+			}
+			err := tc.execute(&config.Config{Limits: config.LimitsConfig{MaxResponseBytes: 1024}}, server.URL)
+			var se statusErr
+			if !errors.As(err, &se) || se.StatusCode() != http.StatusBadGateway {
+				t.Fatalf("error = %v, want 502 statusErr", err)
+			}
+		})
+	}
+}
+
+func TestStreamScannerBufferSize(t *testing.T) {
+	if got := streamScannerBufferSize(nil); got != streamScannerBuffer {
+		t.Fatalf("default buffer = %d, want %d", got, streamScannerBuffer)
+	}
+	cfg := &config.Config{Limits: config.LimitsConfig{StreamScannerBufferBytes: 4096}}
+	if got := streamScannerBufferSize(cfg); got != 4096 {
+		t.Fatalf("configured buffer = %d, want 4096", got)
+	}
+}
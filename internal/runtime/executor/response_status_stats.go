@@ -0,0 +1,74 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// unknownResponseStatsKey labels responses whose provider or model was not recorded.
+const unknownResponseStatsKey = "unknown"
+
+var responseStatusStats = struct {
+	mu     sync.Mutex
+	counts map[string]map[string]map[int]int64
+}{counts: make(map[string]map[string]map[int]int64)}
+
+// recordResponseStatus counts an upstream response status for the provider/model carried in ctx.
+func recordResponseStatus(ctx context.Context, status int) {
+	if status <= 0 {
+		return
+	}
+	provider, model := cliproxyexecutor.ExecutionTarget(ctx)
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		provider = unknownResponseStatsKey
+	}
+	model = strings.TrimSpace(model)
+	if model == "" {
+		model = unknownResponseStatsKey
+	}
+
+	responseStatusStats.mu.Lock()
+	defer responseStatusStats.mu.Unlock()
+	models := responseStatusStats.counts[provider]
+	if models == nil {
+		models = make(map[string]map[int]int64)
+		responseStatusStats.counts[provider] = models
+	}
+	statuses := models[model]
+	if statuses == nil {
+		statuses = make(map[int]int64)
+		models[model] = statuses
+	}
+	statuses[status]++
+}
+
+// ResponseStatusStats returns a snapshot of upstream response status counts keyed by
+// provider, then model, then HTTP status code.
+func ResponseStatusStats() map[string]map[string]map[int]int64 {
+	responseStatusStats.mu.Lock()
+	defer responseStatusStats.mu.Unlock()
+	out := make(map[string]map[string]map[int]int64, len(responseStatusStats.counts))
+	for provider, models := range responseStatusStats.counts {
+		modelsCopy := make(map[string]map[int]int64, len(models))
+		for model, statuses := range models {
+			statusesCopy := make(map[int]int64, len(statuses))
+			for status, count := range statuses {
+				statusesCopy[status] = count
+			}
+			modelsCopy[model] = statusesCopy
+		}
+		out[provider] = modelsCopy
+	}
+	return out
+}
+
+// ResetResponseStatusStats clears all recorded upstream response status counts.
+func ResetResponseStatusStats() {
+	responseStatusStats.mu.Lock()
+	responseStatusStats.counts = make(map[string]map[string]map[int]int64)
+	responseStatusStats.mu.Unlock()
+}
@@ -0,0 +1,38 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestRecordAPIResponseMetadataCountsStatuses(t *testing.T) {
+	ResetResponseStatusStats()
+	t.Cleanup(ResetResponseStatusStats)
+
+	ctx := cliproxyexecutor.WithExecutionTarget(context.Background(), "Claude", "claude-sonnet-4")
+	recordAPIResponseMetadata(ctx, nil, http.StatusOK, nil)
+	recordAPIResponseMetadata(ctx, nil, http.StatusOK, nil)
+	recordAPIResponseMetadata(ctx, nil, http.StatusTooManyRequests, nil)
+
+	stats := ResponseStatusStats()
+	if got := stats["claude"]["claude-sonnet-4"][http.StatusOK]; got != 2 {
+		t.Fatalf("200 count = %d, want 2", got)
+	}
+	if got := stats["claude"]["claude-sonnet-4"][http.StatusTooManyRequests]; got != 1 {
+		t.Fatalf("429 count = %d, want 1", got)
+	}
+
+	// Snapshots are detached from the live counters.
+	stats["claude"]["claude-sonnet-4"][http.StatusOK] = 100
+	if got := ResponseStatusStats()["claude"]["claude-sonnet-4"][http.StatusOK]; got != 2 {
+		t.Fatalf("snapshot mutation leaked: 200 count = %d", got)
+	}
+
+	ResetResponseStatusStats()
+	if len(ResponseStatusStats()) != 0 {
+		t.Fatal("expected stats to be empty after reset")
+	}
+}
@@ -0,0 +1,60 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// streamKeepAlivePayload is the SSE comment emitted while waiting for the first upstream chunk.
+// Handlers write it to SSE clients verbatim instead of translating it.
+var streamKeepAlivePayload = []byte(": ping\n\n")
+
+// streamKeepAliveInterval returns the heartbeat interval used before the first chunk, or 0
+// when keep-alives are disabled or the client did not request SSE output.
+func streamKeepAliveInterval(cfg *config.Config, opts cliproxyexecutor.Options) time.Duration {
+	if cfg == nil || cfg.Streaming.KeepAliveSeconds <= 0 || opts.Alt != "" {
+		return 0
+	}
+	return time.Duration(cfg.Streaming.KeepAliveSeconds) * time.Second
+}
+
+// startStreamKeepAlive sends heartbeat chunks on out every interval until the returned stop
+// function is called or ctx is done. stop is idempotent and waits for the heartbeat goroutine
+// to exit, so callers may close out afterwards.
+func startStreamKeepAlive(ctx context.Context, interval time.Duration, out chan<- cliproxyexecutor.StreamChunk) func() {
+	if interval <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				select {
+				case <-ctx.Done():
+					return
+				case <-done:
+					return
+				case out <- cliproxyexecutor.StreamChunk{Payload: streamKeepAlivePayload}:
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-finished
+	}
+}
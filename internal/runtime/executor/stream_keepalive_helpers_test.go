@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func TestGeminiVertexExecuteStreamSendsKeepAliveBeforeFirstChunk(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(1500 * time.Millisecond)
+		_, _ = w.Write([]byte("data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"hi\"}]},\"finishReason\":\"STOP\"}]}\n\n"))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{SDKConfig: sdkconfig.SDKConfig{Streaming: sdkconfig.StreamingConfig{KeepAliveSeconds: 1}}}
+	executor := NewGeminiVertexExecutor(cfg)
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "test", "base_url": server.URL}}
+	result, err := executor.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "gemini-2.5-pro",
+		Payload: []byte(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("gemini"), Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+
+	var heartbeats, dataChunks int
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		if bytes.Equal(chunk.Payload, streamKeepAlivePayload) {
+			if dataChunks > 0 {
+				t.Fatal("heartbeat sent after data started flowing")
+			}
+			heartbeats++
+			continue
+		}
+		if len(chunk.Payload) > 0 {
+			dataChunks++
+		}
+	}
+	if heartbeats == 0 {
+		t.Fatal("expected a heartbeat before the first chunk")
+	}
+	if dataChunks == 0 {
+		t.Fatal("expected translated data after heartbeats")
+	}
+}
+
+func TestStartStreamKeepAliveStopsOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan cliproxyexecutor.StreamChunk)
+	stop := startStreamKeepAlive(ctx, 10*time.Millisecond, out)
+
+	select {
+	case chunk := <-out:
+		if !bytes.Equal(chunk.Payload, streamKeepAlivePayload) {
+			t.Fatalf("payload = %q, want heartbeat", chunk.Payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a heartbeat")
+	}
+
+	cancel()
+	stopped := make(chan struct{})
+	go func() {
+		stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("keep-alive did not stop after context cancellation")
+	}
+}
@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// defaultTokenCountCacheTTL applies when the cache is enabled without an explicit TTL.
+const defaultTokenCountCacheTTL = 30 * time.Second
+
+type tokenCountCacheEntry struct {
+	key       [sha256.Size]byte
+	count     int64
+	expiresAt time.Time
+}
+
+// tokenCountLRU caches CountTokens results for identical payloads.
+type tokenCountLRU struct {
+	mu      sync.Mutex
+	order   *list.List
+	entries map[[sha256.Size]byte]*list.Element
+}
+
+var tokenCountCache = &tokenCountLRU{
+	order:   list.New(),
+	entries: make(map[[sha256.Size]byte]*list.Element),
+}
+
+// tokenCountCacheKey hashes the provider, model and compacted payload.
+func tokenCountCacheKey(provider, model string, body []byte) [sha256.Size]byte {
+	normalized := body
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, body); err == nil {
+		normalized = compacted.Bytes()
+	}
+	h := sha256.New()
+	h.Write([]byte(provider))
+	h.Write([]byte{0})
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	h.Write(normalized)
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+func (c *tokenCountLRU) get(key [sha256.Size]byte, now time.Time) (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return 0, false
+	}
+	entry := elem.Value.(*tokenCountCacheEntry)
+	if now.After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return 0, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.count, true
+}
+
+func (c *tokenCountLRU) put(key [sha256.Size]byte, count int64, expiresAt time.Time, size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*tokenCountCacheEntry)
+		entry.count = count
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+	} else {
+		c.entries[key] = c.order.PushFront(&tokenCountCacheEntry{key: key, count: count, expiresAt: expiresAt})
+	}
+	for c.order.Len() > size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*tokenCountCacheEntry).key)
+	}
+}
+
+// cachedTokenCount returns a cached count for identical provider, model and body when the
+// token count cache is enabled, and otherwise runs count.
+func cachedTokenCount(cfg *config.Config, provider, model string, body []byte, count func() (int64, error)) (int64, error) {
+	if cfg == nil || cfg.TokenCountCache.Size <= 0 {
+		return count()
+	}
+	ttl := defaultTokenCountCacheTTL
+	if cfg.TokenCountCache.TTLSeconds > 0 {
+		ttl = time.Duration(cfg.TokenCountCache.TTLSeconds) * time.Second
+	}
+	key := tokenCountCacheKey(provider, model, body)
+	now := time.Now()
+	if cached, ok := tokenCountCache.get(key, now); ok {
+		return cached, nil
+	}
+	result, err := count()
+	if err != nil {
+		return result, err
+	}
+	tokenCountCache.put(key, result, now.Add(ttl), cfg.TokenCountCache.Size)
+	return result, nil
+}
+
+// defaultGeminiTokenCountCacheSize bounds cached Gemini and Vertex counts when
+// token-count-cache.size is not set.
+const defaultGeminiTokenCountCacheSize = 1024
+
+// cachedGeminiTokenCount returns the cached total for a Gemini or Vertex countTokens body
+// when gemini.count-tokens-cache-seconds is set. body must be the final upstream payload so
+// that stripped fields and thinking adjustments are part of the key.
+func cachedGeminiTokenCount(cfg *config.Config, provider, model string, body []byte) (int64, bool) {
+	if cfg == nil || cfg.Gemini.CountTokensCacheSeconds <= 0 {
+		return 0, false
+	}
+	return tokenCountCache.get(tokenCountCacheKey(provider, model, body), time.Now())
+}
+
+// storeGeminiTokenCount records an upstream countTokens total for cachedGeminiTokenCount.
+func storeGeminiTokenCount(cfg *config.Config, provider, model string, body []byte, count int64) {
+	if cfg == nil || cfg.Gemini.CountTokensCacheSeconds <= 0 {
+		return
+	}
+	size := cfg.TokenCountCache.Size
+	if size <= 0 {
+		size = defaultGeminiTokenCountCacheSize
+	}
+	ttl := time.Duration(cfg.Gemini.CountTokensCacheSeconds) * time.Second
+	tokenCountCache.put(tokenCountCacheKey(provider, model, body), count, time.Now().Add(ttl), size)
+}
+
+// geminiTokenCountBody builds the countTokens response body for a cached total.
+func geminiTokenCountBody(count int64) []byte {
+	return []byte(`{"totalTokens":` + strconv.FormatInt(count, 10) + `}`)
+}
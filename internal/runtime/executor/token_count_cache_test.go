@@ -0,0 +1,128 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestCachedTokenCountSkipsTokenizerOnHit(t *testing.T) {
+	cfg := &config.Config{TokenCountCache: config.TokenCountCacheConfig{Size: 8}}
+	calls := 0
+	count := func() (int64, error) {
+		calls++
+		return 42, nil
+	}
+
+	first, err := cachedTokenCount(cfg, "codex", "cache-hit-model", []byte(`{"input": "hi"}`), count)
+	if err != nil || first != 42 {
+		t.Fatalf("first count = %d, %v; want 42", first, err)
+	}
+	// Whitespace differences normalize to the same key.
+	second, err := cachedTokenCount(cfg, "codex", "cache-hit-model", []byte(`{"input":"hi"}`), count)
+	if err != nil || second != 42 {
+		t.Fatalf("second count = %d, %v; want 42", second, err)
+	}
+	if calls != 1 {
+		t.Fatalf("tokenizer calls = %d, want 1", calls)
+	}
+
+	if _, err = cachedTokenCount(cfg, "iflow", "cache-hit-model", []byte(`{"input":"hi"}`), count); err != nil {
+		t.Fatalf("other provider: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("tokenizer calls = %d, want 2 after a different provider", calls)
+	}
+}
+
+func TestCachedTokenCountDisabledByDefault(t *testing.T) {
+	calls := 0
+	count := func() (int64, error) {
+		calls++
+		return 1, nil
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := cachedTokenCount(&config.Config{}, "codex", "cache-disabled-model", []byte(`{}`), count); err != nil {
+			t.Fatalf("count: %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("tokenizer calls = %d, want 2 with the cache disabled", calls)
+	}
+}
+
+func TestOpenAICompatCountTokensUsesCache(t *testing.T) {
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{TokenCountCache: config.TokenCountCacheConfig{Size: 8}})
+	req := cliproxyexecutor.Request{
+		Model:   "cache-compat-model",
+		Payload: []byte(`{"model":"cache-compat-model","messages":[{"role":"user","content":"hello there"}]}`),
+	}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")}
+
+	first, err := executor.CountTokens(context.Background(), nil, req, opts)
+	if err != nil {
+		t.Fatalf("first CountTokens: %v", err)
+	}
+	translated := sdktranslator.TranslateRequest(opts.SourceFormat, sdktranslator.FromString("openai"), req.Model, req.Payload, false)
+	if _, ok := tokenCountCache.get(tokenCountCacheKey(executor.Identifier(), req.Model, translated), time.Now()); !ok {
+		t.Fatal("expected CountTokens result to be cached")
+	}
+	second, err := executor.CountTokens(context.Background(), nil, req, opts)
+	if err != nil {
+		t.Fatalf("second CountTokens: %v", err)
+	}
+	if gjson.GetBytes(first.Payload, "usage.prompt_tokens").Int() != gjson.GetBytes(second.Payload, "usage.prompt_tokens").Int() {
+		t.Fatalf("cached count differs: %s vs %s", first.Payload, second.Payload)
+	}
+}
+
+func TestGeminiVertexCountTokensCache(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"totalTokens":42}`))
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": server.URL, "api_key": "vertex-key"}}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("gemini")}
+	count := func(executor *GeminiVertexExecutor, model, text string) {
+		t.Helper()
+		req := cliproxyexecutor.Request{Model: model, Payload: []byte(`{"contents":[{"role":"user","parts":[{"text":"` + text + `"}]}]}`)}
+		resp, err := executor.CountTokens(context.Background(), auth, req, opts)
+		if err != nil {
+			t.Fatalf("CountTokens error: %v", err)
+		}
+		if got := gjson.GetBytes(resp.Payload, "totalTokens").Int(); got != 42 {
+			t.Fatalf("totalTokens = %d, want 42", got)
+		}
+	}
+
+	uncached := NewGeminiVertexExecutor(&config.Config{})
+	count(uncached, "count-cache-off", "hello")
+	count(uncached, "count-cache-off", "hello")
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("upstream calls without cache = %d, want 2", got)
+	}
+
+	calls.Store(0)
+	cached := NewGeminiVertexExecutor(&config.Config{Gemini: config.GeminiConfig{CountTokensCacheSeconds: 60}})
+	count(cached, "count-cache-on", "hello")
+	count(cached, "count-cache-on", "hello")
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("upstream calls for identical bodies = %d, want 1", got)
+	}
+	count(cached, "count-cache-on", "a different prompt")
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("upstream calls after body change = %d, want 2", got)
+	}
+}
@@ -0,0 +1,149 @@
+package executor
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+var jsonSchemaTypes = map[string]struct{}{
+	"object": {}, "array": {}, "string": {}, "number": {}, "integer": {}, "boolean": {}, "null": {},
+}
+
+// validateToolSchemas checks the parameters of every function tool in an OpenAI chat
+// (tools[].function.parameters) or Codex Responses (tools[].parameters) payload when
+// validation.tool-schemas is enabled. Malformed schemas are reported together in a single
+// HTTP 400 error naming each offending tool by index and name.
+func validateToolSchemas(cfg *config.Config, body []byte) error {
+	if cfg == nil || !cfg.Validation.ToolSchemas {
+		return nil
+	}
+	tools := gjson.GetBytes(body, "tools")
+	if !tools.IsArray() {
+		return nil
+	}
+
+	var problems []string
+	for i, tool := range tools.Array() {
+		name := tool.Get("name").String()
+		params := tool.Get("parameters")
+		if fn := tool.Get("function"); fn.Exists() {
+			name = fn.Get("name").String()
+			params = fn.Get("parameters")
+		}
+		if !params.Exists() || params.Type == gjson.Null {
+			continue
+		}
+		label := fmt.Sprintf("tools[%d]", i)
+		if name != "" {
+			label += " (" + name + ")"
+		}
+		if params.Type == gjson.String {
+			if !gjson.Valid(params.String()) {
+				problems = append(problems, label+": parameters is not valid JSON")
+				continue
+			}
+			params = gjson.Parse(params.String())
+		}
+		if !params.IsObject() {
+			problems = append(problems, label+": parameters must be a JSON Schema object")
+			continue
+		}
+		if problem := validateSchemaNode(params, "parameters"); problem != "" {
+			problems = append(problems, label+": "+problem)
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	msg := "Invalid tool schema: " + strings.Join(problems, "; ")
+	errBody := []byte(`{"error":{"message":"","type":"invalid_request_error","param":"tools","code":"invalid_tool_schema"}}`)
+	errBody, _ = sjson.SetBytes(errBody, "error.message", msg)
+	return statusErr{code: http.StatusBadRequest, msg: string(errBody)}
+}
+
+// validateSchemaNode returns a description of the first structural problem in a JSON Schema
+// node, or "" when none is found. Only obviously malformed keywords are checked; unknown
+// keywords are left for the upstream to interpret.
+func validateSchemaNode(node gjson.Result, path string) string {
+	if node.Type == gjson.True || node.Type == gjson.False {
+		return ""
+	}
+	if !node.IsObject() {
+		return path + " must be an object"
+	}
+
+	if typ := node.Get("type"); typ.Exists() {
+		types := []gjson.Result{typ}
+		if typ.IsArray() {
+			types = typ.Array()
+		}
+		for _, t := range types {
+			if _, ok := jsonSchemaTypes[t.String()]; t.Type != gjson.String || !ok {
+				return fmt.Sprintf("%s.type has unsupported value %s", path, typ.Raw)
+			}
+		}
+	}
+	if props := node.Get("properties"); props.Exists() {
+		if !props.IsObject() {
+			return path + ".properties must be an object"
+		}
+		problem := ""
+		props.ForEach(func(key, value gjson.Result) bool {
+			problem = validateSchemaNode(value, path+".properties."+key.String())
+			return problem == ""
+		})
+		if problem != "" {
+			return problem
+		}
+	}
+	if required := node.Get("required"); required.Exists() {
+		if !required.IsArray() {
+			return path + ".required must be an array of property names"
+		}
+		for _, item := range required.Array() {
+			if item.Type != gjson.String {
+				return path + ".required must be an array of property names"
+			}
+		}
+	}
+	if enum := node.Get("enum"); enum.Exists() && !enum.IsArray() {
+		return path + ".enum must be an array"
+	}
+	if items := node.Get("items"); items.Exists() {
+		if items.IsArray() {
+			for i, item := range items.Array() {
+				if problem := validateSchemaNode(item, fmt.Sprintf("%s.items.%d", path, i)); problem != "" {
+					return problem
+				}
+			}
+		} else if problem := validateSchemaNode(items, path+".items"); problem != "" {
+			return problem
+		}
+	}
+	if additional := node.Get("additionalProperties"); additional.Exists() {
+		if problem := validateSchemaNode(additional, path+".additionalProperties"); problem != "" {
+			return problem
+		}
+	}
+	for _, keyword := range []string{"anyOf", "oneOf", "allOf"} {
+		branches := node.Get(keyword)
+		if !branches.Exists() {
+			continue
+		}
+		if !branches.IsArray() {
+			return path + "." + keyword + " must be an array"
+		}
+		for i, branch := range branches.Array() {
+			if problem := validateSchemaNode(branch, fmt.Sprintf("%s.%s.%d", path, keyword, i)); problem != "" {
+				return problem
+			}
+		}
+	}
+	return ""
+}
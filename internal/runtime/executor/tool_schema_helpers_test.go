@@ -0,0 +1,49 @@
+package executor
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestValidateToolSchemasRejectsMalformedSchemas(t *testing.T) {
+	cfg := &config.Config{Validation: config.ValidationConfig{ToolSchemas: true}}
+	body := []byte(`{"tools":[
+		{"type":"function","function":{"name":"ok","parameters":{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}}},
+		{"type":"function","function":{"name":"bad_props","parameters":{"type":"object","properties":["city"]}}},
+		{"type":"function","name":"bad_type","parameters":{"type":"dictionary"}},
+		{"type":"web_search"}
+	]}`)
+
+	err := validateToolSchemas(cfg, body)
+	var se statusErr
+	if !errors.As(err, &se) || se.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("error = %v, want 400 statusErr", err)
+	}
+	for _, want := range []string{"tools[1] (bad_props)", "tools[2] (bad_type)"} {
+		if !strings.Contains(se.msg, want) {
+			t.Fatalf("message %q does not mention %s", se.msg, want)
+		}
+	}
+	if strings.Contains(se.msg, "tools[0]") {
+		t.Fatalf("message %q reports the valid tool", se.msg)
+	}
+}
+
+func TestValidateToolSchemasDisabledOrValid(t *testing.T) {
+	bad := []byte(`{"tools":[{"type":"function","function":{"name":"f","parameters":"{not json"}}]}`)
+	if err := validateToolSchemas(&config.Config{}, bad); err != nil {
+		t.Fatalf("disabled validation returned %v", err)
+	}
+	cfg := &config.Config{Validation: config.ValidationConfig{ToolSchemas: true}}
+	if err := validateToolSchemas(cfg, bad); err == nil {
+		t.Fatal("expected unparsable parameters to be rejected")
+	}
+	valid := []byte(`{"tools":[{"type":"function","name":"f","parameters":{"type":["object","null"],"properties":{"tags":{"type":"array","items":{"type":"string"}},"mode":{"enum":["a","b"]}},"additionalProperties":false}}]}`)
+	if err := validateToolSchemas(cfg, valid); err != nil {
+		t.Fatalf("valid schema rejected: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/sjson"
+)
+
+// translateRequestChecked translates a request payload and records a dead letter when the
+// translator returns empty or invalid output, failing the request with a 400
+// invalid_request_error instead of sending the broken payload upstream. The error stops
+// credential rotation, so a malformed client payload never cools down any credential.
+func translateRequestChecked(ctx context.Context, from, to sdktranslator.Format, model string, payload []byte, stream bool) ([]byte, error) {
+	out := sdktranslator.TranslateRequest(from, to, model, payload, stream)
+	if !sdktranslator.InvalidTranslation(payload, out) {
+		return out, nil
+	}
+	return nil, deadLetterError(ctx, sdktranslator.DeadLetterStageRequest, from, to, model, payload, out)
+}
+
+// translateNonStreamChecked translates a non-streaming upstream response from the upstream
+// format to into the client format from, recording a dead letter and returning a 502 when
+// the translation is empty or invalid.
+func translateNonStreamChecked(ctx context.Context, to, from sdktranslator.Format, model string, originalRequest, request, data []byte, param *any) ([]byte, error) {
+	out := sdktranslator.TranslateNonStream(ctx, to, from, model, originalRequest, request, data, param)
+	if !sdktranslator.InvalidTranslation(data, out) {
+		return out, nil
+	}
+	return nil, deadLetterError(ctx, sdktranslator.DeadLetterStageResponse, to, from, model, data, out)
+}
+
+// deadLetterError records the failed translation and builds the client-facing error. Request
+// translations fail with a non-retryable 400 as no upstream call was made; response
+// translations fail with a 502.
+func deadLetterError(ctx context.Context, stage string, from, to sdktranslator.Format, model string, input, output []byte) error {
+	sdktranslator.RecordDeadLetter(ctx, sdktranslator.DeadLetter{
+		Stage:  stage,
+		From:   from,
+		To:     to,
+		Model:  model,
+		Input:  string(input),
+		Output: string(output),
+	})
+	msg := fmt.Sprintf("%s translation from %s to %s produced invalid output", stage, from, to)
+	if stage == sdktranslator.DeadLetterStageRequest {
+		errBody := []byte(`{"error":{"message":"","type":"invalid_request_error","code":"translation_failed"}}`)
+		errBody, _ = sjson.SetBytes(errBody, "error.message", msg)
+		return statusErr{code: http.StatusBadRequest, msg: string(errBody)}
+	}
+	return statusErr{code: http.StatusBadGateway, msg: msg}
+}
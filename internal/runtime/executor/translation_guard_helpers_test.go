@@ -0,0 +1,114 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+type recordingDeadLetterSink struct {
+	mu      sync.Mutex
+	letters []sdktranslator.DeadLetter
+}
+
+func (s *recordingDeadLetterSink) RecordDeadLetter(_ context.Context, letter sdktranslator.DeadLetter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.letters = append(s.letters, letter)
+}
+
+type unexpectedUpstreamTransport struct {
+	calls int
+}
+
+func (t *unexpectedUpstreamTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	t.calls++
+	return nil, errors.New("unexpected upstream call")
+}
+
+func TestClaudeExecuteRecordsDeadLetterForEmptyTranslation(t *testing.T) {
+	from := sdktranslator.FromString("dead-letter-test")
+	to := sdktranslator.FromString("claude")
+	sdktranslator.Register(from, to, func(string, []byte, bool) []byte { return nil }, sdktranslator.ResponseTransform{})
+
+	sink := &recordingDeadLetterSink{}
+	sdktranslator.SetDeadLetterSink(sink)
+	t.Cleanup(func() { sdktranslator.SetDeadLetterSink(nil) })
+
+	transport := &unexpectedUpstreamTransport{}
+	ctx := context.WithValue(context.Background(), "cliproxy.roundtripper", http.RoundTripper(transport))
+	payload := []byte(`{"model":"claude-sonnet-4","messages":[{"role":"user","content":"hi"}]}`)
+	exec := NewClaudeExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Provider: "claude", Attributes: map[string]string{"api_key": "key"}}
+
+	_, err := exec.Execute(ctx, auth, cliproxyexecutor.Request{Model: "claude-sonnet-4", Payload: payload}, cliproxyexecutor.Options{SourceFormat: from})
+
+	var sErr statusErr
+	if !errors.As(err, &sErr) || sErr.StatusCode() != http.StatusBadRequest || !strings.Contains(sErr.Error(), "invalid_request_error") {
+		t.Fatalf("Execute error = %v, want 400 invalid_request_error", err)
+	}
+	if transport.calls != 0 {
+		t.Fatalf("upstream calls = %d, want 0", transport.calls)
+	}
+	if len(sink.letters) != 1 {
+		t.Fatalf("dead letters = %d, want 1", len(sink.letters))
+	}
+	letter := sink.letters[0]
+	if letter.Stage != sdktranslator.DeadLetterStageRequest || letter.From != from || letter.To != to {
+		t.Fatalf("dead letter = %+v, want request %s -> %s", letter, from, to)
+	}
+	if letter.Input != string(payload) {
+		t.Fatalf("dead letter input = %q, want original payload", letter.Input)
+	}
+}
+
+func TestRequestTranslationFailureDoesNotCoolDownCredentials(t *testing.T) {
+	from := sdktranslator.FromString("dead-letter-cooldown-test")
+	sdktranslator.Register(from, sdktranslator.FromString("claude"), func(string, []byte, bool) []byte { return nil }, sdktranslator.ResponseTransform{})
+	sdktranslator.SetDeadLetterSink(&recordingDeadLetterSink{})
+	t.Cleanup(func() { sdktranslator.SetDeadLetterSink(nil) })
+
+	transport := &unexpectedUpstreamTransport{}
+	ctx := context.WithValue(context.Background(), "cliproxy.roundtripper", http.RoundTripper(transport))
+	cfg := &config.Config{}
+	manager := cliproxyauth.NewManager(nil, nil, nil)
+	manager.SetConfig(cfg)
+	manager.RegisterExecutor(NewClaudeExecutor(cfg))
+	reg := registry.GetGlobalRegistry()
+	ids := []string{"dead-letter-auth-a", "dead-letter-auth-b"}
+	for _, id := range ids {
+		reg.RegisterClient(id, "claude", []*registry.ModelInfo{{ID: "dead-letter-model"}})
+		t.Cleanup(func() { reg.UnregisterClient(id) })
+		auth := &cliproxyauth.Auth{ID: id, Provider: "claude", Attributes: map[string]string{"api_key": "key"}}
+		if _, errRegister := manager.Register(ctx, auth); errRegister != nil {
+			t.Fatalf("register auth: %v", errRegister)
+		}
+	}
+
+	payload := []byte(`{"model":"dead-letter-model","messages":[{"role":"user","content":"hi"}]}`)
+	_, err := manager.Execute(ctx, []string{"claude"}, cliproxyexecutor.Request{Model: "dead-letter-model", Payload: payload}, cliproxyexecutor.Options{SourceFormat: from})
+	if err == nil || !strings.Contains(err.Error(), "invalid_request_error") {
+		t.Fatalf("Execute error = %v, want invalid_request_error", err)
+	}
+	if transport.calls != 0 {
+		t.Fatalf("upstream calls = %d, want 0", transport.calls)
+	}
+	for _, id := range ids {
+		auth, ok := manager.GetByID(id)
+		if !ok {
+			t.Fatalf("auth %s missing", id)
+		}
+		if state := auth.ModelStates["dead-letter-model"]; state != nil && !state.NextRetryAfter.IsZero() {
+			t.Fatalf("auth %s cooled down until %v after a request translation failure", id, state.NextRetryAfter)
+		}
+	}
+}
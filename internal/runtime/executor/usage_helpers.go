@@ -20,10 +20,13 @@ type usageReporter struct {
 	model       string
 	authID      string
 	authIndex   string
+	authLabel   string
 	apiKey      string
 	source      string
 	requestedAt time.Time
-	once        sync.Once
+	// upstreamLatency is reported as Record.UpstreamLatency when set.
+	upstreamLatency time.Duration
+	once            sync.Once
 }
 
 func newUsageReporter(ctx context.Context, provider, model string, auth *cliproxyauth.Auth) *usageReporter {
@@ -38,6 +41,7 @@ func newUsageReporter(ctx context.Context, provider, model string, auth *cliprox
 	if auth != nil {
 		reporter.authID = auth.ID
 		reporter.authIndex = auth.EnsureIndex()
+		reporter.authLabel = auth.Label
 	}
 	return reporter
 }
@@ -95,16 +99,18 @@ func (r *usageReporter) buildRecord(detail usage.Detail, failed bool) usage.Reco
 		return usage.Record{Detail: detail, Failed: failed}
 	}
 	return usage.Record{
-		Provider:    r.provider,
-		Model:       r.model,
-		Source:      r.source,
-		APIKey:      r.apiKey,
-		AuthID:      r.authID,
-		AuthIndex:   r.authIndex,
-		RequestedAt: r.requestedAt,
-		Latency:     r.latency(),
-		Failed:      failed,
-		Detail:      detail,
+		Provider:        r.provider,
+		Model:           r.model,
+		Source:          r.source,
+		APIKey:          r.apiKey,
+		AuthID:          r.authID,
+		AuthIndex:       r.authIndex,
+		AuthLabel:       r.authLabel,
+		RequestedAt:     r.requestedAt,
+		Latency:         r.latency(),
+		UpstreamLatency: r.upstreamLatency,
+		Failed:          failed,
+		Detail:          detail,
 	}
 }
 
@@ -285,6 +291,10 @@ func parseClaudeStreamUsage(line []byte) (usage.Detail, bool) {
 		return usage.Detail{}, false
 	}
 	usageNode := gjson.GetBytes(payload, "usage")
+	if !usageNode.Exists() {
+		// message_start nests usage under the message object.
+		usageNode = gjson.GetBytes(payload, "message.usage")
+	}
 	if !usageNode.Exists() {
 		return usage.Detail{}, false
 	}
@@ -300,6 +310,41 @@ func parseClaudeStreamUsage(line []byte) (usage.Detail, bool) {
 	return detail, true
 }
 
+// claudeStreamUsage accumulates usage across a Claude SSE stream. message_start carries the
+// input and cache token counts and each message_delta carries the running output token count,
+// so the final total is only known once the stream ends.
+type claudeStreamUsage struct {
+	detail usage.Detail
+	seen   bool
+}
+
+// add merges the usage carried by an SSE line, if any.
+func (u *claudeStreamUsage) add(line []byte) {
+	detail, ok := parseClaudeStreamUsage(line)
+	if !ok {
+		return
+	}
+	u.seen = true
+	if detail.InputTokens > 0 {
+		u.detail.InputTokens = detail.InputTokens
+	}
+	if detail.CachedTokens > 0 {
+		u.detail.CachedTokens = detail.CachedTokens
+	}
+	if detail.OutputTokens > u.detail.OutputTokens {
+		u.detail.OutputTokens = detail.OutputTokens
+	}
+	u.detail.TotalTokens = u.detail.InputTokens + u.detail.OutputTokens
+}
+
+// publish reports the accumulated usage once the stream has ended.
+func (u *claudeStreamUsage) publish(ctx context.Context, reporter *usageReporter) {
+	if !u.seen {
+		return
+	}
+	reporter.publish(ctx, u.detail)
+}
+
 func parseGeminiFamilyUsageDetail(node gjson.Result) usage.Detail {
 	detail := usage.Detail{
 		InputTokens:     node.Get("promptTokenCount").Int(),
@@ -62,3 +62,27 @@ func TestUsageReporterBuildRecordIncludesLatency(t *testing.T) {
 		t.Fatalf("latency = %v, want <= 3s", record.Latency)
 	}
 }
+
+func TestClaudeStreamUsageAccumulatesMessageDeltas(t *testing.T) {
+	stream := []string{
+		`event: message_start`,
+		`data: {"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":25,"cache_read_input_tokens":5,"output_tokens":1}}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`,
+		`event: message_delta`,
+		`data: {"type":"message_delta","delta":{"stop_reason":null},"usage":{"output_tokens":7}}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":15}}`,
+		`data: {"type":"message_stop"}`,
+	}
+	var acc claudeStreamUsage
+	for _, line := range stream {
+		acc.add([]byte(line))
+	}
+
+	if !acc.seen {
+		t.Fatal("expected usage to be seen")
+	}
+	got := acc.detail
+	if got.InputTokens != 25 || got.OutputTokens != 15 || got.CachedTokens != 5 || got.TotalTokens != 40 {
+		t.Fatalf("usage = %+v, want input 25, output 15, cached 5, total 40", got)
+	}
+}
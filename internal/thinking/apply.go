@@ -59,6 +59,10 @@ func IsUserDefinedModel(modelInfo *registry.ModelInfo) bool {
 // This enables users to override thinking settings via the model name without modifying their
 // request payload.
 //
+// Default Suffix: When neither a suffix nor a thinking config is present, the default suffix
+// configured for the model via SetModelDefaultThinkingSuffix is applied as if the client had
+// sent it.
+//
 // Parameters:
 //   - body: Original request body JSON
 //   - model: Model name, optionally with thinking suffix (e.g., "claude-sonnet-4-5(16384)")
@@ -106,7 +110,7 @@ func ApplyThinking(body []byte, model string, fromFormat string, toFormat string
 	}
 
 	// 2. Parse suffix and get modelInfo
-	suffixResult := ParseSuffix(model)
+	suffixResult := applyDefaultSuffix(ParseSuffix(model), body, providerFormat, fromFormat)
 	baseModel := suffixResult.ModelName
 	// Use provider-specific lookup to handle capability differences across providers.
 	modelInfo := registry.LookupModelInfo(baseModel, providerKey)
@@ -115,7 +119,7 @@ func ApplyThinking(body []byte, model string, fromFormat string, toFormat string
 	// Unknown models are treated as user-defined so thinking config can still be applied.
 	// The upstream service is responsible for validating the configuration.
 	if IsUserDefinedModel(modelInfo) {
-		return applyUserDefinedModel(body, modelInfo, fromFormat, providerFormat, suffixResult)
+		return applyUserDefinedModel(body, modelInfo, fromFormat, providerFormat, providerKey, suffixResult)
 	}
 	if modelInfo.Thinking == nil {
 		config := extractThinkingConfig(body, providerFormat)
@@ -164,6 +168,7 @@ func ApplyThinking(body []byte, model string, fromFormat string, toFormat string
 		}).Debug("thinking: no config found, passthrough |")
 		return body, nil
 	}
+	config = applyReasoningEffortMapping(config, providerKey, providerFormat)
 
 	// 5. Validate and normalize configuration
 	validated, err := ValidateConfig(config, modelInfo, fromFormat, providerFormat, suffixResult.HasSuffix)
@@ -243,7 +248,7 @@ func parseSuffixToConfig(rawSuffix, provider, model string) ThinkingConfig {
 
 // applyUserDefinedModel applies thinking configuration for user-defined models
 // without ThinkingSupport validation.
-func applyUserDefinedModel(body []byte, modelInfo *registry.ModelInfo, fromFormat, toFormat string, suffixResult SuffixResult) ([]byte, error) {
+func applyUserDefinedModel(body []byte, modelInfo *registry.ModelInfo, fromFormat, toFormat, providerKey string, suffixResult SuffixResult) ([]byte, error) {
 	// Get model ID for logging
 	modelID := ""
 	if modelInfo != nil {
@@ -270,6 +275,7 @@ func applyUserDefinedModel(body []byte, modelInfo *registry.ModelInfo, fromForma
 		}).Debug("thinking: user-defined model, passthrough (no config) |")
 		return body, nil
 	}
+	config = applyReasoningEffortMapping(config, providerKey, toFormat)
 
 	applier := GetProviderApplier(toFormat)
 	if applier == nil {
@@ -0,0 +1,51 @@
+package thinking
+
+import (
+	"strings"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// modelDefaultThinkingSuffix holds operator-defined suffixes keyed by lower-case base model name.
+var modelDefaultThinkingSuffix atomic.Pointer[map[string]string]
+
+// SetModelDefaultThinkingSuffix replaces the per-model default thinking suffixes applied by
+// ApplyThinking when the client sends neither a model suffix nor a thinking config.
+//
+// Values use the suffix syntax without parentheses, e.g. "high", "8192" or "none".
+// Keys are expected in lower case, as produced by config sanitization.
+func SetModelDefaultThinkingSuffix(defaults map[string]string) {
+	if len(defaults) == 0 {
+		modelDefaultThinkingSuffix.Store(nil)
+		return
+	}
+	modelDefaultThinkingSuffix.Store(&defaults)
+}
+
+// applyDefaultSuffix returns suffixResult carrying the configured default suffix for its model
+// when the client specified no thinking settings. Explicit suffixes and request body configs
+// always win.
+func applyDefaultSuffix(suffixResult SuffixResult, body []byte, formats ...string) SuffixResult {
+	if suffixResult.HasSuffix {
+		return suffixResult
+	}
+	defaults := modelDefaultThinkingSuffix.Load()
+	if defaults == nil {
+		return suffixResult
+	}
+	rawSuffix, ok := (*defaults)[strings.ToLower(suffixResult.ModelName)]
+	if !ok {
+		return suffixResult
+	}
+	for _, format := range formats {
+		if hasThinkingConfig(extractThinkingConfig(body, format)) {
+			return suffixResult
+		}
+	}
+	log.WithFields(log.Fields{
+		"model":  suffixResult.ModelName,
+		"suffix": rawSuffix,
+	}).Debug("thinking: applied model default suffix |")
+	return SuffixResult{ModelName: suffixResult.ModelName, HasSuffix: true, RawSuffix: rawSuffix}
+}
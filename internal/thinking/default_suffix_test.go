@@ -0,0 +1,38 @@
+package thinking_test
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/thinking/provider/codex"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyThinking_ModelDefaultThinkingSuffix(t *testing.T) {
+	thinking.SetModelDefaultThinkingSuffix(map[string]string{"custom-codex-default": "high"})
+	t.Cleanup(func() { thinking.SetModelDefaultThinkingSuffix(nil) })
+
+	tests := []struct {
+		name  string
+		body  string
+		model string
+		want  string
+	}{
+		{name: "default applied", body: `{"input":"hi"}`, model: "custom-codex-default", want: "high"},
+		{name: "model lookup ignores case", body: `{"input":"hi"}`, model: "Custom-Codex-Default", want: "high"},
+		{name: "explicit suffix overrides default", body: `{"input":"hi"}`, model: "custom-codex-default(low)", want: "low"},
+		{name: "body config overrides default", body: `{"input":"hi","reasoning":{"effort":"medium"}}`, model: "custom-codex-default", want: "medium"},
+		{name: "other models untouched", body: `{"input":"hi"}`, model: "custom-codex-other", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := thinking.ApplyThinking([]byte(tt.body), tt.model, "openai-response", "codex", "codex")
+			if err != nil {
+				t.Fatalf("ApplyThinking error = %v", err)
+			}
+			if got := gjson.GetBytes(out, "reasoning.effort").String(); got != tt.want {
+				t.Fatalf("reasoning.effort = %q, want %q, body=%s", got, tt.want, out)
+			}
+		})
+	}
+}
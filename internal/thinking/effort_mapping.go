@@ -0,0 +1,67 @@
+package thinking
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// reasoningEffortMapping holds operator-defined level overrides keyed by provider, then level.
+var reasoningEffortMapping atomic.Pointer[map[string]map[string]string]
+
+// SetReasoningEffortMapping replaces the per-provider level overrides applied by ApplyThinking.
+//
+// Each value is the provider's native representation of the level: an integer is used as a
+// thinking budget (0 disables thinking, -1 selects auto), anything else as a level name.
+// Keys are expected in lower case, as produced by config sanitization.
+func SetReasoningEffortMapping(mapping map[string]map[string]string) {
+	if len(mapping) == 0 {
+		reasoningEffortMapping.Store(nil)
+		return
+	}
+	reasoningEffortMapping.Store(&mapping)
+}
+
+// applyReasoningEffortMapping rewrites a level config with the native value configured for the
+// first provider key that has a mapping for the level. Other configs are returned unchanged.
+func applyReasoningEffortMapping(config ThinkingConfig, providers ...string) ThinkingConfig {
+	if config.Mode != ModeLevel {
+		return config
+	}
+	mapping := reasoningEffortMapping.Load()
+	if mapping == nil {
+		return config
+	}
+	level := strings.ToLower(string(config.Level))
+	for _, provider := range providers {
+		value, ok := (*mapping)[provider][level]
+		if !ok {
+			continue
+		}
+		mapped := parseMappedEffort(value)
+		log.WithFields(log.Fields{
+			"provider": provider,
+			"level":    level,
+			"mapped":   value,
+		}).Debug("thinking: applied reasoning effort mapping |")
+		return mapped
+	}
+	return config
+}
+
+// parseMappedEffort converts a configured native value into a ThinkingConfig.
+func parseMappedEffort(value string) ThinkingConfig {
+	if budget, err := strconv.Atoi(value); err == nil {
+		switch {
+		case budget == 0:
+			return ThinkingConfig{Mode: ModeNone, Budget: 0}
+		case budget < 0:
+			return ThinkingConfig{Mode: ModeAuto, Budget: -1}
+		default:
+			return ThinkingConfig{Mode: ModeBudget, Budget: budget}
+		}
+	}
+	return ThinkingConfig{Mode: ModeLevel, Level: ThinkingLevel(strings.ToLower(value))}
+}
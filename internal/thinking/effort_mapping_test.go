@@ -0,0 +1,45 @@
+package thinking_test
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/thinking/provider/codex"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/thinking/provider/gemini"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyThinking_ReasoningEffortMapping(t *testing.T) {
+	thinking.SetReasoningEffortMapping(map[string]map[string]string{
+		"gemini": {"high": "32768"},
+		"codex":  {"high": "xhigh"},
+	})
+	t.Cleanup(func() { thinking.SetReasoningEffortMapping(nil) })
+
+	geminiOut, err := thinking.ApplyThinking([]byte(`{"generationConfig":{"thinkingConfig":{"thinkingLevel":"high"}}}`), "custom-gemini-mapping", "openai", "gemini", "gemini")
+	if err != nil {
+		t.Fatalf("ApplyThinking(gemini) error = %v", err)
+	}
+	if got := gjson.GetBytes(geminiOut, "generationConfig.thinkingConfig.thinkingBudget").Int(); got != 32768 {
+		t.Fatalf("thinkingBudget = %d, want 32768, body=%s", got, geminiOut)
+	}
+	if gjson.GetBytes(geminiOut, "generationConfig.thinkingConfig.thinkingLevel").Exists() {
+		t.Fatalf("thinkingLevel should be replaced, body=%s", geminiOut)
+	}
+
+	codexOut, err := thinking.ApplyThinking([]byte(`{"reasoning":{"effort":"high"}}`), "custom-codex-mapping", "openai", "codex", "codex")
+	if err != nil {
+		t.Fatalf("ApplyThinking(codex) error = %v", err)
+	}
+	if got := gjson.GetBytes(codexOut, "reasoning.effort").String(); got != "xhigh" {
+		t.Fatalf("reasoning.effort = %q, want xhigh, body=%s", got, codexOut)
+	}
+
+	lowOut, err := thinking.ApplyThinking([]byte(`{"reasoning":{"effort":"low"}}`), "custom-codex-mapping", "openai", "codex", "codex")
+	if err != nil {
+		t.Fatalf("ApplyThinking(codex low) error = %v", err)
+	}
+	if got := gjson.GetBytes(lowOut, "reasoning.effort").String(); got != "low" {
+		t.Fatalf("unmapped reasoning.effort = %q, want low, body=%s", got, lowOut)
+	}
+}
@@ -347,14 +347,15 @@ func ConvertClaudeRequestToAntigravity(modelName string, inputRawJSON []byte, _
 						if len(thinkingParts) > 0 {
 							firstPartIsThinking := parts[0].Get("thought").Bool()
 							if !firstPartIsThinking || len(thinkingParts) > 1 {
-								var newParts []interface{}
+								// Reassemble from raw JSON so numeric literals in tool args keep full precision.
+								newParts := []byte(`[]`)
 								for _, p := range thinkingParts {
-									newParts = append(newParts, p.Value())
+									newParts, _ = sjson.SetRawBytes(newParts, "-1", []byte(p.Raw))
 								}
 								for _, p := range otherParts {
-									newParts = append(newParts, p.Value())
+									newParts, _ = sjson.SetRawBytes(newParts, "-1", []byte(p.Raw))
 								}
-								clientContentJSON, _ = sjson.SetBytes(clientContentJSON, "parts", newParts)
+								clientContentJSON, _ = sjson.SetRawBytes(clientContentJSON, "parts", newParts)
 							}
 						}
 					}
@@ -361,6 +361,45 @@ func TestConvertClaudeRequestToAntigravity_ReorderThinking(t *testing.T) {
 	}
 }
 
+func TestConvertClaudeRequestToAntigravity_ReorderThinkingPreservesLargeToolArgs(t *testing.T) {
+	cache.ClearSignatureCache("")
+
+	validSignature := "abc123validSignature1234567890123456789012345678901234567890"
+	thinkingText := "Looking up the order..."
+
+	inputJSON := []byte(`{
+		"model": "claude-sonnet-4-5-thinking",
+		"messages": [
+			{
+				"role": "user",
+				"content": [{"type": "text", "text": "Find order 9007199254740993"}]
+			},
+			{
+				"role": "assistant",
+				"content": [
+					{"type": "tool_use", "id": "call_1", "name": "get_order", "input": {"order_id": 9007199254740993, "ratio": 0.1000000000000000055511151231257827}},
+					{"type": "thinking", "thinking": "` + thinkingText + `", "signature": "` + validSignature + `"}
+				]
+			}
+		]
+	}`)
+
+	cache.CacheSignature("claude-sonnet-4-5-thinking", thinkingText, validSignature)
+
+	output := ConvertClaudeRequestToAntigravity("claude-sonnet-4-5-thinking", inputJSON, false)
+
+	args := gjson.GetBytes(output, "request.contents.1.parts.1.functionCall.args")
+	if !gjson.GetBytes(output, "request.contents.1.parts.0.thought").Bool() {
+		t.Fatalf("expected thinking part first, got %s", gjson.GetBytes(output, "request.contents.1.parts").Raw)
+	}
+	if got := args.Get("order_id").Raw; got != "9007199254740993" {
+		t.Fatalf("order_id = %s, want 9007199254740993", got)
+	}
+	if got := args.Get("ratio").Raw; got != "0.1000000000000000055511151231257827" {
+		t.Fatalf("ratio = %s, want literal preserved", got)
+	}
+}
+
 func TestConvertClaudeRequestToAntigravity_ToolResult(t *testing.T) {
 	inputJSON := []byte(`{
 		"model": "claude-3-5-sonnet-20240620",
@@ -0,0 +1,34 @@
+package chat_completions
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToAntigravity_StrictToolRequiresAllProperties(t *testing.T) {
+	input := []byte(`{"model":"gemini-2.5-pro","messages":[{"role":"user","content":"hi"}],"tools":[
+		{"type":"function","function":{"name":"lookup","strict":true,"parameters":{"type":"object","properties":{
+			"city":{"type":"string"},
+			"filters":{"type":"array","items":{"type":"object","properties":{"field":{"type":"string"},"value":{"type":"string"}},"required":["field"]}}
+		},"required":["city"],"additionalProperties":false}}},
+		{"type":"function","function":{"name":"loose","parameters":{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"string"}},"required":["a"]}}}
+	]}`)
+	out := ConvertOpenAIRequestToAntigravity("gemini-2.5-pro", input, false)
+
+	strict := gjson.GetBytes(out, "request.tools.0.functionDeclarations.0")
+	if strict.Get("strict").Exists() {
+		t.Fatalf("strict flag should not be forwarded: %s", strict.Raw)
+	}
+	if got := strict.Get("parametersJsonSchema.required").Raw; got != `["city","filters"]` {
+		t.Fatalf("strict required = %s, want all properties; out=%s", got, out)
+	}
+	if got := strict.Get("parametersJsonSchema.properties.filters.items.required").Raw; got != `["field","value"]` {
+		t.Fatalf("strict nested required = %s, want all properties; out=%s", got, out)
+	}
+
+	loose := gjson.GetBytes(out, "request.tools.0.functionDeclarations.1")
+	if got := loose.Get("parametersJsonSchema.required").Raw; got != `["a"]` {
+		t.Fatalf("non-strict required = %s, want unchanged; out=%s", got, out)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+
 	"github.com/tidwall/gjson"
 )
 
@@ -126,3 +128,20 @@ func TestNoFinishReasonOnIntermediateChunks(t *testing.T) {
 		t.Errorf("Expected no finish_reason on intermediate chunk, got: %v", fr2)
 	}
 }
+
+func TestConvertAntigravityResponseToOpenAI_SuppressesThoughtParts(t *testing.T) {
+	ctx := translatorcommon.WithReasoningSuppressed(context.Background())
+	var param any
+
+	chunk := []byte(`{"response":{"candidates":[{"content":{"role":"model","parts":[{"text":"Weighing options.","thought":true},{"text":"Pick B."}]}}]}}`)
+	chunks := ConvertAntigravityResponseToOpenAI(ctx, "model", nil, nil, chunk, &param)
+	if len(chunks) != 1 {
+		t.Fatalf("chunks = %d, want 1", len(chunks))
+	}
+	if gjson.GetBytes(chunks[0], "choices.0.delta.reasoning_content").String() != "" {
+		t.Fatalf("unexpected reasoning_content: %s", chunks[0])
+	}
+	if got := gjson.GetBytes(chunks[0], "choices.0.delta.content").String(); got != "Pick B." {
+		t.Fatalf("delta.content = %q, want %q", got, "Pick B.")
+	}
+}
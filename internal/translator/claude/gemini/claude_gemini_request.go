@@ -346,7 +346,7 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 
 	// Tools mapping: Gemini functionDeclarations -> Claude Code tools
 	if tools := root.Get("tools"); tools.Exists() && tools.IsArray() {
-		var anthropicTools []interface{}
+		anthropicTools := []byte(`[]`)
 
 		tools.ForEach(func(_, tool gjson.Result) bool {
 			if funcDecls := tool.Get("functionDeclarations"); funcDecls.Exists() && funcDecls.IsArray() {
@@ -373,15 +373,15 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 						anthropicTool, _ = sjson.SetRawBytes(anthropicTool, "input_schema", cleaned)
 					}
 
-					anthropicTools = append(anthropicTools, gjson.ParseBytes(anthropicTool).Value())
+					anthropicTools, _ = sjson.SetRawBytes(anthropicTools, "-1", anthropicTool)
 					return true
 				})
 			}
 			return true
 		})
 
-		if len(anthropicTools) > 0 {
-			out, _ = sjson.SetBytes(out, "tools", anthropicTools)
+		if gjson.GetBytes(anthropicTools, "#").Int() > 0 {
+			out, _ = sjson.SetRawBytes(out, "tools", anthropicTools)
 		}
 	}
 
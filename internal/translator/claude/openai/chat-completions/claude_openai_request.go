@@ -16,6 +16,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -143,6 +144,9 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 		out, _ = sjson.SetBytes(out, "top_p", topP.Float())
 	}
 
+	// Claude has no presence/frequency penalty equivalent; drop them with a debug log.
+	out = translatorcommon.ApplyOpenAIPenalties(out, rawJSON, modelName, "claude", nil)
+
 	// Stop sequences configuration for custom termination conditions
 	if stop := root.Get("stop"); stop.Exists() {
 		if stop.IsArray() {
@@ -324,6 +328,14 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 		}
 	}
 
+	// Claude expresses parallel_tool_calls=false as tool_choice.disable_parallel_tool_use.
+	if parallel := root.Get("parallel_tool_calls"); parallel.Exists() && !parallel.Bool() && root.Get("tool_choice").String() != "none" && gjson.GetBytes(out, "tools").Exists() {
+		if !gjson.GetBytes(out, "tool_choice").Exists() {
+			out, _ = sjson.SetRawBytes(out, "tool_choice", []byte(`{"type":"auto"}`))
+		}
+		out, _ = sjson.SetBytes(out, "tool_choice.disable_parallel_tool_use", true)
+	}
+
 	return out
 }
 
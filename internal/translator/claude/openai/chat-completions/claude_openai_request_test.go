@@ -135,3 +135,34 @@ func TestConvertOpenAIRequestToClaude_ToolResultURLImageOnly(t *testing.T) {
 		t.Fatalf("Unexpected image URL: %q", got)
 	}
 }
+
+func TestConvertOpenAIRequestToClaude_StripsPenalties(t *testing.T) {
+	inputJSON := `{"model":"claude-sonnet-4-5","presence_penalty":0.5,"frequency_penalty":0.25,"messages":[{"role":"user","content":"hi"}]}`
+	result := ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false)
+
+	if gjson.GetBytes(result, "presence_penalty").Exists() || gjson.GetBytes(result, "frequency_penalty").Exists() {
+		t.Fatalf("expected penalties to be stripped, got %s", result)
+	}
+}
+
+func TestConvertOpenAIRequestToClaude_StripsClientMetadata(t *testing.T) {
+	inputJSON := `{"model":"claude-sonnet-4-5","metadata":{"trace":"abc"},"messages":[{"role":"user","content":"hi"}]}`
+	result := ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false)
+
+	if gjson.GetBytes(result, "metadata.trace").Exists() {
+		t.Fatalf("expected client metadata to be stripped for Claude, got %s", result)
+	}
+}
+
+func TestConvertOpenAIRequestToClaude_MapsParallelToolCalls(t *testing.T) {
+	tools := `"tools":[{"type":"function","function":{"name":"lookup","parameters":{"type":"object"}}}]`
+	result := ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(`{"messages":[{"role":"user","content":"hi"}],"parallel_tool_calls":false,"tool_choice":"required",`+tools+`}`), false)
+	if got := gjson.GetBytes(result, "tool_choice"); got.Get("type").String() != "any" || !got.Get("disable_parallel_tool_use").Bool() {
+		t.Fatalf("tool_choice = %s, want any with disable_parallel_tool_use", got.Raw)
+	}
+
+	result = ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(`{"messages":[{"role":"user","content":"hi"}],"parallel_tool_calls":true,`+tools+`}`), false)
+	if gjson.GetBytes(result, "tool_choice.disable_parallel_tool_use").Exists() || gjson.GetBytes(result, "parallel_tool_calls").Exists() {
+		t.Fatalf("parallel_tool_calls=true should leave Claude defaults, got %s", result)
+	}
+}
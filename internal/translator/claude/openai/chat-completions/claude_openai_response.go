@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -27,6 +28,8 @@ type ConvertAnthropicResponseToOpenAIParams struct {
 	FinishReason string
 	// Tool calls accumulator for streaming
 	ToolCallsAccumulator map[int]*ToolCallAccumulator
+	// MetadataEchoed records whether a chunk already carried the request metadata.
+	MetadataEchoed bool
 }
 
 // ToolCallAccumulator holds the state for accumulating tool call data
@@ -61,7 +64,7 @@ func calculateClaudeUsageTokens(usage gjson.Result) (promptTokens, completionTok
 //
 // Returns:
 //   - [][]byte: A slice of OpenAI-compatible JSON responses
-func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
+func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) (chunks [][]byte) {
 	if *param == nil {
 		*param = &ConvertAnthropicResponseToOpenAIParams{
 			CreatedAt:    0,
@@ -69,6 +72,10 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 			FinishReason: "",
 		}
 	}
+	defer func() {
+		p := (*param).(*ConvertAnthropicResponseToOpenAIParams)
+		chunks = translatorcommon.EchoOpenAIMetadataOnFirstChunk(chunks, originalRequestRawJSON, &p.MetadataEchoed)
+	}()
 
 	if !bytes.HasPrefix(rawJSON, dataTag) {
 		return [][]byte{}
@@ -434,5 +441,5 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 		out, _ = sjson.SetBytes(out, "choices.0.finish_reason", mapAnthropicStopReasonToOpenAI(stopReason))
 	}
 
-	return out
+	return translatorcommon.EchoOpenAIMetadata(out, originalRequestRawJSON)
 }
@@ -56,3 +56,20 @@ func TestConvertClaudeResponseToOpenAINonStream_UsageIncludesCachedTokens(t *tes
 		t.Fatalf("expected cached_tokens %d, got %d", 22000, gotCachedTokens)
 	}
 }
+
+func TestConvertClaudeResponseToOpenAINonStream_EchoesRequestMetadata(t *testing.T) {
+	original := []byte(`{"model":"claude-sonnet-4-5","metadata":{"trace":"abc","tenant":"t1"},"messages":[{"role":"user","content":"hi"}]}`)
+	raw := []byte("data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"claude-sonnet-4-5\"}}\n" +
+		"data: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n" +
+		"data: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"hello\"}}\n" +
+		"data: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":1}}\n")
+
+	out := ConvertClaudeResponseToOpenAINonStream(context.Background(), "", original, nil, raw, nil)
+
+	if got := gjson.GetBytes(out, "metadata.trace").String(); got != "abc" {
+		t.Fatalf("metadata.trace = %q, want %q; out=%s", got, "abc", out)
+	}
+	if got := gjson.GetBytes(out, "metadata.tenant").String(); got != "t1" {
+		t.Fatalf("metadata.tenant = %q, want %q", got, "t1")
+	}
+}
@@ -316,7 +316,7 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 				completed, _ = sjson.SetBytes(completed, "response.prompt_cache_key", v.String())
 			}
 			if v := req.Get("reasoning"); v.Exists() {
-				completed, _ = sjson.SetBytes(completed, "response.reasoning", v.Value())
+				completed, _ = sjson.SetRawBytes(completed, "response.reasoning", []byte(v.Raw))
 			}
 			if v := req.Get("safety_identifier"); v.Exists() {
 				completed, _ = sjson.SetBytes(completed, "response.safety_identifier", v.String())
@@ -331,13 +331,13 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 				completed, _ = sjson.SetBytes(completed, "response.temperature", v.Float())
 			}
 			if v := req.Get("text"); v.Exists() {
-				completed, _ = sjson.SetBytes(completed, "response.text", v.Value())
+				completed, _ = sjson.SetRawBytes(completed, "response.text", []byte(v.Raw))
 			}
 			if v := req.Get("tool_choice"); v.Exists() {
-				completed, _ = sjson.SetBytes(completed, "response.tool_choice", v.Value())
+				completed, _ = sjson.SetRawBytes(completed, "response.tool_choice", []byte(v.Raw))
 			}
 			if v := req.Get("tools"); v.Exists() {
-				completed, _ = sjson.SetBytes(completed, "response.tools", v.Value())
+				completed, _ = sjson.SetRawBytes(completed, "response.tools", []byte(v.Raw))
 			}
 			if v := req.Get("top_logprobs"); v.Exists() {
 				completed, _ = sjson.SetBytes(completed, "response.top_logprobs", v.Int())
@@ -349,10 +349,10 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 				completed, _ = sjson.SetBytes(completed, "response.truncation", v.String())
 			}
 			if v := req.Get("user"); v.Exists() {
-				completed, _ = sjson.SetBytes(completed, "response.user", v.Value())
+				completed, _ = sjson.SetRawBytes(completed, "response.user", []byte(v.Raw))
 			}
 			if v := req.Get("metadata"); v.Exists() {
-				completed, _ = sjson.SetBytes(completed, "response.metadata", v.Value())
+				completed, _ = sjson.SetRawBytes(completed, "response.metadata", []byte(v.Raw))
 			}
 		}
 
@@ -587,7 +587,7 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			out, _ = sjson.SetBytes(out, "prompt_cache_key", v.String())
 		}
 		if v := req.Get("reasoning"); v.Exists() {
-			out, _ = sjson.SetBytes(out, "reasoning", v.Value())
+			out, _ = sjson.SetRawBytes(out, "reasoning", []byte(v.Raw))
 		}
 		if v := req.Get("safety_identifier"); v.Exists() {
 			out, _ = sjson.SetBytes(out, "safety_identifier", v.String())
@@ -602,13 +602,13 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			out, _ = sjson.SetBytes(out, "temperature", v.Float())
 		}
 		if v := req.Get("text"); v.Exists() {
-			out, _ = sjson.SetBytes(out, "text", v.Value())
+			out, _ = sjson.SetRawBytes(out, "text", []byte(v.Raw))
 		}
 		if v := req.Get("tool_choice"); v.Exists() {
-			out, _ = sjson.SetBytes(out, "tool_choice", v.Value())
+			out, _ = sjson.SetRawBytes(out, "tool_choice", []byte(v.Raw))
 		}
 		if v := req.Get("tools"); v.Exists() {
-			out, _ = sjson.SetBytes(out, "tools", v.Value())
+			out, _ = sjson.SetRawBytes(out, "tools", []byte(v.Raw))
 		}
 		if v := req.Get("top_logprobs"); v.Exists() {
 			out, _ = sjson.SetBytes(out, "top_logprobs", v.Int())
@@ -620,10 +620,10 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			out, _ = sjson.SetBytes(out, "truncation", v.String())
 		}
 		if v := req.Get("user"); v.Exists() {
-			out, _ = sjson.SetBytes(out, "user", v.Value())
+			out, _ = sjson.SetRawBytes(out, "user", []byte(v.Raw))
 		}
 		if v := req.Get("metadata"); v.Exists() {
-			out, _ = sjson.SetBytes(out, "metadata", v.Value())
+			out, _ = sjson.SetRawBytes(out, "metadata", []byte(v.Raw))
 		}
 	}
 
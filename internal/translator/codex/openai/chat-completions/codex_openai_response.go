@@ -10,6 +10,7 @@ import (
 	"context"
 	"time"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -26,6 +27,36 @@ type ConvertCliToOpenAIParams struct {
 	FunctionCallIndex         int
 	HasReceivedArgumentsDelta bool
 	HasToolCallAnnounced      bool
+	// ToolCallIndexes maps upstream output_index values to chat tool_calls indexes so
+	// interleaved message and function_call items keep their own slots.
+	ToolCallIndexes map[int64]int
+	// ArgumentsStreamed records which tool_calls indexes already received argument deltas.
+	ArgumentsStreamed map[int]bool
+	// MetadataEchoed records whether a chunk already carried the request metadata.
+	MetadataEchoed bool
+}
+
+// toolCallIndex resolves the tool_calls index for a function call event, preferring the
+// event's output_index and falling back to the most recently announced call.
+func (p *ConvertCliToOpenAIParams) toolCallIndex(root gjson.Result) int {
+	if outputIndex := root.Get("output_index"); outputIndex.Exists() {
+		if idx, ok := p.ToolCallIndexes[outputIndex.Int()]; ok {
+			return idx
+		}
+	}
+	return p.FunctionCallIndex
+}
+
+// registerToolCall assigns the next tool_calls index to a new function call item.
+func (p *ConvertCliToOpenAIParams) registerToolCall(root gjson.Result) int {
+	p.FunctionCallIndex++
+	if outputIndex := root.Get("output_index"); outputIndex.Exists() {
+		if p.ToolCallIndexes == nil {
+			p.ToolCallIndexes = make(map[int64]int)
+		}
+		p.ToolCallIndexes[outputIndex.Int()] = p.FunctionCallIndex
+	}
+	return p.FunctionCallIndex
 }
 
 // ConvertCodexResponseToOpenAI translates a single chunk of a streaming response from the
@@ -42,7 +73,7 @@ type ConvertCliToOpenAIParams struct {
 //
 // Returns:
 //   - [][]byte: A slice of OpenAI-compatible JSON responses
-func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
+func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) (chunks [][]byte) {
 	if *param == nil {
 		*param = &ConvertCliToOpenAIParams{
 			Model:                     modelName,
@@ -53,6 +84,10 @@ func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalR
 			HasToolCallAnnounced:      false,
 		}
 	}
+	defer func() {
+		p := (*param).(*ConvertCliToOpenAIParams)
+		chunks = translatorcommon.EchoOpenAIMetadataOnFirstChunk(chunks, originalRequestRawJSON, &p.MetadataEchoed)
+	}()
 
 	if !bytes.HasPrefix(rawJSON, dataTag) {
 		return [][]byte{}
@@ -133,13 +168,13 @@ func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalR
 			return [][]byte{}
 		}
 
-		// Increment index for this new function call item.
-		(*param).(*ConvertCliToOpenAIParams).FunctionCallIndex++
+		// Assign the next index to this new function call item.
+		toolCallIndex := (*param).(*ConvertCliToOpenAIParams).registerToolCall(rootResult)
 		(*param).(*ConvertCliToOpenAIParams).HasReceivedArgumentsDelta = false
 		(*param).(*ConvertCliToOpenAIParams).HasToolCallAnnounced = true
 
 		functionCallItemTemplate := []byte(`{"index":0,"id":"","type":"function","function":{"name":"","arguments":""}}`)
-		functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "index", (*param).(*ConvertCliToOpenAIParams).FunctionCallIndex)
+		functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "index", toolCallIndex)
 		functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "id", itemResult.Get("call_id").String())
 
 		// Restore original tool name if it was shortened.
@@ -156,18 +191,28 @@ func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalR
 		template, _ = sjson.SetRawBytes(template, "choices.0.delta.tool_calls.-1", functionCallItemTemplate)
 
 	} else if dataType == "response.function_call_arguments.delta" {
+		toolCallIndex := (*param).(*ConvertCliToOpenAIParams).toolCallIndex(rootResult)
 		(*param).(*ConvertCliToOpenAIParams).HasReceivedArgumentsDelta = true
+		if (*param).(*ConvertCliToOpenAIParams).ArgumentsStreamed == nil {
+			(*param).(*ConvertCliToOpenAIParams).ArgumentsStreamed = make(map[int]bool)
+		}
+		(*param).(*ConvertCliToOpenAIParams).ArgumentsStreamed[toolCallIndex] = true
 
 		deltaValue := rootResult.Get("delta").String()
 		functionCallItemTemplate := []byte(`{"index":0,"function":{"arguments":""}}`)
-		functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "index", (*param).(*ConvertCliToOpenAIParams).FunctionCallIndex)
+		functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "index", toolCallIndex)
 		functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "function.arguments", deltaValue)
 
 		template, _ = sjson.SetRawBytes(template, "choices.0.delta.tool_calls", []byte(`[]`))
 		template, _ = sjson.SetRawBytes(template, "choices.0.delta.tool_calls.-1", functionCallItemTemplate)
 
 	} else if dataType == "response.function_call_arguments.done" {
-		if (*param).(*ConvertCliToOpenAIParams).HasReceivedArgumentsDelta {
+		toolCallIndex := (*param).(*ConvertCliToOpenAIParams).toolCallIndex(rootResult)
+		argumentsStreamed := (*param).(*ConvertCliToOpenAIParams).HasReceivedArgumentsDelta
+		if rootResult.Get("output_index").Exists() {
+			argumentsStreamed = (*param).(*ConvertCliToOpenAIParams).ArgumentsStreamed[toolCallIndex]
+		}
+		if argumentsStreamed {
 			// Arguments were already streamed via delta events; nothing to emit.
 			return [][]byte{}
 		}
@@ -175,7 +220,7 @@ func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalR
 		// Fallback: no delta events were received, emit the full arguments as a single chunk.
 		fullArgs := rootResult.Get("arguments").String()
 		functionCallItemTemplate := []byte(`{"index":0,"function":{"arguments":""}}`)
-		functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "index", (*param).(*ConvertCliToOpenAIParams).FunctionCallIndex)
+		functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "index", toolCallIndex)
 		functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "function.arguments", fullArgs)
 
 		template, _ = sjson.SetRawBytes(template, "choices.0.delta.tool_calls", []byte(`[]`))
@@ -187,17 +232,22 @@ func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalR
 			return [][]byte{}
 		}
 
-		if (*param).(*ConvertCliToOpenAIParams).HasToolCallAnnounced {
+		if outputIndex := rootResult.Get("output_index"); outputIndex.Exists() {
+			if _, announced := (*param).(*ConvertCliToOpenAIParams).ToolCallIndexes[outputIndex.Int()]; announced {
+				// This item was already announced via its own output_item.added; skip emission.
+				return [][]byte{}
+			}
+		} else if (*param).(*ConvertCliToOpenAIParams).HasToolCallAnnounced {
 			// Tool call was already announced via output_item.added; skip emission.
 			(*param).(*ConvertCliToOpenAIParams).HasToolCallAnnounced = false
 			return [][]byte{}
 		}
 
 		// Fallback path: model skipped output_item.added, so emit complete tool call now.
-		(*param).(*ConvertCliToOpenAIParams).FunctionCallIndex++
+		toolCallIndex := (*param).(*ConvertCliToOpenAIParams).registerToolCall(rootResult)
 
 		functionCallItemTemplate := []byte(`{"index":0,"id":"","type":"function","function":{"name":"","arguments":""}}`)
-		functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "index", (*param).(*ConvertCliToOpenAIParams).FunctionCallIndex)
+		functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "index", toolCallIndex)
 
 		template, _ = sjson.SetRawBytes(template, "choices.0.delta.tool_calls", []byte(`[]`))
 		functionCallItemTemplate, _ = sjson.SetBytes(functionCallItemTemplate, "id", itemResult.Get("call_id").String())
@@ -372,7 +422,7 @@ func ConvertCodexResponseToOpenAINonStream(_ context.Context, _ string, original
 		}
 	}
 
-	return template
+	return translatorcommon.EchoOpenAIMetadata(template, originalRequestRawJSON)
 }
 
 // buildReverseMapFromOriginalOpenAI builds a map of shortened tool name -> original tool name
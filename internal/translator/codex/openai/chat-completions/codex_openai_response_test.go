@@ -90,3 +90,73 @@ func TestConvertCodexResponseToOpenAI_ToolCallArgumentsDeltaOmitsNullContentFiel
 		t.Fatalf("expected tool call arguments delta to exist, got %s", string(out[0]))
 	}
 }
+
+func TestConvertCodexResponseToOpenAI_InterleavedMessageAndFunctionCalls(t *testing.T) {
+	ctx := context.Background()
+	var param any
+
+	events := []string{
+		`data: {"type":"response.output_text.delta","output_index":0,"delta":"Checking "}`,
+		`data: {"type":"response.output_item.added","output_index":1,"item":{"type":"function_call","call_id":"call_a","name":"lookup"}}`,
+		`data: {"type":"response.output_item.added","output_index":3,"item":{"type":"function_call","call_id":"call_b","name":"search"}}`,
+		`data: {"type":"response.output_text.delta","output_index":2,"delta":"both sources."}`,
+		`data: {"type":"response.function_call_arguments.delta","output_index":1,"delta":"{\"id\":1}"}`,
+		`data: {"type":"response.function_call_arguments.delta","output_index":3,"delta":"{\"q\":\"x\"}"}`,
+		`data: {"type":"response.function_call_arguments.done","output_index":1,"arguments":"{\"id\":1}"}`,
+		`data: {"type":"response.output_item.done","output_index":1,"item":{"type":"function_call","call_id":"call_a","name":"lookup","arguments":"{\"id\":1}"}}`,
+		`data: {"type":"response.function_call_arguments.done","output_index":3,"arguments":"{\"q\":\"x\"}"}`,
+		`data: {"type":"response.output_item.done","output_index":3,"item":{"type":"function_call","call_id":"call_b","name":"search","arguments":"{\"q\":\"x\"}"}}`,
+		`data: {"type":"response.completed","response":{"id":"resp_1"}}`,
+	}
+
+	var chunks [][]byte
+	for _, event := range events {
+		chunks = append(chunks, ConvertCodexResponseToOpenAI(ctx, "gpt-5.4", nil, nil, []byte(event), &param)...)
+	}
+
+	type step struct {
+		content string
+		index   int64
+		callID  string
+		args    string
+	}
+	want := []step{
+		{content: "Checking ", index: -1},
+		{index: 0, callID: "call_a"},
+		{index: 1, callID: "call_b"},
+		{content: "both sources.", index: -1},
+		{index: 0, args: `{"id":1}`},
+		{index: 1, args: `{"q":"x"}`},
+	}
+	if len(chunks) != len(want)+1 {
+		t.Fatalf("chunks = %d, want %d", len(chunks), len(want)+1)
+	}
+	for i, w := range want {
+		chunk := chunks[i]
+		toolCall := gjson.GetBytes(chunk, "choices.0.delta.tool_calls.0")
+		if w.index < 0 {
+			if got := gjson.GetBytes(chunk, "choices.0.delta.content").String(); got != w.content {
+				t.Fatalf("chunk %d content = %q, want %q", i, got, w.content)
+			}
+			if toolCall.Exists() {
+				t.Fatalf("chunk %d: unexpected tool call in text chunk: %s", i, chunk)
+			}
+			continue
+		}
+		if gjson.GetBytes(chunk, "choices.0.delta.content").Exists() {
+			t.Fatalf("chunk %d: unexpected content in tool call chunk: %s", i, chunk)
+		}
+		if got := toolCall.Get("index").Int(); got != w.index {
+			t.Fatalf("chunk %d tool call index = %d, want %d", i, got, w.index)
+		}
+		if w.callID != "" && toolCall.Get("id").String() != w.callID {
+			t.Fatalf("chunk %d tool call id = %q, want %q", i, toolCall.Get("id").String(), w.callID)
+		}
+		if w.args != "" && toolCall.Get("function.arguments").String() != w.args {
+			t.Fatalf("chunk %d arguments = %q, want %q", i, toolCall.Get("function.arguments").String(), w.args)
+		}
+	}
+	if got := gjson.GetBytes(chunks[len(chunks)-1], "choices.0.finish_reason").String(); got != "tool_calls" {
+		t.Fatalf("finish_reason = %q, want tool_calls", got)
+	}
+}
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// EchoOpenAIMetadata copies the client's OpenAI request metadata object onto a translated
+// response so clients can round-trip their tracking data even when the upstream provider
+// does not accept it. Responses that already carry metadata are left unchanged.
+func EchoOpenAIMetadata(out, originalRequestRawJSON []byte) []byte {
+	metadata := gjson.GetBytes(originalRequestRawJSON, "metadata")
+	if !metadata.IsObject() || gjson.GetBytes(out, "metadata").Exists() {
+		return out
+	}
+	if updated, errSet := sjson.SetRawBytes(out, "metadata", []byte(metadata.Raw)); errSet == nil {
+		return updated
+	}
+	return out
+}
+
+// EchoOpenAIMetadataOnFirstChunk copies the client's request metadata onto the first chat
+// completion chunk among chunks, unless echoed reports that an earlier chunk of the stream
+// already carried it.
+func EchoOpenAIMetadataOnFirstChunk(chunks [][]byte, originalRequestRawJSON []byte, echoed *bool) [][]byte {
+	if *echoed {
+		return chunks
+	}
+	for i, chunk := range chunks {
+		if !gjson.GetBytes(chunk, "choices").Exists() {
+			continue
+		}
+		chunks[i] = EchoOpenAIMetadata(chunk, originalRequestRawJSON)
+		*echoed = true
+		break
+	}
+	return chunks
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// OpenAIPenaltyParams lists the OpenAI sampling penalties handled by ApplyOpenAIPenalties.
+var OpenAIPenaltyParams = []string{"presence_penalty", "frequency_penalty"}
+
+// openAIPenaltyModels holds operator-listed lower-case model names whose penalties are forwarded
+// regardless of the registry. "*" matches every model.
+var openAIPenaltyModels atomic.Pointer[map[string]struct{}]
+
+// SetOpenAIPenaltyModels replaces the models whose presence/frequency penalties are forwarded
+// even when the registry does not declare support for them. Names are expected in lower case,
+// as produced by config sanitization; "*" forwards penalties for every model.
+func SetOpenAIPenaltyModels(models []string) {
+	if len(models) == 0 {
+		openAIPenaltyModels.Store(nil)
+		return
+	}
+	set := make(map[string]struct{}, len(models))
+	for _, model := range models {
+		set[model] = struct{}{}
+	}
+	openAIPenaltyModels.Store(&set)
+}
+
+// SupportsOpenAIPenalty reports whether the model declares the given OpenAI penalty
+// parameter in its registry supported_parameters list, or is listed by SetOpenAIPenaltyModels.
+func SupportsOpenAIPenalty(modelName, provider, param string) bool {
+	if models := openAIPenaltyModels.Load(); models != nil {
+		if _, ok := (*models)["*"]; ok {
+			return true
+		}
+		if _, ok := (*models)[strings.ToLower(strings.TrimSpace(modelName))]; ok {
+			return true
+		}
+	}
+	info := registry.LookupModelInfo(modelName, provider)
+	if info == nil {
+		return false
+	}
+	for _, supported := range info.SupportedParameters {
+		if strings.EqualFold(strings.TrimSpace(supported), param) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyOpenAIPenalties copies presence_penalty/frequency_penalty from an OpenAI request into
+// out at the paths given by targets, but only for models that declare support for them or are
+// listed in the openai-penalty-models config. Penalties without a target path or without model
+// support are dropped with a debug log so that upstreams lacking an equivalent never receive them.
+func ApplyOpenAIPenalties(out, rawJSON []byte, modelName, provider string, targets map[string]string) []byte {
+	for _, param := range OpenAIPenaltyParams {
+		value := gjson.GetBytes(rawJSON, param)
+		if !value.Exists() || value.Type != gjson.Number {
+			continue
+		}
+		target := targets[param]
+		if target == "" {
+			log.Debugf("%s translator: dropped %s, which has no upstream equivalent", provider, param)
+			continue
+		}
+		if !SupportsOpenAIPenalty(modelName, provider, param) {
+			log.Debugf("%s translator: dropped %s for model %s; list the model in openai-penalty-models to forward it", provider, param, modelName)
+			continue
+		}
+		if updated, errSet := sjson.SetBytes(out, target, value.Num); errSet == nil {
+			out = updated
+		}
+	}
+	return out
+}
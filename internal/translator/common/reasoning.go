@@ -0,0 +1,23 @@
+package common
+
+import "context"
+
+type suppressReasoningKey struct{}
+
+// WithReasoningSuppressed marks ctx so response translators drop upstream reasoning
+// content instead of mapping it into the client's reasoning fields.
+func WithReasoningSuppressed(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, suppressReasoningKey{}, true)
+}
+
+// ReasoningSuppressed reports whether ctx was marked by WithReasoningSuppressed.
+func ReasoningSuppressed(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	suppressed, _ := ctx.Value(suppressReasoningKey{}).(bool)
+	return suppressed
+}
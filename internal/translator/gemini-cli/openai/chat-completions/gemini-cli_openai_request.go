@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
@@ -67,6 +68,12 @@ func ConvertOpenAIRequestToGeminiCLI(modelName string, inputRawJSON []byte, _ bo
 		out, _ = sjson.SetBytes(out, "request.generationConfig.topK", tkr.Num)
 	}
 
+	// Presence/frequency penalties are only forwarded for models that declare support.
+	out = translatorcommon.ApplyOpenAIPenalties(out, rawJSON, modelName, "gemini-cli", map[string]string{
+		"presence_penalty":  "request.generationConfig.presencePenalty",
+		"frequency_penalty": "request.generationConfig.frequencyPenalty",
+	})
+
 	// Candidate count (OpenAI 'n' parameter)
 	if n := gjson.GetBytes(rawJSON, "n"); n.Exists() && n.Type == gjson.Number {
 		if val := n.Int(); val > 1 {
@@ -74,6 +81,11 @@ func ConvertOpenAIRequestToGeminiCLI(modelName string, inputRawJSON []byte, _ bo
 		}
 	}
 
+	// OpenAI JSON mode -> Gemini JSON output without a schema
+	if gjson.GetBytes(rawJSON, "response_format.type").String() == "json_object" {
+		out, _ = sjson.SetBytes(out, "request.generationConfig.responseMimeType", "application/json")
+	}
+
 	// Map OpenAI modalities -> Gemini CLI request.generationConfig.responseModalities
 	// e.g. "modalities": ["image", "text"] -> ["IMAGE", "TEXT"]
 	if mods := gjson.GetBytes(rawJSON, "modalities"); mods.Exists() && mods.IsArray() {
@@ -332,6 +344,10 @@ func ConvertOpenAIRequestToGeminiCLI(modelName string, inputRawJSON []byte, _ bo
 						}
 					}
 					fnRaw, _ = sjson.SetBytes(fnRaw, "name", util.SanitizeFunctionName(fn.Get("name").String()))
+					if fn.Get("strict").Bool() {
+						strictSchema := util.ApplyStrictSchemaForGemini(gjson.GetBytes(fnRaw, "parametersJsonSchema").Raw)
+						fnRaw, _ = sjson.SetRawBytes(fnRaw, "parametersJsonSchema", []byte(strictSchema))
+					}
 					fnRaw, _ = sjson.DeleteBytes(fnRaw, "strict")
 					if !hasFunction {
 						functionToolNode, _ = sjson.SetRawBytes(functionToolNode, "functionDeclarations", []byte("[]"))
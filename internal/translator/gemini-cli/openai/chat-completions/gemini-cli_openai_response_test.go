@@ -0,0 +1,35 @@
+package chat_completions
+
+import (
+	"context"
+	"testing"
+
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertCliResponseToOpenAI_SuppressesThoughtParts(t *testing.T) {
+	chunk := []byte(`{"response":{"candidates":[{"content":{"role":"model","parts":[{"text":"Weighing options.","thought":true},{"text":"Pick B."}]}}]}}`)
+
+	var param any
+	chunks := ConvertCliResponseToOpenAI(context.Background(), "", nil, nil, chunk, &param)
+	if len(chunks) != 1 {
+		t.Fatalf("chunks = %d, want 1", len(chunks))
+	}
+	if got := gjson.GetBytes(chunks[0], "choices.0.delta.reasoning_content").String(); got != "Weighing options." {
+		t.Fatalf("delta.reasoning_content = %q, want %q", got, "Weighing options.")
+	}
+
+	param = nil
+	ctx := translatorcommon.WithReasoningSuppressed(context.Background())
+	chunks = ConvertCliResponseToOpenAI(ctx, "", nil, nil, chunk, &param)
+	if len(chunks) != 1 {
+		t.Fatalf("chunks = %d, want 1", len(chunks))
+	}
+	if gjson.GetBytes(chunks[0], "choices.0.delta.reasoning_content").String() != "" {
+		t.Fatalf("unexpected reasoning_content: %s", chunks[0])
+	}
+	if got := gjson.GetBytes(chunks[0], "choices.0.delta.content").String(); got != "Pick B." {
+		t.Fatalf("delta.content = %q, want %q", got, "Pick B.")
+	}
+}
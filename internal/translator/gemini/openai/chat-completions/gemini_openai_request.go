@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
@@ -67,6 +68,12 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 		out, _ = sjson.SetBytes(out, "generationConfig.topK", tkr.Num)
 	}
 
+	// Presence/frequency penalties are only forwarded for models that declare support.
+	out = translatorcommon.ApplyOpenAIPenalties(out, rawJSON, modelName, "gemini", map[string]string{
+		"presence_penalty":  "generationConfig.presencePenalty",
+		"frequency_penalty": "generationConfig.frequencyPenalty",
+	})
+
 	// Candidate count (OpenAI 'n' parameter)
 	if n := gjson.GetBytes(rawJSON, "n"); n.Exists() && n.Type == gjson.Number {
 		if val := n.Int(); val > 1 {
@@ -74,6 +81,16 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 		}
 	}
 
+	// Sampling seed
+	if seed := gjson.GetBytes(rawJSON, "seed"); seed.Exists() && seed.Type == gjson.Number {
+		out, _ = sjson.SetBytes(out, "generationConfig.seed", seed.Int())
+	}
+
+	// OpenAI JSON mode -> Gemini JSON output without a schema
+	if gjson.GetBytes(rawJSON, "response_format.type").String() == "json_object" {
+		out, _ = sjson.SetBytes(out, "generationConfig.responseMimeType", "application/json")
+	}
+
 	// Map OpenAI modalities -> Gemini generationConfig.responseModalities
 	// e.g. "modalities": ["image", "text"] -> ["IMAGE", "TEXT"]
 	if mods := gjson.GetBytes(rawJSON, "modalities"); mods.Exists() && mods.IsArray() {
@@ -344,6 +361,10 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 					fnRawBytes := []byte(fnRaw)
 					fnRawBytes, _ = sjson.SetBytes(fnRawBytes, "name", util.SanitizeFunctionName(fn.Get("name").String()))
 					fnRaw = string(fnRawBytes)
+					if fn.Get("strict").Bool() {
+						strictSchema := util.ApplyStrictSchemaForGemini(gjson.Get(fnRaw, "parametersJsonSchema").Raw)
+						fnRaw, _ = sjson.SetRaw(fnRaw, "parametersJsonSchema", strictSchema)
+					}
 					fnRaw, _ = sjson.Delete(fnRaw, "strict")
 					if !hasFunction {
 						functionToolNode, _ = sjson.SetRawBytes(functionToolNode, "functionDeclarations", []byte("[]"))
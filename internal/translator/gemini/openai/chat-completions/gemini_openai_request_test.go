@@ -0,0 +1,136 @@
+package chat_completions
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToGemini_MapsPenaltiesForSupportingModel(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("penalty-test-client", "gemini", []*registry.ModelInfo{{
+		ID:                  "gemini-penalty-test",
+		SupportedParameters: []string{"presence_penalty", "frequency_penalty"},
+	}})
+	t.Cleanup(func() { reg.UnregisterClient("penalty-test-client") })
+
+	input := []byte(`{"model":"gemini-penalty-test","presence_penalty":0.5,"frequency_penalty":0.25,"messages":[{"role":"user","content":"hi"}]}`)
+	out := ConvertOpenAIRequestToGemini("gemini-penalty-test", input, false)
+
+	if got := gjson.GetBytes(out, "generationConfig.presencePenalty").Float(); got != 0.5 {
+		t.Fatalf("presencePenalty = %v, want 0.5; out=%s", got, out)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.frequencyPenalty").Float(); got != 0.25 {
+		t.Fatalf("frequencyPenalty = %v, want 0.25; out=%s", got, out)
+	}
+}
+
+func TestConvertOpenAIRequestToGemini_StripsPenaltiesForUnsupportedModel(t *testing.T) {
+	input := []byte(`{"model":"gemini-2.5-pro","presence_penalty":0.5,"frequency_penalty":0.25,"messages":[{"role":"user","content":"hi"}]}`)
+	out := ConvertOpenAIRequestToGemini("gemini-2.5-pro", input, false)
+
+	if gjson.GetBytes(out, "generationConfig.presencePenalty").Exists() {
+		t.Fatalf("unexpected presencePenalty in %s", out)
+	}
+	if gjson.GetBytes(out, "generationConfig.frequencyPenalty").Exists() {
+		t.Fatalf("unexpected frequencyPenalty in %s", out)
+	}
+}
+
+func TestConvertOpenAIRequestToGemini_MapsPenaltiesForConfiguredModel(t *testing.T) {
+	translatorcommon.SetOpenAIPenaltyModels([]string{"gemini-2.5-pro"})
+	t.Cleanup(func() { translatorcommon.SetOpenAIPenaltyModels(nil) })
+
+	input := []byte(`{"model":"gemini-2.5-pro","presence_penalty":0.5,"frequency_penalty":0.25,"messages":[{"role":"user","content":"hi"}]}`)
+	out := ConvertOpenAIRequestToGemini("Gemini-2.5-Pro", input, false)
+	if got := gjson.GetBytes(out, "generationConfig.presencePenalty").Float(); got != 0.5 {
+		t.Fatalf("presencePenalty = %v, want 0.5; out=%s", got, out)
+	}
+
+	other := ConvertOpenAIRequestToGemini("gemini-2.5-flash", input, false)
+	if gjson.GetBytes(other, "generationConfig.frequencyPenalty").Exists() {
+		t.Fatalf("unexpected frequencyPenalty for unlisted model: %s", other)
+	}
+
+	translatorcommon.SetOpenAIPenaltyModels([]string{"*"})
+	other = ConvertOpenAIRequestToGemini("gemini-2.5-flash", input, false)
+	if got := gjson.GetBytes(other, "generationConfig.frequencyPenalty").Float(); got != 0.25 {
+		t.Fatalf("frequencyPenalty = %v, want 0.25 with wildcard; out=%s", got, other)
+	}
+}
+
+func TestConvertOpenAIRequestToGemini_MapsJSONObjectResponseFormat(t *testing.T) {
+	input := []byte(`{"model":"gemini-2.5-pro","response_format":{"type":"json_object"},"messages":[{"role":"user","content":"hi"}]}`)
+	out := ConvertOpenAIRequestToGemini("gemini-2.5-pro", input, false)
+
+	if got := gjson.GetBytes(out, "generationConfig.responseMimeType").String(); got != "application/json" {
+		t.Fatalf("responseMimeType = %q, want application/json; out=%s", got, out)
+	}
+	if gjson.GetBytes(out, "generationConfig.responseSchema").Exists() || gjson.GetBytes(out, "generationConfig.responseJsonSchema").Exists() {
+		t.Fatalf("unexpected response schema for json_object: %s", out)
+	}
+
+	plain := ConvertOpenAIRequestToGemini("gemini-2.5-pro", []byte(`{"model":"gemini-2.5-pro","response_format":{"type":"text"},"messages":[{"role":"user","content":"hi"}]}`), false)
+	if gjson.GetBytes(plain, "generationConfig.responseMimeType").Exists() {
+		t.Fatalf("unexpected responseMimeType for text format: %s", plain)
+	}
+}
+
+func TestConvertOpenAIRequestToGemini_MapsSeedAndCandidateCount(t *testing.T) {
+	input := []byte(`{"model":"imagen-4.0-generate-001","n":4,"seed":7,"messages":[{"role":"user","content":"a cat"}]}`)
+	out := ConvertOpenAIRequestToGemini("imagen-4.0-generate-001", input, false)
+
+	if got := gjson.GetBytes(out, "generationConfig.candidateCount").Int(); got != 4 {
+		t.Fatalf("candidateCount = %d, want 4; out=%s", got, out)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.seed").Int(); got != 7 {
+		t.Fatalf("seed = %d, want 7; out=%s", got, out)
+	}
+}
+
+func TestConvertOpenAIRequestToGemini_StrictToolRequiresAllProperties(t *testing.T) {
+	input := []byte(`{"model":"gemini-2.5-pro","messages":[{"role":"user","content":"hi"}],"tools":[
+		{"type":"function","function":{"name":"lookup","strict":true,"parameters":{"type":"object","properties":{
+			"city":{"type":"string"},
+			"unit":{"type":["string","null"]},
+			"filters":{"type":"array","items":{"type":"object","properties":{"field":{"type":"string"},"value":{"type":"string"}},"required":["field"]}}
+		},"required":["city"],"additionalProperties":false}}},
+		{"type":"function","function":{"name":"loose","parameters":{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"string"}},"required":["a"]}}}
+	]}`)
+	out := ConvertOpenAIRequestToGemini("gemini-2.5-pro", input, false)
+
+	strict := gjson.GetBytes(out, "tools.0.functionDeclarations.0")
+	if strict.Get("strict").Exists() {
+		t.Fatalf("strict flag should not be forwarded: %s", strict.Raw)
+	}
+	if got := strict.Get("parametersJsonSchema.required").Raw; got != `["city","unit","filters"]` {
+		t.Fatalf("strict required = %s, want all properties; out=%s", got, out)
+	}
+	if got := strict.Get("parametersJsonSchema.properties.filters.items.required").Raw; got != `["field","value"]` {
+		t.Fatalf("strict nested required = %s, want all properties; out=%s", got, out)
+	}
+	if got := strict.Get("parametersJsonSchema.additionalProperties"); !got.Exists() || got.Bool() {
+		t.Fatalf("additionalProperties = %s, want false; out=%s", got.Raw, out)
+	}
+
+	loose := gjson.GetBytes(out, "tools.0.functionDeclarations.1")
+	if got := loose.Get("parametersJsonSchema.required").Raw; got != `["a"]` {
+		t.Fatalf("non-strict required = %s, want unchanged; out=%s", got, out)
+	}
+}
+
+func TestConvertOpenAIRequestToGemini_SystemMessagesGoToSystemInstruction(t *testing.T) {
+	input := []byte(`{"model":"gemini-2.5-pro","messages":[{"role":"system","content":"be terse"},{"role":"user","content":"hi"},{"role":"developer","content":"answer in French"}]}`)
+	out := ConvertOpenAIRequestToGemini("gemini-2.5-pro", input, false)
+
+	parts := gjson.GetBytes(out, "systemInstruction.parts").Array()
+	if len(parts) != 2 || parts[0].Get("text").String() != "be terse" || parts[1].Get("text").String() != "answer in French" {
+		t.Fatalf("systemInstruction.parts = %s, want both system texts; out=%s", gjson.GetBytes(out, "systemInstruction.parts").Raw, out)
+	}
+	contents := gjson.GetBytes(out, "contents").Array()
+	if len(contents) != 1 || contents[0].Get("role").String() != "user" || contents[0].Get("parts.0.text").String() != "hi" {
+		t.Fatalf("contents = %s, want only the user turn", gjson.GetBytes(out, "contents").Raw)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
@@ -25,6 +26,8 @@ type convertGeminiResponseToOpenAIChatParams struct {
 	// FunctionIndex tracks tool call indices per candidate index to support multiple candidates.
 	FunctionIndex    map[int]int
 	SanitizedNameMap map[string]string
+	// MetadataEchoed records whether a chunk already carried the request metadata.
+	MetadataEchoed bool
 }
 
 // functionCallIDCounter provides a process-wide unique counter for function call identifiers.
@@ -44,7 +47,7 @@ var functionCallIDCounter uint64
 //
 // Returns:
 //   - [][]byte: A slice of OpenAI-compatible JSON responses
-func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
+func ConvertGeminiResponseToOpenAI(ctx context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) (chunks [][]byte) {
 	// Initialize parameters if nil.
 	if *param == nil {
 		*param = &convertGeminiResponseToOpenAIChatParams{
@@ -62,6 +65,10 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 	if p.SanitizedNameMap == nil {
 		p.SanitizedNameMap = util.SanitizedToolNameMap(originalRequestRawJSON)
 	}
+	defer func() {
+		chunks = translatorcommon.EchoOpenAIMetadataOnFirstChunk(chunks, originalRequestRawJSON, &p.MetadataEchoed)
+	}()
+	suppressThoughts := translatorcommon.ReasoningSuppressed(ctx)
 
 	if bytes.HasPrefix(rawJSON, []byte("data:")) {
 		rawJSON = bytes.TrimSpace(rawJSON[5:])
@@ -165,7 +172,8 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 					}
 
 					hasThoughtSignature := thoughtSignatureResult.Exists() && thoughtSignatureResult.String() != ""
-					hasContentPayload := partTextResult.Exists() || functionCallResult.Exists() || inlineDataResult.Exists()
+					codeText, hasCodeExecution := geminiCodeExecutionContent(partResult)
+					hasContentPayload := partTextResult.Exists() || functionCallResult.Exists() || inlineDataResult.Exists() || hasCodeExecution
 
 					// Skip pure thoughtSignature parts but keep any actual payload in the same part.
 					if hasThoughtSignature && !hasContentPayload {
@@ -176,11 +184,19 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 						text := partTextResult.String()
 						// Handle text content, distinguishing between regular content and reasoning/thoughts.
 						if partResult.Get("thought").Bool() {
+							if suppressThoughts {
+								continue
+							}
 							template, _ = sjson.SetBytes(template, "choices.0.delta.reasoning_content", text)
 						} else {
 							template, _ = sjson.SetBytes(template, "choices.0.delta.content", text)
 						}
 						template, _ = sjson.SetBytes(template, "choices.0.delta.role", "assistant")
+					} else if hasCodeExecution {
+						// Surface built-in code execution as readable assistant content.
+						oldVal := gjson.GetBytes(template, "choices.0.delta.content").String()
+						template, _ = sjson.SetBytes(template, "choices.0.delta.content", oldVal+codeText)
+						template, _ = sjson.SetBytes(template, "choices.0.delta.role", "assistant")
 					} else if functionCallResult.Exists() {
 						// Handle function call content.
 						hasFunctionCall = true
@@ -270,8 +286,9 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 //
 // Returns:
 //   - []byte: An OpenAI-compatible JSON response containing all message content and metadata
-func ConvertGeminiResponseToOpenAINonStream(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) []byte {
+func ConvertGeminiResponseToOpenAINonStream(ctx context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) []byte {
 	sanitizedNameMap := util.SanitizedToolNameMap(originalRequestRawJSON)
+	suppressThoughts := translatorcommon.ReasoningSuppressed(ctx)
 	var unixTimestamp int64
 	// Initialize template with an empty choices array to support multiple candidates.
 	template := []byte(`{"id":"","object":"chat.completion","created":123456,"model":"model","choices":[]}`)
@@ -350,6 +367,9 @@ func ConvertGeminiResponseToOpenAINonStream(_ context.Context, _ string, origina
 					if partTextResult.Exists() {
 						// Append text content, distinguishing between regular content and reasoning.
 						if partResult.Get("thought").Bool() {
+							if suppressThoughts {
+								continue
+							}
 							oldVal := gjson.GetBytes(choiceTemplate, "message.reasoning_content").String()
 							choiceTemplate, _ = sjson.SetBytes(choiceTemplate, "message.reasoning_content", oldVal+partTextResult.String())
 						} else {
@@ -357,6 +377,11 @@ func ConvertGeminiResponseToOpenAINonStream(_ context.Context, _ string, origina
 							choiceTemplate, _ = sjson.SetBytes(choiceTemplate, "message.content", oldVal+partTextResult.String())
 						}
 						choiceTemplate, _ = sjson.SetBytes(choiceTemplate, "message.role", "assistant")
+					} else if codeText, ok := geminiCodeExecutionContent(partResult); ok {
+						// Append built-in code execution as readable assistant content.
+						oldVal := gjson.GetBytes(choiceTemplate, "message.content").String()
+						choiceTemplate, _ = sjson.SetBytes(choiceTemplate, "message.content", oldVal+codeText)
+						choiceTemplate, _ = sjson.SetBytes(choiceTemplate, "message.role", "assistant")
 					} else if functionCallResult.Exists() {
 						// Append function call content to the tool_calls array.
 						hasFunctionCall = true
@@ -410,5 +435,35 @@ func ConvertGeminiResponseToOpenAINonStream(_ context.Context, _ string, origina
 		})
 	}
 
-	return template
+	return translatorcommon.EchoOpenAIMetadata(template, originalRequestRawJSON)
+}
+
+// geminiCodeExecutionContent renders executableCode and codeExecutionResult parts produced by
+// Gemini's built-in codeExecution tool as Markdown code blocks, since OpenAI has no equivalent.
+func geminiCodeExecutionContent(part gjson.Result) (string, bool) {
+	executableCode := part.Get("executableCode")
+	if !executableCode.Exists() {
+		executableCode = part.Get("executable_code")
+	}
+	if executableCode.Exists() {
+		language := strings.ToLower(executableCode.Get("language").String())
+		if language == "" || language == "language_unspecified" {
+			language = "python"
+		}
+		return fmt.Sprintf("\n```%s\n%s\n```\n", language, strings.TrimRight(executableCode.Get("code").String(), "\n")), true
+	}
+
+	executionResult := part.Get("codeExecutionResult")
+	if !executionResult.Exists() {
+		executionResult = part.Get("code_execution_result")
+	}
+	if executionResult.Exists() {
+		output := strings.TrimRight(executionResult.Get("output").String(), "\n")
+		outcome := executionResult.Get("outcome").String()
+		if outcome != "" && outcome != "OUTCOME_OK" {
+			return fmt.Sprintf("\n```output\n%s\n```\n(code execution outcome: %s)\n", output, strings.ToLower(strings.TrimPrefix(outcome, "OUTCOME_"))), true
+		}
+		return fmt.Sprintf("\n```output\n%s\n```\n", output), true
+	}
+	return "", false
 }
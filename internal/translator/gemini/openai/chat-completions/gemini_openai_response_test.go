@@ -0,0 +1,125 @@
+package chat_completions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+	"github.com/tidwall/gjson"
+)
+
+const geminiCodeExecutionResponse = `{"responseId":"resp-1","candidates":[{"index":0,"finishReason":"STOP","content":{"role":"model","parts":[` +
+	`{"text":"Let me compute that."},` +
+	`{"executableCode":{"language":"PYTHON","code":"print(2 + 2)\n"}},` +
+	`{"codeExecutionResult":{"outcome":"OUTCOME_OK","output":"4\n"}},` +
+	`{"text":"The answer is 4."}]}}]}`
+
+func TestConvertGeminiResponseToOpenAINonStream_RendersCodeExecution(t *testing.T) {
+	out := ConvertGeminiResponseToOpenAINonStream(context.Background(), "", nil, nil, []byte(geminiCodeExecutionResponse), nil)
+
+	content := gjson.GetBytes(out, "choices.0.message.content").String()
+	want := "Let me compute that.\n```python\nprint(2 + 2)\n```\n\n```output\n4\n```\nThe answer is 4."
+	if content != want {
+		t.Fatalf("content = %q, want %q", content, want)
+	}
+	if gjson.GetBytes(out, "choices.0.message.tool_calls").IsArray() {
+		t.Fatalf("unexpected tool_calls for code execution: %s", out)
+	}
+}
+
+func TestConvertGeminiResponseToOpenAI_StreamsCodeExecution(t *testing.T) {
+	var param any
+	chunk := []byte(`{"candidates":[{"index":0,"content":{"role":"model","parts":[{"codeExecutionResult":{"outcome":"OUTCOME_FAILED","output":"Traceback"}}]}}]}`)
+
+	chunks := ConvertGeminiResponseToOpenAI(context.Background(), "", nil, nil, chunk, &param)
+	if len(chunks) != 1 {
+		t.Fatalf("chunks = %d, want 1", len(chunks))
+	}
+	content := gjson.GetBytes(chunks[0], "choices.0.delta.content").String()
+	if !strings.Contains(content, "```output\nTraceback\n```") || !strings.Contains(content, "outcome: failed") {
+		t.Fatalf("unexpected streamed content: %q", content)
+	}
+}
+
+func TestConvertGeminiResponseToOpenAINonStream_EchoesRequestMetadata(t *testing.T) {
+	original := []byte(`{"model":"gemini-2.5-pro","metadata":{"trace":"abc"},"messages":[{"role":"user","content":"hi"}]}`)
+	raw := []byte(`{"candidates":[{"index":0,"finishReason":"STOP","content":{"role":"model","parts":[{"text":"hello"}]}}]}`)
+
+	out := ConvertGeminiResponseToOpenAINonStream(context.Background(), "", original, nil, raw, nil)
+	if got := gjson.GetBytes(out, "metadata.trace").String(); got != "abc" {
+		t.Fatalf("metadata.trace = %q, want %q; out=%s", got, "abc", out)
+	}
+
+	out = ConvertGeminiResponseToOpenAINonStream(context.Background(), "", []byte(`{"messages":[]}`), nil, raw, nil)
+	if gjson.GetBytes(out, "metadata").Exists() {
+		t.Fatalf("unexpected metadata without request metadata: %s", out)
+	}
+}
+
+const geminiThoughtResponse = `{"candidates":[{"index":0,"finishReason":"STOP","content":{"role":"model","parts":[` +
+	`{"text":"Weighing options.","thought":true},` +
+	`{"text":"Pick B."}]}}]}`
+
+func TestConvertGeminiResponseToOpenAI_MapsThoughtParts(t *testing.T) {
+	out := ConvertGeminiResponseToOpenAINonStream(context.Background(), "", nil, nil, []byte(geminiThoughtResponse), nil)
+	if got := gjson.GetBytes(out, "choices.0.message.reasoning_content").String(); got != "Weighing options." {
+		t.Fatalf("reasoning_content = %q, want %q", got, "Weighing options.")
+	}
+	if got := gjson.GetBytes(out, "choices.0.message.content").String(); got != "Pick B." {
+		t.Fatalf("content = %q, want %q", got, "Pick B.")
+	}
+
+	var param any
+	chunk := []byte(`{"candidates":[{"index":0,"content":{"role":"model","parts":[{"text":"Weighing options.","thought":true}]}}]}`)
+	chunks := ConvertGeminiResponseToOpenAI(context.Background(), "", nil, nil, chunk, &param)
+	if len(chunks) != 1 {
+		t.Fatalf("chunks = %d, want 1", len(chunks))
+	}
+	if got := gjson.GetBytes(chunks[0], "choices.0.delta.reasoning_content").String(); got != "Weighing options." {
+		t.Fatalf("delta.reasoning_content = %q, want %q", got, "Weighing options.")
+	}
+}
+
+func TestConvertGeminiResponseToOpenAI_SuppressesThoughtParts(t *testing.T) {
+	ctx := translatorcommon.WithReasoningSuppressed(context.Background())
+
+	out := ConvertGeminiResponseToOpenAINonStream(ctx, "", nil, nil, []byte(geminiThoughtResponse), nil)
+	if gjson.GetBytes(out, "choices.0.message.reasoning_content").String() != "" {
+		t.Fatalf("unexpected reasoning_content: %s", out)
+	}
+	if got := gjson.GetBytes(out, "choices.0.message.content").String(); got != "Pick B." {
+		t.Fatalf("content = %q, want %q", got, "Pick B.")
+	}
+
+	var param any
+	chunk := []byte(`{"candidates":[{"index":0,"content":{"role":"model","parts":[{"text":"Weighing options.","thought":true},{"text":"Pick B."}]}}]}`)
+	chunks := ConvertGeminiResponseToOpenAI(ctx, "", nil, nil, chunk, &param)
+	if len(chunks) != 1 {
+		t.Fatalf("chunks = %d, want 1", len(chunks))
+	}
+	if gjson.GetBytes(chunks[0], "choices.0.delta.reasoning_content").String() != "" {
+		t.Fatalf("unexpected delta.reasoning_content: %s", chunks[0])
+	}
+	if got := gjson.GetBytes(chunks[0], "choices.0.delta.content").String(); got != "Pick B." {
+		t.Fatalf("delta.content = %q, want %q", got, "Pick B.")
+	}
+}
+
+func TestConvertGeminiResponseToOpenAI_EchoesRequestMetadataOnFirstChunk(t *testing.T) {
+	original := []byte(`{"model":"gemini-2.5-pro","metadata":{"trace":"abc"},"messages":[{"role":"user","content":"hi"}],"stream":true}`)
+	chunk := []byte(`{"candidates":[{"index":0,"content":{"role":"model","parts":[{"text":"hello"}]}}]}`)
+	var param any
+
+	first := ConvertGeminiResponseToOpenAI(context.Background(), "", original, nil, chunk, &param)
+	second := ConvertGeminiResponseToOpenAI(context.Background(), "", original, nil, chunk, &param)
+
+	if len(first) == 0 || gjson.GetBytes(first[0], "metadata.trace").String() != "abc" {
+		t.Fatalf("first chunk = %q, want request metadata", first)
+	}
+	for _, out := range second {
+		if gjson.GetBytes(out, "metadata").Exists() {
+			t.Fatalf("later chunk = %s, want metadata only on the first chunk", out)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package responses
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIResponsesRequestToGemini_SystemAndDeveloperGoToSystemInstruction(t *testing.T) {
+	input := []byte(`{"model":"gemini-2.5-pro","instructions":"be terse","input":[` +
+		`{"type":"message","role":"system","content":[{"type":"input_text","text":"no emojis"}]},` +
+		`{"type":"message","role":"user","content":[{"type":"input_text","text":"hi"}]},` +
+		`{"type":"message","role":"developer","content":[{"type":"input_text","text":"answer in French"}]}]}`)
+	out := ConvertOpenAIResponsesRequestToGemini("gemini-2.5-pro", input, false)
+
+	var texts []string
+	for _, part := range gjson.GetBytes(out, "systemInstruction.parts").Array() {
+		texts = append(texts, part.Get("text").String())
+	}
+	want := []string{"be terse", "no emojis", "answer in French"}
+	if len(texts) != len(want) {
+		t.Fatalf("systemInstruction texts = %q, want %q; out=%s", texts, want, out)
+	}
+	for i := range want {
+		if texts[i] != want[i] {
+			t.Fatalf("systemInstruction texts = %q, want %q", texts, want)
+		}
+	}
+
+	contents := gjson.GetBytes(out, "contents").Array()
+	if len(contents) != 1 {
+		t.Fatalf("contents = %s, want only the user turn", gjson.GetBytes(out, "contents").Raw)
+	}
+	if role := contents[0].Get("role").String(); role != "user" {
+		t.Fatalf("contents[0].role = %q, want user", role)
+	}
+	if text := contents[0].Get("parts.0.text").String(); text != "hi" {
+		t.Fatalf("contents[0] text = %q, want hi", text)
+	}
+}
+
+func TestConvertOpenAIResponsesRequestToGemini_StrictToolRequiresAllProperties(t *testing.T) {
+	input := []byte(`{"model":"gemini-2.5-pro","input":"hi","tools":[
+		{"type":"function","name":"lookup","strict":true,"parameters":{"type":"object","properties":{
+			"city":{"type":"string"},
+			"filters":{"type":"array","items":{"type":"object","properties":{"field":{"type":"string"},"value":{"type":"string"}},"required":["field"]}}
+		},"required":["city"],"additionalProperties":false}},
+		{"type":"function","name":"loose","parameters":{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"string"}},"required":["a"]}}
+	]}`)
+	out := ConvertOpenAIResponsesRequestToGemini("gemini-2.5-pro", input, false)
+
+	strict := gjson.GetBytes(out, "tools.0.functionDeclarations.0")
+	if strict.Get("strict").Exists() {
+		t.Fatalf("strict flag should not be forwarded: %s", strict.Raw)
+	}
+	if got := strict.Get("parametersJsonSchema.required").Raw; got != `["city","filters"]` {
+		t.Fatalf("strict required = %s, want all properties; out=%s", got, out)
+	}
+	if got := strict.Get("parametersJsonSchema.properties.filters.items.required").Raw; got != `["field","value"]` {
+		t.Fatalf("strict nested required = %s, want all properties; out=%s", got, out)
+	}
+
+	loose := gjson.GetBytes(out, "tools.0.functionDeclarations.1")
+	if got := loose.Get("parametersJsonSchema.required").Raw; got != `["a"]` {
+		t.Fatalf("non-strict required = %s, want unchanged; out=%s", got, out)
+	}
+}
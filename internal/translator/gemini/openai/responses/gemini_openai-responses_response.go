@@ -460,7 +460,7 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 				completed, _ = sjson.SetBytes(completed, "response.prompt_cache_key", v.String())
 			}
 			if v := req.Get("reasoning"); v.Exists() {
-				completed, _ = sjson.SetBytes(completed, "response.reasoning", v.Value())
+				completed, _ = sjson.SetRawBytes(completed, "response.reasoning", []byte(v.Raw))
 			}
 			if v := req.Get("safety_identifier"); v.Exists() {
 				completed, _ = sjson.SetBytes(completed, "response.safety_identifier", v.String())
@@ -475,13 +475,13 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 				completed, _ = sjson.SetBytes(completed, "response.temperature", v.Float())
 			}
 			if v := req.Get("text"); v.Exists() {
-				completed, _ = sjson.SetBytes(completed, "response.text", v.Value())
+				completed, _ = sjson.SetRawBytes(completed, "response.text", []byte(v.Raw))
 			}
 			if v := req.Get("tool_choice"); v.Exists() {
-				completed, _ = sjson.SetBytes(completed, "response.tool_choice", v.Value())
+				completed, _ = sjson.SetRawBytes(completed, "response.tool_choice", []byte(v.Raw))
 			}
 			if v := req.Get("tools"); v.Exists() {
-				completed, _ = sjson.SetBytes(completed, "response.tools", v.Value())
+				completed, _ = sjson.SetRawBytes(completed, "response.tools", []byte(v.Raw))
 			}
 			if v := req.Get("top_logprobs"); v.Exists() {
 				completed, _ = sjson.SetBytes(completed, "response.top_logprobs", v.Int())
@@ -493,10 +493,10 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 				completed, _ = sjson.SetBytes(completed, "response.truncation", v.String())
 			}
 			if v := req.Get("user"); v.Exists() {
-				completed, _ = sjson.SetBytes(completed, "response.user", v.Value())
+				completed, _ = sjson.SetRawBytes(completed, "response.user", []byte(v.Raw))
 			}
 			if v := req.Get("metadata"); v.Exists() {
-				completed, _ = sjson.SetBytes(completed, "response.metadata", v.Value())
+				completed, _ = sjson.SetRawBytes(completed, "response.metadata", []byte(v.Raw))
 			}
 		}
 
@@ -623,7 +623,7 @@ func ConvertGeminiResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			resp, _ = sjson.SetBytes(resp, "prompt_cache_key", v.String())
 		}
 		if v := req.Get("reasoning"); v.Exists() {
-			resp, _ = sjson.SetBytes(resp, "reasoning", v.Value())
+			resp, _ = sjson.SetRawBytes(resp, "reasoning", []byte(v.Raw))
 		}
 		if v := req.Get("safety_identifier"); v.Exists() {
 			resp, _ = sjson.SetBytes(resp, "safety_identifier", v.String())
@@ -638,13 +638,13 @@ func ConvertGeminiResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			resp, _ = sjson.SetBytes(resp, "temperature", v.Float())
 		}
 		if v := req.Get("text"); v.Exists() {
-			resp, _ = sjson.SetBytes(resp, "text", v.Value())
+			resp, _ = sjson.SetRawBytes(resp, "text", []byte(v.Raw))
 		}
 		if v := req.Get("tool_choice"); v.Exists() {
-			resp, _ = sjson.SetBytes(resp, "tool_choice", v.Value())
+			resp, _ = sjson.SetRawBytes(resp, "tool_choice", []byte(v.Raw))
 		}
 		if v := req.Get("tools"); v.Exists() {
-			resp, _ = sjson.SetBytes(resp, "tools", v.Value())
+			resp, _ = sjson.SetRawBytes(resp, "tools", []byte(v.Raw))
 		}
 		if v := req.Get("top_logprobs"); v.Exists() {
 			resp, _ = sjson.SetBytes(resp, "top_logprobs", v.Int())
@@ -656,10 +656,10 @@ func ConvertGeminiResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			resp, _ = sjson.SetBytes(resp, "truncation", v.String())
 		}
 		if v := req.Get("user"); v.Exists() {
-			resp, _ = sjson.SetBytes(resp, "user", v.Value())
+			resp, _ = sjson.SetRawBytes(resp, "user", []byte(v.Raw))
 		}
 		if v := req.Get("metadata"); v.Exists() {
-			resp, _ = sjson.SetBytes(resp, "metadata", v.Value())
+			resp, _ = sjson.SetRawBytes(resp, "metadata", []byte(v.Raw))
 		}
 	} else if v := root.Get("modelVersion"); v.Exists() {
 		resp, _ = sjson.SetBytes(resp, "model", v.String())
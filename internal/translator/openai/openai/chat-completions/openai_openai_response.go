@@ -5,6 +5,8 @@ package chat_completions
 import (
 	"bytes"
 	"context"
+
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 )
 
 // ConvertOpenAIResponseToOpenAI normalizes a single chunk of an OpenAI-compatible streaming response.
@@ -26,7 +28,17 @@ func ConvertOpenAIResponseToOpenAI(_ context.Context, _ string, originalRequestR
 	if bytes.Equal(rawJSON, []byte("[DONE]")) {
 		return [][]byte{}
 	}
-	return [][]byte{rawJSON}
+	if *param == nil {
+		*param = &convertOpenAIResponseToOpenAIParams{}
+	}
+	p := (*param).(*convertOpenAIResponseToOpenAIParams)
+	return translatorcommon.EchoOpenAIMetadataOnFirstChunk([][]byte{rawJSON}, originalRequestRawJSON, &p.MetadataEchoed)
+}
+
+// convertOpenAIResponseToOpenAIParams holds the state of a streamed OpenAI passthrough.
+type convertOpenAIResponseToOpenAIParams struct {
+	// MetadataEchoed records whether a chunk already carried the request metadata.
+	MetadataEchoed bool
 }
 
 // ConvertOpenAIResponseToOpenAINonStream passes through a non-streaming OpenAI response.
@@ -40,5 +52,5 @@ func ConvertOpenAIResponseToOpenAI(_ context.Context, _ string, originalRequestR
 // Returns:
 //   - []byte: The OpenAI-compatible JSON response.
 func ConvertOpenAIResponseToOpenAINonStream(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []byte {
-	return rawJSON
+	return translatorcommon.EchoOpenAIMetadata(rawJSON, originalRequestRawJSON)
 }
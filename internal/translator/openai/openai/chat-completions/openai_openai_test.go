@@ -0,0 +1,47 @@
+package chat_completions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToOpenAI_PreservesMetadata(t *testing.T) {
+	input := []byte(`{"model":"alias","metadata":{"trace":"abc"},"messages":[{"role":"user","content":"hi"}]}`)
+	out := ConvertOpenAIRequestToOpenAI("gpt-4o", input, false)
+
+	if got := gjson.GetBytes(out, "metadata.trace").String(); got != "abc" {
+		t.Fatalf("metadata.trace = %q, want %q; out=%s", got, "abc", out)
+	}
+}
+
+func TestConvertOpenAIResponseToOpenAINonStream_EchoesMissingMetadata(t *testing.T) {
+	original := []byte(`{"model":"gpt-4o","metadata":{"trace":"abc"}}`)
+
+	out := ConvertOpenAIResponseToOpenAINonStream(context.Background(), "", original, nil, []byte(`{"id":"c1","choices":[]}`), nil)
+	if got := gjson.GetBytes(out, "metadata.trace").String(); got != "abc" {
+		t.Fatalf("metadata.trace = %q, want %q; out=%s", got, "abc", out)
+	}
+
+	upstream := []byte(`{"id":"c1","metadata":{"trace":"upstream"},"choices":[]}`)
+	out = ConvertOpenAIResponseToOpenAINonStream(context.Background(), "", original, nil, upstream, nil)
+	if got := gjson.GetBytes(out, "metadata.trace").String(); got != "upstream" {
+		t.Fatalf("metadata.trace = %q, want upstream value preserved", got)
+	}
+}
+
+func TestConvertOpenAIResponseToOpenAI_EchoesRequestMetadataOnFirstChunk(t *testing.T) {
+	original := []byte(`{"model":"gpt-4o","metadata":{"trace":"abc"},"messages":[{"role":"user","content":"hi"}],"stream":true}`)
+	var param any
+
+	first := ConvertOpenAIResponseToOpenAI(context.Background(), "", original, nil, []byte(`data: {"id":"c1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant"}}]}`), &param)
+	second := ConvertOpenAIResponseToOpenAI(context.Background(), "", original, nil, []byte(`data: {"id":"c1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"hi"}}]}`), &param)
+
+	if len(first) != 1 || gjson.GetBytes(first[0], "metadata.trace").String() != "abc" {
+		t.Fatalf("first chunk = %q, want request metadata", first)
+	}
+	if len(second) != 1 || gjson.GetBytes(second[0], "metadata").Exists() {
+		t.Fatalf("second chunk = %q, want metadata only on the first chunk", second)
+	}
+}
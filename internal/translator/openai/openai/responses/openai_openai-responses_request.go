@@ -158,7 +158,7 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 
 	// Convert tools from responses format to chat completions format
 	if tools := root.Get("tools"); tools.Exists() && tools.IsArray() {
-		var chatCompletionsTools []interface{}
+		chatCompletionsTools := []byte(`[]`)
 
 		tools.ForEach(func(_, tool gjson.Result) bool {
 			// Built-in tools (e.g. {"type":"web_search"}) are already compatible with the Chat Completions schema.
@@ -188,13 +188,13 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 			}
 
 			chatTool, _ = sjson.SetRawBytes(chatTool, "function", function)
-			chatCompletionsTools = append(chatCompletionsTools, gjson.ParseBytes(chatTool).Value())
+			chatCompletionsTools, _ = sjson.SetRawBytes(chatCompletionsTools, "-1", chatTool)
 
 			return true
 		})
 
-		if len(chatCompletionsTools) > 0 {
-			out, _ = sjson.SetBytes(out, "tools", chatCompletionsTools)
+		if gjson.GetBytes(chatCompletionsTools, "#").Int() > 0 {
+			out, _ = sjson.SetRawBytes(out, "tools", chatCompletionsTools)
 		}
 	}
 
@@ -475,7 +475,7 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 						completed, _ = sjson.SetBytes(completed, "response.prompt_cache_key", v.String())
 					}
 					if v := req.Get("reasoning"); v.Exists() {
-						completed, _ = sjson.SetBytes(completed, "response.reasoning", v.Value())
+						completed, _ = sjson.SetRawBytes(completed, "response.reasoning", []byte(v.Raw))
 					}
 					if v := req.Get("safety_identifier"); v.Exists() {
 						completed, _ = sjson.SetBytes(completed, "response.safety_identifier", v.String())
@@ -490,13 +490,13 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 						completed, _ = sjson.SetBytes(completed, "response.temperature", v.Float())
 					}
 					if v := req.Get("text"); v.Exists() {
-						completed, _ = sjson.SetBytes(completed, "response.text", v.Value())
+						completed, _ = sjson.SetRawBytes(completed, "response.text", []byte(v.Raw))
 					}
 					if v := req.Get("tool_choice"); v.Exists() {
-						completed, _ = sjson.SetBytes(completed, "response.tool_choice", v.Value())
+						completed, _ = sjson.SetRawBytes(completed, "response.tool_choice", []byte(v.Raw))
 					}
 					if v := req.Get("tools"); v.Exists() {
-						completed, _ = sjson.SetBytes(completed, "response.tools", v.Value())
+						completed, _ = sjson.SetRawBytes(completed, "response.tools", []byte(v.Raw))
 					}
 					if v := req.Get("top_logprobs"); v.Exists() {
 						completed, _ = sjson.SetBytes(completed, "response.top_logprobs", v.Int())
@@ -508,10 +508,10 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 						completed, _ = sjson.SetBytes(completed, "response.truncation", v.String())
 					}
 					if v := req.Get("user"); v.Exists() {
-						completed, _ = sjson.SetBytes(completed, "response.user", v.Value())
+						completed, _ = sjson.SetRawBytes(completed, "response.user", []byte(v.Raw))
 					}
 					if v := req.Get("metadata"); v.Exists() {
-						completed, _ = sjson.SetBytes(completed, "response.metadata", v.Value())
+						completed, _ = sjson.SetRawBytes(completed, "response.metadata", []byte(v.Raw))
 					}
 				}
 				// Build response.output using aggregated buffers
@@ -656,7 +656,7 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponsesNonStream(_ context.Co
 			resp, _ = sjson.SetBytes(resp, "prompt_cache_key", v.String())
 		}
 		if v := req.Get("reasoning"); v.Exists() {
-			resp, _ = sjson.SetBytes(resp, "reasoning", v.Value())
+			resp, _ = sjson.SetRawBytes(resp, "reasoning", []byte(v.Raw))
 		}
 		if v := req.Get("safety_identifier"); v.Exists() {
 			resp, _ = sjson.SetBytes(resp, "safety_identifier", v.String())
@@ -671,13 +671,13 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponsesNonStream(_ context.Co
 			resp, _ = sjson.SetBytes(resp, "temperature", v.Float())
 		}
 		if v := req.Get("text"); v.Exists() {
-			resp, _ = sjson.SetBytes(resp, "text", v.Value())
+			resp, _ = sjson.SetRawBytes(resp, "text", []byte(v.Raw))
 		}
 		if v := req.Get("tool_choice"); v.Exists() {
-			resp, _ = sjson.SetBytes(resp, "tool_choice", v.Value())
+			resp, _ = sjson.SetRawBytes(resp, "tool_choice", []byte(v.Raw))
 		}
 		if v := req.Get("tools"); v.Exists() {
-			resp, _ = sjson.SetBytes(resp, "tools", v.Value())
+			resp, _ = sjson.SetRawBytes(resp, "tools", []byte(v.Raw))
 		}
 		if v := req.Get("top_logprobs"); v.Exists() {
 			resp, _ = sjson.SetBytes(resp, "top_logprobs", v.Int())
@@ -689,10 +689,10 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponsesNonStream(_ context.Co
 			resp, _ = sjson.SetBytes(resp, "truncation", v.String())
 		}
 		if v := req.Get("user"); v.Exists() {
-			resp, _ = sjson.SetBytes(resp, "user", v.Value())
+			resp, _ = sjson.SetRawBytes(resp, "user", []byte(v.Raw))
 		}
 		if v := req.Get("metadata"); v.Exists() {
-			resp, _ = sjson.SetBytes(resp, "metadata", v.Value())
+			resp, _ = sjson.SetRawBytes(resp, "metadata", []byte(v.Raw))
 		}
 	} else if v := root.Get("model"); v.Exists() {
 		// Fallback model from response
@@ -773,7 +773,7 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponsesNonStream(_ context.Co
 			resp, _ = sjson.SetBytes(resp, "usage.total_tokens", usage.Get("total_tokens").Int())
 		} else {
 			// Fallback to raw usage object if structure differs
-			resp, _ = sjson.SetBytes(resp, "usage", usage.Value())
+			resp, _ = sjson.SetRawBytes(resp, "usage", []byte(usage.Raw))
 		}
 	}
 
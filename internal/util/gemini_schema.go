@@ -28,6 +28,57 @@ func CleanJSONSchemaForGemini(jsonStr string) string {
 	return cleanJSONSchema(jsonStr, false)
 }
 
+// ApplyStrictSchemaForGemini carries OpenAI strict tool semantics into a Gemini parameter schema.
+// Gemini function declarations have no strict flag, so the closest enforceable constraint is
+// listing every declared property as required on each object schema, which mirrors OpenAI's
+// rule that strict schemas require all properties. Optional fields are expected to use nullable
+// types as strict mode already demands. Gemini does not constrain decoding to the schema, so
+// adherence remains best effort.
+func ApplyStrictSchemaForGemini(jsonStr string) string {
+	return requireAllProperties(jsonStr, "")
+}
+
+// requireAllProperties sets required to every key of properties on the object schema at path
+// and recurses into nested properties, array items, combinators and definitions. An empty path
+// addresses the root schema.
+func requireAllProperties(jsonStr, path string) string {
+	node := gjson.Parse(jsonStr)
+	if path != "" {
+		node = gjson.Get(jsonStr, path)
+	}
+	if !node.IsObject() {
+		return jsonStr
+	}
+
+	if props := node.Get("properties"); props.IsObject() {
+		var keys []string
+		props.ForEach(func(key, _ gjson.Result) bool {
+			keys = append(keys, key.String())
+			jsonStr = requireAllProperties(jsonStr, joinPath(path, "properties."+escapeGJSONPathKey(key.String())))
+			return true
+		})
+		if len(keys) > 0 {
+			updated, _ := sjson.SetBytes([]byte(jsonStr), joinPath(path, "required"), keys)
+			jsonStr = string(updated)
+		}
+	}
+	if node.Get("items").IsObject() {
+		jsonStr = requireAllProperties(jsonStr, joinPath(path, "items"))
+	}
+	for _, keyword := range []string{"anyOf", "oneOf", "allOf"} {
+		for i := range node.Get(keyword).Array() {
+			jsonStr = requireAllProperties(jsonStr, joinPath(path, fmt.Sprintf("%s.%d", keyword, i)))
+		}
+	}
+	for _, keyword := range []string{"$defs", "definitions"} {
+		node.Get(keyword).ForEach(func(key, _ gjson.Result) bool {
+			jsonStr = requireAllProperties(jsonStr, joinPath(path, keyword+"."+escapeGJSONPathKey(key.String())))
+			return true
+		})
+	}
+	return jsonStr
+}
+
 // cleanJSONSchema performs the core cleaning operations on the JSON schema.
 func cleanJSONSchema(jsonStr string, addPlaceholder bool) string {
 	// Phase 1: Convert and add hints
@@ -1,14 +1,50 @@
 package util
 
 import (
+	"context"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
+// NoHeaderPassthroughHeader is the client request header that limits upstream requests to
+// the executor's baseline headers, skipping custom and client header passthrough.
+const NoHeaderPassthroughHeader = "X-No-Header-Passthrough"
+
+type headerPassthroughDisabledKey struct{}
+
+// WithHeaderPassthroughDisabled marks ctx so executors send only their baseline headers.
+func WithHeaderPassthroughDisabled(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, headerPassthroughDisabledKey{}, true)
+}
+
+// HeaderPassthroughDisabled reports whether ctx was marked by WithHeaderPassthroughDisabled.
+func HeaderPassthroughDisabled(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	disabled, _ := ctx.Value(headerPassthroughDisabledKey{}).(bool)
+	return disabled
+}
+
+// HeaderPassthroughDisabledByClient reports whether the inbound client headers opt out of
+// header passthrough via NoHeaderPassthroughHeader.
+func HeaderPassthroughDisabledByClient(clientHeaders http.Header) bool {
+	if clientHeaders == nil {
+		return false
+	}
+	disabled, err := strconv.ParseBool(strings.TrimSpace(clientHeaders.Get(NoHeaderPassthroughHeader)))
+	return err == nil && disabled
+}
+
 // ApplyCustomHeadersFromAttrs applies user-defined headers stored in the provided attributes map.
-// Custom headers override built-in defaults when conflicts occur.
+// Custom headers override built-in defaults when conflicts occur. Nothing is applied when the
+// request context has header passthrough disabled.
 func ApplyCustomHeadersFromAttrs(r *http.Request, attrs map[string]string) {
-	if r == nil {
+	if r == nil || HeaderPassthroughDisabled(r.Context()) {
 		return
 	}
 	applyCustomHeaders(r, extractCustomHeaders(attrs))
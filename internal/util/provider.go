@@ -5,11 +5,14 @@ package util
 
 import (
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 )
 
 // GetProviderName determines all AI service providers capable of serving a registered model.
@@ -267,3 +270,46 @@ func shouldMaskQueryParam(key string) bool {
 	}
 	return false
 }
+
+// MaskSensitiveJSON masks string values in a JSON document whose keys look like credentials,
+// using the same key rules as MaskSensitiveQuery. Non-JSON input is returned unchanged.
+func MaskSensitiveJSON(body []byte) []byte {
+	if len(body) == 0 || !gjson.ValidBytes(body) {
+		return body
+	}
+	var paths []string
+	collectSensitiveJSONPaths(gjson.ParseBytes(body), "", &paths)
+	for _, path := range paths {
+		value := gjson.GetBytes(body, path).String()
+		if updated, err := sjson.SetBytes(body, path, HideAPIKey(value)); err == nil {
+			body = updated
+		}
+	}
+	return body
+}
+
+func collectSensitiveJSONPaths(node gjson.Result, prefix string, paths *[]string) {
+	if !node.IsObject() && !node.IsArray() {
+		return
+	}
+	index := 0
+	node.ForEach(func(key, value gjson.Result) bool {
+		var segment string
+		if node.IsArray() {
+			segment = strconv.Itoa(index)
+			index++
+		} else {
+			segment = escapeGJSONPathKey(key.String())
+		}
+		path := segment
+		if prefix != "" {
+			path = prefix + "." + segment
+		}
+		if node.IsObject() && value.Type == gjson.String && shouldMaskQueryParam(key.String()) {
+			*paths = append(*paths, path)
+			return true
+		}
+		collectSensitiveJSONPaths(value, path, paths)
+		return true
+	})
+}
@@ -51,6 +51,12 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.ErrorLogsMaxFiles != newCfg.ErrorLogsMaxFiles {
 		changes = append(changes, fmt.Sprintf("error-logs-max-files: %d -> %d", oldCfg.ErrorLogsMaxFiles, newCfg.ErrorLogsMaxFiles))
 	}
+	if oldCfg.LogSampleRate != newCfg.LogSampleRate {
+		changes = append(changes, fmt.Sprintf("log-sample-rate: %g -> %g", oldCfg.LogSampleRate, newCfg.LogSampleRate))
+	}
+	if oldCfg.TranslationDeadLetterFile != newCfg.TranslationDeadLetterFile {
+		changes = append(changes, fmt.Sprintf("translation-dead-letter-file: %s -> %s", oldCfg.TranslationDeadLetterFile, newCfg.TranslationDeadLetterFile))
+	}
 	if oldCfg.RequestRetry != newCfg.RequestRetry {
 		changes = append(changes, fmt.Sprintf("request-retry: %d -> %d", oldCfg.RequestRetry, newCfg.RequestRetry))
 	}
@@ -60,6 +66,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.MaxRetryInterval != newCfg.MaxRetryInterval {
 		changes = append(changes, fmt.Sprintf("max-retry-interval: %d -> %d", oldCfg.MaxRetryInterval, newCfg.MaxRetryInterval))
 	}
+	if oldCfg.AuthCircuitBreaker.FailureThreshold != newCfg.AuthCircuitBreaker.FailureThreshold {
+		changes = append(changes, fmt.Sprintf("auth-circuit-breaker.failure-threshold: %d -> %d", oldCfg.AuthCircuitBreaker.FailureThreshold, newCfg.AuthCircuitBreaker.FailureThreshold))
+	}
 	if oldCfg.ProxyURL != newCfg.ProxyURL {
 		changes = append(changes, fmt.Sprintf("proxy-url: %s -> %s", formatProxyURL(oldCfg.ProxyURL), formatProxyURL(newCfg.ProxyURL)))
 	}
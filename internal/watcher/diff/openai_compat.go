@@ -75,6 +75,9 @@ func describeOpenAICompatibilityUpdate(oldEntry, newEntry config.OpenAICompatibi
 	if !equalStringMap(oldEntry.Headers, newEntry.Headers) {
 		details = append(details, "headers updated")
 	}
+	if strings.Join(oldEntry.EffectiveStripFields(), ",") != strings.Join(newEntry.EffectiveStripFields(), ",") {
+		details = append(details, "strip-fields updated")
+	}
 	if len(details) == 0 {
 		return ""
 	}
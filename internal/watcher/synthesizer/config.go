@@ -225,6 +225,7 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 				attrs["models_hash"] = hash
 			}
 			addConfigHeadersToAttrs(compat.Headers, attrs)
+			addStripFieldsToAttrs(compat.EffectiveStripFields(), attrs)
 			a := &coreauth.Auth{
 				ID:         id,
 				Provider:   providerName,
@@ -256,6 +257,7 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 				attrs["models_hash"] = hash
 			}
 			addConfigHeadersToAttrs(compat.Headers, attrs)
+			addStripFieldsToAttrs(compat.EffectiveStripFields(), attrs)
 			a := &coreauth.Auth{
 				ID:         id,
 				Provider:   providerName,
@@ -118,3 +118,20 @@ func addConfigHeadersToAttrs(headers map[string]string, attrs map[string]string)
 		attrs["header:"+key] = val
 	}
 }
+
+// addStripFieldsToAttrs records request body paths the provider rejects as a comma-separated
+// "strip_fields" attribute.
+func addStripFieldsToAttrs(fields []string, attrs map[string]string) {
+	if len(fields) == 0 || attrs == nil {
+		return
+	}
+	cleaned := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			cleaned = append(cleaned, field)
+		}
+	}
+	if len(cleaned) > 0 {
+		attrs["strip_fields"] = strings.Join(cleaned, ",")
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// DryRunHeader names the request header that asks for the prepared upstream request
+// instead of sending it.
+const DryRunHeader = "X-CLIProxy-DryRun"
+
+// applyDryRunHeader marks the request as a dry run when it carries X-CLIProxy-DryRun: true.
+// The header is only honoured for client API keys listed in dry-run-api-keys; others get 403.
+func (h *BaseAPIHandler) applyDryRunHeader(ctx context.Context, meta map[string]any) *interfaces.ErrorMessage {
+	if ctx == nil {
+		return nil
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return nil
+	}
+	enabled, errParse := strconv.ParseBool(strings.TrimSpace(ginCtx.GetHeader(DryRunHeader)))
+	if errParse != nil || !enabled {
+		return nil
+	}
+	if !h.dryRunAllowed(ginCtx.GetString("apiKey")) {
+		return &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("%s header is not allowed for this API key", DryRunHeader)}
+	}
+	meta[coreexecutor.DryRunMetadataKey] = true
+	return nil
+}
+
+func (h *BaseAPIHandler) dryRunAllowed(apiKey string) bool {
+	if h.Cfg == nil || apiKey == "" {
+		return false
+	}
+	for _, allowed := range h.Cfg.DryRunAPIKeys {
+		if allowed == apiKey {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func dryRunRequestContext(apiKey, idempotencyKey string, dryRun bool) context.Context {
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if dryRun {
+		ginCtx.Request.Header.Set(DryRunHeader, "true")
+	}
+	if idempotencyKey != "" {
+		ginCtx.Request.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	ginCtx.Set("apiKey", apiKey)
+	return context.WithValue(context.Background(), "gin", ginCtx)
+}
+
+// dryRunPinAuthExecutor is the pin-auth test executor declaring dry-run support.
+type dryRunPinAuthExecutor struct {
+	*pinAuthRecordingExecutor
+}
+
+func (dryRunPinAuthExecutor) SupportsDryRun() bool { return true }
+
+func TestExecuteWithAuthManager_DryRunRejectedForUnsupportedProvider(t *testing.T) {
+	handler, executor := newPinAuthTestHandler(t)
+	handler.Cfg.DryRunAPIKeys = []string{"admin-key"}
+
+	_, _, errMsg := handler.ExecuteWithAuthManager(dryRunRequestContext("admin-key", "", true), "openai", "pin-model", []byte(`{"model":"pin-model"}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusNotImplemented {
+		t.Fatalf("errMsg = %+v, want 501", errMsg)
+	}
+	if got := executor.calls(); got != 0 {
+		t.Fatalf("executor calls = %d, want no upstream call for an unsupported dry run", got)
+	}
+}
+
+func TestExecuteWithAuthManager_DryRunHeaderRejectedForUnlistedKey(t *testing.T) {
+	handler, executor := newPinAuthTestHandler(t)
+	handler.Cfg.DryRunAPIKeys = []string{"admin-key"}
+
+	_, _, errMsg := handler.ExecuteWithAuthManager(dryRunRequestContext("other-key", "", true), "openai", "pin-model", []byte(`{}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusForbidden {
+		t.Fatalf("errMsg = %+v, want 403", errMsg)
+	}
+	if got := executor.calls(); got != 0 {
+		t.Fatalf("executor calls = %d, want 0", got)
+	}
+}
+
+func TestExecuteWithAuthManager_DryRunSkipsIdempotencyCache(t *testing.T) {
+	handler, executor := newPinAuthTestHandler(t)
+	handler.AuthManager.RegisterExecutor(dryRunPinAuthExecutor{executor})
+	handler.Cfg.DryRunAPIKeys = []string{"admin-key"}
+	handler.Cfg.IdempotencyTTLSeconds = 60
+	body := []byte(`{"model":"pin-model"}`)
+
+	if _, _, errMsg := handler.ExecuteWithAuthManager(dryRunRequestContext("admin-key", t.Name(), true), "openai", "pin-model", body, ""); errMsg != nil {
+		t.Fatalf("dry run error: %v", errMsg.Error)
+	}
+	if _, _, errMsg := handler.ExecuteWithAuthManager(dryRunRequestContext("admin-key", t.Name(), false), "openai", "pin-model", body, ""); errMsg != nil {
+		t.Fatalf("real request error: %v", errMsg.Error)
+	}
+	if got := executor.calls(); got != 2 {
+		t.Fatalf("executor calls = %d, want the real request to bypass the dry run's entry", got)
+	}
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/tidwall/gjson"
+)
+
+// Normalized error types returned when normalize-errors is enabled.
+const (
+	ErrorTypeRateLimit      = "rate_limit"
+	ErrorTypeInvalidRequest = "invalid_request"
+	ErrorTypeAuthentication = "authentication"
+	ErrorTypeServerError    = "server_error"
+	ErrorTypeOverloaded     = "overloaded"
+)
+
+// errorFormatContextKey stores the handler type of the current request on the gin context
+// so error responses can be rendered in the client's format.
+const errorFormatContextKey = "cliproxy.error_format"
+
+// upstreamErrorCodes maps provider-native error codes to normalized error types.
+// Keys cover Gemini status values, OpenAI error types/codes and Anthropic error types.
+var upstreamErrorCodes = map[string]string{
+	// Gemini
+	"RESOURCE_EXHAUSTED":  ErrorTypeRateLimit,
+	"INVALID_ARGUMENT":    ErrorTypeInvalidRequest,
+	"FAILED_PRECONDITION": ErrorTypeInvalidRequest,
+	"NOT_FOUND":           ErrorTypeInvalidRequest,
+	"UNAUTHENTICATED":     ErrorTypeAuthentication,
+	"PERMISSION_DENIED":   ErrorTypeAuthentication,
+	"UNAVAILABLE":         ErrorTypeOverloaded,
+	"INTERNAL":            ErrorTypeServerError,
+	"DEADLINE_EXCEEDED":   ErrorTypeServerError,
+	// OpenAI
+	"rate_limit_error":      ErrorTypeRateLimit,
+	"rate_limit_exceeded":   ErrorTypeRateLimit,
+	"insufficient_quota":    ErrorTypeRateLimit,
+	"invalid_request_error": ErrorTypeInvalidRequest,
+	"invalid_api_key":       ErrorTypeAuthentication,
+	"authentication_error":  ErrorTypeAuthentication,
+	"permission_error":      ErrorTypeAuthentication,
+	"server_error":          ErrorTypeServerError,
+	"api_error":             ErrorTypeServerError,
+	"overloaded_error":      ErrorTypeOverloaded,
+	"not_found_error":       ErrorTypeInvalidRequest,
+	"request_too_large":     ErrorTypeInvalidRequest,
+}
+
+// NormalizedError is a provider-independent view of an upstream error.
+type NormalizedError struct {
+	// Type is one of the ErrorType* constants.
+	Type string
+	// Message is the upstream error message, or the raw error text when it is not JSON.
+	Message string
+	// Code is the provider-native code the type was derived from, if any.
+	Code string
+}
+
+// NormalizeUpstreamError classifies an upstream error body from Gemini, OpenAI or Anthropic,
+// falling back to the HTTP status when the body carries no recognizable code.
+func NormalizeUpstreamError(status int, errText string) NormalizedError {
+	out := NormalizedError{Message: strings.TrimSpace(errText)}
+	if out.Message == "" {
+		out.Message = http.StatusText(status)
+	}
+	if json.Valid([]byte(out.Message)) {
+		errNode := gjson.Get(out.Message, "error")
+		if errNode.IsObject() {
+			if msg := errNode.Get("message").String(); msg != "" {
+				out.Message = msg
+			}
+			// Gemini reports the canonical code in error.status; OpenAI and Anthropic use
+			// error.type, with OpenAI sometimes carrying a more specific error.code.
+			for _, path := range []string{"status", "code", "type"} {
+				code := errNode.Get(path)
+				if code.Type != gjson.String {
+					continue
+				}
+				if normalized, ok := upstreamErrorCodes[code.String()]; ok {
+					out.Type = normalized
+					out.Code = code.String()
+					break
+				}
+			}
+		}
+	}
+	if out.Type == "" {
+		out.Type = errorTypeFromStatus(status)
+	}
+	return out
+}
+
+func errorTypeFromStatus(status int) string {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrorTypeRateLimit
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrorTypeAuthentication
+	case status == http.StatusServiceUnavailable || status == 529:
+		return ErrorTypeOverloaded
+	case status >= http.StatusInternalServerError || status <= 0:
+		return ErrorTypeServerError
+	default:
+		return ErrorTypeInvalidRequest
+	}
+}
+
+// BuildNormalizedErrorBody renders a normalized upstream error in the error envelope of
+// the given handler type: Anthropic for Claude clients, Google for Gemini clients and
+// OpenAI for everything else.
+func BuildNormalizedErrorBody(handlerType string, status int, errText string) []byte {
+	if status <= 0 {
+		status = http.StatusInternalServerError
+	}
+	normalized := NormalizeUpstreamError(status, errText)
+	var payload any
+	switch handlerType {
+	case constant.Claude:
+		payload = map[string]any{
+			"type":  "error",
+			"error": map[string]any{"type": normalized.Type, "message": normalized.Message},
+		}
+	case constant.Gemini, constant.GeminiCLI:
+		payload = map[string]any{
+			"error": map[string]any{"code": status, "message": normalized.Message, "status": normalized.Type},
+		}
+	default:
+		payload = ErrorResponse{Error: ErrorDetail{Message: normalized.Message, Type: normalized.Type, Code: normalized.Code}}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return BuildErrorResponseBody(status, errText)
+	}
+	return body
+}
+
+// errorFormatFromContext returns the handler type recorded for the request, if any.
+func errorFormatFromContext(c *gin.Context) string {
+	if c == nil {
+		return ""
+	}
+	if v, ok := c.Get(errorFormatContextKey); ok {
+		if handlerType, okType := v.(string); okType {
+			return handlerType
+		}
+	}
+	return ""
+}
+
+// StreamErrorBody renders the body of a terminal stream error for a client of handlerType.
+// With normalize-errors enabled it uses the same normalized envelope as WriteErrorResponse;
+// otherwise the upstream body is kept as BuildErrorResponseBody does. OpenAI Responses clients
+// get a stream error chunk in both cases.
+func (h *BaseAPIHandler) StreamErrorBody(handlerType string, status int, errText string) []byte {
+	if h == nil || h.Cfg == nil || !h.Cfg.NormalizeErrors {
+		if handlerType == constant.OpenaiResponse {
+			return BuildOpenAIResponsesStreamErrorChunk(status, errText, 0)
+		}
+		return BuildErrorResponseBody(status, errText)
+	}
+	if handlerType == constant.OpenaiResponse {
+		normalized := NormalizeUpstreamError(status, errText)
+		body, err := json.Marshal(openAIResponsesStreamErrorChunk{Type: "error", Code: normalized.Type, Message: normalized.Message})
+		if err == nil {
+			return body
+		}
+		return BuildOpenAIResponsesStreamErrorChunk(status, errText, 0)
+	}
+	return BuildNormalizedErrorBody(handlerType, status, errText)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestNormalizeUpstreamError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantType    string
+		wantMessage string
+		wantCode    string
+	}{
+		{
+			name:        "gemini status",
+			status:      http.StatusTooManyRequests,
+			body:        `{"error":{"code":429,"message":"Quota exceeded for metric","status":"RESOURCE_EXHAUSTED"}}`,
+			wantType:    ErrorTypeRateLimit,
+			wantMessage: "Quota exceeded for metric",
+			wantCode:    "RESOURCE_EXHAUSTED",
+		},
+		{
+			name:        "openai error type",
+			status:      http.StatusBadRequest,
+			body:        `{"error":{"message":"Unknown parameter: foo","type":"invalid_request_error","param":"foo","code":null}}`,
+			wantType:    ErrorTypeInvalidRequest,
+			wantMessage: "Unknown parameter: foo",
+			wantCode:    "invalid_request_error",
+		},
+		{
+			name:        "anthropic error type",
+			status:      529,
+			body:        `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			wantType:    ErrorTypeOverloaded,
+			wantMessage: "Overloaded",
+			wantCode:    "overloaded_error",
+		},
+		{
+			name:        "plain text falls back to status",
+			status:      http.StatusUnauthorized,
+			body:        "token expired",
+			wantType:    ErrorTypeAuthentication,
+			wantMessage: "token expired",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeUpstreamError(tt.status, tt.body)
+			if got.Type != tt.wantType || got.Message != tt.wantMessage || got.Code != tt.wantCode {
+				t.Fatalf("NormalizeUpstreamError() = %+v, want type=%q message=%q code=%q", got, tt.wantType, tt.wantMessage, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestWriteErrorResponse_NormalizesIntoClientFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	upstream := errors.New(`{"error":{"code":429,"message":"Quota exceeded","status":"RESOURCE_EXHAUSTED"}}`)
+
+	tests := []struct {
+		handlerType string
+		typePath    string
+		msgPath     string
+	}{
+		{handlerType: constant.OpenAI, typePath: "error.type", msgPath: "error.message"},
+		{handlerType: constant.Claude, typePath: "error.type", msgPath: "error.message"},
+		{handlerType: constant.Gemini, typePath: "error.status", msgPath: "error.message"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.handlerType, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+			c.Set(errorFormatContextKey, tt.handlerType)
+
+			handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{NormalizeErrors: true}, nil)
+			handler.WriteErrorResponse(c, &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: upstream})
+
+			body := recorder.Body.Bytes()
+			if got := gjson.GetBytes(body, tt.typePath).String(); got != ErrorTypeRateLimit {
+				t.Fatalf("%s = %q, want %q; body=%s", tt.typePath, got, ErrorTypeRateLimit, body)
+			}
+			if got := gjson.GetBytes(body, tt.msgPath).String(); got != "Quota exceeded" {
+				t.Fatalf("%s = %q, want %q", tt.msgPath, got, "Quota exceeded")
+			}
+			if tt.handlerType == constant.Claude && gjson.GetBytes(body, "type").String() != "error" {
+				t.Fatalf("expected Anthropic envelope, got %s", body)
+			}
+		})
+	}
+}
+
+func TestWriteErrorResponse_PassesUpstreamErrorThroughByDefault(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+
+	raw := `{"error":{"code":429,"message":"Quota exceeded","status":"RESOURCE_EXHAUSTED"}}`
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+	handler.WriteErrorResponse(c, &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: errors.New(raw)})
+
+	if got := recorder.Body.String(); got != raw {
+		t.Fatalf("body = %s, want upstream body unchanged", got)
+	}
+}
+
+func TestStreamErrorBody_NormalizesTerminalStreamErrors(t *testing.T) {
+	raw := `{"error":{"code":429,"message":"Quota exceeded","status":"RESOURCE_EXHAUSTED"}}`
+	normalizing := NewBaseAPIHandlers(&sdkconfig.SDKConfig{NormalizeErrors: true}, nil)
+	passthrough := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+
+	tests := []struct {
+		handlerType string
+		typePath    string
+		msgPath     string
+	}{
+		{handlerType: constant.OpenAI, typePath: "error.type", msgPath: "error.message"},
+		{handlerType: constant.Claude, typePath: "error.type", msgPath: "error.message"},
+		{handlerType: constant.Gemini, typePath: "error.status", msgPath: "error.message"},
+		{handlerType: constant.OpenaiResponse, typePath: "code", msgPath: "message"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.handlerType, func(t *testing.T) {
+			body := normalizing.StreamErrorBody(tt.handlerType, http.StatusTooManyRequests, raw)
+			if got := gjson.GetBytes(body, tt.typePath).String(); got != ErrorTypeRateLimit {
+				t.Fatalf("%s = %q, want %q; body=%s", tt.typePath, got, ErrorTypeRateLimit, body)
+			}
+			if got := gjson.GetBytes(body, tt.msgPath).String(); got != "Quota exceeded" {
+				t.Fatalf("%s = %q, want %q", tt.msgPath, got, "Quota exceeded")
+			}
+		})
+	}
+
+	if got := string(passthrough.StreamErrorBody(constant.OpenAI, http.StatusTooManyRequests, raw)); got != raw {
+		t.Fatalf("body = %s, want upstream body unchanged", got)
+	}
+}
@@ -228,6 +228,10 @@ func (h *GeminiAPIHandler) handleStreamGenerateContent(c *gin.Context, modelName
 				cliCancel(nil)
 				return
 			}
+			if alt != "" && handlers.IsStreamHeartbeat(chunk) {
+				// JSON output cannot carry SSE comments; keep waiting for data.
+				continue
+			}
 
 			// Success! Set headers.
 			if alt == "" {
@@ -236,7 +240,9 @@ func (h *GeminiAPIHandler) handleStreamGenerateContent(c *gin.Context, modelName
 			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
 
 			// Write first chunk
-			if alt == "" {
+			if handlers.IsStreamHeartbeat(chunk) {
+				_, _ = c.Writer.Write(chunk)
+			} else if alt == "" {
 				_, _ = c.Writer.Write([]byte("data: "))
 				_, _ = c.Writer.Write(chunk)
 				_, _ = c.Writer.Write([]byte("\n\n"))
@@ -330,7 +336,7 @@ func (h *GeminiAPIHandler) forwardGeminiStream(c *gin.Context, flusher http.Flus
 			if errMsg.Error != nil && errMsg.Error.Error() != "" {
 				errText = errMsg.Error.Error()
 			}
-			body := handlers.BuildErrorResponseBody(status, errText)
+			body := h.StreamErrorBody(h.HandlerType(), status, errText)
 			if alt == "" {
 				_, _ = fmt.Fprintf(c.Writer, "event: error\ndata: %s\n\n", string(body))
 			} else {
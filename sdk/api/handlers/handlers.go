@@ -5,7 +5,9 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -22,7 +24,6 @@ import (
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
-	"golang.org/x/net/context"
 )
 
 // ErrorResponse represents a standard error response format for the API.
@@ -179,6 +180,18 @@ func StreamingBootstrapRetries(cfg *config.SDKConfig) int {
 	return retries
 }
 
+// errStreamFirstByteTimeout reports that a streaming upstream sent no payload within the configured window.
+var errStreamFirstByteTimeout = errors.New("upstream sent no data before the first-byte timeout")
+
+// StreamingFirstByteTimeout returns how long a streaming request may wait for its first payload.
+// Returning 0 disables the timeout (default when unset).
+func StreamingFirstByteTimeout(cfg *config.SDKConfig) time.Duration {
+	if cfg == nil || cfg.Streaming.FirstByteTimeoutSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(cfg.Streaming.FirstByteTimeoutSeconds) * time.Second
+}
+
 // PassthroughHeadersEnabled returns whether upstream response headers should be forwarded to clients.
 // Default is false.
 func PassthroughHeadersEnabled(cfg *config.SDKConfig) bool {
@@ -189,9 +202,11 @@ func requestExecutionMetadata(ctx context.Context) map[string]any {
 	// Idempotency-Key is an optional client-supplied header used to correlate retries.
 	// It is forwarded as execution metadata; when absent we generate a UUID.
 	key := ""
+	requestedAccount := ""
 	if ctx != nil {
 		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
 			key = strings.TrimSpace(ginCtx.GetHeader("Idempotency-Key"))
+			requestedAccount = strings.TrimSpace(ginCtx.GetHeader(coreexecutor.CodexAccountHeader))
 		}
 	}
 	if key == "" {
@@ -199,6 +214,9 @@ func requestExecutionMetadata(ctx context.Context) map[string]any {
 	}
 
 	meta := map[string]any{idempotencyKeyMetadataKey: key}
+	if requestedAccount != "" {
+		meta[coreexecutor.RequestedAccountMetadataKey] = requestedAccount
+	}
 	if pinnedAuthID := pinnedAuthIDFromContext(ctx); pinnedAuthID != "" {
 		meta[coreexecutor.PinnedAuthMetadataKey] = pinnedAuthID
 	}
@@ -261,6 +279,9 @@ type BaseAPIHandler struct {
 
 	// Cfg holds the current application configuration.
 	Cfg *config.SDKConfig
+
+	// idempotency caches responses for requests carrying an Idempotency-Key.
+	idempotency idempotencyCache
 }
 
 // NewBaseAPIHandlers creates a new API handlers instance.
@@ -349,10 +370,16 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 			}
 		}()
 	}
+	if c != nil && handler != nil {
+		c.Set(errorFormatContextKey, handler.HandlerType())
+	}
+	if c != nil && c.Request != nil && util.HeaderPassthroughDisabledByClient(c.Request.Header) {
+		newCtx = util.WithHeaderPassthroughDisabled(newCtx)
+	}
 	newCtx = context.WithValue(newCtx, "gin", c)
 	newCtx = context.WithValue(newCtx, "handler", handler)
 	return newCtx, func(params ...interface{}) {
-		if h.Cfg.RequestLog && len(params) == 1 {
+		if (h.Cfg.RequestLog || logging.IsGinRequestLogSampled(c)) && len(params) == 1 {
 			if existing, exists := c.Get("API_RESPONSE"); exists {
 				if existingBytes, ok := existing.([]byte); ok && len(bytes.TrimSpace(existingBytes)) > 0 {
 					switch params[0].(type) {
@@ -475,6 +502,43 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	if errMsg = h.applyPinAuthHeader(ctx, providers, normalizedModel, reqMeta); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if errMsg = h.applyRawPassthroughHeader(ctx, reqMeta); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if errMsg = h.applyDryRunHeader(ctx, reqMeta); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	// Dry runs never touch the idempotency cache, so a described request cannot be
+	// replayed later as the response to a real one.
+	var idempotencyKey string
+	if dryRun, _ := reqMeta[coreexecutor.DryRunMetadataKey].(bool); !dryRun {
+		idempotencyKey = h.idempotencyCacheKey(ctx, handlerType, normalizedModel)
+	}
+	var bodyHash string
+	idempotencyStored := false
+	if idempotencyKey != "" {
+		bodyHash = idempotencyBodyHash(rawJSON)
+		payload, headers, ok, errAcquire := h.idempotency.acquire(ctx, idempotencyKey, bodyHash)
+		if errors.Is(errAcquire, errIdempotencyKeyReused) {
+			return nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusUnprocessableEntity, Error: errAcquire}
+		}
+		if errAcquire != nil {
+			return nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusConflict, Error: fmt.Errorf("request with this Idempotency-Key is still in progress: %w", errAcquire)}
+		}
+		if ok {
+			return payload, headers, nil
+		}
+		// Failed requests are not cached; releasing the pending entry lets waiting
+		// duplicates retry upstream.
+		defer func() {
+			if !idempotencyStored {
+				h.idempotency.release(idempotencyKey)
+			}
+		}()
+	}
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -506,10 +570,12 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		}
 		return nil, nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
-	if !PassthroughHeadersEnabled(h.Cfg) {
-		return resp.Payload, nil, nil
+	headers := clientResponseHeaders(h.Cfg, resp.Headers)
+	if idempotencyKey != "" {
+		h.idempotency.put(idempotencyKey, bodyHash, resp.Payload, headers, time.Now().Add(time.Duration(h.Cfg.IdempotencyTTLSeconds)*time.Second))
+		idempotencyStored = true
 	}
-	return resp.Payload, FilterUpstreamHeaders(resp.Headers), nil
+	return resp.Payload, headers, nil
 }
 
 // ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
@@ -521,6 +587,12 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	if errMsg = h.applyPinAuthHeader(ctx, providers, normalizedModel, reqMeta); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if errMsg = h.applyRawPassthroughHeader(ctx, reqMeta); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -552,10 +624,7 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 		}
 		return nil, nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
-	if !PassthroughHeadersEnabled(h.Cfg) {
-		return resp.Payload, nil, nil
-	}
-	return resp.Payload, FilterUpstreamHeaders(resp.Headers), nil
+	return resp.Payload, clientResponseHeaders(h.Cfg, resp.Headers), nil
 }
 
 // ExecuteStreamWithAuthManager executes a streaming request via the core auth manager.
@@ -571,6 +640,18 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	if errMsg = h.applyPinAuthHeader(ctx, providers, normalizedModel, reqMeta); errMsg == nil {
+		errMsg = h.applyRawPassthroughHeader(ctx, reqMeta)
+	}
+	if errMsg == nil {
+		errMsg = h.applyDryRunHeader(ctx, reqMeta)
+	}
+	if errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -586,9 +667,35 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		SourceFormat:    sdktranslator.FromString(handlerType),
 	}
 	opts.Metadata = reqMeta
-	streamResult, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+
+	// Bound the wait for the first payload without capping the stream's total duration:
+	// the timer cancels the upstream context only until the first chunk arrives.
+	streamCtx, streamCancel := ctx, context.CancelCauseFunc(func(error) {})
+	var firstByteTimer *time.Timer
+	if timeout := StreamingFirstByteTimeout(h.Cfg); timeout > 0 && ctx != nil {
+		streamCtx, streamCancel = context.WithCancelCause(ctx)
+		firstByteTimer = time.AfterFunc(timeout, func() { streamCancel(errStreamFirstByteTimeout) })
+	}
+	stopFirstByteTimer := func() {
+		if firstByteTimer != nil {
+			firstByteTimer.Stop()
+		}
+	}
+	firstByteTimedOut := func() bool {
+		return streamCtx != ctx && errors.Is(context.Cause(streamCtx), errStreamFirstByteTimeout)
+	}
+
+	streamResult, err := h.AuthManager.ExecuteStream(streamCtx, providers, req, opts)
 	if err != nil {
+		stopFirstByteTimer()
 		errChan := make(chan *interfaces.ErrorMessage, 1)
+		if firstByteTimedOut() {
+			streamCancel(nil)
+			errChan <- &interfaces.ErrorMessage{StatusCode: http.StatusGatewayTimeout, Error: errStreamFirstByteTimeout}
+			close(errChan)
+			return nil, nil, errChan
+		}
+		streamCancel(nil)
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
 			if code := se.StatusCode(); code > 0 {
@@ -605,22 +712,21 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		close(errChan)
 		return nil, nil, errChan
 	}
-	passthroughHeadersEnabled := PassthroughHeadersEnabled(h.Cfg)
 	// Capture upstream headers from the initial connection synchronously before the goroutine starts.
 	// Keep a mutable map so bootstrap retries can replace it before first payload is sent.
-	var upstreamHeaders http.Header
-	if passthroughHeadersEnabled {
-		upstreamHeaders = cloneHeader(FilterUpstreamHeaders(streamResult.Headers))
-		if upstreamHeaders == nil {
-			upstreamHeaders = make(http.Header)
-		}
+	upstreamHeaders := cloneHeader(clientResponseHeaders(h.Cfg, streamResult.Headers))
+	if upstreamHeaders == nil {
+		upstreamHeaders = make(http.Header)
 	}
 	chunks := streamResult.Chunks
+	rawPassthrough, _ := reqMeta[coreexecutor.RawPassthroughMetadataKey].(bool)
 	dataChan := make(chan []byte)
 	errChan := make(chan *interfaces.ErrorMessage, 1)
 	go func() {
 		defer close(dataChan)
 		defer close(errChan)
+		defer streamCancel(nil)
+		defer stopFirstByteTimer()
 		sentPayload := false
 		bootstrapRetries := 0
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
@@ -674,12 +780,20 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					select {
 					case <-ctx.Done():
 						return
+					case <-streamCtx.Done():
+						if firstByteTimedOut() {
+							_ = sendErr(&interfaces.ErrorMessage{StatusCode: http.StatusGatewayTimeout, Error: errStreamFirstByteTimeout})
+						}
+						return
 					case chunk, ok = <-chunks:
 					}
 				} else {
 					chunk, ok = <-chunks
 				}
 				if !ok {
+					if !sentPayload && firstByteTimedOut() {
+						_ = sendErr(&interfaces.ErrorMessage{StatusCode: http.StatusGatewayTimeout, Error: errStreamFirstByteTimeout})
+					}
 					return
 				}
 				if chunk.Err != nil {
@@ -687,13 +801,15 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					// Safe bootstrap recovery: if the upstream fails before any payload bytes are sent,
 					// retry a few times (to allow auth rotation / transient recovery) and then attempt model fallback.
 					if !sentPayload {
+						if firstByteTimedOut() {
+							_ = sendErr(&interfaces.ErrorMessage{StatusCode: http.StatusGatewayTimeout, Error: errStreamFirstByteTimeout})
+							return
+						}
 						if bootstrapRetries < maxBootstrapRetries && bootstrapEligible(streamErr) {
 							bootstrapRetries++
-							retryResult, retryErr := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+							retryResult, retryErr := h.AuthManager.ExecuteStream(streamCtx, providers, req, opts)
 							if retryErr == nil {
-								if passthroughHeadersEnabled {
-									replaceHeader(upstreamHeaders, FilterUpstreamHeaders(retryResult.Headers))
-								}
+								replaceHeader(upstreamHeaders, clientResponseHeaders(h.Cfg, retryResult.Headers))
 								chunks = retryResult.Chunks
 								continue outer
 							}
@@ -716,6 +832,21 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					_ = sendErr(&interfaces.ErrorMessage{StatusCode: status, Error: streamErr, Addon: addon})
 					return
 				}
+				if IsStreamHeartbeat(chunk.Payload) {
+					// Heartbeats are not upstream payload: keep the first-byte timer running and
+					// leave bootstrap retries available.
+					if okSendData := sendData(cloneBytes(chunk.Payload)); !okSendData {
+						return
+					}
+					continue
+				}
+				if rawPassthrough && len(chunk.Payload) > 0 {
+					payload, keep := rawPassthroughStreamChunk(handlerType, chunk.Payload)
+					if !keep {
+						continue
+					}
+					chunk.Payload = payload
+				}
 				if len(chunk.Payload) > 0 {
 					if handlerType == "openai-response" {
 						if err := validateSSEDataJSON(chunk.Payload); err != nil {
@@ -723,6 +854,9 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 							return
 						}
 					}
+					if !sentPayload {
+						stopFirstByteTimer()
+					}
 					sentPayload = true
 					if okSendData := sendData(cloneBytes(chunk.Payload)); !okSendData {
 						return
@@ -776,6 +910,12 @@ func statusFromError(err error) int {
 }
 
 func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string, normalizedModel string, err *interfaces.ErrorMessage) {
+	if strings.TrimSpace(modelName) == "" {
+		if h.Cfg == nil || strings.TrimSpace(h.Cfg.DefaultModel) == "" {
+			return nil, "", &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errors.New("model is required")}
+		}
+		modelName = strings.TrimSpace(h.Cfg.DefaultModel)
+	}
 	resolvedModelName := modelName
 	initialSuffix := thinking.ParseSuffix(modelName)
 	if initialSuffix.ModelName == "auto" {
@@ -846,8 +986,17 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 	if msg != nil && msg.StatusCode > 0 {
 		status = msg.StatusCode
 	}
-	if msg != nil && msg.Addon != nil && PassthroughHeadersEnabled(h.Cfg) {
-		for key, values := range msg.Addon {
+	if msg != nil && msg.Addon != nil {
+		addon := msg.Addon
+		if !PassthroughHeadersEnabled(h.Cfg) {
+			addon = nil
+			// Concurrency rejections come from the proxy itself, so their Retry-After is
+			// sent even when upstream headers are not passed through.
+			if coreauth.IsConcurrencyLimited(msg.Error) {
+				addon = pickHeaders(msg.Addon, "Retry-After", coreauth.QueueWaitHeader)
+			}
+		}
+		for key, values := range addon {
 			if len(values) == 0 {
 				continue
 			}
@@ -866,6 +1015,9 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 	}
 
 	body := BuildErrorResponseBody(status, errText)
+	if h.Cfg != nil && h.Cfg.NormalizeErrors {
+		body = BuildNormalizedErrorBody(errorFormatFromContext(c), status, errText)
+	}
 	// Append first to preserve upstream response logs, then drop duplicate payloads if already recorded.
 	var previous []byte
 	if existing, exists := c.Get("API_RESPONSE"); exists {
@@ -891,18 +1043,18 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 }
 
 func (h *BaseAPIHandler) LoggingAPIResponseError(ctx context.Context, err *interfaces.ErrorMessage) {
-	if h.Cfg.RequestLog {
-		if ginContext, ok := ctx.Value("gin").(*gin.Context); ok {
-			if apiResponseErrors, isExist := ginContext.Get("API_RESPONSE_ERROR"); isExist {
-				if slicesAPIResponseError, isOk := apiResponseErrors.([]*interfaces.ErrorMessage); isOk {
-					slicesAPIResponseError = append(slicesAPIResponseError, err)
-					ginContext.Set("API_RESPONSE_ERROR", slicesAPIResponseError)
-				}
-			} else {
-				// Create new response data entry
-				ginContext.Set("API_RESPONSE_ERROR", []*interfaces.ErrorMessage{err})
-			}
+	ginContext, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || !(h.Cfg.RequestLog || logging.IsGinRequestLogSampled(ginContext)) {
+		return
+	}
+	if apiResponseErrors, isExist := ginContext.Get("API_RESPONSE_ERROR"); isExist {
+		if slicesAPIResponseError, isOk := apiResponseErrors.([]*interfaces.ErrorMessage); isOk {
+			slicesAPIResponseError = append(slicesAPIResponseError, err)
+			ginContext.Set("API_RESPONSE_ERROR", slicesAPIResponseError)
 		}
+	} else {
+		// Create new response data entry
+		ginContext.Set("API_RESPONSE_ERROR", []*interfaces.ErrorMessage{err})
 	}
 }
 
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"net/http"
 	"reflect"
 	"testing"
 	"time"
@@ -116,3 +117,36 @@ func TestGetRequestDetails_PreservesSuffix(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRequestDetails_MissingModel(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-request-details-default", "gemini", []*registry.ModelInfo{
+		{ID: "default-model-test", Created: time.Now().Unix()},
+	})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-request-details-default") })
+
+	t.Run("rejected without default", func(t *testing.T) {
+		handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, coreauth.NewManager(nil, nil, nil))
+		_, _, errMsg := handler.getRequestDetails("  ")
+		if errMsg == nil {
+			t.Fatal("expected an error for a missing model")
+		}
+		if errMsg.StatusCode != http.StatusBadRequest {
+			t.Fatalf("status = %d, want %d", errMsg.StatusCode, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("default applied", func(t *testing.T) {
+		handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{DefaultModel: "default-model-test"}, coreauth.NewManager(nil, nil, nil))
+		providers, model, errMsg := handler.getRequestDetails("")
+		if errMsg != nil {
+			t.Fatalf("unexpected error: %v", errMsg.Error)
+		}
+		if model != "default-model-test" {
+			t.Fatalf("model = %q, want %q", model, "default-model-test")
+		}
+		if !reflect.DeepEqual(providers, []string{"gemini"}) {
+			t.Fatalf("providers = %v, want [gemini]", providers)
+		}
+	})
+}
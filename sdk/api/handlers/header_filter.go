@@ -3,6 +3,9 @@ package handlers
 import (
 	"net/http"
 	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
 
 // hopByHopHeaders lists RFC 7230 Section 6.1 hop-by-hop headers that MUST NOT
@@ -48,6 +51,30 @@ func FilterUpstreamHeaders(src http.Header) http.Header {
 	return dst
 }
 
+// clientResponseHeaders returns the headers from src to send to the client: every
+// forwardable upstream header when passthrough-headers is enabled, otherwise only the queue
+// wait reported by the proxy's own concurrency limits.
+func clientResponseHeaders(cfg *config.SDKConfig, src http.Header) http.Header {
+	if PassthroughHeadersEnabled(cfg) {
+		return FilterUpstreamHeaders(src)
+	}
+	return pickHeaders(src, coreauth.QueueWaitHeader)
+}
+
+// pickHeaders returns a copy of the given keys from src, or nil when none is present.
+func pickHeaders(src http.Header, keys ...string) http.Header {
+	var dst http.Header
+	for _, key := range keys {
+		if values := src.Values(key); len(values) > 0 {
+			if dst == nil {
+				dst = make(http.Header)
+			}
+			dst[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
+	}
+	return dst
+}
+
 func connectionScopedHeaders(src http.Header) map[string]struct{} {
 	scoped := make(map[string]struct{})
 	for _, rawValue := range src.Values("Connection") {
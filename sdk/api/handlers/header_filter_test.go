@@ -3,6 +3,8 @@ package handlers
 import (
 	"net/http"
 	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
 
 func TestFilterUpstreamHeaders_RemovesConnectionScopedHeaders(t *testing.T) {
@@ -53,3 +55,17 @@ func TestFilterUpstreamHeaders_ReturnsNilWhenAllHeadersBlocked(t *testing.T) {
 		t.Fatalf("expected nil when all headers are filtered, got %#v", filtered)
 	}
 }
+
+func TestClientResponseHeaders_KeepsQueueWaitWithoutPassthrough(t *testing.T) {
+	src := http.Header{}
+	src.Set(coreauth.QueueWaitHeader, "120")
+	src.Set("X-Request-Id", "req-1")
+
+	got := clientResponseHeaders(nil, src)
+	if v := got.Get(coreauth.QueueWaitHeader); v != "120" {
+		t.Fatalf("%s = %q, want %q", coreauth.QueueWaitHeader, v, "120")
+	}
+	if v := got.Get("X-Request-Id"); v != "" {
+		t.Fatalf("X-Request-Id = %q, want upstream headers withheld", v)
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxIdempotentResponses bounds the number of cached responses; the entries closest to
+// expiry are evicted first.
+const maxIdempotentResponses = 1024
+
+// errIdempotencyKeyReused reports a retry whose body differs from the cached request.
+var errIdempotencyKeyReused = errors.New("Idempotency-Key was already used with a different request body")
+
+type idempotentResponse struct {
+	bodyHash  string
+	payload   []byte
+	headers   http.Header
+	expiresAt time.Time
+	// done is non-nil while the first request with the key is still running upstream and
+	// is closed once it stores its response or gives up.
+	done chan struct{}
+}
+
+// idempotencyCache holds successful non-streaming responses keyed by client and
+// Idempotency-Key so retried requests are not re-run upstream. The zero value is ready to use.
+type idempotencyCache struct {
+	mu      sync.Mutex
+	entries map[string]*idempotentResponse
+}
+
+// idempotencyCacheKey returns the cache key for a request carrying a client-supplied
+// Idempotency-Key, or "" when the cache is disabled or the header is absent. Keys are scoped
+// to the client API key, handler format and model.
+func (h *BaseAPIHandler) idempotencyCacheKey(ctx context.Context, handlerType, model string) string {
+	if h.Cfg == nil || h.Cfg.IdempotencyTTLSeconds <= 0 || ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return ""
+	}
+	key := strings.TrimSpace(ginCtx.GetHeader("Idempotency-Key"))
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(ginCtx.GetString("apiKey") + "\x00" + handlerType + "\x00" + model + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// idempotencyBodyHash fingerprints a request body so a reused key with a different body
+// is rejected instead of replaying an unrelated response.
+func idempotencyBodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// acquire returns the cached response for key. When nothing is cached it records a pending
+// entry and returns ok=false; the caller then owns the key and must call put or release.
+// Concurrent requests with the same key wait for the owner instead of running upstream
+// again. It fails with errIdempotencyKeyReused when the key belongs to a different request
+// body, or with the context error when ctx ends while waiting.
+func (c *idempotencyCache) acquire(ctx context.Context, key, bodyHash string) ([]byte, http.Header, bool, error) {
+	for {
+		c.mu.Lock()
+		entry, ok := c.entries[key]
+		if ok && entry.done == nil && time.Now().After(entry.expiresAt) {
+			delete(c.entries, key)
+			ok = false
+		}
+		if !ok {
+			if c.entries == nil {
+				c.entries = make(map[string]*idempotentResponse)
+			}
+			c.entries[key] = &idempotentResponse{bodyHash: bodyHash, done: make(chan struct{})}
+			c.mu.Unlock()
+			return nil, nil, false, nil
+		}
+		if entry.bodyHash != bodyHash {
+			c.mu.Unlock()
+			return nil, nil, false, errIdempotencyKeyReused
+		}
+		if entry.done == nil {
+			payload, headers := cloneBytes(entry.payload), cloneHeader(entry.headers)
+			c.mu.Unlock()
+			return payload, headers, true, nil
+		}
+		done := entry.done
+		c.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, nil, false, ctx.Err()
+		}
+	}
+}
+
+// release drops the pending entry for key after its owner failed, waking any waiting
+// duplicates so one of them can retry upstream.
+func (c *idempotencyCache) release(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[key]; ok && entry.done != nil {
+		delete(c.entries, key)
+		close(entry.done)
+	}
+}
+
+func (c *idempotencyCache) put(key, bodyHash string, payload []byte, headers http.Header, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]*idempotentResponse)
+	}
+	if pending, ok := c.entries[key]; ok && pending.done != nil {
+		close(pending.done)
+	}
+	c.entries[key] = &idempotentResponse{bodyHash: bodyHash, payload: cloneBytes(payload), headers: cloneHeader(headers), expiresAt: expiresAt}
+	if len(c.entries) <= maxIdempotentResponses {
+		return
+	}
+	now := time.Now()
+	oldestKey := ""
+	var oldest time.Time
+	for k, entry := range c.entries {
+		if entry.done != nil {
+			continue
+		}
+		if now.After(entry.expiresAt) {
+			delete(c.entries, k)
+			continue
+		}
+		if oldestKey == "" || entry.expiresAt.Before(oldest) {
+			oldestKey, oldest = k, entry.expiresAt
+		}
+	}
+	if len(c.entries) > maxIdempotentResponses && oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
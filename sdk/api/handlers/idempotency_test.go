@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func idempotentRequestContext(key string) context.Context {
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ginCtx.Request.Header.Set("Idempotency-Key", key)
+	ginCtx.Set("apiKey", "client-key")
+	return context.WithValue(context.Background(), "gin", ginCtx)
+}
+
+func (e *pinAuthRecordingExecutor) calls() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.authIDs)
+}
+
+func TestExecuteWithAuthManager_IdempotencyKeyReplaysSuccessfulResponse(t *testing.T) {
+	handler, executor := newPinAuthTestHandler(t)
+	handler.Cfg.IdempotencyTTLSeconds = 60
+
+	for i := 0; i < 2; i++ {
+		payload, _, errMsg := handler.ExecuteWithAuthManager(idempotentRequestContext(t.Name()+"-1"), "openai", "pin-model", []byte(`{"model":"pin-model"}`), "")
+		if errMsg != nil {
+			t.Fatalf("ExecuteWithAuthManager error: %v", errMsg.Error)
+		}
+		if string(payload) != `{"ok":true}` {
+			t.Fatalf("payload = %s", payload)
+		}
+	}
+	if got := executor.calls(); got != 1 {
+		t.Fatalf("executor calls = %d, want 1 for a retried Idempotency-Key", got)
+	}
+
+	if _, _, errMsg := handler.ExecuteWithAuthManager(idempotentRequestContext(t.Name()+"-2"), "openai", "pin-model", []byte(`{}`), ""); errMsg != nil {
+		t.Fatalf("ExecuteWithAuthManager error: %v", errMsg.Error)
+	}
+	if got := executor.calls(); got != 2 {
+		t.Fatalf("executor calls = %d, want 2 for a new Idempotency-Key", got)
+	}
+}
+
+func TestExecuteWithAuthManager_IdempotencyKeyDoesNotCacheFailures(t *testing.T) {
+	handler, executor := newPinAuthTestHandler(t)
+	handler.Cfg.IdempotencyTTLSeconds = 60
+	executor.err = &coreauth.Error{Code: "bad_request", Message: "bad", HTTPStatus: http.StatusBadRequest}
+
+	for i := 0; i < 2; i++ {
+		if _, _, errMsg := handler.ExecuteWithAuthManager(idempotentRequestContext(t.Name()), "openai", "pin-model", []byte(`{}`), ""); errMsg == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if got := executor.calls(); got < 2 {
+		t.Fatalf("executor calls = %d, want failed requests to be retried upstream", got)
+	}
+}
+
+func TestExecuteWithAuthManager_IdempotencyKeyRejectsDifferentBody(t *testing.T) {
+	handler, executor := newPinAuthTestHandler(t)
+	handler.Cfg.IdempotencyTTLSeconds = 60
+
+	if _, _, errMsg := handler.ExecuteWithAuthManager(idempotentRequestContext(t.Name()), "openai", "pin-model", []byte(`{"model":"pin-model","n":1}`), ""); errMsg != nil {
+		t.Fatalf("ExecuteWithAuthManager error: %v", errMsg.Error)
+	}
+	_, _, errMsg := handler.ExecuteWithAuthManager(idempotentRequestContext(t.Name()), "openai", "pin-model", []byte(`{"model":"pin-model","n":2}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("errMsg = %+v, want 422 for a reused key with a different body", errMsg)
+	}
+	if got := executor.calls(); got != 1 {
+		t.Fatalf("executor calls = %d, want 1", got)
+	}
+}
+
+// blockingPinAuthExecutor holds every Execute call until release is closed.
+type blockingPinAuthExecutor struct {
+	*pinAuthRecordingExecutor
+	started chan struct{}
+	release chan struct{}
+}
+
+func (e *blockingPinAuthExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	e.started <- struct{}{}
+	<-e.release
+	return e.pinAuthRecordingExecutor.Execute(ctx, auth, req, opts)
+}
+
+func TestExecuteWithAuthManager_IdempotencyKeyConcurrentDuplicateWaitsForFirst(t *testing.T) {
+	handler, recorder := newPinAuthTestHandler(t)
+	handler.Cfg.IdempotencyTTLSeconds = 60
+	executor := &blockingPinAuthExecutor{pinAuthRecordingExecutor: recorder, started: make(chan struct{}, 2), release: make(chan struct{})}
+	handler.AuthManager.RegisterExecutor(executor)
+
+	body := []byte(`{"model":"pin-model"}`)
+	results := make(chan *interfaces.ErrorMessage, 2)
+	run := func() {
+		payload, _, errMsg := handler.ExecuteWithAuthManager(idempotentRequestContext(t.Name()), "openai", "pin-model", body, "")
+		if errMsg == nil && string(payload) != `{"ok":true}` {
+			errMsg = &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: fmt.Errorf("payload = %s", payload)}
+		}
+		results <- errMsg
+	}
+	go run()
+	<-executor.started
+	go run()
+
+	select {
+	case <-executor.started:
+		t.Fatal("duplicate request reached the executor while the first was still running")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(executor.release)
+
+	for i := 0; i < 2; i++ {
+		if errMsg := <-results; errMsg != nil {
+			t.Fatalf("ExecuteWithAuthManager error: %v", errMsg.Error)
+		}
+	}
+	if got := recorder.calls(); got != 1 {
+		t.Fatalf("executor calls = %d, want 1 for concurrent requests sharing an Idempotency-Key", got)
+	}
+}
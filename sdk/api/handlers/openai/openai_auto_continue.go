@@ -0,0 +1,90 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxAutoContinueOnLength caps auto-continue follow-ups regardless of configuration.
+const maxAutoContinueOnLength = 8
+
+// autoContinueLimit returns how many follow-up requests may be issued for a truncated response.
+func (h *OpenAIAPIHandler) autoContinueLimit() int {
+	if h == nil || h.Cfg == nil || h.Cfg.AutoContinueOnLength <= 0 {
+		return 0
+	}
+	return min(h.Cfg.AutoContinueOnLength, maxAutoContinueOnLength)
+}
+
+// executeWithAutoContinue executes a non-streaming chat completion and, when enabled, keeps
+// issuing follow-up requests while the response stops with finish_reason "length". Each
+// follow-up appends the output so far as an assistant message; the returned response carries
+// the concatenated content, the final finish_reason and the summed usage.
+func (h *OpenAIAPIHandler) executeWithAutoContinue(ctx context.Context, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(ctx, h.HandlerType(), modelName, rawJSON, alt)
+	limit := h.autoContinueLimit()
+	if errMsg != nil || limit == 0 {
+		return resp, upstreamHeaders, errMsg
+	}
+
+	for attempt := 0; attempt < limit && shouldAutoContinue(resp); attempt++ {
+		content := gjson.GetBytes(resp, "choices.0.message.content").String()
+		followUp, errSet := sjson.SetBytes(rawJSON, "messages.-1", map[string]string{"role": "assistant", "content": content})
+		if errSet != nil {
+			break
+		}
+		next, nextHeaders, errNext := h.ExecuteWithAuthManager(ctx, h.HandlerType(), modelName, followUp, alt)
+		if errNext != nil {
+			// Keep the truncated response rather than failing the whole request.
+			log.Debugf("openai handler: auto-continue attempt %d failed: %v", attempt+1, errNext.Error)
+			break
+		}
+		resp = mergeAutoContinueResponse(resp, next)
+		if nextHeaders != nil {
+			upstreamHeaders = nextHeaders
+		}
+	}
+	return resp, upstreamHeaders, nil
+}
+
+// shouldAutoContinue reports whether a chat completion was truncated by the token limit and
+// can be continued: a single choice with text content and no tool calls.
+func shouldAutoContinue(resp []byte) bool {
+	choices := gjson.GetBytes(resp, "choices")
+	if !choices.IsArray() || len(choices.Array()) != 1 {
+		return false
+	}
+	choice := choices.Array()[0]
+	if choice.Get("finish_reason").String() != "length" {
+		return false
+	}
+	if calls := choice.Get("message.tool_calls"); calls.IsArray() && len(calls.Array()) > 0 {
+		return false
+	}
+	return choice.Get("message.content").Type == gjson.String
+}
+
+// mergeAutoContinueResponse appends the continuation content to the previous response and
+// takes the continuation's finish_reason, summing token usage across both requests.
+func mergeAutoContinueResponse(prev, next []byte) []byte {
+	content := gjson.GetBytes(prev, "choices.0.message.content").String() + gjson.GetBytes(next, "choices.0.message.content").String()
+	out, _ := sjson.SetBytes(prev, "choices.0.message.content", content)
+	out, _ = sjson.SetBytes(out, "choices.0.finish_reason", gjson.GetBytes(next, "choices.0.finish_reason").Value())
+	if reason := gjson.GetBytes(next, "choices.0.native_finish_reason"); reason.Exists() {
+		out, _ = sjson.SetBytes(out, "choices.0.native_finish_reason", reason.Value())
+	}
+	for _, field := range []string{"prompt_tokens", "completion_tokens", "total_tokens"} {
+		path := "usage." + field
+		nextValue := gjson.GetBytes(next, path)
+		if !nextValue.Exists() {
+			continue
+		}
+		out, _ = sjson.SetBytes(out, path, gjson.GetBytes(prev, path).Int()+nextValue.Int())
+	}
+	return out
+}
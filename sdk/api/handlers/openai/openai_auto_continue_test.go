@@ -0,0 +1,119 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type truncatingExecutor struct {
+	payloads [][]byte
+}
+
+func (e *truncatingExecutor) Identifier() string { return "auto-continue-provider" }
+
+func (e *truncatingExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.payloads = append(e.payloads, req.Payload)
+	if len(e.payloads) == 1 {
+		return coreexecutor.Response{Payload: []byte(`{"id":"c1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Hello, "},"finish_reason":"length"}],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`)}, nil
+	}
+	return coreexecutor.Response{Payload: []byte(`{"id":"c2","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"world!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":7,"completion_tokens":2,"total_tokens":9}}`)}, nil
+}
+
+func (e *truncatingExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *truncatingExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *truncatingExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *truncatingExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func serveAutoContinueChat(t *testing.T, cfg *sdkconfig.SDKConfig, executor *truncatingExecutor) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+
+	auth := &coreauth.Auth{ID: "auto-continue-" + t.Name(), Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "auto-continue-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(cfg, manager))
+	router := gin.New()
+	router.POST("/v1/chat/completions", h.ChatCompletions)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"auto-continue-model","messages":[{"role":"user","content":"greet"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body=%s", resp.Code, http.StatusOK, resp.Body.String())
+	}
+	return resp
+}
+
+func TestChatCompletionsAutoContinuesOnLength(t *testing.T) {
+	executor := &truncatingExecutor{}
+	resp := serveAutoContinueChat(t, &sdkconfig.SDKConfig{AutoContinueOnLength: 3}, executor)
+
+	if len(executor.payloads) != 2 {
+		t.Fatalf("executor calls = %d, want 2", len(executor.payloads))
+	}
+	followUp := gjson.GetBytes(executor.payloads[1], "messages")
+	if n := len(followUp.Array()); n != 2 {
+		t.Fatalf("follow-up messages = %d, want 2; payload=%s", n, executor.payloads[1])
+	}
+	if got := followUp.Get("1.role").String(); got != "assistant" {
+		t.Fatalf("follow-up role = %q, want assistant", got)
+	}
+	if got := followUp.Get("1.content").String(); got != "Hello, " {
+		t.Fatalf("follow-up content = %q, want %q", got, "Hello, ")
+	}
+
+	body := resp.Body.Bytes()
+	if got := gjson.GetBytes(body, "choices.0.message.content").String(); got != "Hello, world!" {
+		t.Fatalf("content = %q, want %q", got, "Hello, world!")
+	}
+	if got := gjson.GetBytes(body, "choices.0.finish_reason").String(); got != "stop" {
+		t.Fatalf("finish_reason = %q, want stop", got)
+	}
+	if got := gjson.GetBytes(body, "usage.completion_tokens").Int(); got != 4 {
+		t.Fatalf("completion_tokens = %d, want 4", got)
+	}
+}
+
+func TestChatCompletionsAutoContinueDisabledByDefault(t *testing.T) {
+	executor := &truncatingExecutor{}
+	resp := serveAutoContinueChat(t, &sdkconfig.SDKConfig{}, executor)
+
+	if len(executor.payloads) != 1 {
+		t.Fatalf("executor calls = %d, want 1", len(executor.payloads))
+	}
+	if got := gjson.GetBytes(resp.Body.Bytes(), "choices.0.finish_reason").String(); got != "length" {
+		t.Fatalf("finish_reason = %q, want length", got)
+	}
+}
@@ -0,0 +1,65 @@
+package openai
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// openAIFinalUsage tracks a chat completions stream so a usage chunk can be emitted
+// before `[DONE]` when the translated stream did not include one.
+type openAIFinalUsage struct {
+	collector *usage.Collector
+	seenUsage bool
+	id        string
+	model     string
+	created   int64
+}
+
+// observe records stream identity and whether the chunk already carried usage.
+func (u *openAIFinalUsage) observe(chunk []byte) {
+	if u == nil {
+		return
+	}
+	if v := gjson.GetBytes(chunk, "usage"); v.Exists() && v.Type != gjson.Null {
+		u.seenUsage = true
+	}
+	if v := gjson.GetBytes(chunk, "id"); v.Exists() && u.id == "" {
+		u.id = v.String()
+	}
+	if v := gjson.GetBytes(chunk, "model"); v.Exists() && u.model == "" {
+		u.model = v.String()
+	}
+	if v := gjson.GetBytes(chunk, "created"); v.Exists() && u.created == 0 {
+		u.created = v.Int()
+	}
+}
+
+// chunk builds the final usage chunk, or returns nil when none should be written.
+func (u *openAIFinalUsage) chunk() []byte {
+	if u == nil || u.seenUsage {
+		return nil
+	}
+	detail, ok := u.collector.Detail()
+	if !ok {
+		return nil
+	}
+	out := []byte(`{"id":"","object":"chat.completion.chunk","created":0,"model":"","choices":[],"usage":{"prompt_tokens":0,"completion_tokens":0,"total_tokens":0}}`)
+	out, _ = sjson.SetBytes(out, "id", u.id)
+	out, _ = sjson.SetBytes(out, "created", u.created)
+	out, _ = sjson.SetBytes(out, "model", u.model)
+	out, _ = sjson.SetBytes(out, "usage.prompt_tokens", detail.InputTokens)
+	out, _ = sjson.SetBytes(out, "usage.completion_tokens", detail.OutputTokens)
+	total := detail.TotalTokens
+	if total == 0 {
+		total = detail.InputTokens + detail.OutputTokens
+	}
+	out, _ = sjson.SetBytes(out, "usage.total_tokens", total)
+	if detail.CachedTokens > 0 {
+		out, _ = sjson.SetBytes(out, "usage.prompt_tokens_details.cached_tokens", detail.CachedTokens)
+	}
+	if detail.ReasoningTokens > 0 {
+		out, _ = sjson.SetBytes(out, "usage.completion_tokens_details.reasoning_tokens", detail.ReasoningTokens)
+	}
+	return out
+}
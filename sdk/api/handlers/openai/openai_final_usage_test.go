@@ -0,0 +1,145 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type usageStreamExecutor struct {
+	chunks []string
+}
+
+func (e *usageStreamExecutor) Identifier() string { return "final-usage-provider" }
+
+func (e *usageStreamExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *usageStreamExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	ch := make(chan coreexecutor.StreamChunk, len(e.chunks))
+	for _, chunk := range e.chunks {
+		ch <- coreexecutor.StreamChunk{Payload: []byte(chunk)}
+	}
+	close(ch)
+	usage.PublishRecord(ctx, usage.Record{Provider: e.Identifier(), Detail: usage.Detail{InputTokens: 11, OutputTokens: 4, ReasoningTokens: 2, TotalTokens: 17}})
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *usageStreamExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *usageStreamExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *usageStreamExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func serveFinalUsageStream(t *testing.T, cfg *sdkconfig.SDKConfig, executor *usageStreamExecutor) []string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+
+	auth := &coreauth.Auth{ID: "final-usage-" + t.Name(), Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "final-usage-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(cfg, manager))
+	router := gin.New()
+	router.POST("/v1/chat/completions", h.ChatCompletions)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"final-usage-model","stream":true,"messages":[{"role":"user","content":"hi"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body=%s", resp.Code, http.StatusOK, resp.Body.String())
+	}
+
+	var events []string
+	for _, line := range strings.Split(resp.Body.String(), "\n") {
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			events = append(events, data)
+		}
+	}
+	return events
+}
+
+func TestChatCompletionsStreamEmitsFinalUsageBeforeDone(t *testing.T) {
+	executor := &usageStreamExecutor{chunks: []string{
+		`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"final-usage-model","choices":[{"index":0,"delta":{"content":"hello"}}]}`,
+	}}
+	cfg := &sdkconfig.SDKConfig{Streaming: sdkconfig.StreamingConfig{EmitFinalUsage: true}}
+	events := serveFinalUsageStream(t, cfg, executor)
+
+	if len(events) != 3 {
+		t.Fatalf("events = %d, want 3: %v", len(events), events)
+	}
+	if events[2] != "[DONE]" {
+		t.Fatalf("last event = %q, want [DONE]", events[2])
+	}
+	usageChunk := gjson.Parse(events[1])
+	if got := usageChunk.Get("usage.prompt_tokens").Int(); got != 11 {
+		t.Fatalf("prompt_tokens = %d, want 11; chunk=%s", got, events[1])
+	}
+	if got := usageChunk.Get("usage.completion_tokens").Int(); got != 4 {
+		t.Fatalf("completion_tokens = %d, want 4", got)
+	}
+	if got := usageChunk.Get("usage.total_tokens").Int(); got != 17 {
+		t.Fatalf("total_tokens = %d, want 17", got)
+	}
+	if got := usageChunk.Get("usage.completion_tokens_details.reasoning_tokens").Int(); got != 2 {
+		t.Fatalf("reasoning_tokens = %d, want 2", got)
+	}
+	if got := usageChunk.Get("id").String(); got != "chatcmpl-1" {
+		t.Fatalf("id = %q, want chatcmpl-1", got)
+	}
+	if n := len(usageChunk.Get("choices").Array()); n != 0 {
+		t.Fatalf("choices = %d, want empty", n)
+	}
+}
+
+func TestChatCompletionsStreamSkipsFinalUsageWhenUpstreamSentUsage(t *testing.T) {
+	executor := &usageStreamExecutor{chunks: []string{
+		`{"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"hello"}}]}`,
+		`{"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`,
+	}}
+	cfg := &sdkconfig.SDKConfig{Streaming: sdkconfig.StreamingConfig{EmitFinalUsage: true}}
+	events := serveFinalUsageStream(t, cfg, executor)
+
+	if len(events) != 3 || events[2] != "[DONE]" {
+		t.Fatalf("events = %v, want the two upstream chunks then [DONE]", events)
+	}
+}
+
+func TestChatCompletionsStreamFinalUsageDisabledByDefault(t *testing.T) {
+	executor := &usageStreamExecutor{chunks: []string{
+		`{"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"hello"}}]}`,
+	}}
+	events := serveFinalUsageStream(t, &sdkconfig.SDKConfig{}, executor)
+
+	if len(events) != 2 || events[1] != "[DONE]" {
+		t.Fatalf("events = %v, want chunk then [DONE]", events)
+	}
+}
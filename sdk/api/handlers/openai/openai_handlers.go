@@ -19,6 +19,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	responsesconverter "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/openai/responses"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -57,12 +58,17 @@ func (h *OpenAIAPIHandler) Models() []map[string]any {
 
 // OpenAIModels handles the /v1/models endpoint.
 // It returns a list of available AI models with their capabilities
-// and specifications in OpenAI-compatible format.
+// and specifications in OpenAI-compatible format, annotated with the
+// providers serving each model and whether all of them are rate-limited.
 func (h *OpenAIAPIHandler) OpenAIModels(c *gin.Context) {
 	// Get all available models
 	allModels := h.Models()
+	aggregated := make(map[string]registry.AggregatedModel)
+	for _, model := range registry.GetGlobalRegistry().ListAllModels() {
+		aggregated[model.Info.ID] = model
+	}
 
-	// Filter to only include the 4 required fields: id, object, created, owned_by
+	// Filter to the 4 required fields (id, object, created, owned_by) plus provider annotations
 	filteredModels := make([]map[string]any, len(allModels))
 	for i, model := range allModels {
 		filteredModel := map[string]any{
@@ -80,6 +86,13 @@ func (h *OpenAIAPIHandler) OpenAIModels(c *gin.Context) {
 			filteredModel["owned_by"] = ownedBy
 		}
 
+		if id, ok := model["id"].(string); ok {
+			if entry, exists := aggregated[id]; exists {
+				filteredModel["providers"] = entry.Providers
+				filteredModel["rate_limited"] = entry.RateLimited
+			}
+		}
+
 		filteredModels[i] = filteredModel
 	}
 
@@ -431,7 +444,7 @@ func (h *OpenAIAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSON []
 
 	modelName := gjson.GetBytes(rawJSON, "model").String()
 	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
-	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, h.GetAlt(c))
+	resp, upstreamHeaders, errMsg := h.executeWithAutoContinue(cliCtx, modelName, rawJSON, h.GetAlt(c))
 	if errMsg != nil {
 		h.WriteErrorResponse(c, errMsg)
 		cliCancel(errMsg.Error)
@@ -464,6 +477,11 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 
 	modelName := gjson.GetBytes(rawJSON, "model").String()
 	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	var finalUsage *openAIFinalUsage
+	if h.Cfg != nil && h.Cfg.Streaming.EmitFinalUsage {
+		finalUsage = &openAIFinalUsage{}
+		cliCtx, finalUsage.collector = usage.WithCollector(cliCtx)
+	}
 	dataChan, upstreamHeaders, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, h.GetAlt(c))
 
 	setSSEHeaders := func() {
@@ -508,11 +526,16 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 			setSSEHeaders()
 			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
 
-			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(chunk))
+			if handlers.IsStreamHeartbeat(chunk) {
+				_, _ = c.Writer.Write(chunk)
+			} else {
+				finalUsage.observe(chunk)
+				_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(chunk))
+			}
 			flusher.Flush()
 
 			// Continue streaming the rest
-			h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
+			h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, finalUsage)
 			return
 		}
 	}
@@ -615,8 +638,10 @@ func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, ra
 			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
 
 			// Write the first chunk
-			converted := convertChatCompletionsStreamChunkToCompletions(chunk)
-			if converted != nil {
+			if handlers.IsStreamHeartbeat(chunk) {
+				_, _ = c.Writer.Write(chunk)
+				flusher.Flush()
+			} else if converted := convertChatCompletionsStreamChunkToCompletions(chunk); converted != nil {
 				_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(converted))
 				flusher.Flush()
 			}
@@ -636,7 +661,10 @@ func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, ra
 						if !ok {
 							return
 						}
-						converted := convertChatCompletionsStreamChunkToCompletions(chunk)
+						converted := chunk
+						if !handlers.IsStreamHeartbeat(chunk) {
+							converted = convertChatCompletionsStreamChunkToCompletions(chunk)
+						}
 						if converted == nil {
 							continue
 						}
@@ -652,14 +680,15 @@ func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, ra
 			h.handleStreamResult(c, flusher, func(err error) {
 				stop()
 				cliCancel(err)
-			}, convertedChan, errChan)
+			}, convertedChan, errChan, nil)
 			return
 		}
 	}
 }
-func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, finalUsage *openAIFinalUsage) {
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
 		WriteChunk: func(chunk []byte) {
+			finalUsage.observe(chunk)
 			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(chunk))
 		},
 		WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
@@ -674,10 +703,13 @@ func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flush
 			if errMsg.Error != nil && errMsg.Error.Error() != "" {
 				errText = errMsg.Error.Error()
 			}
-			body := handlers.BuildErrorResponseBody(status, errText)
+			body := h.StreamErrorBody(h.HandlerType(), status, errText)
 			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(body))
 		},
 		WriteDone: func() {
+			if usageChunk := finalUsage.chunk(); usageChunk != nil {
+				_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(usageChunk))
+			}
 			_, _ = fmt.Fprint(c.Writer, "data: [DONE]\n\n")
 		},
 	})
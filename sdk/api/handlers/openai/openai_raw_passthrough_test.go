@@ -0,0 +1,60 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestChatCompletionsRawPassthroughStreamIsFramedOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	executor := &usageStreamExecutor{chunks: []string{
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"hello"}}]}`,
+		``,
+		`data: [DONE]`,
+	}}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "raw-passthrough-" + t.Name(), Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "final-usage-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	cfg := &sdkconfig.SDKConfig{RawPassthroughAPIKeys: []string{"raw-key"}}
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(cfg, manager))
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("apiKey", "raw-key") })
+	router.POST("/v1/chat/completions", h.ChatCompletions)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"final-usage-model","stream":true,"messages":[{"role":"user","content":"hi"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(handlers.RawPassthroughHeader, "true")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body=%s", resp.Code, http.StatusOK, resp.Body.String())
+	}
+
+	body := resp.Body.String()
+	if strings.Contains(body, "data: data:") {
+		t.Fatalf("stream chunks framed twice: %q", body)
+	}
+	if !strings.Contains(body, `data: {"id":"chatcmpl-1"`) {
+		t.Fatalf("upstream chunk missing from stream: %q", body)
+	}
+	if n := strings.Count(body, "[DONE]"); n != 1 {
+		t.Fatalf("[DONE] count = %d, want 1; body=%q", n, body)
+	}
+}
@@ -41,3 +41,25 @@ func TestForwardResponsesStreamTerminalErrorUsesResponsesErrorChunk(t *testing.T
 		t.Fatalf("expected streaming error chunk (top-level type), got HTTP error body: %q", body)
 	}
 }
+
+func TestForwardResponsesStreamTerminalErrorIsNormalized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{NormalizeErrors: true}, nil)
+	h := NewOpenAIResponsesAPIHandler(base)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/responses", nil)
+	flusher, _ := c.Writer.(http.Flusher)
+
+	data := make(chan []byte)
+	errs := make(chan *interfaces.ErrorMessage, 1)
+	errs <- &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: errors.New(`{"error":{"code":429,"message":"Quota exceeded","status":"RESOURCE_EXHAUSTED"}}`)}
+	close(errs)
+
+	h.forwardResponsesStream(c, flusher, func(error) {}, data, errs)
+	body := recorder.Body.String()
+	if !strings.Contains(body, `"code":"rate_limit"`) || !strings.Contains(body, `"message":"Quota exceeded"`) {
+		t.Fatalf("expected normalized responses error chunk, got: %q", body)
+	}
+}
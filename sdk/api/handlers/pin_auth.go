@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// PinAuthHeader names the request header that forces execution on a specific auth ID.
+const PinAuthHeader = "X-Pin-Auth"
+
+// applyPinAuthHeader pins the request to the auth named in the X-Pin-Auth header. The header
+// is only honoured for client API keys listed in pin-auth-api-keys; others get 403. A pinned
+// auth that does not exist or cannot serve model is reported as 404.
+func (h *BaseAPIHandler) applyPinAuthHeader(ctx context.Context, providers []string, model string, meta map[string]any) *interfaces.ErrorMessage {
+	if ctx == nil {
+		return nil
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return nil
+	}
+	authID := strings.TrimSpace(ginCtx.GetHeader(PinAuthHeader))
+	if authID == "" {
+		return nil
+	}
+	if !h.pinAuthAllowed(ginCtx.GetString("apiKey")) {
+		return &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("%s header is not allowed for this API key", PinAuthHeader)}
+	}
+
+	notFound := &interfaces.ErrorMessage{StatusCode: http.StatusNotFound, Error: fmt.Errorf("auth %s not found or cannot serve model %s", authID, model)}
+	if h.AuthManager == nil {
+		return notFound
+	}
+	auth, ok := h.AuthManager.GetByID(authID)
+	if !ok || auth == nil || auth.Disabled {
+		return notFound
+	}
+	servesProvider := false
+	for _, provider := range providers {
+		if strings.EqualFold(provider, auth.Provider) {
+			servesProvider = true
+			break
+		}
+	}
+	baseModel := thinking.ParseSuffix(model).ModelName
+	if !servesProvider || !registry.GetGlobalRegistry().ClientSupportsModel(authID, baseModel) {
+		return notFound
+	}
+	meta[coreexecutor.PinnedAuthMetadataKey] = authID
+	return nil
+}
+
+func (h *BaseAPIHandler) pinAuthAllowed(apiKey string) bool {
+	if h.Cfg == nil || apiKey == "" {
+		return false
+	}
+	for _, allowed := range h.Cfg.PinAuthAPIKeys {
+		if allowed == apiKey {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+type pinAuthRecordingExecutor struct {
+	mu      sync.Mutex
+	authIDs []string
+	err     error
+}
+
+func (e *pinAuthRecordingExecutor) Identifier() string { return "pin-test" }
+
+func (e *pinAuthRecordingExecutor) Execute(_ context.Context, auth *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.authIDs = append(e.authIDs, auth.ID)
+	err := e.err
+	e.mu.Unlock()
+	if err != nil {
+		return coreexecutor.Response{}, err
+	}
+	return coreexecutor.Response{Payload: []byte(`{"ok":true}`)}, nil
+}
+
+func (e *pinAuthRecordingExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *pinAuthRecordingExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *pinAuthRecordingExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *pinAuthRecordingExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented"}
+}
+
+func newPinAuthTestHandler(t *testing.T) (*BaseAPIHandler, *pinAuthRecordingExecutor) {
+	t.Helper()
+	executor := &pinAuthRecordingExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	for _, id := range []string{"pin-auth-1", "pin-auth-2"} {
+		if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: id, Provider: "pin-test", Status: coreauth.StatusActive}); err != nil {
+			t.Fatalf("manager.Register(%s): %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "pin-test", []*registry.ModelInfo{{ID: "pin-model"}})
+	}
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient("pin-auth-1")
+		registry.GetGlobalRegistry().UnregisterClient("pin-auth-2")
+	})
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{PinAuthAPIKeys: []string{"admin-key"}}, manager)
+	return handler, executor
+}
+
+func pinAuthRequestContext(apiKey, authID string) context.Context {
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ginCtx.Request.Header.Set(PinAuthHeader, authID)
+	ginCtx.Set("apiKey", apiKey)
+	return context.WithValue(context.Background(), "gin", ginCtx)
+}
+
+func TestExecuteWithAuthManager_PinAuthHeaderUsesPinnedAuth(t *testing.T) {
+	handler, executor := newPinAuthTestHandler(t)
+
+	for i := 0; i < 3; i++ {
+		if _, _, errMsg := handler.ExecuteWithAuthManager(pinAuthRequestContext("admin-key", "pin-auth-2"), "openai", "pin-model", []byte(`{"model":"pin-model"}`), ""); errMsg != nil {
+			t.Fatalf("ExecuteWithAuthManager error: %v", errMsg.Error)
+		}
+	}
+
+	executor.mu.Lock()
+	defer executor.mu.Unlock()
+	for _, id := range executor.authIDs {
+		if id != "pin-auth-2" {
+			t.Fatalf("auth IDs = %v, want only pin-auth-2", executor.authIDs)
+		}
+	}
+	if len(executor.authIDs) != 3 {
+		t.Fatalf("executor calls = %d, want 3", len(executor.authIDs))
+	}
+}
+
+func TestExecuteWithAuthManager_PinAuthHeaderRejections(t *testing.T) {
+	handler, executor := newPinAuthTestHandler(t)
+
+	cases := []struct {
+		name   string
+		apiKey string
+		authID string
+		model  string
+		want   int
+	}{
+		{name: "not allowlisted", apiKey: "user-key", authID: "pin-auth-1", model: "pin-model", want: http.StatusForbidden},
+		{name: "unknown auth", apiKey: "admin-key", authID: "missing", model: "pin-model", want: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, errMsg := handler.ExecuteWithAuthManager(pinAuthRequestContext(tc.apiKey, tc.authID), "openai", tc.model, []byte(`{}`), "")
+			if errMsg == nil || errMsg.StatusCode != tc.want {
+				t.Fatalf("error = %+v, want status %d", errMsg, tc.want)
+			}
+		})
+	}
+	if len(executor.authIDs) != 0 {
+		t.Fatalf("executor called for rejected pins: %v", executor.authIDs)
+	}
+}
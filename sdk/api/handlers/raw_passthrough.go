@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// RawPassthroughHeader names the request header that disables payload translation.
+const RawPassthroughHeader = "X-Raw-Passthrough"
+
+// applyRawPassthroughHeader marks the request for raw passthrough when it carries
+// X-Raw-Passthrough: true. The header is only honoured for client API keys listed in
+// raw-passthrough-api-keys; others get 403.
+func (h *BaseAPIHandler) applyRawPassthroughHeader(ctx context.Context, meta map[string]any) *interfaces.ErrorMessage {
+	if ctx == nil {
+		return nil
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return nil
+	}
+	enabled, errParse := strconv.ParseBool(strings.TrimSpace(ginCtx.GetHeader(RawPassthroughHeader)))
+	if errParse != nil || !enabled {
+		return nil
+	}
+	if !h.rawPassthroughAllowed(ginCtx.GetString("apiKey")) {
+		return &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("%s header is not allowed for this API key", RawPassthroughHeader)}
+	}
+	meta[coreexecutor.RawPassthroughMetadataKey] = true
+	return nil
+}
+
+func (h *BaseAPIHandler) rawPassthroughAllowed(apiKey string) bool {
+	if h.Cfg == nil || apiKey == "" {
+		return false
+	}
+	for _, allowed := range h.Cfg.RawPassthroughAPIKeys {
+		if allowed == apiKey {
+			return true
+		}
+	}
+	return false
+}
+
+// rawPassthroughStreamChunk reframes one untranslated upstream stream line for the handler of
+// handlerType. Executors forward raw SSE lines without their terminators. OpenAI chat and
+// Gemini handlers add their own "data: " framing, so they get the bare payload; Claude and
+// Responses handlers write chunks verbatim, so lines keep their field name and regain the
+// line endings. ok is false for lines that should be dropped.
+func rawPassthroughStreamChunk(handlerType string, chunk []byte) ([]byte, bool) {
+	line := bytes.TrimSpace(chunk)
+	if len(line) == 0 {
+		return nil, false
+	}
+	switch handlerType {
+	case "openai", "gemini", "gemini-cli":
+		data, isData := bytes.CutPrefix(line, []byte("data:"))
+		if !isData {
+			if isSSEFieldLine(line) {
+				return nil, false
+			}
+			return line, true
+		}
+		data = bytes.TrimSpace(data)
+		// The OpenAI handler terminates the stream with its own [DONE].
+		if len(data) == 0 || (handlerType == "openai" && bytes.Equal(data, []byte("[DONE]"))) {
+			return nil, false
+		}
+		return data, true
+	default:
+		if bytes.HasPrefix(line, []byte("data:")) {
+			return append(line, '\n', '\n'), true
+		}
+		return append(line, '\n'), true
+	}
+}
+
+// isSSEFieldLine reports whether line is an SSE comment or a non-data field.
+func isSSEFieldLine(line []byte) bool {
+	if bytes.HasPrefix(line, []byte(":")) {
+		return true
+	}
+	for _, field := range []string{"event:", "id:", "retry:"} {
+		if bytes.HasPrefix(line, []byte(field)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import "testing"
+
+func TestRawPassthroughStreamChunk(t *testing.T) {
+	cases := []struct {
+		handlerType string
+		chunk       string
+		want        string
+		keep        bool
+	}{
+		{"openai", `data: {"a":1}`, `{"a":1}`, true},
+		{"openai", `data: [DONE]`, "", false},
+		{"openai", `: keep-alive`, "", false},
+		{"openai", ``, "", false},
+		{"gemini", `data: {"candidates":[]}`, `{"candidates":[]}`, true},
+		{"gemini", `{"candidates":[]}`, `{"candidates":[]}`, true},
+		{"claude", `event: message_start`, "event: message_start\n", true},
+		{"claude", `data: {"type":"message_start"}`, "data: {\"type\":\"message_start\"}\n\n", true},
+		{"openai-response", `data: {"type":"response.completed"}`, "data: {\"type\":\"response.completed\"}\n\n", true},
+	}
+	for _, tc := range cases {
+		got, keep := rawPassthroughStreamChunk(tc.handlerType, []byte(tc.chunk))
+		if keep != tc.keep || string(got) != tc.want {
+			t.Errorf("rawPassthroughStreamChunk(%q, %q) = %q, %v; want %q, %v", tc.handlerType, tc.chunk, got, keep, tc.want, tc.keep)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"bytes"
 	"net/http"
 	"time"
 
@@ -29,6 +30,13 @@ type StreamForwardOptions struct {
 	WriteKeepAlive func()
 }
 
+// IsStreamHeartbeat reports whether chunk is an SSE comment heartbeat emitted by an executor
+// while waiting for the first upstream payload. Heartbeats are written to SSE clients verbatim
+// and dropped for other output formats.
+func IsStreamHeartbeat(chunk []byte) bool {
+	return bytes.HasPrefix(chunk, []byte(":"))
+}
+
 func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, opts StreamForwardOptions) {
 	if c == nil {
 		return
@@ -53,6 +61,8 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 	if opts.KeepAliveInterval != nil {
 		keepAliveInterval = *opts.KeepAliveInterval
 	}
+	// An explicit non-positive override marks a non-SSE output that cannot carry heartbeats.
+	heartbeatsAllowed := opts.KeepAliveInterval == nil || *opts.KeepAliveInterval > 0
 	var keepAlive *time.Ticker
 	var keepAliveC <-chan time.Time
 	if keepAliveInterval > 0 {
@@ -94,6 +104,13 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 				cancel(nil)
 				return
 			}
+			if IsStreamHeartbeat(chunk) {
+				if heartbeatsAllowed {
+					_, _ = c.Writer.Write(chunk)
+					flusher.Flush()
+				}
+				continue
+			}
 			writeChunk(chunk)
 			flusher.Flush()
 		case errMsg, ok := <-errs:
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Audit event types.
+const (
+	// AuthEventTokenRefreshed is emitted when a refresh rotated a credential's token.
+	AuthEventTokenRefreshed = "token_refreshed"
+)
+
+// AuthEvent records a security-relevant change to a credential. Tokens are never included;
+// only their fingerprints are.
+type AuthEvent struct {
+	// Type is the event kind, e.g. AuthEventTokenRefreshed.
+	Type string `json:"type"`
+	// AuthID identifies the credential.
+	AuthID string `json:"auth_id"`
+	// Provider is the credential's provider key.
+	Provider string `json:"provider"`
+	// OldTokenFingerprint is the fingerprint of the token before the change.
+	OldTokenFingerprint string `json:"old_token_fingerprint,omitempty"`
+	// NewTokenFingerprint is the fingerprint of the token after the change.
+	NewTokenFingerprint string `json:"new_token_fingerprint,omitempty"`
+	// Time is when the event occurred.
+	Time time.Time `json:"time"`
+}
+
+// AuthEventSink receives credential audit events, e.g. to forward them to a security log.
+// Sinks are called synchronously and must not block.
+type AuthEventSink interface {
+	RecordAuthEvent(ctx context.Context, event AuthEvent)
+}
+
+// AuthEventSinkFunc adapts a function to the AuthEventSink interface.
+type AuthEventSinkFunc func(ctx context.Context, event AuthEvent)
+
+// RecordAuthEvent calls f(ctx, event).
+func (f AuthEventSinkFunc) RecordAuthEvent(ctx context.Context, event AuthEvent) { f(ctx, event) }
+
+var (
+	authEventSinksMu sync.RWMutex
+	authEventSinks   []AuthEventSink
+)
+
+// RegisterAuthEventSink adds a sink receiving every emitted audit event.
+func RegisterAuthEventSink(sink AuthEventSink) {
+	if sink == nil {
+		return
+	}
+	authEventSinksMu.Lock()
+	authEventSinks = append(authEventSinks, sink)
+	authEventSinksMu.Unlock()
+}
+
+// EmitAuthEvent delivers event to every registered sink, filling in Time when unset.
+func EmitAuthEvent(ctx context.Context, event AuthEvent) {
+	authEventSinksMu.RLock()
+	sinks := authEventSinks
+	authEventSinksMu.RUnlock()
+	if len(sinks) == 0 {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	for _, sink := range sinks {
+		sink.RecordAuthEvent(ctx, event)
+	}
+}
+
+// TokenFingerprint returns a short SHA-256 based fingerprint of token, or "" for an empty token.
+func TokenFingerprint(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
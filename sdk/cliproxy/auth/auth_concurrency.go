@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// acquireAuthSlot reserves one of the max-concurrency-per-auth slots for authID, waiting up to
+// auth-concurrency-wait-seconds for a slot to free up. The returned release func is never nil
+// and must be called exactly once. Failures are not recorded against the credential, so a busy
+// credential is skipped without entering cooldown.
+func (m *Manager) acquireAuthSlot(ctx context.Context, authID string) (func(), error) {
+	noop := func() {}
+	cfg, limit := m.authConcurrencyLimit(authID)
+	if limit <= 0 {
+		return noop, nil
+	}
+	slots := m.authLimiter.slotsFor(authID, limit)
+	select {
+	case slots <- struct{}{}:
+		return m.authLimiter.holdSlot(authID, slots), nil
+	default:
+	}
+	limited := m.authConcurrencyLimited(authID, limit)
+	if cfg.AuthConcurrencyWaitSeconds <= 0 {
+		return noop, limited
+	}
+	timer := time.NewTimer(time.Duration(cfg.AuthConcurrencyWaitSeconds) * time.Second)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return m.authLimiter.holdSlot(authID, slots), nil
+	case <-timer.C:
+		return noop, limited
+	case <-ctx.Done():
+		return noop, ctx.Err()
+	}
+}
+
+// authConcurrencyLimit returns the runtime config and the per-auth concurrency limit, which is
+// zero when no limit applies to authID.
+func (m *Manager) authConcurrencyLimit(authID string) (*internalconfig.Config, int) {
+	if m == nil || authID == "" {
+		return nil, 0
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || cfg.MaxConcurrencyPerAuth <= 0 {
+		return cfg, 0
+	}
+	return cfg, cfg.MaxConcurrencyPerAuth
+}
+
+// authConcurrencyLimited builds the retryable 429 returned when authID has no free slot.
+func (m *Manager) authConcurrencyLimited(authID string, limit int) *Error {
+	return &Error{
+		Code:       "auth_concurrency_limited",
+		Message:    fmt.Sprintf("too many concurrent requests for credential (limit %d)", limit),
+		Retryable:  true,
+		HTTPStatus: http.StatusTooManyRequests,
+		headers:    retryAfterHeaders(m.authLimiter.retryAfter(authID, limit)),
+	}
+}
+
+// busyAuth is a picked credential whose concurrency slots were all taken.
+type busyAuth struct {
+	auth     *Auth
+	executor ProviderExecutor
+	provider string
+	slots    chan struct{}
+}
+
+// pickNextMixedWithSlot picks the next credential like pickNextMixed and reserves one of its
+// max-concurrency-per-auth slots. Busy credentials are passed over so every free candidate is
+// tried first; only when none is left does it wait, once, up to auth-concurrency-wait-seconds
+// for whichever busy credential frees a slot first. Busy credentials are not added to tried.
+// The returned release func is never nil and must be called exactly once.
+func (m *Manager) pickNextMixedWithSlot(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, func(), error) {
+	noop := func() {}
+	var busy []busyAuth
+	defer func() {
+		for _, b := range busy {
+			delete(tried, b.auth.ID)
+		}
+	}()
+	for {
+		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, model, opts, tried)
+		if errPick != nil {
+			if len(busy) == 0 {
+				return nil, nil, "", noop, errPick
+			}
+			picked, release, errWait := m.waitAuthSlot(ctx, busy)
+			if errWait != nil {
+				return nil, nil, "", noop, errWait
+			}
+			return picked.auth, picked.executor, picked.provider, release, nil
+		}
+		_, limit := m.authConcurrencyLimit(auth.ID)
+		if limit <= 0 {
+			return auth, executor, provider, noop, nil
+		}
+		slots := m.authLimiter.slotsFor(auth.ID, limit)
+		select {
+		case slots <- struct{}{}:
+			return auth, executor, provider, m.authLimiter.holdSlot(auth.ID, slots), nil
+		default:
+		}
+		tried[auth.ID] = struct{}{}
+		busy = append(busy, busyAuth{auth: auth, executor: executor, provider: provider, slots: slots})
+	}
+}
+
+// waitAuthSlot waits up to auth-concurrency-wait-seconds for a slot on any of the busy
+// credentials and returns the first one to free up with its release func.
+func (m *Manager) waitAuthSlot(ctx context.Context, busy []busyAuth) (busyAuth, func(), error) {
+	noop := func() {}
+	first := busy[0]
+	cfg, limit := m.authConcurrencyLimit(first.auth.ID)
+	limited := m.authConcurrencyLimited(first.auth.ID, limit)
+	if cfg == nil || cfg.AuthConcurrencyWaitSeconds <= 0 {
+		return busyAuth{}, noop, limited
+	}
+	timer := time.NewTimer(time.Duration(cfg.AuthConcurrencyWaitSeconds) * time.Second)
+	defer timer.Stop()
+
+	cases := make([]reflect.SelectCase, 0, len(busy)+2)
+	cases = append(cases,
+		reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(timer.C)},
+	)
+	for _, b := range busy {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(b.slots), Send: reflect.ValueOf(struct{}{})})
+	}
+	switch chosen, _, _ := reflect.Select(cases); chosen {
+	case 0:
+		return busyAuth{}, noop, ctx.Err()
+	case 1:
+		return busyAuth{}, noop, limited
+	default:
+		picked := busy[chosen-2]
+		return picked, m.authLimiter.holdSlot(picked.auth.ID, picked.slots), nil
+	}
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func newAuthConcurrencyTestManager(t *testing.T, waitSeconds int) (*Manager, *blockingModelExecutor) {
+	t.Helper()
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{MaxConcurrencyPerAuth: 1, AuthConcurrencyWaitSeconds: waitSeconds})
+	executor := &blockingModelExecutor{entered: make(chan string, 4), unblock: make(chan struct{})}
+	m.RegisterExecutor(executor)
+
+	auth := &Auth{ID: uuid.NewString(), Provider: "claude"}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(auth.ID, "claude", []*registry.ModelInfo{{ID: "auth-capped-model"}})
+	t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+	if _, errRegister := m.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("register: %v", errRegister)
+	}
+	return m, executor
+}
+
+func TestManager_AuthConcurrencyLimitRejectsBusyCredential(t *testing.T) {
+	m, executor := newAuthConcurrencyTestManager(t, 0)
+	req := cliproxyexecutor.Request{Model: "auth-capped-model"}
+
+	firstDone := make(chan error, 1)
+	go func() {
+		_, errExec := m.Execute(context.Background(), []string{"claude"}, req, cliproxyexecutor.Options{})
+		firstDone <- errExec
+	}()
+	waitEntered(t, executor, "auth-capped-model")
+
+	_, errExec := m.Execute(context.Background(), []string{"claude"}, req, cliproxyexecutor.Options{})
+	authErr, ok := errExec.(*Error)
+	if !ok || authErr.HTTPStatus != http.StatusTooManyRequests || authErr.Code != "auth_concurrency_limited" {
+		t.Fatalf("busy credential error = %v, want auth_concurrency_limited 429", errExec)
+	}
+
+	close(executor.unblock)
+	if errFirst := <-firstDone; errFirst != nil {
+		t.Fatalf("first request: %v", errFirst)
+	}
+
+	// The rejection must not cool the credential down.
+	if _, errAfter := m.Execute(context.Background(), []string{"claude"}, req, cliproxyexecutor.Options{}); errAfter != nil {
+		t.Fatalf("request after slot freed: %v", errAfter)
+	}
+	waitEntered(t, executor, "auth-capped-model")
+}
+
+func TestManager_AuthConcurrencyLimitWaitsForSlot(t *testing.T) {
+	m, executor := newAuthConcurrencyTestManager(t, 5)
+	req := cliproxyexecutor.Request{Model: "auth-capped-model"}
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, errExec := m.Execute(context.Background(), []string{"claude"}, req, cliproxyexecutor.Options{})
+			done <- errExec
+		}()
+	}
+	waitEntered(t, executor, "auth-capped-model")
+	select {
+	case model := <-executor.entered:
+		t.Fatalf("second request for %s entered while the credential was busy", model)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(executor.unblock)
+	waitEntered(t, executor, "auth-capped-model")
+	for i := 0; i < 2; i++ {
+		if errExec := <-done; errExec != nil {
+			t.Fatalf("request %d: %v", i, errExec)
+		}
+	}
+}
+
+func TestManager_AuthConcurrencyLimitPrefersFreeCredentialOverWaiting(t *testing.T) {
+	m, executor := newAuthConcurrencyTestManager(t, 5)
+	// Fill-first keeps picking the busy credential first.
+	m.SetSelector(&FillFirstSelector{})
+	second := &Auth{ID: uuid.NewString(), Provider: "claude"}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(second.ID, "claude", []*registry.ModelInfo{{ID: "auth-capped-model"}})
+	t.Cleanup(func() { reg.UnregisterClient(second.ID) })
+	if _, errRegister := m.Register(context.Background(), second); errRegister != nil {
+		t.Fatalf("register: %v", errRegister)
+	}
+	req := cliproxyexecutor.Request{Model: "auth-capped-model"}
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, errExec := m.Execute(context.Background(), []string{"claude"}, req, cliproxyexecutor.Options{})
+			done <- errExec
+		}()
+	}
+	// Both requests must be in flight at once, one per credential, without waiting out the
+	// 5s auth-concurrency-wait-seconds on whichever credential the second one picked first.
+	waitEntered(t, executor, "auth-capped-model")
+	waitEntered(t, executor, "auth-capped-model")
+
+	close(executor.unblock)
+	for i := 0; i < 2; i++ {
+		if errExec := <-done; errExec != nil {
+			t.Fatalf("request %d: %v", i, errExec)
+		}
+	}
+}
+
+type blockingCountExecutor struct {
+	*blockingModelExecutor
+}
+
+func (e blockingCountExecutor) CountTokens(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return e.Execute(ctx, auth, req, opts)
+}
+
+func TestManager_AuthConcurrencyLimitAppliesToCountTokens(t *testing.T) {
+	m, executor := newAuthConcurrencyTestManager(t, 0)
+	m.RegisterExecutor(blockingCountExecutor{executor})
+	req := cliproxyexecutor.Request{Model: "auth-capped-model"}
+
+	firstDone := make(chan error, 1)
+	go func() {
+		_, errExec := m.Execute(context.Background(), []string{"claude"}, req, cliproxyexecutor.Options{})
+		firstDone <- errExec
+	}()
+	waitEntered(t, executor, "auth-capped-model")
+
+	countCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, errCount := m.ExecuteCount(countCtx, []string{"claude"}, req, cliproxyexecutor.Options{})
+	authErr, ok := errCount.(*Error)
+	if !ok || authErr.HTTPStatus != http.StatusTooManyRequests || authErr.Code != "auth_concurrency_limited" {
+		t.Fatalf("count on busy credential error = %v, want auth_concurrency_limited 429", errCount)
+	}
+
+	close(executor.unblock)
+	if errFirst := <-firstDone; errFirst != nil {
+		t.Fatalf("first request: %v", errFirst)
+	}
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"strings"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+// requestedCodexAccount returns the ChatGPT account a request targets: the X-Codex-Account-Id
+// header recorded in metadata, else _cliproxy.account_id in the client body.
+func requestedCodexAccount(opts cliproxyexecutor.Options) string {
+	if account, _ := opts.Metadata[cliproxyexecutor.RequestedAccountMetadataKey].(string); strings.TrimSpace(account) != "" {
+		return strings.TrimSpace(account)
+	}
+	if len(opts.OriginalRequest) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(gjson.GetBytes(opts.OriginalRequest, "_cliproxy.account_id").String())
+}
+
+// codexAccountExclusions returns tried extended with every Codex credential among providers
+// that does not own the requested account, so selection only considers owners. It returns nil
+// when no account was requested or no credential owns it; the executor then rejects the
+// request with a clear error instead of the selector hiding it.
+func (m *Manager) codexAccountExclusions(providers []string, opts cliproxyexecutor.Options, tried map[string]struct{}) map[string]struct{} {
+	if !containsProvider(normalizeProviderKeys(providers), "codex") {
+		return nil
+	}
+	account := requestedCodexAccount(opts)
+	if account == "" {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var foreign []string
+	owned := false
+	for id, candidate := range m.auths {
+		if candidate == nil || !strings.EqualFold(strings.TrimSpace(candidate.Provider), "codex") {
+			continue
+		}
+		if codexAuthOwnsAccount(candidate, account) {
+			owned = true
+			continue
+		}
+		foreign = append(foreign, id)
+	}
+	if !owned || len(foreign) == 0 {
+		return nil
+	}
+	excluded := make(map[string]struct{}, len(tried)+len(foreign))
+	for id := range tried {
+		excluded[id] = struct{}{}
+	}
+	for _, id := range foreign {
+		excluded[id] = struct{}{}
+	}
+	return excluded
+}
+
+func codexAuthOwnsAccount(auth *Auth, account string) bool {
+	for _, id := range auth.CodexAccountIDs() {
+		if id == account {
+			return true
+		}
+	}
+	return false
+}
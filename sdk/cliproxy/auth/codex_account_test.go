@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestManagerPrefersCodexCredentialOwningRequestedAccount(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	executor := &authFallbackExecutor{id: "codex"}
+	m.RegisterExecutor(executor)
+
+	baseID := uuid.NewString()
+	owner := &Auth{ID: baseID + "-owner", Provider: "codex", Metadata: map[string]any{"account_id": "acct-personal", "account_ids": []any{"acct-team"}}}
+	other := &Auth{ID: baseID + "-other", Provider: "codex", Metadata: map[string]any{"account_id": "acct-other"}}
+	reg := registry.GetGlobalRegistry()
+	for _, auth := range []*Auth{owner, other} {
+		reg.RegisterClient(auth.ID, "codex", []*registry.ModelInfo{{ID: "gpt-5"}})
+		if _, err := m.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register %s: %v", auth.ID, err)
+		}
+	}
+	t.Cleanup(func() {
+		reg.UnregisterClient(owner.ID)
+		reg.UnregisterClient(other.ID)
+	})
+
+	byHeader := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.RequestedAccountMetadataKey: "acct-team"}}
+	byBody := cliproxyexecutor.Options{OriginalRequest: []byte(`{"_cliproxy":{"account_id":"acct-team"}}`)}
+	for i, opts := range []cliproxyexecutor.Options{byHeader, byBody, byHeader, byBody} {
+		resp, err := m.Execute(context.Background(), []string{"codex"}, cliproxyexecutor.Request{Model: "gpt-5"}, opts)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		if string(resp.Payload) != owner.ID {
+			t.Fatalf("request %d used %s, want the credential owning acct-team", i, resp.Payload)
+		}
+	}
+
+	// Without an owner the request still reaches a credential so the executor can reject it.
+	unowned := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.RequestedAccountMetadataKey: "acct-missing"}}
+	if _, err := m.Execute(context.Background(), []string{"codex"}, cliproxyexecutor.Request{Model: "gpt-5"}, unowned); err != nil {
+		t.Fatalf("unowned account: %v", err)
+	}
+}
@@ -47,12 +47,32 @@ type ExecutionSessionCloser interface {
 	CloseExecutionSession(sessionID string)
 }
 
+// DryRunExecutor is implemented by executors that honour the dry-run metadata flag by
+// returning the prepared upstream request instead of sending it. Dry runs routed to other
+// executors fail with 501 before any upstream call.
+type DryRunExecutor interface {
+	SupportsDryRun() bool
+}
+
+// ExecutionSessionDrainer allows executors to let in-flight session requests finish before
+// shutdown. Drain returns once all sessions are closed or ctx is done.
+type ExecutionSessionDrainer interface {
+	Drain(ctx context.Context)
+}
+
 const (
 	// CloseAllExecutionSessionsID asks an executor to release all active execution sessions.
 	// Executors that do not support this marker may ignore it.
 	CloseAllExecutionSessionsID = "__all_execution_sessions__"
 )
 
+// Pinger is implemented by executors that can cheaply verify an auth's credentials,
+// e.g. by listing models, without running a generation.
+type Pinger interface {
+	// Ping performs a minimal authenticated request and returns nil when the credentials work.
+	Ping(ctx context.Context, auth *Auth) error
+}
+
 // RefreshEvaluator allows runtime state to override refresh decisions.
 type RefreshEvaluator interface {
 	ShouldRefresh(now time.Time, auth *Auth) bool
@@ -63,6 +83,7 @@ const (
 	refreshMaxConcurrency = 16
 	refreshPendingBackoff = time.Minute
 	refreshFailureBackoff = 5 * time.Minute
+	pingRefreshLead       = 5 * time.Minute
 	quotaBackoffBase      = time.Second
 	quotaBackoffMax       = 30 * time.Minute
 )
@@ -160,6 +181,22 @@ type Manager struct {
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// shadowSink receives shadow traffic comparisons; nil falls back to logging.
+	shadowSink ShadowSink
+	// shadowInFlight counts shadow requests still running.
+	shadowInFlight atomic.Int64
+
+	// modelRouter optionally overrides provider/auth selection per model.
+	modelRouter ModelRouter
+
+	// inputDenylist caches the compiled input-content-denylist for the runtime config.
+	inputDenylist atomic.Value
+
+	// modelLimiter enforces model-concurrency-limits across all credentials.
+	modelLimiter concurrencyLimiter
+	// authLimiter enforces max-concurrency-per-auth for each credential.
+	authLimiter concurrencyLimiter
+
 	// Auto refresh state
 	refreshCancel    context.CancelFunc
 	refreshSemaphore chan struct{}
@@ -621,8 +658,11 @@ func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, re
 	return &cliproxyexecutor.StreamResult{Headers: headers, Chunks: out}
 }
 
-func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor ProviderExecutor, auth *Auth, provider string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, routeModel string, execModels []string, pooled bool) (*cliproxyexecutor.StreamResult, error) {
+// executeStreamWithModelPool streams from auth, falling back through execModels. releaseAuth
+// frees the concurrency slot already held for the first attempt; later attempts take their own.
+func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor ProviderExecutor, auth *Auth, provider string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, routeModel string, execModels []string, pooled bool, releaseAuth func()) (*cliproxyexecutor.StreamResult, error) {
 	if executor == nil {
+		releaseAuth()
 		return nil, &Error{Code: "executor_not_found", Message: "executor not registered"}
 	}
 	var lastErr error
@@ -630,8 +670,20 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 		resultModel := executionResultModel(routeModel, execModel, pooled)
 		execReq := req
 		execReq.Model = execModel
-		streamResult, errStream := executor.ExecuteStream(ctx, auth, execReq, opts)
+		if idx > 0 {
+			var errSlot error
+			releaseAuth, errSlot = m.acquireAuthSlot(ctx, auth.ID)
+			if errSlot != nil {
+				if errCtx := ctx.Err(); errCtx != nil {
+					return nil, errCtx
+				}
+				lastErr = errSlot
+				break
+			}
+		}
+		streamResult, errStream := m.executeStreamWithToolPolicy(cliproxyexecutor.WithExecutionTarget(ctx, provider, execModel), executor, auth, provider, execReq, opts)
 		if errStream != nil {
+			releaseAuth()
 			if errCtx := ctx.Err(); errCtx != nil {
 				return nil, errCtx
 			}
@@ -648,6 +700,7 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 			lastErr = errStream
 			continue
 		}
+		streamResult = releaseSlotOnStreamEnd(ctx, streamResult, releaseAuth)
 
 		buffered, closed, bootstrapErr := readStreamBootstrap(ctx, streamResult.Chunks)
 		if bootstrapErr != nil {
@@ -973,22 +1026,36 @@ func (m *Manager) Load(ctx context.Context) error {
 // Execute performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
 func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if errDenied := m.checkInputContent(ctx, req); errDenied != nil {
+		return cliproxyexecutor.Response{}, errDenied
+	}
+	providers, opts, errRoute := m.applyModelRouter(ctx, providers, req, opts)
+	if errRoute != nil {
+		return cliproxyexecutor.Response{}, errRoute
+	}
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
+	release, queueWait, errSlot := m.acquireModelSlot(ctx, req.Model)
+	if errSlot != nil {
+		return cliproxyexecutor.Response{}, errSlot
+	}
+	defer release()
 
+	ctx = m.withRetryBudget(ctx)
 	_, maxRetryCredentials, maxWait := m.retrySettings()
 
 	var lastErr error
 	for attempt := 0; ; attempt++ {
 		resp, errExec := m.executeMixedOnce(ctx, normalized, req, opts, maxRetryCredentials)
 		if errExec == nil {
-			return resp, nil
+			resp.Headers = withQueueWaitHeader(resp.Headers, queueWait)
+			return m.applyRefusalFallback(ctx, opts, resp), nil
 		}
 		lastErr = errExec
 		wait, shouldRetry := m.shouldRetryAfterError(errExec, attempt, normalized, req.Model, maxWait)
-		if !shouldRetry {
+		if !shouldRetry || !cliproxyexecutor.RetryBudgetFromContext(ctx).Consume() {
 			break
 		}
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
@@ -1004,11 +1071,19 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 // ExecuteCount performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
 func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if errDenied := m.checkInputContent(ctx, req); errDenied != nil {
+		return cliproxyexecutor.Response{}, errDenied
+	}
+	providers, opts, errRoute := m.applyModelRouter(ctx, providers, req, opts)
+	if errRoute != nil {
+		return cliproxyexecutor.Response{}, errRoute
+	}
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
 
+	ctx = m.withRetryBudget(ctx)
 	_, maxRetryCredentials, maxWait := m.retrySettings()
 
 	var lastErr error
@@ -1019,7 +1094,7 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 		}
 		lastErr = errExec
 		wait, shouldRetry := m.shouldRetryAfterError(errExec, attempt, normalized, req.Model, maxWait)
-		if !shouldRetry {
+		if !shouldRetry || !cliproxyexecutor.RetryBudgetFromContext(ctx).Consume() {
 			break
 		}
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
@@ -1035,22 +1110,44 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 // ExecuteStream performs a streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
 func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	if errDenied := m.checkInputContent(ctx, req); errDenied != nil {
+		return nil, errDenied
+	}
+	providers, opts, errRoute := m.applyModelRouter(ctx, providers, req, opts)
+	if errRoute != nil {
+		return nil, errRoute
+	}
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return nil, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
+	release, queueWait, errSlot := m.acquireModelSlot(ctx, req.Model)
+	if errSlot != nil {
+		return nil, errSlot
+	}
+	released := false
+	defer func() {
+		if !released {
+			release()
+		}
+	}()
 
+	ctx = m.withRetryBudget(ctx)
 	_, maxRetryCredentials, maxWait := m.retrySettings()
 
 	var lastErr error
 	for attempt := 0; ; attempt++ {
 		result, errStream := m.executeStreamMixedOnce(ctx, normalized, req, opts, maxRetryCredentials)
 		if errStream == nil {
-			return result, nil
+			released = true
+			if result != nil {
+				result.Headers = withQueueWaitHeader(result.Headers, queueWait)
+			}
+			return releaseSlotOnStreamEnd(ctx, result, release), nil
 		}
 		lastErr = errStream
 		wait, shouldRetry := m.shouldRetryAfterError(errStream, attempt, normalized, req.Model, maxWait)
-		if !shouldRetry {
+		if !shouldRetry || !cliproxyexecutor.RetryBudgetFromContext(ctx).Consume() {
 			break
 		}
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
@@ -1073,19 +1170,23 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	attempted := make(map[string]struct{})
 	var lastErr error
 	for {
-		if maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
+		if (maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials) || (lastErr != nil && !cliproxyexecutor.RetryBudgetFromContext(ctx).Consume()) {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
 			}
 			return cliproxyexecutor.Response{}, &Error{Code: "auth_not_found", Message: "no auth available"}
 		}
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		auth, executor, provider, releaseAuth, errPick := m.pickNextMixedWithSlot(ctx, providers, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
 			}
 			return cliproxyexecutor.Response{}, errPick
 		}
+		if errDryRun := dryRunUnsupportedError(executor, provider, opts); errDryRun != nil {
+			releaseAuth()
+			return cliproxyexecutor.Response{}, errDryRun
+		}
 
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, req.Model)
@@ -1100,15 +1201,29 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 
 		models, pooled := m.preparedExecutionModels(auth, routeModel)
 		if len(models) == 0 {
+			releaseAuth()
 			continue
 		}
 		attempted[auth.ID] = struct{}{}
 		var authErr error
-		for _, upstreamModel := range models {
+		for idx, upstreamModel := range models {
 			resultModel := executionResultModel(routeModel, upstreamModel, pooled)
 			execReq := req
 			execReq.Model = upstreamModel
-			resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
+			if idx > 0 {
+				var errSlot error
+				releaseAuth, errSlot = m.acquireAuthSlot(execCtx, auth.ID)
+				if errSlot != nil {
+					if errCtx := execCtx.Err(); errCtx != nil {
+						return cliproxyexecutor.Response{}, errCtx
+					}
+					authErr = errSlot
+					break
+				}
+			}
+			start := time.Now()
+			resp, errExec := executor.Execute(cliproxyexecutor.WithExecutionTarget(execCtx, provider, upstreamModel), auth, execReq, opts)
+			releaseAuth()
 			result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: errExec == nil}
 			if errExec != nil {
 				if errCtx := execCtx.Err(); errCtx != nil {
@@ -1129,6 +1244,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 				continue
 			}
 			m.MarkResult(execCtx, result)
+			m.launchShadow(ctx, req, opts, resp, provider, time.Since(start))
 			return resp, nil
 		}
 		if authErr != nil {
@@ -1151,19 +1267,23 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 	attempted := make(map[string]struct{})
 	var lastErr error
 	for {
-		if maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
+		if (maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials) || (lastErr != nil && !cliproxyexecutor.RetryBudgetFromContext(ctx).Consume()) {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
 			}
 			return cliproxyexecutor.Response{}, &Error{Code: "auth_not_found", Message: "no auth available"}
 		}
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		auth, executor, provider, releaseAuth, errPick := m.pickNextMixedWithSlot(ctx, providers, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
 			}
 			return cliproxyexecutor.Response{}, errPick
 		}
+		if errDryRun := dryRunUnsupportedError(executor, provider, opts); errDryRun != nil {
+			releaseAuth()
+			return cliproxyexecutor.Response{}, errDryRun
+		}
 
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, req.Model)
@@ -1178,15 +1298,28 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 
 		models, pooled := m.preparedExecutionModels(auth, routeModel)
 		if len(models) == 0 {
+			releaseAuth()
 			continue
 		}
 		attempted[auth.ID] = struct{}{}
 		var authErr error
-		for _, upstreamModel := range models {
+		for idx, upstreamModel := range models {
 			resultModel := executionResultModel(routeModel, upstreamModel, pooled)
 			execReq := req
 			execReq.Model = upstreamModel
-			resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
+			if idx > 0 {
+				var errSlot error
+				releaseAuth, errSlot = m.acquireAuthSlot(execCtx, auth.ID)
+				if errSlot != nil {
+					if errCtx := execCtx.Err(); errCtx != nil {
+						return cliproxyexecutor.Response{}, errCtx
+					}
+					authErr = errSlot
+					break
+				}
+			}
+			resp, errExec := executor.CountTokens(cliproxyexecutor.WithExecutionTarget(execCtx, provider, upstreamModel), auth, execReq, opts)
+			releaseAuth()
 			result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: errExec == nil}
 			if errExec != nil {
 				if errCtx := execCtx.Err(); errCtx != nil {
@@ -1229,7 +1362,7 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	attempted := make(map[string]struct{})
 	var lastErr error
 	for {
-		if maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
+		if (maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials) || (lastErr != nil && !cliproxyexecutor.RetryBudgetFromContext(ctx).Consume()) {
 			if lastErr != nil {
 				var bootstrapErr *streamBootstrapError
 				if errors.As(lastErr, &bootstrapErr) && bootstrapErr != nil {
@@ -1239,7 +1372,7 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			}
 			return nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 		}
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		auth, executor, provider, releaseAuth, errPick := m.pickNextMixedWithSlot(ctx, providers, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				var bootstrapErr *streamBootstrapError
@@ -1250,6 +1383,10 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			}
 			return nil, errPick
 		}
+		if errDryRun := dryRunUnsupportedError(executor, provider, opts); errDryRun != nil {
+			releaseAuth()
+			return nil, errDryRun
+		}
 
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, req.Model)
@@ -1263,10 +1400,11 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		}
 		models, pooled := m.preparedExecutionModels(auth, routeModel)
 		if len(models) == 0 {
+			releaseAuth()
 			continue
 		}
 		attempted[auth.ID] = struct{}{}
-		streamResult, errStream := m.executeStreamWithModelPool(execCtx, executor, auth, provider, req, opts, routeModel, models, pooled)
+		streamResult, errStream := m.executeStreamWithModelPool(execCtx, executor, auth, provider, req, opts, routeModel, models, pooled, releaseAuth)
 		if errStream != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
 				return nil, errCtx
@@ -2222,6 +2360,33 @@ func (m *Manager) CloseExecutionSession(sessionID string) {
 	}
 }
 
+// DrainExecutionSessions asks all registered executors that support draining to finish their
+// in-flight session requests and close their sessions, waiting until they are done or ctx ends.
+func (m *Manager) DrainExecutionSessions(ctx context.Context) {
+	if m == nil {
+		return
+	}
+
+	m.mu.RLock()
+	drainers := make([]ExecutionSessionDrainer, 0, len(m.executors))
+	for _, exec := range m.executors {
+		if drainer, ok := exec.(ExecutionSessionDrainer); ok && drainer != nil {
+			drainers = append(drainers, drainer)
+		}
+	}
+	m.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for i := range drainers {
+		wg.Add(1)
+		go func(drainer ExecutionSessionDrainer) {
+			defer wg.Done()
+			drainer.Drain(ctx)
+		}(drainers[i])
+	}
+	wg.Wait()
+}
+
 func (m *Manager) useSchedulerFastPath() bool {
 	if m == nil || m.scheduler == nil {
 		return false
@@ -2305,6 +2470,9 @@ func (m *Manager) pickNextLegacy(ctx context.Context, provider, model string, op
 }
 
 func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, error) {
+	if excluded := m.codexAccountExclusions([]string{provider}, opts, tried); excluded != nil {
+		tried = excluded
+	}
 	if !m.useSchedulerFastPath() {
 		return m.pickNextLegacy(ctx, provider, model, opts, tried)
 	}
@@ -2419,6 +2587,9 @@ func (m *Manager) pickNextMixedLegacy(ctx context.Context, providers []string, m
 }
 
 func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, error) {
+	if excluded := m.codexAccountExclusions(providers, opts, tried); excluded != nil {
+		tried = excluded
+	}
 	if !m.useSchedulerFastPath() {
 		return m.pickNextMixedLegacy(ctx, providers, model, opts, tried)
 	}
@@ -2793,7 +2964,58 @@ func (m *Manager) markRefreshPending(id string, now time.Time) bool {
 	return true
 }
 
+// PingAuth verifies that the auth's credentials still work. Credentials backed by stored
+// metadata (OAuth tokens) are refreshed first only when they are due for refresh or close
+// to expiry, claiming the refresh the same way the auto-refresh loop does so the two never
+// rotate a token concurrently; the executor then performs a minimal authenticated request.
+func (m *Manager) PingAuth(ctx context.Context, id string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	auth, ok := m.GetByID(id)
+	if !ok {
+		return &Error{Code: "auth_not_found", Message: "auth not found", HTTPStatus: http.StatusNotFound}
+	}
+	exec := m.executorFor(auth.Provider)
+	if exec == nil {
+		return &Error{Code: "executor_not_found", Message: "executor not registered"}
+	}
+	pinger, ok := exec.(Pinger)
+	if !ok {
+		return &Error{Code: "ping_not_supported", Message: "executor does not support ping", HTTPStatus: http.StatusNotImplemented}
+	}
+	if now := time.Now(); len(auth.Metadata) > 0 && m.pingNeedsRefresh(auth, now) && m.markRefreshPending(id, now) {
+		if errRefresh := m.refreshAuthNow(ctx, id); errRefresh != nil {
+			return errRefresh
+		}
+		if refreshed, okRefreshed := m.GetByID(id); okRefreshed {
+			auth = refreshed
+		}
+	}
+	if rt := m.roundTripperFor(auth); rt != nil {
+		ctx = context.WithValue(ctx, roundTripperContextKey{}, rt)
+		ctx = context.WithValue(ctx, "cliproxy.roundtripper", rt)
+	}
+	return pinger.Ping(ctx, auth)
+}
+
 func (m *Manager) refreshAuth(ctx context.Context, id string) {
+	_ = m.refreshAuthNow(ctx, id)
+}
+
+// pingNeedsRefresh reports whether PingAuth should refresh a before probing it: the regular
+// refresh policy says so, or the token expires within pingRefreshLead.
+func (m *Manager) pingNeedsRefresh(a *Auth, now time.Time) bool {
+	if m.shouldRefresh(a, now) {
+		return true
+	}
+	expiry, ok := a.ExpirationTime()
+	return ok && !expiry.IsZero() && expiry.Sub(now) <= pingRefreshLead
+}
+
+// refreshAuthNow refreshes the auth through its executor, records the outcome and returns
+// the refresh error, if any.
+func (m *Manager) refreshAuthNow(ctx context.Context, id string) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
@@ -2805,13 +3027,13 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	}
 	m.mu.RUnlock()
 	if auth == nil || exec == nil {
-		return
+		return nil
 	}
 	cloned := auth.Clone()
 	updated, err := exec.Refresh(ctx, cloned)
 	if err != nil && errors.Is(err, context.Canceled) {
 		log.Debugf("refresh canceled for %s, %s", auth.Provider, auth.ID)
-		return
+		return err
 	}
 	log.Debugf("refreshed %s, %s, %v", auth.Provider, auth.ID, err)
 	now := time.Now()
@@ -2826,7 +3048,7 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 			}
 		}
 		m.mu.Unlock()
-		return
+		return err
 	}
 	if updated == nil {
 		updated = cloned
@@ -2841,6 +3063,7 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	updated.LastError = nil
 	updated.UpdatedAt = now
 	_, _ = m.Update(ctx, updated)
+	return nil
 }
 
 func (m *Manager) executorFor(provider string) ProviderExecutor {
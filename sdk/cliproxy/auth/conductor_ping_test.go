@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type pingTestExecutor struct {
+	schedulerProviderTestExecutor
+
+	mu         sync.Mutex
+	refreshes  int
+	pingTokens []string
+	refreshErr error
+	pingErr    error
+}
+
+func (e *pingTestExecutor) Refresh(ctx context.Context, auth *Auth) (*Auth, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.refreshes++
+	if e.refreshErr != nil {
+		return nil, e.refreshErr
+	}
+	updated := auth.Clone()
+	updated.Metadata["access_token"] = "refreshed"
+	return updated, nil
+}
+
+func (e *pingTestExecutor) Ping(ctx context.Context, auth *Auth) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	token, _ := auth.Metadata["access_token"].(string)
+	e.pingTokens = append(e.pingTokens, token)
+	return e.pingErr
+}
+
+func TestManager_PingAuth_RefreshesExpiringOAuthBeforePing(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager(nil, nil, nil)
+	exec := &pingTestExecutor{schedulerProviderTestExecutor: schedulerProviderTestExecutor{provider: "ping-oauth"}}
+	manager.RegisterExecutor(exec)
+	expiring := time.Now().Add(time.Minute).Format(time.RFC3339)
+	if _, err := manager.Register(ctx, &Auth{ID: "oauth-1", Provider: "ping-oauth", Metadata: map[string]any{"access_token": "stale", "expired": expiring}}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := manager.Register(ctx, &Auth{ID: "key-1", Provider: "ping-oauth", Attributes: map[string]string{"api_key": "k"}}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if err := manager.PingAuth(ctx, "oauth-1"); err != nil {
+		t.Fatalf("PingAuth(oauth) error = %v", err)
+	}
+	if err := manager.PingAuth(ctx, "key-1"); err != nil {
+		t.Fatalf("PingAuth(api key) error = %v", err)
+	}
+	if exec.refreshes != 1 {
+		t.Fatalf("refreshes = %d, want 1", exec.refreshes)
+	}
+	if len(exec.pingTokens) != 2 || exec.pingTokens[0] != "refreshed" {
+		t.Fatalf("ping tokens = %v, want refreshed token first", exec.pingTokens)
+	}
+}
+
+func TestManager_PingAuth_SkipsRefreshForFreshToken(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager(nil, nil, nil)
+	exec := &pingTestExecutor{schedulerProviderTestExecutor: schedulerProviderTestExecutor{provider: "ping-oauth"}}
+	manager.RegisterExecutor(exec)
+	fresh := time.Now().Add(time.Hour).Format(time.RFC3339)
+	if _, err := manager.Register(ctx, &Auth{ID: "oauth-1", Provider: "ping-oauth", Metadata: map[string]any{"access_token": "current", "expired": fresh}}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := manager.PingAuth(ctx, "oauth-1"); err != nil {
+			t.Fatalf("PingAuth error = %v", err)
+		}
+	}
+	if exec.refreshes != 0 {
+		t.Fatalf("refreshes = %d, want 0 for a token far from expiry", exec.refreshes)
+	}
+	if len(exec.pingTokens) != 3 || exec.pingTokens[0] != "current" {
+		t.Fatalf("ping tokens = %v, want current token", exec.pingTokens)
+	}
+}
+
+func TestManager_PingAuth_ReportsRefreshFailure(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager(nil, nil, nil)
+	exec := &pingTestExecutor{
+		schedulerProviderTestExecutor: schedulerProviderTestExecutor{provider: "ping-oauth"},
+		refreshErr:                    errors.New("refresh token revoked"),
+	}
+	manager.RegisterExecutor(exec)
+	expired := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	if _, err := manager.Register(ctx, &Auth{ID: "oauth-1", Provider: "ping-oauth", Metadata: map[string]any{"access_token": "stale", "expired": expired}}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if err := manager.PingAuth(ctx, "oauth-1"); err == nil || err.Error() != "refresh token revoked" {
+		t.Fatalf("PingAuth error = %v, want refresh error", err)
+	}
+	if len(exec.pingTokens) != 0 {
+		t.Fatalf("ping should not run after a failed refresh")
+	}
+}
+
+func TestManager_PingAuth_SkipsRefreshClaimedByAutoRefresh(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager(nil, nil, nil)
+	exec := &pingTestExecutor{schedulerProviderTestExecutor: schedulerProviderTestExecutor{provider: "ping-oauth"}}
+	manager.RegisterExecutor(exec)
+	expiring := time.Now().Add(time.Minute).Format(time.RFC3339)
+	if _, err := manager.Register(ctx, &Auth{ID: "oauth-1", Provider: "ping-oauth", Metadata: map[string]any{"access_token": "stale", "expired": expiring}}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if !manager.markRefreshPending("oauth-1", time.Now()) {
+		t.Fatal("markRefreshPending = false, want claim")
+	}
+
+	if err := manager.PingAuth(ctx, "oauth-1"); err != nil {
+		t.Fatalf("PingAuth error = %v", err)
+	}
+	if exec.refreshes != 0 {
+		t.Fatalf("refreshes = %d, want 0 while the auto-refresh loop owns the refresh", exec.refreshes)
+	}
+}
+
+func TestManager_PingAuth_UnsupportedExecutor(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(schedulerProviderTestExecutor{provider: "no-ping"})
+	if _, err := manager.Register(ctx, &Auth{ID: "a", Provider: "no-ping"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	var authErr *Error
+	if err := manager.PingAuth(ctx, "a"); !errors.As(err, &authErr) || authErr.HTTPStatus != http.StatusNotImplemented {
+		t.Fatalf("PingAuth error = %v, want not implemented", err)
+	}
+	if err := manager.PingAuth(ctx, "missing"); !errors.As(err, &authErr) || authErr.Code != "auth_not_found" {
+		t.Fatalf("PingAuth error = %v, want auth_not_found", err)
+	}
+}
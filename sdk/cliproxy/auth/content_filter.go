@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+// inputDenylist caches compiled input-content-denylist patterns for one config snapshot.
+type inputDenylist struct {
+	cfg      *internalconfig.Config
+	patterns []*regexp.Regexp
+}
+
+// promptTextKeys lists the JSON keys whose string values carry prompt text across the
+// OpenAI, Claude, Gemini and Responses request formats.
+var promptTextKeys = map[string]struct{}{
+	"text":         {},
+	"content":      {},
+	"input":        {},
+	"prompt":       {},
+	"instructions": {},
+	"system":       {},
+}
+
+// inputDenylistPatterns returns the compiled denylist for the current runtime config,
+// recompiling only when the config snapshot changes.
+func (m *Manager) inputDenylistPatterns() []*regexp.Regexp {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || len(cfg.InputContentDenylist) == 0 {
+		return nil
+	}
+	if cached, ok := m.inputDenylist.Load().(*inputDenylist); ok && cached != nil && cached.cfg == cfg {
+		return cached.patterns
+	}
+	patterns := make([]*regexp.Regexp, 0, len(cfg.InputContentDenylist))
+	for _, pattern := range cfg.InputContentDenylist {
+		re, errCompile := regexp.Compile(pattern)
+		if errCompile != nil {
+			continue
+		}
+		patterns = append(patterns, re)
+	}
+	m.inputDenylist.Store(&inputDenylist{cfg: cfg, patterns: patterns})
+	return patterns
+}
+
+// checkInputContent rejects requests whose prompt text matches the configured denylist.
+// The returned error deliberately omits the matched pattern.
+func (m *Manager) checkInputContent(ctx context.Context, req cliproxyexecutor.Request) error {
+	patterns := m.inputDenylistPatterns()
+	if len(patterns) == 0 || len(req.Payload) == 0 {
+		return nil
+	}
+	texts := extractPromptText(gjson.ParseBytes(req.Payload), false, nil)
+	for _, text := range texts {
+		for _, re := range patterns {
+			if re.MatchString(text) {
+				logEntryWithRequestID(ctx).Warnf("request rejected by input content denylist (model %s)", req.Model)
+				return &Error{
+					Code:       "content_policy_violation",
+					Message:    "Request rejected by content policy.",
+					HTTPStatus: http.StatusBadRequest,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// extractPromptText collects string values stored under prompt text keys, walking nested
+// messages, content parts and Gemini contents.
+func extractPromptText(node gjson.Result, textKey bool, out []string) []string {
+	switch {
+	case node.Type == gjson.String:
+		if textKey {
+			if text := strings.TrimSpace(node.String()); text != "" {
+				out = append(out, text)
+			}
+		}
+	case node.IsArray():
+		node.ForEach(func(_, value gjson.Result) bool {
+			out = extractPromptText(value, textKey, out)
+			return true
+		})
+	case node.IsObject():
+		node.ForEach(func(key, value gjson.Result) bool {
+			_, isTextKey := promptTextKeys[key.String()]
+			out = extractPromptText(value, isTextKey, out)
+			return true
+		})
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+func TestManager_InputContentDenylist(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{InputContentDenylist: []string{`(?i)sk-[a-z0-9]{8,}`, `forbidden phrase`}})
+	executor := &authFallbackExecutor{id: "claude"}
+	m.RegisterExecutor(executor)
+
+	auth := &Auth{ID: uuid.NewString(), Provider: "claude"}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(auth.ID, "claude", []*registry.ModelInfo{{ID: "denylist-model"}})
+	t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+	if _, errRegister := m.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("register: %v", errRegister)
+	}
+
+	denied := cliproxyexecutor.Request{Model: "denylist-model", Payload: []byte(`{"messages":[{"role":"user","content":[{"type":"text","text":"my key is SK-abcdef123456"}]}]}`)}
+	_, errExec := m.Execute(context.Background(), []string{"claude"}, denied, cliproxyexecutor.Options{})
+	if errExec == nil {
+		t.Fatal("expected denylisted request to be rejected")
+	}
+	var authErr *Error
+	if !errors.As(errExec, &authErr) || authErr.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("error = %v, want 400 content policy error", errExec)
+	}
+	if calls := executor.ExecuteCalls(); len(calls) != 0 {
+		t.Fatalf("executor calls = %v, want none", calls)
+	}
+
+	allowed := cliproxyexecutor.Request{Model: "denylist-model", Payload: []byte(`{"system":"be brief","messages":[{"role":"user","content":"hello there"}]}`)}
+	resp, errExec := m.Execute(context.Background(), []string{"claude"}, allowed, cliproxyexecutor.Options{})
+	if errExec != nil {
+		t.Fatalf("execute allowed request: %v", errExec)
+	}
+	if string(resp.Payload) != auth.ID {
+		t.Fatalf("payload = %q, want %q", resp.Payload, auth.ID)
+	}
+}
+
+func TestExtractPromptTextAcrossFormats(t *testing.T) {
+	payload := []byte(`{"contents":[{"role":"user","parts":[{"text":"gemini text"},{"inlineData":{"data":"AAAA"}}]}],"input":"responses text","instructions":"sys"}`)
+	texts := extractPromptText(gjson.ParseBytes(payload), false, nil)
+	want := map[string]bool{"gemini text": true, "responses text": true, "sys": true}
+	if len(texts) != len(want) {
+		t.Fatalf("texts = %v, want %d entries", texts, len(want))
+	}
+	for _, text := range texts {
+		if !want[text] {
+			t.Fatalf("unexpected text %q in %v", text, texts)
+		}
+	}
+}
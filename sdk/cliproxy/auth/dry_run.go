@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// dryRunUnsupportedError returns a 501 error when opts ask for a dry run that executor cannot
+// honour, so the request is rejected instead of being sent upstream and using quota.
+func dryRunUnsupportedError(executor ProviderExecutor, provider string, opts cliproxyexecutor.Options) error {
+	if dryRun, _ := opts.Metadata[cliproxyexecutor.DryRunMetadataKey].(bool); !dryRun {
+		return nil
+	}
+	if supporter, ok := executor.(DryRunExecutor); ok && supporter.SupportsDryRun() {
+		return nil
+	}
+	return &Error{
+		Code:       "dry_run_unsupported",
+		Message:    fmt.Sprintf("dry run is not supported for provider %s", provider),
+		HTTPStatus: http.StatusNotImplemented,
+	}
+}
@@ -1,5 +1,7 @@
 package auth
 
+import "net/http"
+
 // Error describes an authentication related failure in a provider agnostic format.
 type Error struct {
 	// Code is a short machine readable identifier.
@@ -10,6 +12,9 @@ type Error struct {
 	Retryable bool `json:"retryable"`
 	// HTTPStatus optionally records an HTTP-like status code for the error.
 	HTTPStatus int `json:"http_status,omitempty"`
+
+	// headers carries response headers for the client, such as Retry-After.
+	headers http.Header
 }
 
 // Error implements the error interface.
@@ -30,3 +35,11 @@ func (e *Error) StatusCode() int {
 	}
 	return e.HTTPStatus
 }
+
+// Headers returns response headers to send to the client alongside the error.
+func (e *Error) Headers() http.Header {
+	if e == nil || e.headers == nil {
+		return nil
+	}
+	return e.headers.Clone()
+}
@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// QueueWaitHeader reports how long a request waited for a model concurrency slot, in
+// milliseconds. It is only set on responses that were actually queued.
+const QueueWaitHeader = "X-Queue-Wait-Ms"
+
+// concurrencyLimiter bounds in-flight requests per key, such as a requested model or auth ID.
+// It also keeps a moving average of how long slots are held so rejected callers can be told
+// when to retry.
+type concurrencyLimiter struct {
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+	// overflow counts holders carried over from a larger semaphore that did not fit into the
+	// resized one; their releases are absorbed here before freeing slots.
+	overflow map[string]int
+	holds    map[string]time.Duration
+}
+
+// slotsFor returns the semaphore for key sized to limit. When the configured limit changed,
+// the semaphore is replaced and its current occupancy carried over, so in-flight requests
+// keep counting against the new limit.
+func (l *concurrencyLimiter) slotsFor(key string, limit int) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.slots == nil {
+		l.slots = make(map[string]chan struct{})
+	}
+	slots, ok := l.slots[key]
+	if ok && cap(slots) == limit {
+		return slots
+	}
+	resized := make(chan struct{}, limit)
+	held := l.overflow[key]
+	if ok {
+		held += drainSlots(slots)
+	}
+	for held > 0 && len(resized) < limit {
+		resized <- struct{}{}
+		held--
+	}
+	if held > 0 {
+		if l.overflow == nil {
+			l.overflow = make(map[string]int)
+		}
+		l.overflow[key] = held
+	} else {
+		delete(l.overflow, key)
+	}
+	l.slots[key] = resized
+	return resized
+}
+
+// drainSlots empties slots without blocking and returns how many slots were held.
+func drainSlots(slots chan struct{}) int {
+	n := 0
+	for {
+		select {
+		case <-slots:
+			n++
+		default:
+			return n
+		}
+	}
+}
+
+// holdSlot returns the release func for a slot taken from slots at the current time. Releasing
+// frees a slot in the current semaphore for key, which may have replaced slots after a limit
+// change, and records the hold duration for key.
+func (l *concurrencyLimiter) holdSlot(key string, slots chan struct{}) func() {
+	start := time.Now()
+	l.adoptStaleSlot(key, slots)
+	return func() {
+		l.releaseSlot(key, slots)
+		l.observeHold(key, time.Since(start))
+	}
+}
+
+// adoptStaleSlot moves a slot taken from a semaphore that was replaced before the slot landed
+// in it, so the holder counts against the current semaphore for key.
+func (l *concurrencyLimiter) adoptStaleSlot(key string, slots chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	current, ok := l.slots[key]
+	if !ok || current == slots {
+		return
+	}
+	select {
+	case <-slots:
+	default:
+		return
+	}
+	select {
+	case current <- struct{}{}:
+	default:
+		if l.overflow == nil {
+			l.overflow = make(map[string]int)
+		}
+		l.overflow[key]++
+	}
+}
+
+// releaseSlot frees one slot for key, preferring carried-over overflow before the current
+// semaphore. slots is the semaphore the slot was taken from.
+func (l *concurrencyLimiter) releaseSlot(key string, slots chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n := l.overflow[key]; n > 0 {
+		if n == 1 {
+			delete(l.overflow, key)
+		} else {
+			l.overflow[key] = n - 1
+		}
+		return
+	}
+	if current, ok := l.slots[key]; ok {
+		slots = current
+	}
+	select {
+	case <-slots:
+	default:
+	}
+}
+
+// observeHold folds a slot hold duration into the per-key moving average.
+func (l *concurrencyLimiter) observeHold(key string, held time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holds == nil {
+		l.holds = make(map[string]time.Duration)
+	}
+	if prev, ok := l.holds[key]; ok {
+		held = (prev*4 + held) / 5
+	}
+	l.holds[key] = held
+}
+
+// retryAfter estimates how long until one of limit slots for key frees up, based on recent
+// throughput. Keys without history, or estimates under a second, report one second.
+func (l *concurrencyLimiter) retryAfter(key string, limit int) time.Duration {
+	l.mu.Lock()
+	held := l.holds[key]
+	l.mu.Unlock()
+	if limit > 1 {
+		held /= time.Duration(limit)
+	}
+	if held < time.Second {
+		return time.Second
+	}
+	return held.Round(time.Second)
+}
+
+// IsConcurrencyLimited reports whether err is a per-credential or per-model concurrency
+// rejection produced by the manager itself rather than by an upstream provider.
+func IsConcurrencyLimited(err error) bool {
+	authErr, ok := errors.AsType[*Error](err)
+	return ok && (authErr.Code == "auth_concurrency_limited" || authErr.Code == "model_concurrency_limited")
+}
+
+// retryAfterHeaders builds the Retry-After header for a concurrency rejection.
+func retryAfterHeaders(wait time.Duration) http.Header {
+	headers := make(http.Header)
+	headers.Set("Retry-After", strconv.Itoa(int(wait/time.Second)))
+	return headers
+}
+
+// withQueueWaitHeader returns headers with QueueWaitHeader set to waited. headers is cloned
+// so upstream header maps are not modified; nothing is added when the request did not wait.
+func withQueueWaitHeader(headers http.Header, waited time.Duration) http.Header {
+	if waited <= 0 {
+		return headers
+	}
+	if headers == nil {
+		headers = make(http.Header)
+	} else {
+		headers = headers.Clone()
+	}
+	headers.Set(QueueWaitHeader, strconv.FormatInt(waited.Milliseconds(), 10))
+	return headers
+}
+
+// acquireModelSlot reserves a concurrency slot for the requested model when a limit is
+// configured, and reports how long the request was queued. The returned release func is never
+// nil and must be called exactly once.
+func (m *Manager) acquireModelSlot(ctx context.Context, model string) (func(), time.Duration, error) {
+	noop := func() {}
+	if m == nil {
+		return noop, 0, nil
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || len(cfg.ModelConcurrencyLimits) == 0 {
+		return noop, 0, nil
+	}
+	key := strings.ToLower(strings.TrimSpace(model))
+	limit := cfg.ModelConcurrencyLimits[key]
+	if limit <= 0 {
+		return noop, 0, nil
+	}
+	slots := m.modelLimiter.slotsFor(key, limit)
+	select {
+	case slots <- struct{}{}:
+		return m.modelLimiter.holdSlot(key, slots), 0, nil
+	default:
+	}
+	if cfg.ModelConcurrencyPolicy == internalconfig.ModelConcurrencyPolicyReject {
+		return noop, 0, &Error{
+			Code:       "model_concurrency_limited",
+			Message:    fmt.Sprintf("too many concurrent requests for model %s (limit %d)", model, limit),
+			Retryable:  true,
+			HTTPStatus: http.StatusTooManyRequests,
+			headers:    retryAfterHeaders(m.modelLimiter.retryAfter(key, limit)),
+		}
+	}
+	queuedAt := time.Now()
+	select {
+	case slots <- struct{}{}:
+		return m.modelLimiter.holdSlot(key, slots), time.Since(queuedAt), nil
+	case <-ctx.Done():
+		return noop, 0, ctx.Err()
+	}
+}
+
+// releaseSlotOnStreamEnd forwards stream chunks and releases a concurrency slot once the
+// upstream channel closes, so long-lived streams keep their slot until they finish.
+func releaseSlotOnStreamEnd(ctx context.Context, result *cliproxyexecutor.StreamResult, release func()) *cliproxyexecutor.StreamResult {
+	if result == nil || result.Chunks == nil {
+		release()
+		return result
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func(in <-chan cliproxyexecutor.StreamChunk) {
+		defer release()
+		defer close(out)
+		for chunk := range in {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				for range in {
+				}
+				return
+			}
+		}
+	}(result.Chunks)
+	return &cliproxyexecutor.StreamResult{Headers: result.Headers, Chunks: out}
+}
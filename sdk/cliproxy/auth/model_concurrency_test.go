@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// blockingModelExecutor holds every Execute call until unblock is closed.
+type blockingModelExecutor struct {
+	entered chan string
+	unblock chan struct{}
+}
+
+func (e *blockingModelExecutor) Identifier() string { return "claude" }
+
+func (e *blockingModelExecutor) Execute(ctx context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.entered <- req.Model
+	select {
+	case <-e.unblock:
+		return cliproxyexecutor.Response{Payload: []byte(req.Model)}, nil
+	case <-ctx.Done():
+		return cliproxyexecutor.Response{}, ctx.Err()
+	}
+}
+
+func (e *blockingModelExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	return nil, &Error{HTTPStatus: http.StatusNotImplemented, Message: "not implemented"}
+}
+
+func (e *blockingModelExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	return auth, nil
+}
+
+func (e *blockingModelExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, &Error{HTTPStatus: http.StatusNotImplemented, Message: "not implemented"}
+}
+
+func (e *blockingModelExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func newModelConcurrencyTestManager(t *testing.T, policy string) (*Manager, *blockingModelExecutor) {
+	t.Helper()
+	cfg := &internalconfig.Config{
+		ModelConcurrencyLimits: map[string]int{"Capped-Model": 1},
+		ModelConcurrencyPolicy: policy,
+	}
+	cfg.SanitizeModelConcurrencyLimits()
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(cfg)
+	executor := &blockingModelExecutor{entered: make(chan string, 4), unblock: make(chan struct{})}
+	m.RegisterExecutor(executor)
+
+	auth := &Auth{ID: uuid.NewString(), Provider: "claude"}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(auth.ID, "claude", []*registry.ModelInfo{{ID: "capped-model"}, {ID: "free-model"}})
+	t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+	if _, errRegister := m.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("register: %v", errRegister)
+	}
+	return m, executor
+}
+
+func waitEntered(t *testing.T, executor *blockingModelExecutor, want string) {
+	t.Helper()
+	select {
+	case got := <-executor.entered:
+		if got != want {
+			t.Fatalf("entered model = %q, want %q", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for %s to reach the executor", want)
+	}
+}
+
+func TestManager_ModelConcurrencyLimitRejectsBeyondCap(t *testing.T) {
+	m, executor := newModelConcurrencyTestManager(t, internalconfig.ModelConcurrencyPolicyReject)
+
+	firstDone := make(chan error, 1)
+	go func() {
+		_, errExec := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "capped-model"}, cliproxyexecutor.Options{})
+		firstDone <- errExec
+	}()
+	waitEntered(t, executor, "capped-model")
+
+	_, errExec := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "capped-model"}, cliproxyexecutor.Options{})
+	authErr, ok := errExec.(*Error)
+	if !ok || authErr.HTTPStatus != http.StatusTooManyRequests {
+		t.Fatalf("second capped request error = %v, want 429", errExec)
+	}
+	if retryAfter := authErr.Headers().Get("Retry-After"); retryAfter != "1" {
+		t.Fatalf("Retry-After = %q, want 1 without throughput history", retryAfter)
+	}
+
+	freeDone := make(chan error, 1)
+	go func() {
+		_, errFree := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "free-model"}, cliproxyexecutor.Options{})
+		freeDone <- errFree
+	}()
+	waitEntered(t, executor, "free-model")
+
+	close(executor.unblock)
+	if errFirst := <-firstDone; errFirst != nil {
+		t.Fatalf("first capped request: %v", errFirst)
+	}
+	if errFree := <-freeDone; errFree != nil {
+		t.Fatalf("free request: %v", errFree)
+	}
+}
+
+func TestManager_ModelConcurrencyLimitQueuesBeyondCap(t *testing.T) {
+	m, executor := newModelConcurrencyTestManager(t, "")
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, errExec := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "capped-model"}, cliproxyexecutor.Options{})
+			done <- errExec
+		}()
+	}
+	waitEntered(t, executor, "capped-model")
+
+	select {
+	case model := <-executor.entered:
+		t.Fatalf("queued request for %s reached the executor while the cap was held", model)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(executor.unblock)
+	waitEntered(t, executor, "capped-model")
+	for i := 0; i < 2; i++ {
+		if errExec := <-done; errExec != nil {
+			t.Fatalf("capped request: %v", errExec)
+		}
+	}
+}
+
+func TestManager_ModelConcurrencyQueueReportsWait(t *testing.T) {
+	m, executor := newModelConcurrencyTestManager(t, "")
+
+	firstDone := make(chan cliproxyexecutor.Response, 1)
+	go func() {
+		resp, _ := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "capped-model"}, cliproxyexecutor.Options{})
+		firstDone <- resp
+	}()
+	waitEntered(t, executor, "capped-model")
+
+	queuedDone := make(chan cliproxyexecutor.Response, 1)
+	go func() {
+		resp, errExec := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "capped-model"}, cliproxyexecutor.Options{})
+		if errExec != nil {
+			t.Errorf("queued request: %v", errExec)
+		}
+		queuedDone <- resp
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(executor.unblock)
+
+	if first := <-firstDone; first.Headers.Get(QueueWaitHeader) != "" {
+		t.Fatalf("unqueued request reported %s = %q", QueueWaitHeader, first.Headers.Get(QueueWaitHeader))
+	}
+	queued := <-queuedDone
+	waitMs, errParse := strconv.Atoi(queued.Headers.Get(QueueWaitHeader))
+	if errParse != nil || waitMs < 40 {
+		t.Fatalf("%s = %q, want the time spent queued", QueueWaitHeader, queued.Headers.Get(QueueWaitHeader))
+	}
+}
+
+func TestConcurrencyLimiterRetryAfterTracksHoldTime(t *testing.T) {
+	var limiter concurrencyLimiter
+	if got := limiter.retryAfter("model", 2); got != time.Second {
+		t.Fatalf("retryAfter without history = %v, want 1s", got)
+	}
+	limiter.observeHold("model", 8*time.Second)
+	limiter.observeHold("model", 8*time.Second)
+	if got := limiter.retryAfter("model", 2); got != 4*time.Second {
+		t.Fatalf("retryAfter = %v, want 4s for 8s holds across 2 slots", got)
+	}
+}
+
+func TestConcurrencyLimiterCarriesOccupancyAcrossLimitChange(t *testing.T) {
+	var limiter concurrencyLimiter
+	slots := limiter.slotsFor("model", 3)
+	var releases []func()
+	for range 3 {
+		slots <- struct{}{}
+		releases = append(releases, limiter.holdSlot("model", slots))
+	}
+
+	shrunk := limiter.slotsFor("model", 2)
+	if len(shrunk) != 2 {
+		t.Fatalf("shrunk occupancy = %d, want 2", len(shrunk))
+	}
+	releases[0]()
+	if len(shrunk) != 2 {
+		t.Fatalf("occupancy after releasing overflow = %d, want 2", len(shrunk))
+	}
+
+	grown := limiter.slotsFor("model", 4)
+	if len(grown) != 2 {
+		t.Fatalf("grown occupancy = %d, want 2 in-flight holders", len(grown))
+	}
+	releases[1]()
+	releases[2]()
+	if len(grown) != 0 {
+		t.Fatalf("occupancy after releasing all = %d, want 0", len(grown))
+	}
+}
+
+func TestConcurrencyLimiterAdoptsSlotTakenFromReplacedSemaphore(t *testing.T) {
+	var limiter concurrencyLimiter
+	stale := limiter.slotsFor("model", 2)
+	current := limiter.slotsFor("model", 3)
+	stale <- struct{}{}
+	release := limiter.holdSlot("model", stale)
+	if len(stale) != 0 || len(current) != 1 {
+		t.Fatalf("stale=%d current=%d, want the slot moved to the current semaphore", len(stale), len(current))
+	}
+	release()
+	if len(current) != 0 {
+		t.Fatalf("current occupancy after release = %d, want 0", len(current))
+	}
+}
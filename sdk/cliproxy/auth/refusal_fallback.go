@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// applyRefusalFallback replaces refusals in a non-streaming response with the configured
+// refusal-fallback-message. The response is in the client's format, given by
+// opts.SourceFormat. Responses without a refusal, and raw passthrough responses, are
+// returned unchanged. Streams are not rewritten, since a refusal there is only known once
+// earlier chunks have already reached the client.
+func (m *Manager) applyRefusalFallback(ctx context.Context, opts cliproxyexecutor.Options, resp cliproxyexecutor.Response) cliproxyexecutor.Response {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || cfg.RefusalFallbackMessage == "" || len(resp.Payload) == 0 {
+		return resp
+	}
+	if raw, _ := opts.Metadata[cliproxyexecutor.RawPassthroughMetadataKey].(bool); raw {
+		return resp
+	}
+	payload, original, replaced := replaceRefusal(opts.SourceFormat.String(), resp.Payload, cfg.RefusalFallbackMessage)
+	if !replaced {
+		return resp
+	}
+	logEntryWithRequestID(ctx).Infof("model refusal replaced with fallback message; original refusal: %s", original)
+	resp.Payload = payload
+	return resp
+}
+
+// replaceRefusal detects a refusal in payload for the given client format and swaps in
+// message. It returns the updated payload, a description of the original refusal and
+// whether anything was replaced.
+func replaceRefusal(format string, payload []byte, message string) ([]byte, string, bool) {
+	root := gjson.ParseBytes(payload)
+	replaced := false
+	original := ""
+	set := func(path string, value any) {
+		if updated, errSet := sjson.SetBytes(payload, path, value); errSet == nil {
+			payload = updated
+			replaced = true
+		}
+	}
+
+	switch format {
+	case "openai":
+		root.Get("choices").ForEach(func(key, choice gjson.Result) bool {
+			refusal := choice.Get("message.refusal")
+			if refusal.Type != gjson.String || refusal.String() == "" {
+				return true
+			}
+			original = refusal.String()
+			set(fmt.Sprintf("choices.%d.message.content", key.Int()), message)
+			set(fmt.Sprintf("choices.%d.message.refusal", key.Int()), nil)
+			return true
+		})
+	case "openai-response":
+		root.Get("output").ForEach(func(itemKey, item gjson.Result) bool {
+			item.Get("content").ForEach(func(partKey, part gjson.Result) bool {
+				if part.Get("type").String() != "refusal" {
+					return true
+				}
+				original = part.Get("refusal").String()
+				set(fmt.Sprintf("output.%d.content.%d", itemKey.Int(), partKey.Int()), map[string]any{
+					"type":        "output_text",
+					"text":        message,
+					"annotations": []any{},
+				})
+				return true
+			})
+			return true
+		})
+	case "claude":
+		if root.Get("stop_reason").String() == "refusal" {
+			original = root.Get("content").Raw
+			set("content", []map[string]string{{"type": "text", "text": message}})
+		}
+	case "gemini", "gemini-cli":
+		prefix := ""
+		if format == "gemini-cli" && root.Get("response").Exists() {
+			prefix = "response."
+			root = root.Get("response")
+		}
+		root.Get("candidates").ForEach(func(key, candidate gjson.Result) bool {
+			if candidate.Get("finishReason").String() != "SAFETY" {
+				return true
+			}
+			original = "finishReason SAFETY " + candidate.Get("safetyRatings").Raw
+			set(fmt.Sprintf("%scandidates.%d.content", prefix, key.Int()), map[string]any{
+				"role":  "model",
+				"parts": []map[string]string{{"text": message}},
+			})
+			return true
+		})
+		// A blocked prompt carries no candidates at all, only promptFeedback.blockReason.
+		if reason := root.Get("promptFeedback.blockReason").String(); reason != "" && len(root.Get("candidates").Array()) == 0 {
+			original = "promptFeedback blockReason " + reason
+			set(prefix+"candidates", []map[string]any{{
+				"index":        0,
+				"finishReason": "SAFETY",
+				"content": map[string]any{
+					"role":  "model",
+					"parts": []map[string]string{{"text": message}},
+				},
+			}})
+		}
+	}
+	return payload, original, replaced
+}
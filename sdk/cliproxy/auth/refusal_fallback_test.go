@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestManager_ApplyRefusalFallbackReplacesRefusal(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	refusal := []byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":null,"refusal":"I can't assist with that."},"finish_reason":"stop"}]}`)
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")}
+
+	resp := m.applyRefusalFallback(context.Background(), opts, cliproxyexecutor.Response{Payload: refusal})
+	if string(resp.Payload) != string(refusal) {
+		t.Fatalf("payload changed without a fallback configured: %s", resp.Payload)
+	}
+
+	m.SetConfig(&internalconfig.Config{RefusalFallbackMessage: "Please rephrase your request."})
+	resp = m.applyRefusalFallback(context.Background(), opts, cliproxyexecutor.Response{Payload: refusal})
+	message := gjson.GetBytes(resp.Payload, "choices.0.message")
+	if got := message.Get("content").String(); got != "Please rephrase your request." {
+		t.Fatalf("content = %q, want fallback message", got)
+	}
+	if refusalField := message.Get("refusal"); refusalField.Type != gjson.Null {
+		t.Fatalf("refusal = %s, want null", refusalField.Raw)
+	}
+
+	normal := []byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"hi","refusal":null}}]}`)
+	resp = m.applyRefusalFallback(context.Background(), opts, cliproxyexecutor.Response{Payload: normal})
+	if string(resp.Payload) != string(normal) {
+		t.Fatalf("non-refusal payload changed: %s", resp.Payload)
+	}
+}
+
+func TestReplaceRefusalClaudeAndGemini(t *testing.T) {
+	claude, _, ok := replaceRefusal("claude", []byte(`{"content":[],"stop_reason":"refusal"}`), "fallback")
+	if !ok || gjson.GetBytes(claude, "content.0.text").String() != "fallback" {
+		t.Fatalf("claude payload = %s, replaced = %v", claude, ok)
+	}
+	gemini, _, ok := replaceRefusal("gemini", []byte(`{"candidates":[{"finishReason":"SAFETY"}]}`), "fallback")
+	if !ok || gjson.GetBytes(gemini, "candidates.0.content.parts.0.text").String() != "fallback" {
+		t.Fatalf("gemini payload = %s, replaced = %v", gemini, ok)
+	}
+}
+
+func TestReplaceRefusalGeminiPromptBlock(t *testing.T) {
+	blocked := []byte(`{"promptFeedback":{"blockReason":"SAFETY"},"usageMetadata":{"promptTokenCount":4}}`)
+	gemini, original, ok := replaceRefusal("gemini", blocked, "fallback")
+	if !ok || gjson.GetBytes(gemini, "candidates.0.content.parts.0.text").String() != "fallback" {
+		t.Fatalf("gemini payload = %s, replaced = %v", gemini, ok)
+	}
+	if original != "promptFeedback blockReason SAFETY" {
+		t.Fatalf("original = %q", original)
+	}
+
+	cli, _, ok := replaceRefusal("gemini-cli", []byte(`{"response":{"promptFeedback":{"blockReason":"OTHER"}}}`), "fallback")
+	if !ok || gjson.GetBytes(cli, "response.candidates.0.content.parts.0.text").String() != "fallback" {
+		t.Fatalf("gemini-cli payload = %s, replaced = %v", cli, ok)
+	}
+
+	if _, _, ok = replaceRefusal("gemini", []byte(`{"candidates":[{"finishReason":"STOP","content":{"parts":[{"text":"hi"}]}}]}`), "fallback"); ok {
+		t.Fatal("unblocked response should be left alone")
+	}
+}
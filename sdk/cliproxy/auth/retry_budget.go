@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// withRetryBudget attaches the configured per-request retry budget to ctx unless the
+// caller already supplied one, so nested retry mechanisms draw from a single allowance.
+func (m *Manager) withRetryBudget(ctx context.Context) context.Context {
+	if m == nil || cliproxyexecutor.RetryBudgetFromContext(ctx) != nil {
+		return ctx
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || (cfg.RetryBudget.MaxRetries <= 0 && cfg.RetryBudget.MaxSeconds <= 0) {
+		return ctx
+	}
+	budget := cliproxyexecutor.NewRetryBudget(cfg.RetryBudget.MaxRetries, time.Duration(cfg.RetryBudget.MaxSeconds)*time.Second)
+	return cliproxyexecutor.WithRetryBudget(ctx, budget)
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func newRetryBudgetTestManager(t *testing.T, cfg *internalconfig.Config, authCount int) (*Manager, *authFallbackExecutor) {
+	t.Helper()
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(cfg)
+	executor := &authFallbackExecutor{id: "claude", executeErrors: map[string]error{}}
+	m.RegisterExecutor(executor)
+
+	reg := registry.GetGlobalRegistry()
+	for i := 0; i < authCount; i++ {
+		auth := &Auth{ID: uuid.NewString(), Provider: "claude"}
+		executor.executeErrors[auth.ID] = &Error{HTTPStatus: http.StatusInternalServerError, Message: "upstream failure"}
+		reg.RegisterClient(auth.ID, "claude", []*registry.ModelInfo{{ID: "retry-budget-model"}})
+		t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+		if _, errRegister := m.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register: %v", errRegister)
+		}
+	}
+	return m, executor
+}
+
+func TestManager_RetryBudgetCapsCredentialRotation(t *testing.T) {
+	cfg := &internalconfig.Config{RetryBudget: internalconfig.RetryBudgetConfig{MaxRetries: 2}}
+	m, executor := newRetryBudgetTestManager(t, cfg, 5)
+
+	req := cliproxyexecutor.Request{Model: "retry-budget-model", Payload: []byte(`{}`)}
+	if _, errExec := m.Execute(context.Background(), []string{"claude"}, req, cliproxyexecutor.Options{}); errExec == nil {
+		t.Fatal("expected every credential to fail")
+	}
+	if calls := executor.ExecuteCalls(); len(calls) != 3 {
+		t.Fatalf("executor calls = %d, want 3 (initial attempt + 2 retries)", len(calls))
+	}
+}
+
+func TestManager_RetryBudgetSharedWithCallerBudget(t *testing.T) {
+	m, executor := newRetryBudgetTestManager(t, &internalconfig.Config{}, 5)
+
+	// Another retry mechanism has already spent one of the two retries in this budget.
+	budget := cliproxyexecutor.NewRetryBudget(2, 0)
+	if !budget.Consume() {
+		t.Fatal("expected first retry to be granted")
+	}
+	ctx := cliproxyexecutor.WithRetryBudget(context.Background(), budget)
+
+	req := cliproxyexecutor.Request{Model: "retry-budget-model", Payload: []byte(`{}`)}
+	if _, errExec := m.Execute(ctx, []string{"claude"}, req, cliproxyexecutor.Options{}); errExec == nil {
+		t.Fatal("expected every credential to fail")
+	}
+	if calls := executor.ExecuteCalls(); len(calls) != 2 {
+		t.Fatalf("executor calls = %d, want 2 (initial attempt + remaining retry)", len(calls))
+	}
+	if budget.Consume() {
+		t.Fatal("expected the shared budget to be exhausted")
+	}
+}
+
+func TestManager_WithoutRetryBudgetTriesAllCredentials(t *testing.T) {
+	m, executor := newRetryBudgetTestManager(t, &internalconfig.Config{}, 4)
+
+	req := cliproxyexecutor.Request{Model: "retry-budget-model", Payload: []byte(`{}`)}
+	if _, errExec := m.Execute(context.Background(), []string{"claude"}, req, cliproxyexecutor.Options{}); errExec == nil {
+		t.Fatal("expected every credential to fail")
+	}
+	if calls := executor.ExecuteCalls(); len(calls) != 4 {
+		t.Fatalf("executor calls = %d, want 4", len(calls))
+	}
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// ModelRouter decides which provider, and optionally which auth, serves a model.
+// Returning an empty provider and a nil auth defers to the manager's default routing
+// across the providers supplied by the caller. A non-nil auth pins the request to it.
+// Routes to a provider the caller did not supply are ignored.
+type ModelRouter interface {
+	Route(ctx context.Context, model string, req cliproxyexecutor.Request) (provider string, auth *Auth, err error)
+}
+
+// StaticModelRouter routes models to providers using a fixed model → provider table.
+// Models without an entry fall back to default routing.
+type StaticModelRouter struct {
+	mu     sync.RWMutex
+	routes map[string]string
+}
+
+// NewStaticModelRouter constructs a static router from a model → provider table.
+func NewStaticModelRouter(routes map[string]string) *StaticModelRouter {
+	r := &StaticModelRouter{}
+	r.SetRoutes(routes)
+	return r
+}
+
+// SetRoutes replaces the routing table. Model and provider names are matched case-insensitively.
+func (r *StaticModelRouter) SetRoutes(routes map[string]string) {
+	normalized := make(map[string]string, len(routes))
+	for model, provider := range routes {
+		model = strings.ToLower(strings.TrimSpace(model))
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if model == "" || provider == "" {
+			continue
+		}
+		normalized[model] = provider
+	}
+	r.mu.Lock()
+	r.routes = normalized
+	r.mu.Unlock()
+}
+
+// Routes returns a copy of the routing table.
+func (r *StaticModelRouter) Routes() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return maps.Clone(r.routes)
+}
+
+// Route implements ModelRouter.
+func (r *StaticModelRouter) Route(_ context.Context, model string, _ cliproxyexecutor.Request) (string, *Auth, error) {
+	r.mu.RLock()
+	provider := r.routes[strings.ToLower(strings.TrimSpace(model))]
+	r.mu.RUnlock()
+	return provider, nil, nil
+}
+
+// SetModelRouter installs the router consulted before provider selection.
+// Passing nil restores the default routing.
+func (m *Manager) SetModelRouter(router ModelRouter) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.modelRouter = router
+	m.mu.Unlock()
+}
+
+// applyModelRouter narrows providers (and optionally pins an auth) according to the
+// configured ModelRouter. Requests that already carry a pinned auth are left untouched, and
+// a route to a provider outside the caller's providers is ignored so the router cannot widen
+// the set of providers a request may reach.
+func (m *Manager) applyModelRouter(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) ([]string, cliproxyexecutor.Options, error) {
+	m.mu.RLock()
+	router := m.modelRouter
+	m.mu.RUnlock()
+	if router == nil || pinnedAuthIDFromMetadata(opts.Metadata) != "" {
+		return providers, opts, nil
+	}
+	provider, auth, errRoute := router.Route(ctx, req.Model, req)
+	if errRoute != nil {
+		return nil, opts, errRoute
+	}
+	if provider == "" && auth != nil {
+		provider = auth.Provider
+	}
+	if provider = strings.TrimSpace(provider); provider == "" {
+		return providers, opts, nil
+	}
+	if !slices.ContainsFunc(providers, func(allowed string) bool { return strings.EqualFold(strings.TrimSpace(allowed), provider) }) {
+		logEntryWithRequestID(ctx).Debugf("model router: ignoring route of model %s to provider %s outside %v", req.Model, provider, providers)
+		return providers, opts, nil
+	}
+	if auth != nil {
+		meta := make(map[string]any, len(opts.Metadata)+1)
+		maps.Copy(meta, opts.Metadata)
+		meta[cliproxyexecutor.PinnedAuthMetadataKey] = auth.ID
+		opts.Metadata = meta
+	}
+	return []string{provider}, opts, nil
+}
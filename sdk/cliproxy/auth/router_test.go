@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type modelRouterFunc func(context.Context, string, cliproxyexecutor.Request) (string, *Auth, error)
+
+func (f modelRouterFunc) Route(ctx context.Context, model string, req cliproxyexecutor.Request) (string, *Auth, error) {
+	return f(ctx, model, req)
+}
+
+func newRouterTestManager(t *testing.T) (*Manager, *Auth, *Auth) {
+	t.Helper()
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(&authFallbackExecutor{id: "claude"})
+	m.RegisterExecutor(&authFallbackExecutor{id: "gemini"})
+
+	baseID := uuid.NewString()
+	claudeAuth := &Auth{ID: baseID + "-claude", Provider: "claude"}
+	geminiAuth := &Auth{ID: baseID + "-gemini", Provider: "gemini"}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(claudeAuth.ID, "claude", []*registry.ModelInfo{{ID: "model-a"}, {ID: "model-b"}})
+	reg.RegisterClient(geminiAuth.ID, "gemini", []*registry.ModelInfo{{ID: "model-a"}, {ID: "model-b"}})
+	t.Cleanup(func() {
+		reg.UnregisterClient(claudeAuth.ID)
+		reg.UnregisterClient(geminiAuth.ID)
+	})
+	for _, auth := range []*Auth{claudeAuth, geminiAuth} {
+		if _, errRegister := m.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register %s: %v", auth.ID, errRegister)
+		}
+	}
+	return m, claudeAuth, geminiAuth
+}
+
+func TestManager_ModelRouterDirectsModelsToProviders(t *testing.T) {
+	m, claudeAuth, geminiAuth := newRouterTestManager(t)
+	m.SetModelRouter(modelRouterFunc(func(_ context.Context, model string, _ cliproxyexecutor.Request) (string, *Auth, error) {
+		switch model {
+		case "model-a":
+			return "claude", nil, nil
+		case "model-b":
+			return "", geminiAuth, nil
+		}
+		return "", nil, nil
+	}))
+
+	providers := []string{"claude", "gemini"}
+	for i := 0; i < 3; i++ {
+		resp, errExec := m.Execute(context.Background(), providers, cliproxyexecutor.Request{Model: "model-a"}, cliproxyexecutor.Options{})
+		if errExec != nil {
+			t.Fatalf("execute model-a: %v", errExec)
+		}
+		if got := string(resp.Payload); got != claudeAuth.ID {
+			t.Fatalf("model-a served by %q, want %q", got, claudeAuth.ID)
+		}
+		resp, errExec = m.Execute(context.Background(), providers, cliproxyexecutor.Request{Model: "model-b"}, cliproxyexecutor.Options{})
+		if errExec != nil {
+			t.Fatalf("execute model-b: %v", errExec)
+		}
+		if got := string(resp.Payload); got != geminiAuth.ID {
+			t.Fatalf("model-b served by %q, want %q", got, geminiAuth.ID)
+		}
+	}
+}
+
+func TestManager_ModelRouterCannotWidenCallerProviders(t *testing.T) {
+	m, claudeAuth, geminiAuth := newRouterTestManager(t)
+	m.SetModelRouter(modelRouterFunc(func(_ context.Context, model string, _ cliproxyexecutor.Request) (string, *Auth, error) {
+		if model == "model-a" {
+			return "gemini", nil, nil
+		}
+		return "", geminiAuth, nil
+	}))
+
+	for _, model := range []string{"model-a", "model-b"} {
+		resp, errExec := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{})
+		if errExec != nil {
+			t.Fatalf("execute %s: %v", model, errExec)
+		}
+		if got := string(resp.Payload); got != claudeAuth.ID {
+			t.Fatalf("%s served by %q, want the caller's provider auth %q", model, got, claudeAuth.ID)
+		}
+	}
+}
+
+func TestStaticModelRouter(t *testing.T) {
+	m, claudeAuth, geminiAuth := newRouterTestManager(t)
+	m.SetModelRouter(NewStaticModelRouter(map[string]string{"Model-A": "Gemini", "model-b": "claude"}))
+
+	providers := []string{"claude", "gemini"}
+	resp, errExec := m.Execute(context.Background(), providers, cliproxyexecutor.Request{Model: "model-a"}, cliproxyexecutor.Options{})
+	if errExec != nil {
+		t.Fatalf("execute model-a: %v", errExec)
+	}
+	if got := string(resp.Payload); got != geminiAuth.ID {
+		t.Fatalf("model-a served by %q, want %q", got, geminiAuth.ID)
+	}
+	resp, errExec = m.Execute(context.Background(), providers, cliproxyexecutor.Request{Model: "model-b"}, cliproxyexecutor.Options{})
+	if errExec != nil {
+		t.Fatalf("execute model-b: %v", errExec)
+	}
+	if got := string(resp.Payload); got != claudeAuth.ID {
+		t.Fatalf("model-b served by %q, want %q", got, claudeAuth.ID)
+	}
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// shadowRequestTimeout bounds how long a detached shadow request may run.
+const shadowRequestTimeout = 5 * time.Minute
+
+// ShadowResult compares a primary response with the response of its shadow request.
+type ShadowResult struct {
+	Provider       string
+	Model          string
+	ShadowProvider string
+	ShadowModel    string
+	ShadowAuthID   string
+	PrimaryLatency time.Duration
+	ShadowLatency  time.Duration
+	PrimaryBytes   int
+	ShadowBytes    int
+	// OutputsMatch reports whether both payloads are byte-for-byte identical.
+	OutputsMatch bool
+	// Err is set when the shadow request failed; the primary response is unaffected.
+	Err error
+}
+
+// ShadowSink receives shadow comparison results. Implementations must be safe for concurrent use.
+type ShadowSink interface {
+	HandleShadow(ctx context.Context, result ShadowResult)
+}
+
+// SetShadowSink registers the sink that receives shadow comparison results.
+// When no sink is registered results are written to the log.
+func (m *Manager) SetShadowSink(sink ShadowSink) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.shadowSink = sink
+	m.mu.Unlock()
+}
+
+// shouldShadow reports whether the current request is sampled for shadow traffic.
+func (m *Manager) shouldShadow() (internalconfig.ShadowTrafficConfig, bool) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil {
+		return internalconfig.ShadowTrafficConfig{}, false
+	}
+	shadow := cfg.ShadowTraffic
+	if !shadow.Enable || shadow.Provider == "" || shadow.SampleRate <= 0 {
+		return shadow, false
+	}
+	if shadow.SampleRate < 1 && rand.Float64() >= shadow.SampleRate {
+		return shadow, false
+	}
+	return shadow, true
+}
+
+// launchShadow mirrors a successful non-streaming request to the configured shadow target.
+// The shadow request runs detached from the client context so it never delays or alters
+// the primary response. Requests sampled while the in-flight cap is reached are skipped.
+func (m *Manager) launchShadow(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, primary cliproxyexecutor.Response, primaryProvider string, primaryLatency time.Duration) {
+	shadow, ok := m.shouldShadow()
+	if !ok {
+		return
+	}
+	limit := int64(shadow.MaxInFlight)
+	if limit <= 0 {
+		limit = internalconfig.DefaultShadowMaxInFlight
+	}
+	if m.shadowInFlight.Add(1) > limit {
+		m.shadowInFlight.Add(-1)
+		logEntryWithRequestID(ctx).Debugf("shadow request skipped: %d shadow requests already in flight", limit)
+		return
+	}
+	shadowReq := req
+	shadowReq.Payload = bytes.Clone(req.Payload)
+	if shadow.Model != "" {
+		shadowReq.Model = shadow.Model
+	}
+	shadowOpts := opts
+	shadowOpts.OriginalRequest = bytes.Clone(opts.OriginalRequest)
+	shadowOpts.Metadata = shadowMetadata(opts.Metadata)
+	result := ShadowResult{
+		Provider:       primaryProvider,
+		Model:          req.Model,
+		ShadowProvider: shadow.Provider,
+		ShadowModel:    shadowReq.Model,
+		PrimaryLatency: primaryLatency,
+		PrimaryBytes:   len(primary.Payload),
+	}
+	primaryPayload := bytes.Clone(primary.Payload)
+	go func() {
+		defer m.shadowInFlight.Add(-1)
+		shadowCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shadowRequestTimeout)
+		defer cancel()
+		auth, executor, errPick := m.pickNext(shadowCtx, shadow.Provider, shadowReq.Model, shadowOpts, map[string]struct{}{})
+		if errPick != nil {
+			result.Err = errPick
+			m.publishShadow(shadowCtx, result)
+			return
+		}
+		result.ShadowAuthID = auth.ID
+		if rt := m.roundTripperFor(auth); rt != nil {
+			shadowCtx = context.WithValue(shadowCtx, roundTripperContextKey{}, rt)
+			shadowCtx = context.WithValue(shadowCtx, "cliproxy.roundtripper", rt)
+		}
+		start := time.Now()
+		resp, errExec := executor.Execute(shadowCtx, auth, shadowReq, shadowOpts)
+		result.ShadowLatency = time.Since(start)
+		if errExec != nil {
+			result.Err = errExec
+		} else {
+			result.ShadowBytes = len(resp.Payload)
+			result.OutputsMatch = bytes.Equal(primaryPayload, resp.Payload)
+		}
+		m.publishShadow(shadowCtx, result)
+	}()
+}
+
+// shadowMetadata copies execution metadata for a shadow request, dropping entries that
+// pin or report auth selection for the primary request.
+func shadowMetadata(meta map[string]any) map[string]any {
+	if len(meta) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(meta))
+	for k, v := range meta {
+		switch k {
+		case cliproxyexecutor.PinnedAuthMetadataKey,
+			cliproxyexecutor.SelectedAuthMetadataKey,
+			cliproxyexecutor.SelectedAuthCallbackMetadataKey,
+			cliproxyexecutor.ExecutionSessionMetadataKey:
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func (m *Manager) publishShadow(ctx context.Context, result ShadowResult) {
+	m.mu.RLock()
+	sink := m.shadowSink
+	m.mu.RUnlock()
+	if sink != nil {
+		sink.HandleShadow(ctx, result)
+		return
+	}
+	entry := logEntryWithRequestID(ctx).WithFields(log.Fields{
+		"provider":        result.Provider,
+		"model":           result.Model,
+		"shadow_provider": result.ShadowProvider,
+		"shadow_model":    result.ShadowModel,
+		"primary_latency": result.PrimaryLatency.String(),
+		"shadow_latency":  result.ShadowLatency.String(),
+		"outputs_match":   result.OutputsMatch,
+	})
+	if result.Err != nil {
+		entry.Warnf("shadow request failed: %s", strings.TrimSpace(result.Err.Error()))
+		return
+	}
+	entry.Info("shadow request completed")
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type shadowSinkFunc func(context.Context, ShadowResult)
+
+func (f shadowSinkFunc) HandleShadow(ctx context.Context, result ShadowResult) { f(ctx, result) }
+
+func TestManager_ShadowTrafficFiresWithoutAffectingPrimaryResponse(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{ShadowTraffic: internalconfig.ShadowTrafficConfig{
+		Enable:     true,
+		SampleRate: 1,
+		Provider:   "gemini",
+		Model:      "shadow-model",
+	}})
+
+	primary := &authFallbackExecutor{id: "claude"}
+	shadow := &authFallbackExecutor{id: "gemini"}
+	m.RegisterExecutor(primary)
+	m.RegisterExecutor(shadow)
+
+	baseID := uuid.NewString()
+	primaryAuth := &Auth{ID: baseID + "-primary", Provider: "claude"}
+	shadowAuth := &Auth{ID: baseID + "-shadow", Provider: "gemini"}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(primaryAuth.ID, "claude", []*registry.ModelInfo{{ID: "test-model"}})
+	reg.RegisterClient(shadowAuth.ID, "gemini", []*registry.ModelInfo{{ID: "shadow-model"}})
+	t.Cleanup(func() {
+		reg.UnregisterClient(primaryAuth.ID)
+		reg.UnregisterClient(shadowAuth.ID)
+	})
+	for _, auth := range []*Auth{primaryAuth, shadowAuth} {
+		if _, errRegister := m.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register %s: %v", auth.ID, errRegister)
+		}
+	}
+
+	results := make(chan ShadowResult, 1)
+	m.SetShadowSink(shadowSinkFunc(func(_ context.Context, result ShadowResult) {
+		results <- result
+	}))
+
+	resp, errExecute := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "test-model"}, cliproxyexecutor.Options{})
+	if errExecute != nil {
+		t.Fatalf("execute: %v", errExecute)
+	}
+	if got := string(resp.Payload); got != primaryAuth.ID {
+		t.Fatalf("payload = %q, want primary response %q", got, primaryAuth.ID)
+	}
+
+	select {
+	case result := <-results:
+		if result.Err != nil {
+			t.Fatalf("shadow error: %v", result.Err)
+		}
+		if result.ShadowAuthID != shadowAuth.ID {
+			t.Fatalf("shadow auth = %q, want %q", result.ShadowAuthID, shadowAuth.ID)
+		}
+		if result.ShadowModel != "shadow-model" {
+			t.Fatalf("shadow model = %q, want %q", result.ShadowModel, "shadow-model")
+		}
+		if result.OutputsMatch {
+			t.Fatalf("expected outputs to differ between primary and shadow")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("shadow request did not fire")
+	}
+	if calls := shadow.ExecuteCalls(); len(calls) != 1 || calls[0] != shadowAuth.ID {
+		t.Fatalf("shadow executor calls = %v, want [%s]", calls, shadowAuth.ID)
+	}
+}
+
+func TestManager_ShadowTrafficDisabledByDefault(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	if _, ok := m.shouldShadow(); ok {
+		t.Fatal("expected shadow traffic to be disabled without configuration")
+	}
+	m.SetConfig(&internalconfig.Config{ShadowTraffic: internalconfig.ShadowTrafficConfig{Enable: true, SampleRate: 0, Provider: "gemini"}})
+	if _, ok := m.shouldShadow(); ok {
+		t.Fatal("expected shadow traffic to be skipped with sample-rate 0")
+	}
+}
+
+func TestManager_ShadowTrafficSkipsWhenInFlightCapReached(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{ShadowTraffic: internalconfig.ShadowTrafficConfig{
+		Enable:      true,
+		SampleRate:  1,
+		Provider:    "gemini",
+		Model:       "shadow-cap-model",
+		MaxInFlight: 1,
+	}})
+
+	primary := &authFallbackExecutor{id: "claude"}
+	shadow := &authFallbackExecutor{id: "gemini"}
+	m.RegisterExecutor(primary)
+	m.RegisterExecutor(shadow)
+
+	baseID := uuid.NewString()
+	primaryAuth := &Auth{ID: baseID + "-primary", Provider: "claude"}
+	shadowAuth := &Auth{ID: baseID + "-shadow", Provider: "gemini"}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(primaryAuth.ID, "claude", []*registry.ModelInfo{{ID: "test-cap-model"}})
+	reg.RegisterClient(shadowAuth.ID, "gemini", []*registry.ModelInfo{{ID: "shadow-cap-model"}})
+	t.Cleanup(func() {
+		reg.UnregisterClient(primaryAuth.ID)
+		reg.UnregisterClient(shadowAuth.ID)
+	})
+	for _, auth := range []*Auth{primaryAuth, shadowAuth} {
+		if _, errRegister := m.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register %s: %v", auth.ID, errRegister)
+		}
+	}
+
+	published := make(chan struct{}, 4)
+	release := make(chan struct{})
+	m.SetShadowSink(shadowSinkFunc(func(context.Context, ShadowResult) {
+		published <- struct{}{}
+		<-release
+	}))
+
+	execute := func() {
+		t.Helper()
+		if _, errExecute := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "test-cap-model"}, cliproxyexecutor.Options{}); errExecute != nil {
+			t.Fatalf("execute: %v", errExecute)
+		}
+	}
+	execute()
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("first shadow request did not fire")
+	}
+	execute()
+	if calls := shadow.ExecuteCalls(); len(calls) != 1 {
+		t.Fatalf("shadow executor calls = %d, want 1 while the cap is reached", len(calls))
+	}
+
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for m.shadowInFlight.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("shadow slot was not released")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	execute()
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shadow request did not fire after the slot was released")
+	}
+}
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// toolDeclarationPaths lists, per source format, the request fields that declare tools.
+var toolDeclarationPaths = map[string][]string{
+	"openai":          {"tools", "functions"},
+	"openai-response": {"tools"},
+	"claude":          {"tools"},
+	"gemini":          {"tools"},
+	"gemini-cli":      {"request.tools"},
+}
+
+// toolFieldPaths lists, per source format, every request field removed when tools are stripped.
+var toolFieldPaths = map[string][]string{
+	"openai":          {"tools", "tool_choice", "parallel_tool_calls", "functions", "function_call"},
+	"openai-response": {"tools", "tool_choice", "parallel_tool_calls"},
+	"claude":          {"tools", "tool_choice"},
+	"gemini":          {"tools", "toolConfig"},
+	"gemini-cli":      {"request.tools", "request.toolConfig"},
+}
+
+// streamFieldPaths lists, per source format that carries a "stream" flag, the stream-only
+// fields removed when a request is downgraded. Gemini formats select streaming through the
+// endpoint instead.
+var streamFieldPaths = map[string][]string{
+	"openai":          {"stream_options"},
+	"openai-response": {"stream_options"},
+	"claude":          {},
+}
+
+// streamingToolCallPolicy returns the configured policy for streaming requests with tools
+// sent to provider.
+func (m *Manager) streamingToolCallPolicy(provider string) string {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || len(cfg.StreamingToolCallPolicy) == 0 {
+		return internalconfig.StreamingToolCallPolicyProceed
+	}
+	if policy, ok := cfg.StreamingToolCallPolicy[strings.ToLower(strings.TrimSpace(provider))]; ok {
+		return policy
+	}
+	return internalconfig.StreamingToolCallPolicyProceed
+}
+
+// executeStreamWithToolPolicy starts a streaming execution, applying the provider's streaming
+// tool call policy when the request declares tools.
+func (m *Manager) executeStreamWithToolPolicy(ctx context.Context, executor ProviderExecutor, auth *Auth, provider string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	policy := m.streamingToolCallPolicy(provider)
+	format := opts.SourceFormat.String()
+	if policy == internalconfig.StreamingToolCallPolicyProceed || !requestDeclaresTools(format, req.Payload) {
+		return executor.ExecuteStream(ctx, auth, req, opts)
+	}
+
+	switch policy {
+	case internalconfig.StreamingToolCallPolicyStripTools:
+		logEntryWithRequestID(ctx).Warnf("streaming tool call policy: removed tools from streaming request for provider %s (model %s)", provider, req.Model)
+		req.Payload = stripRequestTools(format, req.Payload)
+		if len(opts.OriginalRequest) > 0 {
+			opts.OriginalRequest = stripRequestTools(format, opts.OriginalRequest)
+		}
+		return executor.ExecuteStream(ctx, auth, req, opts)
+	case internalconfig.StreamingToolCallPolicyNonStream:
+		if !canReplayAsStream(format) {
+			logEntryWithRequestID(ctx).Debugf("streaming tool call policy: cannot replay %s responses as a stream, streaming as usual", format)
+			return executor.ExecuteStream(ctx, auth, req, opts)
+		}
+		nonStreamOpts := opts
+		nonStreamOpts.Stream = false
+		nonStreamOpts.Alt = ""
+		req.Payload = disableRequestStreaming(format, req.Payload)
+		if len(opts.OriginalRequest) > 0 {
+			nonStreamOpts.OriginalRequest = disableRequestStreaming(format, opts.OriginalRequest)
+		}
+		resp, errExec := executor.Execute(ctx, auth, req, nonStreamOpts)
+		if errExec != nil {
+			return nil, errExec
+		}
+		chunks := replayResponseAsStream(format, resp.Payload)
+		out := make(chan cliproxyexecutor.StreamChunk, len(chunks))
+		for _, chunk := range chunks {
+			out <- cliproxyexecutor.StreamChunk{Payload: chunk}
+		}
+		close(out)
+		return &cliproxyexecutor.StreamResult{Headers: resp.Headers, Chunks: out}, nil
+	default:
+		return executor.ExecuteStream(ctx, auth, req, opts)
+	}
+}
+
+// requestDeclaresTools reports whether payload declares at least one tool for format.
+func requestDeclaresTools(format string, payload []byte) bool {
+	if len(payload) == 0 {
+		return false
+	}
+	for _, path := range toolDeclarationPaths[format] {
+		if tools := gjson.GetBytes(payload, path); tools.IsArray() && len(tools.Array()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// stripRequestTools removes tool declarations and tool selection fields from payload.
+func stripRequestTools(format string, payload []byte) []byte {
+	for _, path := range toolFieldPaths[format] {
+		if updated, errDelete := sjson.DeleteBytes(payload, path); errDelete == nil {
+			payload = updated
+		}
+	}
+	return payload
+}
+
+// disableRequestStreaming turns payload into a non-streaming request for format, so
+// translators that keep the client's stream flag do not ask the upstream for SSE.
+func disableRequestStreaming(format string, payload []byte) []byte {
+	paths, ok := streamFieldPaths[format]
+	if !ok || len(payload) == 0 {
+		return payload
+	}
+	if gjson.GetBytes(payload, "stream").Exists() {
+		if updated, errSet := sjson.SetBytes(payload, "stream", false); errSet == nil {
+			payload = updated
+		}
+	}
+	for _, path := range paths {
+		if updated, errDelete := sjson.DeleteBytes(payload, path); errDelete == nil {
+			payload = updated
+		}
+	}
+	return payload
+}
+
+// canReplayAsStream reports whether non-streaming responses in format can be replayed as
+// stream chunks.
+func canReplayAsStream(format string) bool {
+	switch format {
+	case "openai", "claude", "gemini", "gemini-cli":
+		return true
+	default:
+		return false
+	}
+}
+
+// replayResponseAsStream converts a non-streaming response into the stream chunks a client of
+// format expects.
+func replayResponseAsStream(format string, payload []byte) [][]byte {
+	switch format {
+	case "openai":
+		return [][]byte{openAICompletionAsChunk(payload)}
+	case "claude":
+		return claudeMessageAsEvents(payload)
+	default:
+		// Gemini stream chunks share the non-streaming response shape.
+		return [][]byte{payload}
+	}
+}
+
+// openAICompletionAsChunk converts a chat.completion into a single chat.completion.chunk whose
+// deltas carry the complete messages.
+func openAICompletionAsChunk(payload []byte) []byte {
+	out, _ := sjson.SetBytes(payload, "object", "chat.completion.chunk")
+	for i, choice := range gjson.GetBytes(payload, "choices").Array() {
+		delta := []byte(choice.Get("message").Raw)
+		if len(delta) == 0 {
+			delta = []byte(`{}`)
+		}
+		for j := range gjson.GetBytes(delta, "tool_calls").Array() {
+			delta, _ = sjson.SetBytes(delta, "tool_calls."+strconv.Itoa(j)+".index", j)
+		}
+		out, _ = sjson.SetRawBytes(out, "choices."+strconv.Itoa(i)+".delta", delta)
+		out, _ = sjson.DeleteBytes(out, "choices."+strconv.Itoa(i)+".message")
+	}
+	return out
+}
+
+// claudeMessageAsEvents converts a Claude message into the server-sent event sequence of an
+// equivalent streaming response.
+func claudeMessageAsEvents(payload []byte) [][]byte {
+	message := gjson.ParseBytes(payload)
+	events := make([][]byte, 0, 3*len(message.Get("content").Array())+3)
+	appendEvent := func(name string, data []byte) {
+		events = append(events, translatorcommon.AppendSSEEventBytes(nil, name, data, 2))
+	}
+
+	start, _ := sjson.SetRawBytes([]byte(`{"type":"message_start"}`), "message", payload)
+	start, _ = sjson.SetRawBytes(start, "message.content", []byte(`[]`))
+	start, _ = sjson.SetRawBytes(start, "message.stop_reason", []byte(`null`))
+	start, _ = sjson.SetRawBytes(start, "message.stop_sequence", []byte(`null`))
+	if message.Get("usage").Exists() {
+		start, _ = sjson.SetBytes(start, "message.usage.output_tokens", 0)
+	}
+	appendEvent("message_start", start)
+
+	for i, block := range message.Get("content").Array() {
+		blockStart, _ := sjson.SetBytes([]byte(`{"type":"content_block_start"}`), "index", i)
+		var deltas [][]byte
+		switch block.Get("type").String() {
+		case "text":
+			blockStart, _ = sjson.SetRawBytes(blockStart, "content_block", []byte(`{"type":"text","text":""}`))
+			delta, _ := sjson.SetBytes([]byte(`{"type":"text_delta"}`), "text", block.Get("text").String())
+			deltas = append(deltas, delta)
+		case "thinking":
+			blockStart, _ = sjson.SetRawBytes(blockStart, "content_block", []byte(`{"type":"thinking","thinking":""}`))
+			delta, _ := sjson.SetBytes([]byte(`{"type":"thinking_delta"}`), "thinking", block.Get("thinking").String())
+			deltas = append(deltas, delta)
+			if signature := block.Get("signature").String(); signature != "" {
+				signatureDelta, _ := sjson.SetBytes([]byte(`{"type":"signature_delta"}`), "signature", signature)
+				deltas = append(deltas, signatureDelta)
+			}
+		case "tool_use":
+			toolBlock, _ := sjson.SetRawBytes([]byte(block.Raw), "input", []byte(`{}`))
+			blockStart, _ = sjson.SetRawBytes(blockStart, "content_block", toolBlock)
+			input := block.Get("input").Raw
+			if input == "" {
+				input = "{}"
+			}
+			delta, _ := sjson.SetBytes([]byte(`{"type":"input_json_delta"}`), "partial_json", input)
+			deltas = append(deltas, delta)
+		default:
+			blockStart, _ = sjson.SetRawBytes(blockStart, "content_block", []byte(block.Raw))
+		}
+		appendEvent("content_block_start", blockStart)
+		for _, delta := range deltas {
+			deltaEvent, _ := sjson.SetBytes([]byte(`{"type":"content_block_delta"}`), "index", i)
+			deltaEvent, _ = sjson.SetRawBytes(deltaEvent, "delta", delta)
+			appendEvent("content_block_delta", deltaEvent)
+		}
+		blockStop, _ := sjson.SetBytes([]byte(`{"type":"content_block_stop"}`), "index", i)
+		appendEvent("content_block_stop", blockStop)
+	}
+
+	messageDelta := []byte(`{"type":"message_delta","delta":{"stop_reason":null,"stop_sequence":null},"usage":{"output_tokens":0}}`)
+	if stopReason := message.Get("stop_reason"); stopReason.Exists() {
+		messageDelta, _ = sjson.SetRawBytes(messageDelta, "delta.stop_reason", []byte(stopReason.Raw))
+	}
+	if stopSequence := message.Get("stop_sequence"); stopSequence.Exists() {
+		messageDelta, _ = sjson.SetRawBytes(messageDelta, "delta.stop_sequence", []byte(stopSequence.Raw))
+	}
+	messageDelta, _ = sjson.SetBytes(messageDelta, "usage.output_tokens", message.Get("usage.output_tokens").Int())
+	appendEvent("message_delta", messageDelta)
+	appendEvent("message_stop", []byte(`{"type":"message_stop"}`))
+	return events
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+type streamToolPolicyTestExecutor struct {
+	schedulerProviderTestExecutor
+
+	streamCalls  int
+	executeCalls int
+	lastPayload  []byte
+	lastOpts     cliproxyexecutor.Options
+	response     []byte
+}
+
+func (e *streamToolPolicyTestExecutor) Execute(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.executeCalls++
+	e.lastPayload = req.Payload
+	e.lastOpts = opts
+	return cliproxyexecutor.Response{Payload: e.response}, nil
+}
+
+func (e *streamToolPolicyTestExecutor) ExecuteStream(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	e.streamCalls++
+	e.lastPayload = req.Payload
+	e.lastOpts = opts
+	out := make(chan cliproxyexecutor.StreamChunk, 1)
+	out <- cliproxyexecutor.StreamChunk{Payload: []byte("streamed")}
+	close(out)
+	return &cliproxyexecutor.StreamResult{Chunks: out}, nil
+}
+
+func runStreamToolPolicy(t *testing.T, policy string, format sdktranslator.Format, payload, response string) (*streamToolPolicyTestExecutor, [][]byte) {
+	t.Helper()
+	manager := NewManager(nil, nil, nil)
+	manager.SetConfig(&internalconfig.Config{StreamingToolCallPolicy: map[string]string{"limited": policy}})
+	exec := &streamToolPolicyTestExecutor{
+		schedulerProviderTestExecutor: schedulerProviderTestExecutor{provider: "limited"},
+		response:                      []byte(response),
+	}
+	req := cliproxyexecutor.Request{Model: "m", Payload: []byte(payload)}
+	opts := cliproxyexecutor.Options{Stream: true, Alt: "sse", SourceFormat: format, OriginalRequest: []byte(payload)}
+
+	result, err := manager.executeStreamWithToolPolicy(context.Background(), exec, &Auth{ID: "a"}, "limited", req, opts)
+	if err != nil {
+		t.Fatalf("executeStreamWithToolPolicy error = %v", err)
+	}
+	var chunks [][]byte
+	for chunk := range result.Chunks {
+		chunks = append(chunks, chunk.Payload)
+	}
+	return exec, chunks
+}
+
+const streamToolPolicyOpenAIRequest = `{"model":"m","stream":true,"messages":[{"role":"user","content":"hi"}],"tools":[{"type":"function","function":{"name":"lookup"}}],"tool_choice":"auto"}`
+
+func TestStreamingToolCallPolicy_ProceedStreams(t *testing.T) {
+	exec, chunks := runStreamToolPolicy(t, internalconfig.StreamingToolCallPolicyProceed, sdktranslator.FormatOpenAI, streamToolPolicyOpenAIRequest, "")
+
+	if exec.streamCalls != 1 || exec.executeCalls != 0 {
+		t.Fatalf("stream calls = %d, execute calls = %d, want 1 and 0", exec.streamCalls, exec.executeCalls)
+	}
+	if !gjson.GetBytes(exec.lastPayload, "tools").Exists() {
+		t.Fatalf("tools removed from payload: %s", exec.lastPayload)
+	}
+	if len(chunks) != 1 || string(chunks[0]) != "streamed" {
+		t.Fatalf("chunks = %q, want upstream stream", chunks)
+	}
+}
+
+func TestStreamingToolCallPolicy_StripToolsStreamsWithoutTools(t *testing.T) {
+	exec, _ := runStreamToolPolicy(t, internalconfig.StreamingToolCallPolicyStripTools, sdktranslator.FormatOpenAI, streamToolPolicyOpenAIRequest, "")
+
+	if exec.streamCalls != 1 || exec.executeCalls != 0 {
+		t.Fatalf("stream calls = %d, execute calls = %d, want 1 and 0", exec.streamCalls, exec.executeCalls)
+	}
+	for _, payload := range [][]byte{exec.lastPayload, exec.lastOpts.OriginalRequest} {
+		if gjson.GetBytes(payload, "tools").Exists() || gjson.GetBytes(payload, "tool_choice").Exists() {
+			t.Fatalf("tools not stripped: %s", payload)
+		}
+		if !gjson.GetBytes(payload, "messages").Exists() {
+			t.Fatalf("messages lost while stripping tools: %s", payload)
+		}
+	}
+}
+
+func TestStreamingToolCallPolicy_NonStreamReplaysOpenAICompletion(t *testing.T) {
+	response := `{"id":"c1","object":"chat.completion","model":"m","choices":[{"index":0,"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"lookup","arguments":"{}"}}]},"finish_reason":"tool_calls"}],"usage":{"total_tokens":3}}`
+	exec, chunks := runStreamToolPolicy(t, internalconfig.StreamingToolCallPolicyNonStream, sdktranslator.FormatOpenAI, streamToolPolicyOpenAIRequest, response)
+
+	if exec.streamCalls != 0 || exec.executeCalls != 1 {
+		t.Fatalf("stream calls = %d, execute calls = %d, want 0 and 1", exec.streamCalls, exec.executeCalls)
+	}
+	if exec.lastOpts.Stream || exec.lastOpts.Alt != "" {
+		t.Fatalf("non-stream options = %+v, want Stream=false and empty Alt", exec.lastOpts)
+	}
+	if gjson.GetBytes(exec.lastPayload, "stream").Bool() || gjson.GetBytes(exec.lastOpts.OriginalRequest, "stream").Bool() {
+		t.Fatalf("non-stream request still asks for streaming: %s", exec.lastPayload)
+	}
+	if len(chunks) != 1 {
+		t.Fatalf("chunks = %d, want 1", len(chunks))
+	}
+	chunk := gjson.ParseBytes(chunks[0])
+	if got := chunk.Get("object").String(); got != "chat.completion.chunk" {
+		t.Fatalf("object = %q, want chat.completion.chunk", got)
+	}
+	if chunk.Get("choices.0.message").Exists() {
+		t.Fatalf("message not converted to delta: %s", chunks[0])
+	}
+	if got := chunk.Get("choices.0.delta.tool_calls.0.index"); !got.Exists() || got.Int() != 0 {
+		t.Fatalf("tool call index = %s, want 0", got.Raw)
+	}
+	if got := chunk.Get("choices.0.delta.tool_calls.0.function.name").String(); got != "lookup" {
+		t.Fatalf("tool call name = %q, want lookup", got)
+	}
+	if got := chunk.Get("choices.0.finish_reason").String(); got != "tool_calls" {
+		t.Fatalf("finish_reason = %q, want tool_calls", got)
+	}
+}
+
+func TestStreamingToolCallPolicy_NonStreamReplaysClaudeMessage(t *testing.T) {
+	request := `{"model":"m","stream":true,"messages":[{"role":"user","content":"hi"}],"tools":[{"name":"lookup","input_schema":{"type":"object"}}]}`
+	response := `{"id":"msg_1","type":"message","role":"assistant","model":"m","content":[{"type":"text","text":"Checking."},{"type":"tool_use","id":"toolu_1","name":"lookup","input":{"city":"Paris"}}],"stop_reason":"tool_use","stop_sequence":null,"usage":{"input_tokens":5,"output_tokens":7}}`
+	exec, chunks := runStreamToolPolicy(t, internalconfig.StreamingToolCallPolicyNonStream, sdktranslator.FormatClaude, request, response)
+
+	if exec.executeCalls != 1 {
+		t.Fatalf("execute calls = %d, want 1", exec.executeCalls)
+	}
+	if gjson.GetBytes(exec.lastPayload, "stream").Bool() || gjson.GetBytes(exec.lastOpts.OriginalRequest, "stream").Bool() {
+		t.Fatalf("non-stream request still asks for streaming: %s", exec.lastPayload)
+	}
+	var names []string
+	var data []gjson.Result
+	for _, chunk := range chunks {
+		lines := strings.SplitN(strings.TrimSpace(string(chunk)), "\n", 2)
+		names = append(names, strings.TrimPrefix(lines[0], "event: "))
+		data = append(data, gjson.Parse(strings.TrimPrefix(lines[1], "data: ")))
+	}
+	wantNames := []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"message_delta", "message_stop",
+	}
+	if strings.Join(names, ",") != strings.Join(wantNames, ",") {
+		t.Fatalf("events = %v, want %v", names, wantNames)
+	}
+	if got := data[0].Get("message.content").Raw; got != "[]" {
+		t.Fatalf("message_start content = %s, want []", got)
+	}
+	if got := data[2].Get("delta.text").String(); got != "Checking." {
+		t.Fatalf("text delta = %q", got)
+	}
+	if got := data[4].Get("content_block.name").String(); got != "lookup" {
+		t.Fatalf("tool_use block name = %q", got)
+	}
+	if got := data[5].Get("delta.partial_json").String(); got != `{"city":"Paris"}` {
+		t.Fatalf("input_json_delta = %q", got)
+	}
+	if got := data[7].Get("delta.stop_reason").String(); got != "tool_use" {
+		t.Fatalf("stop_reason = %q, want tool_use", got)
+	}
+	if got := data[7].Get("usage.output_tokens").Int(); got != 7 {
+		t.Fatalf("output_tokens = %d, want 7", got)
+	}
+}
+
+func TestStreamingToolCallPolicy_IgnoresRequestsWithoutTools(t *testing.T) {
+	request := `{"model":"m","stream":true,"messages":[{"role":"user","content":"hi"}]}`
+	exec, _ := runStreamToolPolicy(t, internalconfig.StreamingToolCallPolicyNonStream, sdktranslator.FormatOpenAI, request, "")
+
+	if exec.streamCalls != 1 || exec.executeCalls != 0 {
+		t.Fatalf("stream calls = %d, execute calls = %d, want 1 and 0", exec.streamCalls, exec.executeCalls)
+	}
+}
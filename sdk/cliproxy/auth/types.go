@@ -385,6 +385,33 @@ func parseIntAny(val any) (int, bool) {
 	}
 }
 
+// CodexAccountIDs lists the ChatGPT accounts a Codex credential can act for: the default
+// account_id followed by any account_ids metadata.
+func (a *Auth) CodexAccountIDs() []string {
+	if a == nil || a.Metadata == nil {
+		return nil
+	}
+	var ids []string
+	if accountID, _ := a.Metadata["account_id"].(string); strings.TrimSpace(accountID) != "" {
+		ids = append(ids, strings.TrimSpace(accountID))
+	}
+	switch list := a.Metadata["account_ids"].(type) {
+	case []string:
+		for _, id := range list {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	case []any:
+		for _, item := range list {
+			if id, ok := item.(string); ok && strings.TrimSpace(id) != "" {
+				ids = append(ids, strings.TrimSpace(id))
+			}
+		}
+	}
+	return ids
+}
+
 func (a *Auth) AccountInfo() (string, string) {
 	if a == nil {
 		return "", ""
@@ -1,6 +1,9 @@
 package executor
 
-import "context"
+import (
+	"context"
+	"net/http"
+)
 
 type downstreamWebsocketContextKey struct{}
 
@@ -21,3 +24,48 @@ func DownstreamWebsocket(ctx context.Context) bool {
 	enabled, ok := raw.(bool)
 	return ok && enabled
 }
+
+type executionTargetContextKey struct{}
+
+type executionTarget struct {
+	provider string
+	model    string
+}
+
+// WithExecutionTarget records the provider and upstream model an executor call is made for.
+func WithExecutionTarget(ctx context.Context, provider, model string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, executionTargetContextKey{}, executionTarget{provider: provider, model: model})
+}
+
+// ExecutionTarget returns the provider and upstream model recorded by WithExecutionTarget.
+func ExecutionTarget(ctx context.Context) (provider, model string) {
+	if ctx == nil {
+		return "", ""
+	}
+	target, _ := ctx.Value(executionTargetContextKey{}).(executionTarget)
+	return target.provider, target.model
+}
+
+type clientHeadersContextKey struct{}
+
+// WithClientHeaders supplies the inbound client headers executors propagate upstream for calls
+// made outside an HTTP handler, such as background or agent passes. A gin request in the
+// context takes precedence.
+func WithClientHeaders(ctx context.Context, headers http.Header) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, clientHeadersContextKey{}, headers.Clone())
+}
+
+// ClientHeaders returns the headers recorded by WithClientHeaders.
+func ClientHeaders(ctx context.Context) http.Header {
+	if ctx == nil {
+		return nil
+	}
+	headers, _ := ctx.Value(clientHeadersContextKey{}).(http.Header)
+	return headers
+}
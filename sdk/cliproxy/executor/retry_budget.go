@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RetryBudget is a per-request allowance shared by every retry mechanism (credential
+// rotation, cooldown retries, model fallbacks, connection retries). A nil budget is unlimited.
+type RetryBudget struct {
+	mu        sync.Mutex
+	remaining int
+	limited   bool
+	deadline  time.Time
+}
+
+// NewRetryBudget creates a budget allowing at most maxRetries retries within maxDuration
+// of creation. A value <= 0 leaves that dimension unlimited.
+func NewRetryBudget(maxRetries int, maxDuration time.Duration) *RetryBudget {
+	budget := &RetryBudget{}
+	if maxRetries > 0 {
+		budget.remaining = maxRetries
+		budget.limited = true
+	}
+	if maxDuration > 0 {
+		budget.deadline = time.Now().Add(maxDuration)
+	}
+	return budget
+}
+
+// Consume reserves one retry and reports whether it may proceed.
+func (b *RetryBudget) Consume() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.deadline.IsZero() && !time.Now().Before(b.deadline) {
+		return false
+	}
+	if !b.limited {
+		return true
+	}
+	if b.remaining <= 0 {
+		return false
+	}
+	b.remaining--
+	return true
+}
+
+type retryBudgetContextKey struct{}
+
+// WithRetryBudget attaches a retry budget to the context.
+func WithRetryBudget(ctx context.Context, budget *RetryBudget) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, retryBudgetContextKey{}, budget)
+}
+
+// RetryBudgetFromContext returns the retry budget carried by ctx, or nil when none is set.
+func RetryBudgetFromContext(ctx context.Context) *RetryBudget {
+	if ctx == nil {
+		return nil
+	}
+	budget, _ := ctx.Value(retryBudgetContextKey{}).(*RetryBudget)
+	return budget
+}
@@ -19,8 +19,21 @@ const (
 	SelectedAuthCallbackMetadataKey = "selected_auth_callback"
 	// ExecutionSessionMetadataKey identifies a long-lived downstream execution session.
 	ExecutionSessionMetadataKey = "execution_session_id"
+	// RawPassthroughMetadataKey marks a request whose payload is already in the upstream's
+	// native format; executors skip request and response translation for it.
+	RawPassthroughMetadataKey = "raw_passthrough"
+	// RequestedAccountMetadataKey carries the upstream account a client asked for with the
+	// CodexAccountHeader header, so credential selection can prefer credentials that own it.
+	RequestedAccountMetadataKey = "requested_account_id"
+	// DryRunMetadataKey marks a request whose prepared upstream request is returned to the
+	// client instead of being sent.
+	DryRunMetadataKey = "dry_run"
 )
 
+// CodexAccountHeader selects the ChatGPT workspace used by a Codex OAuth credential that
+// belongs to several workspaces.
+const CodexAccountHeader = "X-Codex-Account-Id"
+
 // Request encapsulates the translated payload that will be sent to a provider executor.
 type Request struct {
 	// Model is the upstream model identifier after translation.
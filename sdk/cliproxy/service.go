@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"strings"
 	"sync"
@@ -89,6 +90,9 @@ type Service struct {
 
 	// wsGateway manages websocket Gemini providers.
 	wsGateway *wsrelay.Manager
+
+	// warmedProviders records providers whose upstream connections were already warmed.
+	warmedProviders sync.Map
 }
 
 // RegisterUsagePlugin registers a usage plugin on the global usage manager.
@@ -100,6 +104,18 @@ func (s *Service) RegisterUsagePlugin(plugin usage.Plugin) {
 	usage.RegisterPlugin(plugin)
 }
 
+// RegisterUsageSink registers a sink exporting every usage record, e.g. to a billing system.
+// Sink failures are logged and never affect requests.
+func (s *Service) RegisterUsageSink(sink usage.Sink) {
+	usage.RegisterSink(sink)
+}
+
+// RegisterAuthEventSink registers a sink receiving credential audit events such as token
+// rotation on refresh.
+func (s *Service) RegisterAuthEventSink(sink coreauth.AuthEventSink) {
+	coreauth.RegisterAuthEventSink(sink)
+}
+
 // newDefaultAuthManager creates a default authentication manager with all supported providers.
 func newDefaultAuthManager() *sdkAuth.Manager {
 	return sdkAuth.NewManager(
@@ -316,6 +332,51 @@ func (s *Service) applyCoreAuthAddOrUpdate(ctx context.Context, auth *coreauth.A
 	// have an empty supportedModelSet (because Register/Update upserts into the
 	// scheduler before registerModelsForAuth runs) and are invisible to the scheduler.
 	s.coreManager.RefreshSchedulerEntry(auth.ID)
+
+	s.warmupConnectionsForAuth(auth)
+}
+
+// warmupConnectionsForAuth pre-opens upstream connections the first time an enabled auth
+// is registered for a provider listed in warmup-connections.
+func (s *Service) warmupConnectionsForAuth(auth *coreauth.Auth) {
+	if s == nil || auth == nil || auth.Disabled {
+		return
+	}
+	s.cfgMu.RLock()
+	cfg := s.cfg
+	s.cfgMu.RUnlock()
+	if cfg == nil || len(cfg.WarmupConnections) == 0 {
+		return
+	}
+	provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+	if cfg.WarmupConnections[provider] <= 0 {
+		return
+	}
+	if _, loaded := s.warmedProviders.LoadOrStore(provider, struct{}{}); loaded {
+		return
+	}
+	go executor.WarmupConnections(context.Background(), cfg, auth)
+}
+
+// reloadUpstreamTransports drops the shared upstream transports and warms the providers again
+// when a reload changes the settings that select the upstream transport, since the executors
+// then use a different connection pool.
+func (s *Service) reloadUpstreamTransports(previous, next *config.Config) {
+	if s == nil || next == nil {
+		return
+	}
+	if previous != nil && previous.ProxyURL == next.ProxyURL && previous.HTTPClient == next.HTTPClient &&
+		maps.Equal(previous.WarmupConnections, next.WarmupConnections) {
+		return
+	}
+	executor.ResetProxyTransports()
+	s.warmedProviders.Clear()
+	if s.coreManager == nil || len(next.WarmupConnections) == 0 {
+		return
+	}
+	for _, auth := range s.coreManager.List() {
+		s.warmupConnectionsForAuth(auth)
+	}
 }
 
 func (s *Service) applyCoreAuthRemoval(ctx context.Context, id string) {
@@ -482,19 +543,15 @@ func (s *Service) Run(ctx context.Context) error {
 
 	usage.StartDefault(ctx)
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer shutdownCancel()
-	defer func() {
-		if err := s.Shutdown(shutdownCtx); err != nil {
-			log.Errorf("service shutdown returned error: %v", err)
-		}
-	}()
+	defer s.shutdownOnExit()
 
 	if err := s.ensureAuthDir(); err != nil {
 		return err
 	}
 
 	s.applyRetryConfig(s.cfg)
+	log.Infof("codex client identity: client-version=%s user-agent=%q websocket-beta=%s",
+		s.cfg.Codex.EffectiveClientVersion(), s.cfg.Codex.EffectiveUserAgent(), s.cfg.Codex.EffectiveWebsocketBeta())
 
 	if s.coreManager != nil {
 		if errLoad := s.coreManager.Load(ctx); errLoad != nil {
@@ -654,6 +711,7 @@ func (s *Service) Run(ctx context.Context) error {
 			s.server.UpdateClients(newCfg)
 		}
 		s.cfgMu.Lock()
+		previousCfg := s.cfg
 		s.cfg = newCfg
 		s.cfgMu.Unlock()
 		if s.coreManager != nil {
@@ -661,6 +719,7 @@ func (s *Service) Run(ctx context.Context) error {
 			s.coreManager.SetOAuthModelAlias(newCfg.OAuthModelAlias)
 		}
 		s.rebindExecutors()
+		s.reloadUpstreamTransports(previousCfg, newCfg)
 	}
 
 	watcherWrapper, err = s.watcherFactory(s.configPath, s.cfg.AuthDir, reloadCallback)
@@ -752,6 +811,11 @@ func (s *Service) Shutdown(ctx context.Context) error {
 
 		// no legacy clients to persist
 
+		// Let in-flight websocket session requests finish before the server goes away.
+		if s.coreManager != nil {
+			s.coreManager.DrainExecutionSessions(ctx)
+		}
+
 		if s.server != nil {
 			shutdownCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 			defer cancel()
@@ -768,6 +832,20 @@ func (s *Service) Shutdown(ctx context.Context) error {
 	return shutdownErr
 }
 
+// serviceShutdownTimeout bounds the shutdown Run performs on exit, including the drain of
+// in-flight websocket session requests.
+var serviceShutdownTimeout = 30 * time.Second
+
+// shutdownOnExit shuts the service down with a deadline starting now, so a long-running
+// service still gets the full timeout to drain when it stops.
+func (s *Service) shutdownOnExit() {
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), serviceShutdownTimeout)
+	defer shutdownCancel()
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("service shutdown returned error: %v", err)
+	}
+}
+
 func (s *Service) ensureAuthDir() error {
 	info, err := os.Stat(s.cfg.AuthDir)
 	if err != nil {
@@ -0,0 +1,46 @@
+package cliproxy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+type drainRecordingExecutor struct {
+	coreauth.ProviderExecutor
+	drainErr  error
+	remaining time.Duration
+}
+
+func (e *drainRecordingExecutor) Identifier() string { return "drain-recorder" }
+
+func (e *drainRecordingExecutor) Drain(ctx context.Context) {
+	e.drainErr = ctx.Err()
+	if deadline, ok := ctx.Deadline(); ok {
+		e.remaining = time.Until(deadline)
+	}
+}
+
+func TestShutdownOnExitDrainsWithFreshDeadline(t *testing.T) {
+	previous := serviceShutdownTimeout
+	serviceShutdownTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { serviceShutdownTimeout = previous })
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	drainer := &drainRecordingExecutor{}
+	manager.RegisterExecutor(drainer)
+	service := &Service{coreManager: manager}
+
+	// Stay up for longer than the shutdown timeout before stopping.
+	time.Sleep(2 * serviceShutdownTimeout)
+	service.shutdownOnExit()
+
+	if drainer.drainErr != nil {
+		t.Fatalf("drain context already done: %v", drainer.drainErr)
+	}
+	if drainer.remaining <= serviceShutdownTimeout/2 {
+		t.Fatalf("drain deadline %v away, want close to %v", drainer.remaining, serviceShutdownTimeout)
+	}
+}
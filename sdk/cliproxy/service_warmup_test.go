@@ -0,0 +1,38 @@
+package cliproxy
+
+import (
+	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestReloadUpstreamTransportsResetsWarmedProvidersWhenSettingsChange(t *testing.T) {
+	service := &Service{coreManager: coreauth.NewManager(nil, nil, nil)}
+	previous := &config.Config{}
+	previous.WarmupConnections = map[string]int{"claude": 4}
+
+	service.warmedProviders.Store("claude", struct{}{})
+	unchanged := &config.Config{}
+	unchanged.WarmupConnections = map[string]int{"claude": 4}
+	service.reloadUpstreamTransports(previous, unchanged)
+	if _, ok := service.warmedProviders.Load("claude"); !ok {
+		t.Fatal("warmed state dropped although transport settings did not change")
+	}
+
+	changed := &config.Config{}
+	changed.WarmupConnections = map[string]int{"claude": 8}
+	service.reloadUpstreamTransports(previous, changed)
+	if _, ok := service.warmedProviders.Load("claude"); ok {
+		t.Fatal("warmed state kept after warmup-connections changed")
+	}
+
+	service.warmedProviders.Store("claude", struct{}{})
+	proxied := &config.Config{}
+	proxied.WarmupConnections = map[string]int{"claude": 8}
+	proxied.ProxyURL = "http://127.0.0.1:3128"
+	service.reloadUpstreamTransports(changed, proxied)
+	if _, ok := service.warmedProviders.Load("claude"); ok {
+		t.Fatal("warmed state kept after proxy-url changed")
+	}
+}
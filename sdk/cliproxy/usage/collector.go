@@ -0,0 +1,58 @@
+package usage
+
+import (
+	"context"
+	"sync"
+)
+
+type collectorContextKey struct{}
+
+// Collector captures usage records published for a single request so callers can
+// surface the aggregated usage after an upstream call completes.
+type Collector struct {
+	mu     sync.Mutex
+	detail Detail
+	ok     bool
+}
+
+// WithCollector returns a context whose published usage records are also delivered
+// to the returned collector.
+func WithCollector(ctx context.Context) (context.Context, *Collector) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	collector := &Collector{}
+	return context.WithValue(ctx, collectorContextKey{}, collector), collector
+}
+
+// Detail returns the most recent non-empty usage detail and whether one was recorded.
+func (c *Collector) Detail() (Detail, bool) {
+	if c == nil {
+		return Detail{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.detail, c.ok
+}
+
+func (c *Collector) add(record Record) {
+	if c == nil || record.Failed {
+		return
+	}
+	d := record.Detail
+	if d.InputTokens == 0 && d.OutputTokens == 0 && d.ReasoningTokens == 0 && d.CachedTokens == 0 && d.TotalTokens == 0 {
+		return
+	}
+	c.mu.Lock()
+	c.detail = d
+	c.ok = true
+	c.mu.Unlock()
+}
+
+func collectorFromContext(ctx context.Context) *Collector {
+	if ctx == nil {
+		return nil
+	}
+	collector, _ := ctx.Value(collectorContextKey{}).(*Collector)
+	return collector
+}
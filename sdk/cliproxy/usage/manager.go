@@ -15,11 +15,15 @@ type Record struct {
 	APIKey      string
 	AuthID      string
 	AuthIndex   string
+	AuthLabel   string
 	Source      string
 	RequestedAt time.Time
 	Latency     time.Duration
-	Failed      bool
-	Detail      Detail
+	// UpstreamLatency is the time between sending the request upstream and receiving its
+	// completion. It is only set by transports that can measure it, such as Codex websockets.
+	UpstreamLatency time.Duration
+	Failed          bool
+	Detail          Detail
 }
 
 // Detail holds the token usage breakdown.
@@ -110,6 +114,7 @@ func (m *Manager) Publish(ctx context.Context, record Record) {
 	if m == nil {
 		return
 	}
+	collectorFromContext(ctx).add(record)
 	// ensure worker is running even if Start was not called explicitly
 	m.Start(context.Background())
 	m.mu.Lock()
@@ -0,0 +1,44 @@
+package usage
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Sink exports usage records to an external system such as a billing service.
+//
+// Sinks run on the usage dispatcher, never on the request path, so a slow or failing sink
+// does not delay responses. Returned errors are logged and the record is dropped for that
+// sink only.
+type Sink interface {
+	Record(ctx context.Context, record Record) error
+}
+
+// SinkFunc adapts a function to the Sink interface.
+type SinkFunc func(ctx context.Context, record Record) error
+
+// Record calls f(ctx, record).
+func (f SinkFunc) Record(ctx context.Context, record Record) error { return f(ctx, record) }
+
+// sinkPlugin delivers records to a Sink and logs its failures.
+type sinkPlugin struct {
+	sink Sink
+}
+
+func (p sinkPlugin) HandleUsage(ctx context.Context, record Record) {
+	if errRecord := p.sink.Record(ctx, record); errRecord != nil {
+		log.Warnf("usage: sink %T failed to record usage for %s/%s: %v", p.sink, record.Provider, record.Model, errRecord)
+	}
+}
+
+// RegisterSink adds a sink receiving every published usage record.
+func (m *Manager) RegisterSink(sink Sink) {
+	if sink == nil {
+		return
+	}
+	m.Register(sinkPlugin{sink: sink})
+}
+
+// RegisterSink registers a sink on the default manager.
+func RegisterSink(sink Sink) { DefaultManager().RegisterSink(sink) }
@@ -0,0 +1,32 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestManagerDeliversRecordsToEverySink(t *testing.T) {
+	m := NewManager(0)
+	t.Cleanup(m.Stop)
+
+	failing := SinkFunc(func(context.Context, Record) error { return errors.New("billing unavailable") })
+	received := make(chan Record, 1)
+	m.RegisterSink(failing)
+	m.RegisterSink(SinkFunc(func(_ context.Context, record Record) error {
+		received <- record
+		return nil
+	}))
+
+	m.Publish(context.Background(), Record{Provider: "claude", Model: "claude-sonnet-4", AuthLabel: "team-a", Detail: Detail{InputTokens: 3, OutputTokens: 4, TotalTokens: 7}})
+
+	select {
+	case record := <-received:
+		if record.AuthLabel != "team-a" || record.Detail.TotalTokens != 7 {
+			t.Fatalf("record = %+v", record)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("sink after a failing sink did not receive the record")
+	}
+}
@@ -0,0 +1,187 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Translation stages recorded in dead letters.
+const (
+	DeadLetterStageRequest  = "request"
+	DeadLetterStageResponse = "response"
+)
+
+// DeadLetter describes a translation that produced empty or invalid output.
+type DeadLetter struct {
+	// Time is when the failed translation was detected.
+	Time time.Time `json:"time"`
+	// Stage is DeadLetterStageRequest or DeadLetterStageResponse.
+	Stage string `json:"stage"`
+	// From is the format of Input.
+	From Format `json:"from"`
+	// To is the format the translation targeted.
+	To Format `json:"to"`
+	// Model is the model the translation ran for.
+	Model string `json:"model,omitempty"`
+	// Input is the payload handed to the translator.
+	Input string `json:"input"`
+	// Output is the payload the translator returned.
+	Output string `json:"output"`
+	// Truncated reports whether Input or Output was cut to the sink's payload limit.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// DeadLetterSink receives failed translations for later analysis.
+type DeadLetterSink interface {
+	RecordDeadLetter(ctx context.Context, letter DeadLetter)
+}
+
+// logDeadLetterSink reports failed translations through the process log without their payloads.
+type logDeadLetterSink struct{}
+
+func (logDeadLetterSink) RecordDeadLetter(_ context.Context, letter DeadLetter) {
+	log.Warnf("translator: %s translation %s -> %s for model %s produced invalid output (input %d bytes, output %d bytes)",
+		letter.Stage, letter.From, letter.To, letter.Model, len(letter.Input), len(letter.Output))
+}
+
+var (
+	deadLetterMu   sync.RWMutex
+	deadLetterSink DeadLetterSink = logDeadLetterSink{}
+)
+
+// SetDeadLetterSink replaces the sink receiving failed translations. A nil sink restores the
+// default, which only logs a warning.
+func SetDeadLetterSink(sink DeadLetterSink) {
+	if sink == nil {
+		sink = logDeadLetterSink{}
+	}
+	deadLetterMu.Lock()
+	deadLetterSink = sink
+	deadLetterMu.Unlock()
+}
+
+// RecordDeadLetter hands letter to the configured sink, filling in Time when unset.
+func RecordDeadLetter(ctx context.Context, letter DeadLetter) {
+	if letter.Time.IsZero() {
+		letter.Time = time.Now()
+	}
+	deadLetterMu.RLock()
+	sink := deadLetterSink
+	deadLetterMu.RUnlock()
+	sink.RecordDeadLetter(ctx, letter)
+}
+
+// InvalidTranslation reports whether a translator turned a non-empty input into empty or
+// non-JSON output. Output identical to the input is a passthrough and never invalid.
+func InvalidTranslation(input, output []byte) bool {
+	if len(bytes.TrimSpace(input)) == 0 || bytes.Equal(input, output) {
+		return false
+	}
+	trimmed := bytes.TrimSpace(output)
+	return len(trimmed) == 0 || !json.Valid(trimmed)
+}
+
+// Limits applied by FileDeadLetterSink.
+const (
+	// DeadLetterMaxPayloadBytes caps the Input and Output stored per dead letter.
+	DeadLetterMaxPayloadBytes = 64 << 10
+	// DeadLetterMaxFileBytes is the size at which the dead letter file is rotated.
+	DeadLetterMaxFileBytes = 10 << 20
+)
+
+// FileDeadLetterSink appends failed translations to a file as JSON lines. Payloads are cut to
+// maxPayload bytes, and once the file would grow past maxFile bytes it is renamed to
+// "<path>.1", replacing the previous backup, and a new file is started.
+type FileDeadLetterSink struct {
+	mu         sync.Mutex
+	path       string
+	maxPayload int
+	maxFile    int64
+}
+
+// NewFileDeadLetterSink creates a sink appending to path, creating parent directories on
+// first write. It keeps at most DeadLetterMaxPayloadBytes per payload and rotates the file at
+// DeadLetterMaxFileBytes.
+func NewFileDeadLetterSink(path string) *FileDeadLetterSink {
+	return &FileDeadLetterSink{path: path, maxPayload: DeadLetterMaxPayloadBytes, maxFile: DeadLetterMaxFileBytes}
+}
+
+// RecordDeadLetter appends letter to the sink file. Write failures are logged, never returned.
+func (s *FileDeadLetterSink) RecordDeadLetter(ctx context.Context, letter DeadLetter) {
+	logDeadLetterSink{}.RecordDeadLetter(ctx, letter)
+	if errWrite := s.append(letter); errWrite != nil {
+		log.Errorf("translator: failed to write dead letter to %s: %v", s.path, errWrite)
+	}
+}
+
+func (s *FileDeadLetterSink) append(letter DeadLetter) error {
+	var cutInput, cutOutput bool
+	letter.Input, cutInput = truncatePayload(letter.Input, s.maxPayload)
+	letter.Output, cutOutput = truncatePayload(letter.Output, s.maxPayload)
+	letter.Truncated = letter.Truncated || cutInput || cutOutput
+	line, errMarshal := json.Marshal(letter)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if errMkdir := os.MkdirAll(filepath.Dir(s.path), 0o755); errMkdir != nil {
+		return errMkdir
+	}
+	if errRotate := s.rotateIfFull(int64(len(line) + 1)); errRotate != nil {
+		return errRotate
+	}
+	file, errOpen := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if errOpen != nil {
+		return errOpen
+	}
+	_, errWrite := file.Write(append(line, '\n'))
+	if errClose := file.Close(); errWrite == nil && errClose != nil {
+		errWrite = fmt.Errorf("close: %w", errClose)
+	}
+	return errWrite
+}
+
+// rotateIfFull moves the sink file to its backup when writing next more bytes would take it
+// past maxFile. A file that is still empty is never rotated.
+func (s *FileDeadLetterSink) rotateIfFull(next int64) error {
+	if s.maxFile <= 0 {
+		return nil
+	}
+	info, errStat := os.Stat(s.path)
+	if errStat != nil {
+		if os.IsNotExist(errStat) {
+			return nil
+		}
+		return errStat
+	}
+	if info.Size() == 0 || info.Size()+next <= s.maxFile {
+		return nil
+	}
+	if errRename := os.Rename(s.path, s.path+".1"); errRename != nil {
+		return fmt.Errorf("rotate: %w", errRename)
+	}
+	return nil
+}
+
+// truncatePayload cuts payload to at most limit bytes without splitting a UTF-8 sequence and
+// reports whether anything was removed.
+func truncatePayload(payload string, limit int) (string, bool) {
+	if limit <= 0 || len(payload) <= limit {
+		return payload, false
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(payload[cut]) {
+		cut--
+	}
+	return payload[:cut], true
+}
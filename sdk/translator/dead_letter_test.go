@@ -0,0 +1,95 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInvalidTranslation(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output string
+		want   bool
+	}{
+		{name: "valid output", input: `{"a":1}`, output: `{"b":1}`, want: false},
+		{name: "empty output", input: `{"a":1}`, output: "", want: true},
+		{name: "whitespace output", input: `{"a":1}`, output: " \n", want: true},
+		{name: "truncated output", input: `{"a":1}`, output: `{"b":`, want: true},
+		{name: "empty input", input: "", output: "", want: false},
+		{name: "passthrough", input: "data: not json", output: "data: not json", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InvalidTranslation([]byte(tt.input), []byte(tt.output)); got != tt.want {
+				t.Fatalf("InvalidTranslation(%q, %q) = %v, want %v", tt.input, tt.output, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileDeadLetterSinkAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "dead-letters.jsonl")
+	sink := NewFileDeadLetterSink(path)
+
+	sink.RecordDeadLetter(context.Background(), DeadLetter{Stage: DeadLetterStageRequest, From: FormatOpenAI, To: FormatClaude, Input: `{"a":1}`})
+	sink.RecordDeadLetter(context.Background(), DeadLetter{Stage: DeadLetterStageResponse, From: FormatClaude, To: FormatOpenAI, Input: `{"b":2}`})
+
+	data, errRead := os.ReadFile(path)
+	if errRead != nil {
+		t.Fatalf("read dead letters: %v", errRead)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("lines = %d, want 2: %s", len(lines), data)
+	}
+	var letter DeadLetter
+	if errUnmarshal := json.Unmarshal([]byte(lines[1]), &letter); errUnmarshal != nil {
+		t.Fatalf("unmarshal: %v", errUnmarshal)
+	}
+	if letter.Stage != DeadLetterStageResponse || letter.From != FormatClaude || letter.To != FormatOpenAI || letter.Input != `{"b":2}` {
+		t.Fatalf("letter = %+v", letter)
+	}
+}
+
+func TestFileDeadLetterSinkTruncatesPayloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letters.jsonl")
+	sink := &FileDeadLetterSink{path: path, maxPayload: 4}
+
+	sink.RecordDeadLetter(context.Background(), DeadLetter{Stage: DeadLetterStageRequest, Input: "abcdefgh", Output: "abéé"})
+
+	data, errRead := os.ReadFile(path)
+	if errRead != nil {
+		t.Fatalf("read dead letters: %v", errRead)
+	}
+	var letter DeadLetter
+	if errUnmarshal := json.Unmarshal(data, &letter); errUnmarshal != nil {
+		t.Fatalf("unmarshal: %v", errUnmarshal)
+	}
+	if letter.Input != "abcd" || letter.Output != "abé" || !letter.Truncated {
+		t.Fatalf("letter = %+v, want payloads cut to 4 bytes", letter)
+	}
+}
+
+func TestFileDeadLetterSinkRotatesAtMaxFileSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letters.jsonl")
+	sink := &FileDeadLetterSink{path: path, maxFile: 200}
+
+	for i := 0; i < 5; i++ {
+		sink.RecordDeadLetter(context.Background(), DeadLetter{Stage: DeadLetterStageRequest, Input: strings.Repeat("x", 40)})
+	}
+
+	for _, name := range []string{path, path + ".1"} {
+		info, errStat := os.Stat(name)
+		if errStat != nil {
+			t.Fatalf("stat %s: %v", name, errStat)
+		}
+		if info.Size() > 200 {
+			t.Fatalf("%s is %d bytes, want at most 200", name, info.Size())
+		}
+	}
+}
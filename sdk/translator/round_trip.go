@@ -0,0 +1,109 @@
+package translator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ErrNoForwardTranslator reports that a round trip cannot translate the source format into
+// the target format.
+var ErrNoForwardTranslator = errors.New("translator: no request translator registered for the forward direction")
+
+// ErrNoReverseTranslator reports that a round trip cannot translate the target format back
+// into the source format.
+var ErrNoReverseTranslator = errors.New("translator: no request translator registered for the reverse direction")
+
+// roundTripReadBack maps target formats that have no request translators of their own to
+// the client format whose translators read the same payload shape. Codex upstream requests
+// use the Responses API, so they are read back with the openai-response translators.
+var roundTripReadBack = map[Format]Format{
+	FormatCodex: FormatOpenAIResponse,
+}
+
+// RoundTripReport describes how a request changed after being translated to another format
+// and back.
+type RoundTripReport struct {
+	From Format
+	To   Format
+	// Translated is the request in the target format.
+	Translated []byte
+	// RoundTripped is Translated converted back to the source format.
+	RoundTripped []byte
+	// Dropped lists top-level fields present in the source but absent after the round trip.
+	Dropped []string
+	// Changed lists top-level fields whose JSON value differs after the round trip.
+	Changed []string
+}
+
+// Lossless reports whether no top-level field was dropped or changed.
+func (r RoundTripReport) Lossless() bool {
+	return len(r.Dropped) == 0 && len(r.Changed) == 0
+}
+
+// RoundTripCheck translates a non-streaming request from one format to another and back,
+// then compares the top-level fields of the original and round-tripped payloads. It is a
+// regression-test aid, not a strict equivalence check: nested differences surface only as
+// a changed top-level field, and "model" is not compared because translators set it to the
+// target model name.
+func (r *Registry) RoundTripCheck(from, to Format, model string, payload []byte) (RoundTripReport, error) {
+	report := RoundTripReport{From: from, To: to}
+	var source map[string]any
+	if err := json.Unmarshal(payload, &source); err != nil {
+		return report, fmt.Errorf("translator: round trip source is not a JSON object: %w", err)
+	}
+
+	if !r.hasRequestTransformer(from, to) {
+		return report, fmt.Errorf("%w: %s -> %s", ErrNoForwardTranslator, from, to)
+	}
+	readBack := to
+	if !r.hasRequestTransformer(readBack, from) {
+		alias, ok := roundTripReadBack[to]
+		if !ok || !r.hasRequestTransformer(alias, from) {
+			return report, fmt.Errorf("%w: %s -> %s", ErrNoReverseTranslator, to, from)
+		}
+		readBack = alias
+	}
+
+	report.Translated = r.TranslateRequest(from, to, model, payload, false)
+	report.RoundTripped = r.TranslateRequest(readBack, from, model, report.Translated, false)
+	var roundTripped map[string]any
+	if err := json.Unmarshal(report.RoundTripped, &roundTripped); err != nil {
+		return report, fmt.Errorf("translator: round trip result is not a JSON object: %w", err)
+	}
+
+	for key, value := range source {
+		if key == "model" {
+			continue
+		}
+		after, ok := roundTripped[key]
+		switch {
+		case !ok:
+			report.Dropped = append(report.Dropped, key)
+		case !reflect.DeepEqual(value, after):
+			report.Changed = append(report.Changed, key)
+		}
+	}
+	sort.Strings(report.Dropped)
+	sort.Strings(report.Changed)
+	return report, nil
+}
+
+func (r *Registry) hasRequestTransformer(from, to Format) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if byTarget, ok := r.requests[from]; ok {
+		if fn, isOk := byTarget[to]; isOk && fn != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// RoundTripCheck is a helper on the default registry.
+func RoundTripCheck(from, to Format, model string, payload []byte) (RoundTripReport, error) {
+	return defaultRegistry.RoundTripCheck(from, to, model, payload)
+}
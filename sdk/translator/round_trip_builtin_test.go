@@ -0,0 +1,36 @@
+package translator_test
+
+import (
+	"slices"
+	"testing"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/translator/builtin"
+)
+
+func TestRoundTripCheckBuiltinTranslators(t *testing.T) {
+	registry := builtin.Registry()
+
+	report, err := registry.RoundTripCheck(sdktranslator.FormatOpenAI, sdktranslator.FormatGemini, "gemini-2.5-pro", []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],"temperature":0.5}`))
+	if err != nil {
+		t.Fatalf("openai -> gemini RoundTripCheck error: %v", err)
+	}
+	if !report.Lossless() {
+		t.Fatalf("openai -> gemini report = dropped %v changed %v, want lossless", report.Dropped, report.Changed)
+	}
+
+	report, err = registry.RoundTripCheck(sdktranslator.FormatClaude, sdktranslator.FormatCodex, "gpt-5", []byte(`{"model":"claude-sonnet-4-5","max_tokens":256,"system":"be brief","messages":[{"role":"user","content":"hi"}]}`))
+	if err != nil {
+		t.Fatalf("claude -> codex RoundTripCheck error: %v", err)
+	}
+	if len(report.Translated) == 0 || len(report.RoundTripped) == 0 {
+		t.Fatalf("claude -> codex report is missing payloads: %+v", report)
+	}
+	if slices.Contains(report.Dropped, "model") || slices.Contains(report.Changed, "model") {
+		t.Fatalf("model should not be compared: dropped %v changed %v", report.Dropped, report.Changed)
+	}
+	// The Responses read-back folds the system prompt into messages.
+	if !slices.Contains(report.Dropped, "system") {
+		t.Fatalf("claude -> codex Dropped = %v, want system reported", report.Dropped)
+	}
+}
@@ -0,0 +1,58 @@
+package translator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tidwall/sjson"
+)
+
+func TestRoundTripCheckReportsDroppedAndChangedFields(t *testing.T) {
+	r := NewRegistry()
+	r.Register("client", "upstream", func(model string, rawJSON []byte, _ bool) []byte {
+		out, _ := sjson.DeleteBytes(rawJSON, "temperature")
+		out, _ = sjson.SetBytes(out, "model", model)
+		return out
+	}, ResponseTransform{})
+	r.Register("upstream", "client", func(_ string, rawJSON []byte, _ bool) []byte {
+		out, _ := sjson.SetBytes(rawJSON, "max_tokens", 1)
+		return out
+	}, ResponseTransform{})
+
+	report, err := r.RoundTripCheck("client", "upstream", "model-b", []byte(`{"model":"model-a","temperature":0.2,"max_tokens":64,"messages":[{"role":"user","content":"hi"}]}`))
+	if err != nil {
+		t.Fatalf("RoundTripCheck error: %v", err)
+	}
+	if !reflect.DeepEqual(report.Dropped, []string{"temperature"}) {
+		t.Fatalf("Dropped = %v, want [temperature]", report.Dropped)
+	}
+	if !reflect.DeepEqual(report.Changed, []string{"max_tokens"}) {
+		t.Fatalf("Changed = %v, want [max_tokens]", report.Changed)
+	}
+	if report.Lossless() {
+		t.Fatal("Lossless() = true for a lossy round trip")
+	}
+}
+
+func TestRoundTripCheckReadsCodexBackAsResponses(t *testing.T) {
+	r := NewRegistry()
+	identity := func(_ string, rawJSON []byte, _ bool) []byte { return rawJSON }
+	r.Register(FormatClaude, FormatCodex, identity, ResponseTransform{})
+
+	if _, err := r.RoundTripCheck(FormatCodex, FormatClaude, "", []byte(`{"model":"m"}`)); !errors.Is(err, ErrNoForwardTranslator) {
+		t.Fatalf("error = %v, want ErrNoForwardTranslator", err)
+	}
+	if _, err := r.RoundTripCheck(FormatClaude, FormatCodex, "", []byte(`{"model":"m"}`)); !errors.Is(err, ErrNoReverseTranslator) {
+		t.Fatalf("error = %v, want ErrNoReverseTranslator", err)
+	}
+
+	r.Register(FormatOpenAIResponse, FormatClaude, identity, ResponseTransform{})
+	report, err := r.RoundTripCheck(FormatClaude, FormatCodex, "", []byte(`{"model":"m","system":"be brief"}`))
+	if err != nil {
+		t.Fatalf("RoundTripCheck error: %v", err)
+	}
+	if !report.Lossless() {
+		t.Fatalf("report = %+v, want lossless identity round trip", report)
+	}
+}